@@ -1,41 +1,85 @@
 package auth
 
 import (
+	"database/sql"
+	stderrors "errors"
 	"fmt"
+	"html"
 	"monopoly/errors"
 	"monopoly/store"
 	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dummyPasswordHash is compared against when a login names no existing user,
+// so that case costs the same bcrypt work as a wrong password and response
+// times don't reveal which usernames exist. Same cost as real hashes.
+const dummyPasswordHash = "$2a$10$24/wLNTh6Iz8XIbLQDwoPuiKWIFcvPU.N07qYtbi9GhiLlBe0dj8m"
+
+// maxDisplayNameRunes caps display names so they fit the player list
+const maxDisplayNameRunes = 24
+
+// DefaultUsernamePattern allows plain ASCII letters and digits
+const DefaultUsernamePattern = "^[a-zA-Z0-9]+$"
+
+// UsernamePolicy is what a username must satisfy, checked after sanitizing
+type UsernamePolicy struct {
+	MinLength int            // in characters
+	MaxLength int            // in characters
+	Pattern   *regexp.Regexp // must match the whole name, so anchor it
+}
+
+// DefaultUsernamePolicy allows 3-20 letters and digits
+var DefaultUsernamePolicy = UsernamePolicy{
+	MinLength: 3,
+	MaxLength: 20,
+	Pattern:   regexp.MustCompile(DefaultUsernamePattern),
+}
+
 type Service struct {
-	store   store.AuthStore
-	session *SessionManager
+	store     store.AuthStore
+	session   *SessionManager
+	usernames UsernamePolicy
+	wsTickets *wsTickets
 }
 
 func NewService(store store.AuthStore, sessionManager *SessionManager) *Service {
 	return &Service{
-		store:   store,
-		session: sessionManager,
+		store:     store,
+		session:   sessionManager,
+		usernames: DefaultUsernamePolicy,
+		wsTickets: newWSTickets(),
 	}
 }
 
+// SetUsernamePolicy replaces the rules new usernames are checked against.
+// Existing usernames are left alone. Call before serving requests.
+func (s *Service) SetUsernamePolicy(policy UsernamePolicy) {
+	s.usernames = policy
+}
+
+// Register creates an account. Invalid input fails with VALIDATION_FAILED
+// listing every problem with the username and password, a taken username
+// included, so the client can mark each field.
 func (s *Service) Register(username, password string) error {
 	username = SanitizeString(username)
-	if err := validateUsername(username); err != nil {
-		return err
-	}
-	if err := validatePassword(password); err != nil {
-		return err
-	}
-
-	existingUser, err := s.store.GetUserByUsername(username)
-	if err != nil {
-		return fmt.Errorf("failed to check existing user: %w", err)
+	problems := s.usernames.problems(username)
+	if len(problems) == 0 {
+		existingUser, err := s.store.GetUserByUsername(username)
+		if err != nil {
+			return fmt.Errorf("failed to check existing user: %w", err)
+		}
+		if existingUser != nil {
+			problems = append(problems, errors.FieldError{Field: "username", Code: errors.ErrCodeUserExists, Message: "Username already taken."})
+		}
 	}
-	if existingUser != nil {
-		return errors.UserExists()
+	problems = append(problems, passwordProblems(password)...)
+	if len(problems) > 0 {
+		return errors.ValidationFailed(problems...)
 	}
 
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -58,11 +102,13 @@ func (s *Service) Login(username, password string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to get user: %w", err)
 	}
-	if user == nil {
-		return "", errors.InvalidCredentials()
-	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+	// Always run one bcrypt comparison, against the dummy hash if there is no user
+	hash := dummyPasswordHash
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil || user == nil {
 		return "", errors.InvalidCredentials()
 	}
 
@@ -74,10 +120,79 @@ func (s *Service) Login(username, password string) (string, error) {
 	return sessionID, nil
 }
 
+// VerifyPassword checks the password of an existing user
+func (s *Service) VerifyPassword(userID int64, password string) error {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return errors.UserNotFound()
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errors.InvalidCredentials()
+	}
+	return nil
+}
+
+// DeleteAccount re-verifies the password, then removes the user together with
+// their sessions. Callers must resolve active games (leave/forfeit) beforehand.
+// Finished games keep the user's seats under an anonymised name.
+func (s *Service) DeleteAccount(userID int64, password string) error {
+	if err := s.VerifyPassword(userID, password); err != nil {
+		return err
+	}
+	if err := s.store.DeleteUser(userID); err != nil {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return errors.UserNotFound()
+		}
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// SetDisplayName sets the name other players see instead of the username,
+// which stays the login. Markup is stripped and the result stored as plain
+// text, so clients must escape it like any other text. An empty name clears
+// it and the username is shown again. Returns the stored name.
+func (s *Service) SetDisplayName(userID int64, name string) (string, error) {
+	name = strings.TrimSpace(html.UnescapeString(SanitizeString(name)))
+	if err := validateDisplayName(name); err != nil {
+		return "", err
+	}
+	if err := s.store.SetDisplayName(userID, name); err != nil {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return "", errors.UserNotFound()
+		}
+		return "", fmt.Errorf("failed to set display name: %w", err)
+	}
+	return name, nil
+}
+
 func (s *Service) Logout(sessionID string) {
 	s.session.DeleteSession(sessionID)
 }
 
+// ListSessions returns the user's live sessions, e.g. one per device, most
+// recently used first
+func (s *Service) ListSessions(userID int64) ([]SessionInfo, error) {
+	sessions, err := s.session.ListSessions(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// LogoutAll ends every session of the user, signing them out everywhere.
+// Returns how many sessions were ended.
+func (s *Service) LogoutAll(userID int64) (int, error) {
+	ended, err := s.session.DeleteUserSessions(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to end sessions: %w", err)
+	}
+	return ended, nil
+}
+
 func (s *Service) ValidateSession(sessionID string) (int64, bool) {
 	return s.session.GetUserID(sessionID)
 }
@@ -86,21 +201,72 @@ func (s *Service) GetSessionManager() *SessionManager {
 	return s.session
 }
 
-func validateUsername(username string) error {
-	if len(username) < 3 || len(username) > 20 {
-		return errors.InvalidUsername()
+func (p UsernamePolicy) validate(username string) error {
+	if len(p.problems(username)) > 0 {
+		return p.invalid()
+	}
+	return nil
+}
+
+// problems lists what is wrong with username under the policy
+func (p UsernamePolicy) problems(username string) []errors.FieldError {
+	var problems []errors.FieldError
+	add := func(code errors.ErrorCode, format string, args ...interface{}) {
+		problems = append(problems, errors.FieldError{Field: "username", Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(username); n < p.MinLength {
+		add(errors.ErrCodeTooShort, "Username must be at least %d characters.", p.MinLength)
+	} else if n > p.MaxLength {
+		add(errors.ErrCodeTooLong, "Username must be at most %d characters.", p.MaxLength)
+	}
+	if username != "" && !p.Pattern.MatchString(username) {
+		if p.Pattern.String() == DefaultUsernamePattern {
+			add(errors.ErrCodeInvalidCharacters, "Username may only contain letters and digits.")
+		} else {
+			add(errors.ErrCodeInvalidCharacters, "Username must match %s.", p.Pattern)
+		}
+	}
+	return problems
+}
+
+// invalid describes the policy to the user; a custom pattern is shown as is
+func (p UsernamePolicy) invalid() *errors.AppError {
+	if p.Pattern.String() == DefaultUsernamePattern {
+		if p.MinLength == DefaultUsernamePolicy.MinLength && p.MaxLength == DefaultUsernamePolicy.MaxLength {
+			return errors.InvalidUsername()
+		}
+		return errors.New(errors.ErrCodeInvalidUsername, fmt.Sprintf("Username must be %d-%d alphanumeric characters", p.MinLength, p.MaxLength))
+	}
+	return errors.New(errors.ErrCodeInvalidUsername, fmt.Sprintf("Username must be %d-%d characters matching %s", p.MinLength, p.MaxLength, p.Pattern))
+}
+
+func validateDisplayName(name string) error {
+	if utf8.RuneCountInString(name) > maxDisplayNameRunes {
+		return errors.InvalidDisplayName()
 	}
-	matched, _ := regexp.MatchString("^[a-zA-Z0-9]+$", username)
-	if !matched {
-		return errors.InvalidUsername()
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return errors.InvalidDisplayName()
+		}
 	}
 	return nil
 }
 
 func validatePassword(password string) error {
-	if len(password) < 8 {
+	if len(passwordProblems(password)) > 0 {
 		return errors.InvalidPassword()
 	}
+	return nil
+}
+
+// passwordProblems lists what is wrong with password: it needs at least 8
+// characters with a letter and a digit
+func passwordProblems(password string) []errors.FieldError {
+	var problems []errors.FieldError
+	if len(password) < 8 {
+		problems = append(problems, errors.FieldError{Field: "password", Code: errors.ErrCodeTooShort, Message: "Password must be at least 8 characters."})
+	}
 
 	hasLetter := false
 	hasNumber := false
@@ -114,9 +280,11 @@ func validatePassword(password string) error {
 		}
 	}
 
-	if !hasLetter || !hasNumber {
-		return errors.InvalidPassword()
+	if !hasLetter {
+		problems = append(problems, errors.FieldError{Field: "password", Code: errors.ErrCodeMissingLetter, Message: "Password must contain a letter."})
 	}
-
-	return nil
+	if !hasNumber {
+		problems = append(problems, errors.FieldError{Field: "password", Code: errors.ErrCodeMissingDigit, Message: "Password must contain a digit."})
+	}
+	return problems
 }
@@ -0,0 +1,429 @@
+package auth
+
+import (
+	"database/sql"
+	"monopoly/errors"
+	"monopoly/store"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// userStore knows a single user; everything else panics via the nil AuthStore
+type userStore struct {
+	store.AuthStore
+	user *store.User
+}
+
+func (s userStore) GetUserByUsername(username string) (*store.User, error) {
+	if s.user != nil && s.user.Username == username {
+		return s.user, nil
+	}
+	return nil, nil
+}
+
+func TestLogin_UnknownUserAndWrongPasswordLookAlike(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword failed: %v", err)
+	}
+	svc := NewService(userStore{user: &store.User{ID: 1, Username: "alice", PasswordHash: string(hash)}}, nil)
+
+	// The dummy hash must cost as much to check as a real one, or the
+	// unknown-user path would still answer faster
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil {
+		t.Fatalf("Dummy hash is not a bcrypt hash: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("Expected the dummy hash at cost %d, got %d", bcrypt.DefaultCost, cost)
+	}
+
+	for _, c := range []struct{ name, username, password string }{
+		{"unknown user", "mallory", "correct-password"},
+		{"wrong password", "alice", "wrong-password"},
+	} {
+		_, err := svc.Login(c.username, c.password)
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeInvalidCredentials {
+			t.Errorf("%s: expected INVALID_CREDENTIALS, got %v", c.name, err)
+		}
+	}
+}
+
+// nameStore records display names and knows no user but userID 1
+type nameStore struct {
+	store.AuthStore
+	names map[int64]string
+}
+
+func (s nameStore) SetDisplayName(userID int64, displayName string) error {
+	if userID != 1 {
+		return sql.ErrNoRows
+	}
+	s.names[userID] = displayName
+	return nil
+}
+
+func TestSetDisplayName_SanitizesToPlainText(t *testing.T) {
+	st := nameStore{names: map[int64]string{}}
+	svc := NewService(st, nil)
+
+	for _, c := range []struct{ input, want string }{
+		{"  Tom & Jerry  ", "Tom & Jerry"},
+		{"<b>Boss</b><script>alert(1)</script>", "Boss"},
+		{"Zoë 🎩", "Zoë 🎩"},
+		{"", ""}, // clears it
+	} {
+		got, err := svc.SetDisplayName(1, c.input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", c.input, err)
+		}
+		if got != c.want || st.names[1] != c.want {
+			t.Errorf("%q: expected %q stored and returned, got %q and %q", c.input, c.want, st.names[1], got)
+		}
+	}
+
+	for _, input := range []string{strings.Repeat("x", maxDisplayNameRunes+1), "tab\there"} {
+		if _, err := svc.SetDisplayName(1, input); errors.From(err).Code != errors.ErrCodeInvalidDisplayName {
+			t.Errorf("%q: expected INVALID_DISPLAY_NAME, got %v", input, err)
+		}
+	}
+	if _, err := svc.SetDisplayName(2, "Ghost"); errors.From(err).Code != errors.ErrCodeUserNotFound {
+		t.Errorf("Expected USER_NOT_FOUND for a missing user, got %v", err)
+	}
+}
+
+func TestGuest_ClaimKeepsAccountAndStaleGuestsAreDeleted(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	defer db.Close()
+	authStore := store.NewAuthStore(db)
+	sessions := NewSessionManager(store.NewSessionStore(db), SessionOptions{
+		TTL:             7 * 24 * time.Hour,
+		IdleTTL:         24 * time.Hour,
+		CleanupInterval: time.Hour,
+		GuestTTL:        time.Hour,
+	})
+	svc := NewService(authStore, sessions)
+
+	sessionID, guest, err := svc.CreateGuest()
+	if err != nil {
+		t.Fatalf("CreateGuest failed: %v", err)
+	}
+	if err := DefaultUsernamePolicy.validate(guest.Username); err != nil || !strings.HasPrefix(guest.Username, guestUsernamePrefix) {
+		t.Errorf("Expected a valid generated guest username, got %q", guest.Username)
+	}
+	if userID, ok := svc.ValidateSession(sessionID); !ok || userID != guest.ID {
+		t.Fatalf("Expected the guest session to be valid for user %d, got %d, %v", guest.ID, userID, ok)
+	}
+	var expiresAt time.Time
+	if err := db.QueryRow(`SELECT expires_at FROM sessions WHERE session_id = ?`, sessionID).Scan(&expiresAt); err != nil {
+		t.Fatalf("Failed to read session: %v", err)
+	}
+	if time.Until(expiresAt) > time.Hour {
+		t.Errorf("Expected the guest session capped at the guest TTL, expires in %v", time.Until(expiresAt))
+	}
+	if _, err := svc.Login(guest.Username, ""); errors.From(err).Code != errors.ErrCodeInvalidCredentials {
+		t.Errorf("Expected guests to be unable to log in without a password, got %v", err)
+	}
+
+	// A guest who logged out can never come back
+	leftID, left, err := svc.CreateGuest()
+	if err != nil {
+		t.Fatalf("CreateGuest failed: %v", err)
+	}
+	svc.Logout(leftID)
+	deleted, err := svc.CleanupGuests()
+	if err != nil {
+		t.Fatalf("CleanupGuests failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected only the logged-out guest deleted, got %d", deleted)
+	}
+	if user, _ := authStore.GetUserByID(left.ID); user != nil {
+		t.Errorf("Expected the logged-out guest to be gone")
+	}
+
+	username, err := svc.ClaimGuest(guest.ID, "alice", "password123")
+	if err != nil {
+		t.Fatalf("ClaimGuest failed: %v", err)
+	}
+	if username != "alice" {
+		t.Errorf("Expected the chosen username, got %q", username)
+	}
+	if _, err := svc.Login("alice", "password123"); err != nil {
+		t.Errorf("Expected the claimed account to log in, got %v", err)
+	}
+	if user, _ := authStore.GetUserByID(guest.ID); user == nil || user.IsGuest {
+		t.Errorf("Expected the same user, no longer a guest, got %+v", user)
+	}
+	if _, err := svc.ClaimGuest(guest.ID, "", "password123"); errors.From(err).Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN claiming a regular account, got %v", err)
+	}
+}
+
+// staleGuestStore lists guests, some of which are already gone when deleted
+type staleGuestStore struct {
+	store.AuthStore
+	stale, gone []int64
+}
+
+func (s staleGuestStore) ListStaleGuests(time.Time) ([]int64, error) {
+	return s.stale, nil
+}
+
+func (s staleGuestStore) DeleteUser(userID int64) error {
+	if slices.Contains(s.gone, userID) {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func TestCleanupGuests_CountsOnlyGuestsItRemoved(t *testing.T) {
+	svc := NewService(staleGuestStore{stale: []int64{1, 2, 3}, gone: []int64{2}}, nil)
+	deleted, err := svc.CleanupGuests()
+	if err != nil {
+		t.Fatalf("CleanupGuests failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected the guest deleted meanwhile not counted, got %d deleted", deleted)
+	}
+}
+
+func TestUsernamePolicy_ConfiguredRulesReplaceDefaults(t *testing.T) {
+	svc := NewService(userStore{}, nil)
+	if err := svc.Register("snake_case", "password123"); errors.From(err).Code != errors.ErrCodeValidationFailed {
+		t.Errorf("Expected underscores rejected by default, got %v", err)
+	}
+
+	svc.SetUsernamePolicy(UsernamePolicy{MinLength: 2, MaxLength: 12, Pattern: regexp.MustCompile(`^[a-z0-9_]+$`)})
+	for _, c := range []struct {
+		name string
+		ok   bool
+	}{
+		{"snake_case", true},
+		{"ab", true},
+		{"a", false},
+		{"thirteen_char", false},
+		{"Upper", false},
+		{"ünï", false},
+	} {
+		if err := svc.usernames.validate(c.name); (err == nil) != c.ok {
+			t.Errorf("%q: expected ok=%v, got %v", c.name, c.ok, err)
+		}
+	}
+	err := svc.Register("a", "password123")
+	if fields := errors.From(err).Fields; len(fields) != 1 || fields[0].Code != errors.ErrCodeTooShort || !strings.Contains(fields[0].Message, "2") {
+		t.Errorf("Expected TOO_SHORT describing the configured minimum, got %v", err)
+	}
+	if err := svc.usernames.validate("a"); errors.From(err).Code != errors.ErrCodeInvalidUsername || !strings.Contains(errors.From(err).Message, "2-12") {
+		t.Errorf("Expected INVALID_USERNAME describing the configured rules, got %v", err)
+	}
+}
+
+func TestRegister_ReportsEveryFieldProblem(t *testing.T) {
+	svc := NewService(userStore{user: &store.User{ID: 1, Username: "alice"}}, nil)
+
+	type problem struct {
+		field string
+		code  errors.ErrorCode
+	}
+	for _, c := range []struct {
+		username, password string
+		want               []problem
+	}{
+		{"al", "password123", []problem{{"username", errors.ErrCodeTooShort}}},
+		{"a!", "short", []problem{
+			{"username", errors.ErrCodeTooShort},
+			{"username", errors.ErrCodeInvalidCharacters},
+			{"password", errors.ErrCodeTooShort},
+			{"password", errors.ErrCodeMissingDigit},
+		}},
+		{"alice", "12345678", []problem{{"username", errors.ErrCodeUserExists}, {"password", errors.ErrCodeMissingLetter}}},
+		{strings.Repeat("x", 21), "password123", []problem{{"username", errors.ErrCodeTooLong}}},
+	} {
+		err := svc.Register(c.username, c.password)
+		appErr := errors.From(err)
+		if appErr.Code != errors.ErrCodeValidationFailed {
+			t.Errorf("%q/%q: expected VALIDATION_FAILED, got %v", c.username, c.password, err)
+			continue
+		}
+		var got []problem
+		for _, f := range appErr.Fields {
+			if f.Message == "" {
+				t.Errorf("%q/%q: expected a message for %s %s", c.username, c.password, f.Field, f.Code)
+			}
+			got = append(got, problem{f.Field, f.Code})
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%q/%q: expected %v, got %v", c.username, c.password, c.want, got)
+		}
+	}
+}
+
+func TestWSTicket_SingleUseBoundToSession(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	defer db.Close()
+	authStore := store.NewAuthStore(db)
+	svc := NewService(authStore, NewSessionManager(store.NewSessionStore(db), SessionOptions{TTL: time.Hour, IdleTTL: time.Hour, CleanupInterval: time.Hour}))
+
+	userID, _ := authStore.CreateUser("alice", "hash")
+	sessionID, err := svc.GetSessionManager().CreateSession(userID)
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	ticket, err := svc.IssueWSTicket(sessionID)
+	if err != nil {
+		t.Fatalf("IssueWSTicket failed: %v", err)
+	}
+	if got, ok := svc.RedeemWSTicket(ticket); !ok || got != userID {
+		t.Fatalf("Expected the ticket to authenticate user %d, got %d, %v", userID, got, ok)
+	}
+	if _, ok := svc.RedeemWSTicket(ticket); ok {
+		t.Error("Expected a redeemed ticket to be rejected")
+	}
+
+	expired, _ := svc.IssueWSTicket(sessionID)
+	svc.wsTickets.tickets[expired] = wsTicket{sessionID: sessionID, expiresAt: time.Now().Add(-time.Second)}
+	if _, ok := svc.RedeemWSTicket(expired); ok {
+		t.Error("Expected an expired ticket to be rejected")
+	}
+
+	orphaned, _ := svc.IssueWSTicket(sessionID)
+	svc.Logout(sessionID)
+	if _, ok := svc.RedeemWSTicket(orphaned); ok {
+		t.Error("Expected a ticket to die with its session")
+	}
+}
+
+func TestSessions_ListedWithoutIDsAndEndedTogether(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	defer db.Close()
+	sessions := NewSessionManager(store.NewSessionStore(db), SessionOptions{
+		TTL:             7 * 24 * time.Hour,
+		IdleTTL:         24 * time.Hour,
+		CleanupInterval: time.Hour,
+		GuestTTL:        time.Hour,
+	})
+	svc := NewService(store.NewAuthStore(db), sessions)
+
+	if err := svc.Register("alice", "password123"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := svc.Register("bob", "password123"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	phone, _ := svc.Login("alice", "password123")
+	desktop, _ := svc.Login("alice", "password123")
+	bobs, _ := svc.Login("bob", "password123")
+	aliceID, _ := svc.ValidateSession(phone)
+
+	// Seen a while ago, so the next validation records it
+	if _, err := db.Exec(`UPDATE sessions SET last_seen_at = ? WHERE session_id = ?`, time.Now().Add(-time.Hour), phone); err != nil {
+		t.Fatalf("Failed to age session: %v", err)
+	}
+	svc.ValidateSession(desktop)
+
+	list, err := svc.ListSessions(aliceID)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(list) != 2 || list[1].Key != SessionKey(phone) {
+		t.Fatalf("Expected alice's two sessions, the phone least recently used, got %+v", list)
+	}
+	for _, s := range list {
+		if s.Key == phone || s.Key == desktop || s.LastSeenAt.IsZero() {
+			t.Errorf("Expected a non-secret key and a last-seen time, got %+v", s)
+		}
+	}
+
+	ended, err := svc.LogoutAll(aliceID)
+	if err != nil || ended != 2 {
+		t.Fatalf("Expected both of alice's sessions ended, got %d, %v", ended, err)
+	}
+	for _, sessionID := range []string{phone, desktop} {
+		if _, ok := svc.ValidateSession(sessionID); ok {
+			t.Error("Expected alice's sessions to be invalid after LogoutAll")
+		}
+	}
+	if _, ok := svc.ValidateSession(bobs); !ok {
+		t.Error("Expected other users' sessions to survive")
+	}
+}
+
+func TestGetUserID_TouchesSessionAtMostOncePerMinute(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	defer db.Close()
+	sessions := NewSessionManager(store.NewSessionStore(db), SessionOptions{
+		TTL:             7 * 24 * time.Hour,
+		IdleTTL:         24 * time.Hour,
+		CleanupInterval: time.Hour,
+		GuestTTL:        time.Hour,
+	})
+	svc := NewService(store.NewAuthStore(db), sessions)
+	if err := svc.Register("alice", "password123"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	sessionID, err := svc.Login("alice", "password123")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	age := func() {
+		if _, err := db.Exec(`UPDATE sessions SET last_seen_at = ? WHERE session_id = ?`, hourAgo, sessionID); err != nil {
+			t.Fatalf("Failed to age session: %v", err)
+		}
+	}
+	lastSeen := func() time.Time {
+		sessions.touches.Wait()
+		var seen time.Time
+		if err := db.QueryRow(`SELECT last_seen_at FROM sessions WHERE session_id = ?`, sessionID).Scan(&seen); err != nil {
+			t.Fatalf("Failed to read session: %v", err)
+		}
+		return seen
+	}
+
+	age()
+	for i := 0; i < 3; i++ {
+		if _, ok := svc.ValidateSession(sessionID); !ok {
+			t.Fatal("Expected the session to be valid")
+		}
+	}
+	if seen := lastSeen(); time.Since(seen) > time.Minute {
+		t.Fatalf("Expected the validation to record the session as seen, last seen %v", seen)
+	}
+	if len(sessions.touched) != 1 {
+		t.Errorf("Expected one throttled session, got %d", len(sessions.touched))
+	}
+
+	// Written less than a minute ago, so this process doesn't write again
+	age()
+	svc.ValidateSession(sessionID)
+	if seen := lastSeen(); seen.After(hourAgo.Add(time.Second)) {
+		t.Errorf("Expected no second write within a minute, last seen %v", seen)
+	}
+
+	sessions.forgetTouches(time.Now().Add(time.Minute))
+	svc.ValidateSession(sessionID)
+	if seen := lastSeen(); time.Since(seen) > time.Minute {
+		t.Errorf("Expected a write once the minute is up, last seen %v", seen)
+	}
+}
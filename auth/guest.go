@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"math/big"
+	"monopoly/errors"
+	"monopoly/store"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	guestUsernamePrefix = "Guest"
+	guestUsernameDigits = 6
+
+	// guestUsernameAttempts bounds retries when a generated name is taken
+	guestUsernameAttempts = 5
+)
+
+// CreateGuest creates a passwordless guest account under a generated username
+// and starts a guest session for it. Returns the session ID and the new user.
+func (s *Service) CreateGuest() (string, *store.User, error) {
+	var userID int64
+	var username string
+	for range guestUsernameAttempts {
+		name, err := generateGuestUsername()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to generate guest username: %w", err)
+		}
+		existing, err := s.store.GetUserByUsername(name)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to check existing user: %w", err)
+		}
+		if existing != nil {
+			continue
+		}
+		userID, err = s.store.CreateGuestUser(name)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to create guest: %w", err)
+		}
+		username = name
+		break
+	}
+	if userID == 0 {
+		return "", nil, errors.New(errors.ErrCodeInternal, "Could not pick a guest username, try again")
+	}
+
+	sessionID, err := s.session.CreateGuestSession(userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return sessionID, &store.User{ID: userID, Username: username, IsGuest: true}, nil
+}
+
+// ClaimGuest turns the guest into a regular account by setting a password,
+// and optionally a username ("" keeps the generated one). The account keeps
+// its ID, so its games and stats stay with it. Returns the username.
+func (s *Service) ClaimGuest(userID int64, username, password string) (string, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return "", errors.UserNotFound()
+	}
+	if !user.IsGuest {
+		return "", errors.New(errors.ErrCodeForbidden, "Only guest accounts can be claimed")
+	}
+
+	username = SanitizeString(username)
+	if username == "" {
+		username = user.Username
+	}
+	if err := s.usernames.validate(username); err != nil {
+		return "", err
+	}
+	if err := validatePassword(password); err != nil {
+		return "", err
+	}
+	existing, err := s.store.GetUserByUsername(username)
+	if err != nil {
+		return "", fmt.Errorf("failed to check existing user: %w", err)
+	}
+	if existing != nil && existing.ID != userID {
+		return "", errors.UserExists()
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.store.ClaimGuest(userID, username, string(passwordHash)); err != nil {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return "", errors.New(errors.ErrCodeForbidden, "Only guest accounts can be claimed")
+		}
+		return "", fmt.Errorf("failed to claim guest: %w", err)
+	}
+	return username, nil
+}
+
+// CleanupGuests deletes guests nobody can get back into: no live session and
+// no unfinished game. A guest who finished a game is anonymised instead, like
+// any deleted account, so the game keeps its players. Returns how many were
+// deleted; guests already gone by the time they are reached don't count.
+func (s *Service) CleanupGuests() (int, error) {
+	ids, err := s.store.ListStaleGuests(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, id := range ids {
+		if err := s.store.DeleteUser(id); err != nil {
+			if stderrors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return deleted, fmt.Errorf("failed to delete guest %d: %w", id, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// StartGuestCleanup deletes stale guests every interval for the life of the
+// process
+func (s *Service) StartGuestCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			deleted, err := s.CleanupGuests()
+			if err != nil {
+				log.Printf("Error cleaning up guests: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Cleaned up %d guest accounts", deleted)
+			}
+		}
+	}()
+}
+
+// generateGuestUsername returns a name like Guest042817, which the default
+// username policy allows
+func generateGuestUsername() (string, error) {
+	limit := big.NewInt(1)
+	for range guestUsernameDigits {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%0*d", guestUsernamePrefix, guestUsernameDigits, n), nil
+}
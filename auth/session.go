@@ -2,102 +2,226 @@ package auth
 
 import (
 	"crypto/rand"
-	"database/sql"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"log"
+	"monopoly/store"
 	"net/http"
+	"sync"
 	"time"
 )
 
 const (
-	sessionDuration        = 7 * 24 * time.Hour // 7 days
-	sessionCleanupInterval = 1 * time.Hour
-	sessionIDByteLength    = 32
+	sessionIDByteLength = 32
+
+	// sessionRefreshStep skips the expiry write when a bump would extend the
+	// session by less than this, or when the session was written less than
+	// this ago, so busy clients don't write on every request
+	sessionRefreshStep = time.Minute
 )
 
+// SessionOptions controls how long sessions live
+type SessionOptions struct {
+	TTL             time.Duration // absolute lifetime from login
+	IdleTTL         time.Duration // expiry after the last successful validation, capped by TTL
+	CleanupInterval time.Duration // how often expired sessions are purged
+	GuestTTL        time.Duration // absolute lifetime of a guest's session, which can't be renewed by logging in
+}
+
 type Session struct {
 	UserID    int64
 	ExpiresAt time.Time
 }
 
+// SessionInfo describes a session to its owner without revealing its ID
+type SessionInfo struct {
+	Key        string    `json:"key"` // SessionKey of the session ID
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"` // the session making the request
+}
+
+// SessionKey is a stable, non-secret name for a session: a prefix of its ID's
+// SHA-256, enough to tell a user's sessions apart but useless as a cookie
+func SessionKey(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
 type SessionManager struct {
-	db *sql.DB
+	store store.SessionStore
+	opts  SessionOptions
+
+	// touched is when this process last wrote each session's expiry and last
+	// seen, so concurrent requests don't each write before the first lands
+	touchMu sync.Mutex
+	touched map[string]time.Time
+	touches sync.WaitGroup // writes still running, see touch
 }
 
-func NewSessionManager(db *sql.DB) *SessionManager {
+func NewSessionManager(sessions store.SessionStore, opts SessionOptions) *SessionManager {
 	sm := &SessionManager{
-		db: db,
+		store:   sessions,
+		opts:    opts,
+		touched: make(map[string]time.Time),
 	}
 	go sm.cleanupExpiredSessions()
 	return sm
 }
 
 func (sm *SessionManager) CreateSession(userID int64) (string, error) {
+	return sm.createSession(userID, false)
+}
+
+// CreateGuestSession starts a session for a guest account, capped at the
+// guest TTL instead of the regular one
+func (sm *SessionManager) CreateGuestSession(userID int64) (string, error) {
+	return sm.createSession(userID, true)
+}
+
+func (sm *SessionManager) createSession(userID int64, guest bool) (string, error) {
 	sessionID, err := generateSessionID()
 	if err != nil {
 		return "", err
 	}
 
-	expiresAt := time.Now().Add(sessionDuration)
-
-	_, err = sm.db.Exec(`
-		INSERT INTO sessions (session_id, user_id, expires_at)
-		VALUES (?, ?, ?)
-	`, sessionID, userID, expiresAt)
+	now := time.Now()
+	expiresAt := sm.nextExpiry(now, now, guest)
 
-	if err != nil {
+	if err := sm.store.CreateSession(sessionID, userID, now, expiresAt); err != nil {
 		return "", err
 	}
 
 	return sessionID, nil
 }
 
+// GetUserID validates the session and slides its expiry forward by the idle
+// TTL, never past the absolute TTL measured from creation. Guests are held to
+// the guest TTL until they claim their account.
 func (sm *SessionManager) GetUserID(sessionID string) (int64, bool) {
-	var userID int64
-	var expiresAt time.Time
-
-	err := sm.db.QueryRow(`
-		SELECT user_id, expires_at
-		FROM sessions
-		WHERE session_id = ?
-	`, sessionID).Scan(&userID, &expiresAt)
-
-	if err == sql.ErrNoRows {
-		return 0, false
-	}
-
+	session, err := sm.store.GetSession(sessionID)
 	if err != nil {
 		log.Printf("Error getting session: %v", err)
 		return 0, false
 	}
+	if session == nil {
+		return 0, false
+	}
 
 	// Check if session is expired
-	if time.Now().After(expiresAt) {
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
 		// Delete expired session
 		sm.DeleteSession(sessionID)
 		return 0, false
 	}
 
-	return userID, true
+	// Last seen shares the expiry's write, so it's as fresh as sessionRefreshStep
+	expiresAt, lastSeen := session.ExpiresAt, session.LastSeenAt
+	next := sm.nextExpiry(session.CreatedAt, now, session.IsGuest)
+	if next.Sub(expiresAt) >= sessionRefreshStep || !lastSeen.Valid || now.Sub(lastSeen.Time) >= sessionRefreshStep {
+		if next.Before(expiresAt) {
+			next = expiresAt
+		}
+		sm.touch(sessionID, now, next)
+	}
+
+	return session.UserID, true
+}
+
+// touch records that the session was used at now and slides its expiry to
+// next. The write happens in the background, off the request's path, and at
+// most once per sessionRefreshStep per session.
+func (sm *SessionManager) touch(sessionID string, now, next time.Time) {
+	sm.touchMu.Lock()
+	if last, ok := sm.touched[sessionID]; ok && now.Sub(last) < sessionRefreshStep {
+		sm.touchMu.Unlock()
+		return
+	}
+	sm.touched[sessionID] = now
+	sm.touchMu.Unlock()
+
+	sm.touches.Add(1)
+	go func() {
+		defer sm.touches.Done()
+		if err := sm.store.TouchSession(sessionID, next, now); err != nil {
+			log.Printf("Error refreshing session: %v", err)
+		}
+	}()
+}
+
+// forgetTouches drops the throttle entries that no longer hold anything back
+func (sm *SessionManager) forgetTouches(now time.Time) {
+	sm.touchMu.Lock()
+	defer sm.touchMu.Unlock()
+	for sessionID, last := range sm.touched {
+		if now.Sub(last) >= sessionRefreshStep {
+			delete(sm.touched, sessionID)
+		}
+	}
+}
+
+// nextExpiry is the idle expiry from now, capped at the absolute lifetime
+func (sm *SessionManager) nextExpiry(createdAt, now time.Time, guest bool) time.Time {
+	ttl := sm.opts.TTL
+	if guest {
+		ttl = sm.opts.GuestTTL
+	}
+	expiresAt := now.Add(sm.opts.IdleTTL)
+	if limit := createdAt.Add(ttl); expiresAt.After(limit) {
+		return limit
+	}
+	return expiresAt
 }
 
 func (sm *SessionManager) DeleteSession(sessionID string) {
-	_, err := sm.db.Exec(`
-		DELETE FROM sessions
-		WHERE session_id = ?
-	`, sessionID)
+	sm.touchMu.Lock()
+	delete(sm.touched, sessionID)
+	sm.touchMu.Unlock()
 
-	if err != nil {
+	if err := sm.store.DeleteSession(sessionID); err != nil {
 		log.Printf("Error deleting session: %v", err)
 	}
 }
 
+// ListSessions returns the user's unexpired sessions, most recently used
+// first. Session IDs are replaced by their SessionKey.
+func (sm *SessionManager) ListSessions(userID int64) ([]SessionInfo, error) {
+	stored, err := sm.store.ListSessions(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []SessionInfo{}
+	for _, session := range stored {
+		info := SessionInfo{
+			Key:        SessionKey(session.ID),
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.CreatedAt,
+			ExpiresAt:  session.ExpiresAt,
+		}
+		if session.LastSeenAt.Valid {
+			info.LastSeenAt = session.LastSeenAt.Time
+		}
+		sessions = append(sessions, info)
+	}
+	return sessions, nil
+}
+
+// DeleteUserSessions ends every session of the user and returns how many
+// there were
+func (sm *SessionManager) DeleteUserSessions(userID int64) (int, error) {
+	return sm.store.DeleteUserSessions(userID)
+}
+
 func (sm *SessionManager) SetSessionCookie(w http.ResponseWriter, sessionID string) {
 	cookie := &http.Cookie{
 		Name:     "session_id",
 		Value:    sessionID,
 		Path:     "/",
-		MaxAge:   int(sessionDuration.Seconds()),
+		MaxAge:   int(sm.opts.TTL.Seconds()),
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 		// Secure: true, // Enable in production with HTTPS
@@ -125,21 +249,15 @@ func GetSessionFromRequest(r *http.Request) string {
 }
 
 func (sm *SessionManager) cleanupExpiredSessions() {
-	ticker := time.NewTicker(sessionCleanupInterval)
+	ticker := time.NewTicker(sm.opts.CleanupInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		result, err := sm.db.Exec(`
-			DELETE FROM sessions
-			WHERE expires_at < ?
-		`, time.Now())
-
-		if err != nil {
+		sm.forgetTouches(time.Now())
+		if n, err := sm.store.DeleteExpiredSessions(time.Now()); err != nil {
 			log.Printf("Error cleaning up expired sessions: %v", err)
-		} else {
-			if rows, err := result.RowsAffected(); err == nil && rows > 0 {
-				log.Printf("Cleaned up %d expired sessions", rows)
-			}
+		} else if n > 0 {
+			log.Printf("Cleaned up %d expired sessions", n)
 		}
 	}
 }
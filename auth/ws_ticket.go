@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// WSTicketTTL is how long a WebSocket ticket can be redeemed after minting
+const WSTicketTTL = 30 * time.Second
+
+// wsTicket lets one WebSocket handshake authenticate as the session that
+// minted it, for clients whose upgrade requests arrive without the cookie
+type wsTicket struct {
+	sessionID string
+	expiresAt time.Time
+}
+
+// wsTickets holds unredeemed tickets in memory. They live seconds, so losing
+// them on restart only makes a client fetch a new one. They are also only
+// known to the instance that minted them: behind a load balancer spreading
+// requests over several instances, the upgrade must land on the same one
+// (sticky sessions) until tickets move to shared storage.
+type wsTickets struct {
+	mu      sync.Mutex
+	tickets map[string]wsTicket
+}
+
+func newWSTickets() *wsTickets {
+	return &wsTickets{tickets: make(map[string]wsTicket)}
+}
+
+// IssueWSTicket mints a single-use ticket bound to sessionID, valid for
+// WSTicketTTL
+func (s *Service) IssueWSTicket(sessionID string) (string, error) {
+	token, err := generateSessionID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	s.wsTickets.mu.Lock()
+	defer s.wsTickets.mu.Unlock()
+	for t, ticket := range s.wsTickets.tickets {
+		if now.After(ticket.expiresAt) {
+			delete(s.wsTickets.tickets, t)
+		}
+	}
+	s.wsTickets.tickets[token] = wsTicket{sessionID: sessionID, expiresAt: now.Add(WSTicketTTL)}
+	return token, nil
+}
+
+// RedeemWSTicket consumes the ticket and returns the user of the session it
+// was minted for. Fails for unknown, used or expired tickets, and once the
+// session has ended.
+func (s *Service) RedeemWSTicket(token string) (int64, bool) {
+	s.wsTickets.mu.Lock()
+	ticket, ok := s.wsTickets.tickets[token]
+	delete(s.wsTickets.tickets, token)
+	s.wsTickets.mu.Unlock()
+
+	if !ok || time.Now().After(ticket.expiresAt) {
+		return 0, false
+	}
+	return s.ValidateSession(ticket.sessionID)
+}
@@ -3,15 +3,111 @@ package config
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	ServerPort    string
-	DBPath        string
+	DBDriver      string // one of store.Drivers; Open rejects the rest
+	DBDSN         string // where the database is: for sqlite, the file path
 	SessionSecret string
 	MaxOpenConns  int
 	MaxIdleConns  int
+	DBBusyTimeout time.Duration // how long SQLite writes wait for a lock
+
+	// Session lifetime: idle expiry slides on use, capped by the absolute TTL
+	SessionTTL             time.Duration
+	SessionIdleTTL         time.Duration
+	SessionCleanupInterval time.Duration
+	GuestSessionTTL        time.Duration // absolute lifetime of a guest's session
+
+	// Username rules for registration and claimed guests. The pattern is
+	// matched against the whole sanitized name; lengths count characters.
+	UsernameMinLength int
+	UsernameMaxLength int
+	UsernamePattern   string
+
+	// Auth rate limits, per client IP
+	LoginRatePerMin    float64
+	LoginBurst         int
+	RegisterRatePerMin float64
+	RegisterBurst      int
+
+	// WebSocket limits
+	WSMaxMessageSize    int // max size of an incoming message, in bytes
+	WSSendBufferSize    int // queued outgoing messages per client
+	WSReadBufferSize    int // upgrader I/O buffer sizes, in bytes
+	WSWriteBufferSize   int
+	WSMessageRate       float64 // incoming messages per second per game socket; 0 = unlimited
+	WSMessageBurst      int
+	WSCompression       bool // offer permessage-deflate; trades CPU for bandwidth on large messages
+	WSFineGrainedEvents bool // send each event of an action separately instead of one turn_update
+
+	// WebSocket origins allowed besides the site itself and clients sending
+	// no Origin: exact origins, and custom schemes of native app webviews
+	WSAllowedOrigins   []string
+	WSAppOriginSchemes []string
+
+	// GET /metrics, off by default and reachable only from these CIDR
+	// networks (loopback if none). Behind a reverse proxy on the same host
+	// every request comes from loopback, so set the proxy's networks to
+	// exclude it or block /metrics at the proxy.
+	MetricsEnabled     bool
+	MetricsAllowedNets []string
+
+	// AdminUserIDs may use the /api/admin endpoints. Rights follow the user
+	// ID, which is never reused, rather than a username that can be freed
+	// by deleting the account and registered again by someone else.
+	AdminUserIDs []int64
+	// SeededRandomness draws dice and card shuffles from each game's stored
+	// seed so games can be reproduced. Debugging only: seeds make rolls predictable.
+	SeededRandomness bool
+	// MoneyAudit checks after every action that moves money that the players'
+	// total changed by exactly what went to and from the bank, logging any
+	// difference. Debugging only: each check costs a query.
+	MoneyAudit bool
+
+	// Lobby limits
+	MaxActiveGamesPerUser int           // non-finished games a user may be part of when creating another
+	StartCountdownSeconds int           // delay before a game starts once all players are ready; 0 = immediate
+	IdempotencyKeyTTL     time.Duration // how long a create request's Idempotency-Key is remembered
+
+	// Trade offers left unanswered this long are auto-declined; 0 = never
+	TradeTTL time.Duration
+
+	// A player whose socket drops during their turn has this long to
+	// reconnect before the turn is timed out; their clock is paused meanwhile.
+	// 0 keeps the turn clock running through drops
+	TurnReconnectGrace time.Duration
+
+	// Finished games are marked archived this long after they end, keeping
+	// their results; 0 disables the job
+	GameArchiveAfter    time.Duration
+	GameArchiveInterval time.Duration
+
+	// Games in progress that no player has been connected to for this long
+	// are finished, the richest player winning; 0 disables the sweep
+	GameAbandonAfter    time.Duration
+	GameAbandonInterval time.Duration
+
+	// Game rooms of finished or abandoned waiting games are dropped from
+	// memory after this long without activity; 0 disables the sweep
+	RoomIdleTimeout   time.Duration
+	RoomSweepInterval time.Duration
+
+	// StaticDir holds the frontend (index.html, css, js, templates). With
+	// EmbedStatic the copy compiled into the binary is served instead.
+	StaticDir   string
+	EmbedStatic bool
 }
 
 func Load() *Config {
@@ -19,11 +115,284 @@ func Load() *Config {
 
 	return &Config{
 		ServerPort:    ":8080",
-		DBPath:        "./monopoly.db",
+		DBDriver:      envString("DB_DRIVER", "sqlite"),
+		DBDSN:         envString("DB_DSN", "./monopoly.db"),
 		SessionSecret: secret,
 		MaxOpenConns:  25,
 		MaxIdleConns:  5,
+		DBBusyTimeout: envDuration("DB_BUSY_TIMEOUT", 5*time.Second),
+
+		SessionTTL:             envDuration("SESSION_TTL", 7*24*time.Hour),
+		SessionIdleTTL:         envDuration("SESSION_IDLE_TTL", 24*time.Hour),
+		SessionCleanupInterval: envDuration("SESSION_CLEANUP_INTERVAL", time.Hour),
+		GuestSessionTTL:        envDuration("GUEST_SESSION_TTL", 12*time.Hour),
+
+		UsernameMinLength: envInt("USERNAME_MIN_LENGTH", 3),
+		UsernameMaxLength: envInt("USERNAME_MAX_LENGTH", 20),
+		UsernamePattern:   envString("USERNAME_PATTERN", "^[a-zA-Z0-9]+$"),
+
+		LoginRatePerMin:    envFloat("LOGIN_RATE_PER_MIN", 5),
+		LoginBurst:         envInt("LOGIN_BURST", 5),
+		RegisterRatePerMin: envFloat("REGISTER_RATE_PER_MIN", 3),
+		RegisterBurst:      envInt("REGISTER_BURST", 3),
+
+		WSMaxMessageSize:  envInt("WS_MAX_MESSAGE_SIZE", 64*1024),
+		WSSendBufferSize:  envInt("WS_SEND_BUFFER_SIZE", 256),
+		WSReadBufferSize:  envInt("WS_READ_BUFFER_SIZE", 1024),
+		WSWriteBufferSize: envInt("WS_WRITE_BUFFER_SIZE", 1024),
+		WSMessageRate:     envFloat("WS_MESSAGE_RATE", 10),
+		WSMessageBurst:    envInt("WS_MESSAGE_BURST", 20),
+		WSCompression:     envBool("WS_COMPRESSION", true),
+
+		WSFineGrainedEvents: envBool("WS_FINE_GRAINED_EVENTS", false),
+
+		WSAllowedOrigins:   envList("WS_ALLOWED_ORIGINS"),
+		WSAppOriginSchemes: envList("WS_APP_ORIGIN_SCHEMES"),
+
+		MetricsEnabled:     envBool("METRICS_ENABLED", false),
+		MetricsAllowedNets: envList("METRICS_ALLOWED_NETS"),
+
+		AdminUserIDs:     envIDList("ADMIN_USER_IDS"),
+		SeededRandomness: envBool("SEEDED_RANDOMNESS", false),
+		MoneyAudit:       envBool("MONEY_AUDIT", false),
+
+		MaxActiveGamesPerUser: envInt("MAX_ACTIVE_GAMES_PER_USER", 3),
+		StartCountdownSeconds: envInt("START_COUNTDOWN_SECONDS", 5),
+		IdempotencyKeyTTL:     envDuration("IDEMPOTENCY_KEY_TTL", 5*time.Minute),
+
+		TradeTTL: envDuration("TRADE_TTL", 60*time.Second),
+
+		TurnReconnectGrace: envDuration("TURN_RECONNECT_GRACE", 30*time.Second),
+
+		GameArchiveAfter:    envDuration("GAME_ARCHIVE_AFTER", 30*24*time.Hour),
+		GameArchiveInterval: envDuration("GAME_ARCHIVE_INTERVAL", time.Hour),
+
+		GameAbandonAfter:    envDuration("GAME_ABANDON_AFTER", 24*time.Hour),
+		GameAbandonInterval: envDuration("GAME_ABANDON_INTERVAL", 10*time.Minute),
+
+		RoomIdleTimeout:   envDuration("ROOM_IDLE_TIMEOUT", 30*time.Minute),
+		RoomSweepInterval: envDuration("ROOM_SWEEP_INTERVAL", time.Minute),
+
+		StaticDir:   envString("STATIC_DIR", "./static"),
+		EmbedStatic: envBool("EMBED_STATIC", false),
+	}
+}
+
+// Validate checks that the loaded values are usable
+func (c *Config) Validate() error {
+	if c.DBDriver == "" {
+		return fmt.Errorf("DB_DRIVER must not be empty")
+	}
+	if c.DBDSN == "" {
+		return fmt.Errorf("DB_DSN must not be empty")
+	}
+	if c.DBBusyTimeout < 0 {
+		return fmt.Errorf("DB_BUSY_TIMEOUT must not be negative, got %v", c.DBBusyTimeout)
+	}
+	if c.SessionTTL <= 0 {
+		return fmt.Errorf("SESSION_TTL must be positive, got %v", c.SessionTTL)
+	}
+	if c.SessionIdleTTL <= 0 || c.SessionIdleTTL > c.SessionTTL {
+		return fmt.Errorf("SESSION_IDLE_TTL must be positive and at most SESSION_TTL, got %v", c.SessionIdleTTL)
+	}
+	if c.SessionCleanupInterval <= 0 {
+		return fmt.Errorf("SESSION_CLEANUP_INTERVAL must be positive, got %v", c.SessionCleanupInterval)
+	}
+	if c.GuestSessionTTL <= 0 || c.GuestSessionTTL > c.SessionTTL {
+		return fmt.Errorf("GUEST_SESSION_TTL must be positive and at most SESSION_TTL, got %v", c.GuestSessionTTL)
+	}
+	if c.UsernameMinLength < 1 {
+		return fmt.Errorf("USERNAME_MIN_LENGTH must be at least 1, got %d", c.UsernameMinLength)
+	}
+	if c.UsernameMaxLength < c.UsernameMinLength {
+		return fmt.Errorf("USERNAME_MAX_LENGTH must be at least USERNAME_MIN_LENGTH, got %d", c.UsernameMaxLength)
+	}
+	if _, err := regexp.Compile(c.UsernamePattern); err != nil {
+		return fmt.Errorf("USERNAME_PATTERN is not a valid regular expression: %w", err)
+	}
+	if c.LoginRatePerMin <= 0 {
+		return fmt.Errorf("LOGIN_RATE_PER_MIN must be positive, got %v", c.LoginRatePerMin)
+	}
+	if c.LoginBurst < 1 {
+		return fmt.Errorf("LOGIN_BURST must be at least 1, got %d", c.LoginBurst)
+	}
+	if c.RegisterRatePerMin <= 0 {
+		return fmt.Errorf("REGISTER_RATE_PER_MIN must be positive, got %v", c.RegisterRatePerMin)
+	}
+	if c.RegisterBurst < 1 {
+		return fmt.Errorf("REGISTER_BURST must be at least 1, got %d", c.RegisterBurst)
+	}
+	if c.WSMaxMessageSize < 1 {
+		return fmt.Errorf("WS_MAX_MESSAGE_SIZE must be positive, got %d", c.WSMaxMessageSize)
+	}
+	if c.WSSendBufferSize < 1 {
+		return fmt.Errorf("WS_SEND_BUFFER_SIZE must be at least 1, got %d", c.WSSendBufferSize)
+	}
+	if c.WSReadBufferSize < 1 || c.WSWriteBufferSize < 1 {
+		return fmt.Errorf("WS_READ_BUFFER_SIZE and WS_WRITE_BUFFER_SIZE must be positive")
+	}
+	if c.WSMessageRate < 0 {
+		return fmt.Errorf("WS_MESSAGE_RATE must not be negative, got %v", c.WSMessageRate)
+	}
+	if c.WSMessageRate > 0 && c.WSMessageBurst < 1 {
+		return fmt.Errorf("WS_MESSAGE_BURST must be at least 1, got %d", c.WSMessageBurst)
+	}
+	for _, cidr := range c.MetricsAllowedNets {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("METRICS_ALLOWED_NETS entries must be CIDR networks like 10.0.0.0/8, got %q", cidr)
+		}
+	}
+	for _, origin := range c.WSAllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("WS_ALLOWED_ORIGINS entries must be origins like https://example.com, got %q", origin)
+		}
+	}
+	for _, scheme := range c.WSAppOriginSchemes {
+		// Allowing a web scheme on any host would let every site connect
+		if strings.EqualFold(scheme, "http") || strings.EqualFold(scheme, "https") || strings.Contains(scheme, ":") {
+			return fmt.Errorf("WS_APP_ORIGIN_SCHEMES entries must be bare custom schemes like capacitor, got %q", scheme)
+		}
+	}
+	if c.MaxActiveGamesPerUser < 1 {
+		return fmt.Errorf("MAX_ACTIVE_GAMES_PER_USER must be at least 1, got %d", c.MaxActiveGamesPerUser)
+	}
+	if c.StartCountdownSeconds < 0 {
+		return fmt.Errorf("START_COUNTDOWN_SECONDS must not be negative, got %d", c.StartCountdownSeconds)
+	}
+	if c.IdempotencyKeyTTL <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_KEY_TTL must be positive, got %v", c.IdempotencyKeyTTL)
+	}
+	if c.TradeTTL < 0 {
+		return fmt.Errorf("TRADE_TTL must not be negative, got %v", c.TradeTTL)
+	}
+	if c.TurnReconnectGrace < 0 {
+		return fmt.Errorf("TURN_RECONNECT_GRACE must not be negative, got %v", c.TurnReconnectGrace)
+	}
+	if c.GameArchiveAfter < 0 {
+		return fmt.Errorf("GAME_ARCHIVE_AFTER must not be negative, got %v", c.GameArchiveAfter)
+	}
+	if c.GameArchiveAfter > 0 && c.GameArchiveInterval <= 0 {
+		return fmt.Errorf("GAME_ARCHIVE_INTERVAL must be positive, got %v", c.GameArchiveInterval)
+	}
+	if c.GameAbandonAfter < 0 {
+		return fmt.Errorf("GAME_ABANDON_AFTER must not be negative, got %v", c.GameAbandonAfter)
+	}
+	if c.GameAbandonAfter > 0 && c.GameAbandonInterval <= 0 {
+		return fmt.Errorf("GAME_ABANDON_INTERVAL must be positive, got %v", c.GameAbandonInterval)
+	}
+	if c.RoomIdleTimeout < 0 {
+		return fmt.Errorf("ROOM_IDLE_TIMEOUT must not be negative, got %v", c.RoomIdleTimeout)
+	}
+	if c.RoomIdleTimeout > 0 && c.RoomSweepInterval <= 0 {
+		return fmt.Errorf("ROOM_SWEEP_INTERVAL must be positive, got %v", c.RoomSweepInterval)
+	}
+	if !c.EmbedStatic {
+		if _, err := os.Stat(filepath.Join(c.StaticDir, "index.html")); err != nil {
+			return fmt.Errorf("STATIC_DIR %q has no index.html; set it to the static directory or use EMBED_STATIC=true", c.StaticDir)
+		}
+	}
+	return nil
+}
+
+// envString reads a string from the environment, falling back to def when unset
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt reads an integer from the environment, falling back to def when unset or malformed
+func envInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %d", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// envFloat reads a float from the environment, falling back to def when unset or malformed
+func envFloat(key string, def float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %v", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// envDuration reads a Go duration (e.g. "24h") from the environment, falling back to def when unset or malformed
+func envDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %v", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// envBool reads a boolean (e.g. "true", "1") from the environment, falling back to def when unset or malformed
+func envBool(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %v", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// envList reads a comma-separated list from the environment, skipping blank entries
+func envList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// envIDList reads a comma-separated list of user IDs from the environment,
+// skipping blank and malformed entries
+func envIDList(key string) []int64 {
+	var ids []int64
+	for _, raw := range envList(key) {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			log.Printf("Invalid user ID %q in %s, skipping it", raw, key)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// IsAdmin reports whether userID is listed in ADMIN_USER_IDS
+func (c *Config) IsAdmin(userID int64) bool {
+	for _, admin := range c.AdminUserIDs {
+		if admin == userID {
+			return true
+		}
 	}
+	return false
 }
 
 func generateSessionSecret() string {
@@ -1,6 +1,10 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+)
 
 // ErrorCode represents a specific error type
 type ErrorCode string
@@ -11,6 +15,7 @@ const (
 	ErrCodeGameFull         ErrorCode = "GAME_FULL"
 	ErrCodeGameStarted      ErrorCode = "GAME_STARTED"
 	ErrCodeGameNotStarted   ErrorCode = "GAME_NOT_STARTED"
+	ErrCodeGameFinished     ErrorCode = "GAME_FINISHED"
 	ErrCodeNotEnoughPlayers ErrorCode = "NOT_ENOUGH_PLAYERS"
 	ErrCodeAlreadyInGame    ErrorCode = "ALREADY_IN_GAME"
 	ErrCodeNotInGame        ErrorCode = "NOT_IN_GAME"
@@ -37,20 +42,35 @@ const (
 	ErrCodeNoAuction            ErrorCode = "NO_AUCTION"
 	ErrCodeNotYourBid           ErrorCode = "NOT_YOUR_BID"
 	ErrCodeBidTooLow            ErrorCode = "BID_TOO_LOW"
+	ErrCodeInvalidTaxChoice     ErrorCode = "INVALID_TAX_CHOICE"
+	ErrCodeNoTaxDue             ErrorCode = "NO_TAX_DUE"
+	ErrCodeTooManyGames         ErrorCode = "TOO_MANY_GAMES"
 
 	// Auth errors
 	ErrCodeUnauthorized      ErrorCode = "UNAUTHORIZED"
 	ErrCodeInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
 	ErrCodeInvalidUsername   ErrorCode = "INVALID_USERNAME"
 	ErrCodeInvalidPassword   ErrorCode = "INVALID_PASSWORD"
+	ErrCodeInvalidDisplayName ErrorCode = "INVALID_DISPLAY_NAME"
 	ErrCodeUserExists        ErrorCode = "USER_EXISTS"
 	ErrCodeUserNotFound      ErrorCode = "USER_NOT_FOUND"
+	ErrCodeValidationFailed  ErrorCode = "VALIDATION_FAILED"
+
+	// Field errors, reported per field inside VALIDATION_FAILED
+	ErrCodeTooShort          ErrorCode = "TOO_SHORT"
+	ErrCodeTooLong           ErrorCode = "TOO_LONG"
+	ErrCodeInvalidCharacters ErrorCode = "INVALID_CHARACTERS"
+	ErrCodeMissingLetter     ErrorCode = "MISSING_LETTER"
+	ErrCodeMissingDigit      ErrorCode = "MISSING_DIGIT"
 
 	// General errors
 	ErrCodeInternal    ErrorCode = "INTERNAL_ERROR"
 	ErrCodeBadRequest  ErrorCode = "BAD_REQUEST"
 	ErrCodeNotFound    ErrorCode = "NOT_FOUND"
 	ErrCodeForbidden   ErrorCode = "FORBIDDEN"
+	ErrCodeRateLimited ErrorCode = "RATE_LIMITED"
+	ErrCodeConflict    ErrorCode = "CONFLICT"
+	ErrCodeUnsupportedProtocol ErrorCode = "UNSUPPORTED_PROTOCOL"
 )
 
 // AppError represents a user-friendly application error
@@ -59,6 +79,15 @@ type AppError struct {
 	Message string    // User-friendly message
 	Detail  string    // Internal detail for logging
 	Err     error     // Underlying error for unwrapping
+	Fields  []FieldError // Per-field problems of a VALIDATION_FAILED error
+	RetryAfter int       // Seconds until a RATE_LIMITED request may be retried; 0 if unknown
+}
+
+// FieldError is one problem with one input field, so clients can point at it
+type FieldError struct {
+	Field   string    `json:"field"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
 }
 
 func (e *AppError) Error() string {
@@ -103,6 +132,16 @@ func Wrap(err error, code ErrorCode, message string) *AppError {
 	}
 }
 
+// From returns the AppError in err's chain. Any other error becomes an
+// internal error, so callers always have a stable code to report.
+func From(err error) *AppError {
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
+		return appErr
+	}
+	return InternalError(err.Error())
+}
+
 // Predefined errors with user-friendly messages
 
 func GameNotFound() *AppError {
@@ -117,6 +156,10 @@ func GameAlreadyStarted() *AppError {
 	return New(ErrCodeGameStarted, "This game has already started")
 }
 
+func GameFinished() *AppError {
+	return New(ErrCodeGameFinished, "Game has already finished")
+}
+
 func GameNotStarted() *AppError {
 	return New(ErrCodeGameNotStarted, "This game has not started yet")
 }
@@ -157,10 +200,28 @@ func InvalidPassword() *AppError {
 	return New(ErrCodeInvalidPassword, "Password must be at least 8 characters with letters and numbers")
 }
 
+func InvalidDisplayName() *AppError {
+	return New(ErrCodeInvalidDisplayName, "Display name must be at most 24 printable characters")
+}
+
 func UserExists() *AppError {
 	return New(ErrCodeUserExists, "Username already taken")
 }
 
+// ValidationFailed reports every problem found in a form at once. The message
+// joins the field messages for clients that don't look at the fields.
+func ValidationFailed(fields ...FieldError) *AppError {
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	return &AppError{
+		Code:    ErrCodeValidationFailed,
+		Message: strings.Join(messages, " "),
+		Fields:  fields,
+	}
+}
+
 func UserNotFound() *AppError {
 	return New(ErrCodeUserNotFound, "User not found")
 }
@@ -177,6 +238,16 @@ func BadRequest(message string) *AppError {
 	return New(ErrCodeBadRequest, message)
 }
 
+func RateLimited() *AppError {
+	return New(ErrCodeRateLimited, "You're sending too fast. Please slow down.")
+}
+
+// UnsupportedProtocol is sent to a WebSocket client that offered only protocol
+// versions the server doesn't speak
+func UnsupportedProtocol(supported []string) *AppError {
+	return New(ErrCodeUnsupportedProtocol, fmt.Sprintf("Unsupported client version, please reload. Supported protocols: %s", strings.Join(supported, ", ")))
+}
+
 func AlreadyRolled() *AppError {
 	return New(ErrCodeAlreadyRolled, "You have already rolled this turn")
 }
@@ -237,12 +308,12 @@ func UnevenBuild() *AppError {
 	return New(ErrCodeUnevenBuild, "You must build evenly across all properties in a color group")
 }
 
-func HouseShortage() *AppError {
-	return New(ErrCodeHouseShortage, "No houses available (max 32 houses in game)")
+func HouseShortage(limit int) *AppError {
+	return Newf(ErrCodeHouseShortage, "No houses left in the bank (max %d houses in game)", limit)
 }
 
-func HotelShortage() *AppError {
-	return New(ErrCodeHotelShortage, "No hotels available (max 12 hotels in game)")
+func HotelShortage(limit int) *AppError {
+	return Newf(ErrCodeHotelShortage, "No hotels left in the bank (max %d hotels in game)", limit)
 }
 
 func AuctionInProgress() *AppError {
@@ -260,3 +331,15 @@ func NotYourBid() *AppError {
 func BidTooLow() *AppError {
 	return New(ErrCodeBidTooLow, "Bid must be higher than current bid")
 }
+
+func InvalidTaxChoice() *AppError {
+	return New(ErrCodeInvalidTaxChoice, `Income tax choice must be "flat" or "percent"`)
+}
+
+func NoTaxDue() *AppError {
+	return New(ErrCodeNoTaxDue, "You have no income tax to pay")
+}
+
+func TooManyGames(limit int) *AppError {
+	return Newf(ErrCodeTooManyGames, "You already have the maximum number of active games (%d)", limit)
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"log"
+	"time"
+)
+
+// RecordActivity notes that a player of the game is connected now, which
+// keeps FinishAbandoned away from it
+func (e *Engine) RecordActivity(gameID int64) {
+	if err := e.store.TouchGameActivity(gameID); err != nil {
+		log.Printf("Failed to record activity for game %d: %v", gameID, err)
+	}
+}
+
+// InactiveGames lists the games in progress with no recorded activity since
+// before (or since they started, if later)
+func (e *Engine) InactiveGames(before time.Time) ([]int64, error) {
+	return e.store.ListInactiveGames(before)
+}
+
+// FinishAbandoned ends a game in progress that no player has been connected
+// to since before. The richest solvent player wins, as with a time limit.
+// Returns nil if the game has finished or seen activity in the meantime.
+func (e *Engine) FinishAbandoned(gameID int64, before time.Time) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	tx, err := e.store.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer e.store.RollbackTx(tx)
+
+	game, err := e.store.GetGameTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil || game.Status != StatusInProgress || max(game.StartedAt, game.LastActivityAt) >= before.Unix() {
+		return nil, nil
+	}
+
+	winnerID, err := e.richestActivePlayerTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	event, err := e.finishGameTx(tx, gameID, winnerID, EndReasonAbandoned)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.store.CommitTx(tx); err != nil {
+		return nil, err
+	}
+
+	e.setAuction(gameID, nil)
+	e.setDoubles(gameID, 0)
+	e.forgetMoneyLedger(gameID)
+	return event, nil
+}
@@ -0,0 +1,54 @@
+package game
+
+import (
+	stderrors "errors"
+	"log"
+	"monopoly/errors"
+	"monopoly/store"
+	"time"
+)
+
+// ArchiveGame archives a finished game by hand
+func (l *Lobby) ArchiveGame(gameID int64) error {
+	err := l.store.ArchiveGame(gameID)
+	switch {
+	case stderrors.Is(err, store.ErrGameNotFound):
+		return errors.GameNotFound()
+	case stderrors.Is(err, store.ErrGameNotFinished):
+		return errors.BadRequest("Only finished games can be archived")
+	}
+	return err
+}
+
+// ListArchivedGames returns a page of archived games and the total archived,
+// with the same paging limits as ListGames
+func (l *Lobby) ListArchivedGames(limit, offset int) ([]*store.ArchivedGameDTO, int, error) {
+	if limit <= 0 {
+		limit = DefaultGamesPageSize
+	}
+	if limit > MaxGamesPageSize {
+		limit = MaxGamesPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return l.store.ListArchivedGames(limit, offset)
+}
+
+// StartArchiver archives games that finished more than after ago, checking
+// every interval for the life of the process
+func (l *Lobby) StartArchiver(interval, after time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			archived, err := l.store.ArchiveFinishedGames(time.Now().Add(-after))
+			if err != nil {
+				log.Printf("Error archiving finished games: %v", err)
+			} else if archived > 0 {
+				log.Printf("Archived %d finished games", archived)
+			}
+		}
+	}()
+}
@@ -0,0 +1,220 @@
+package game
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxDiscrepancies caps how many money discrepancies are kept per game
+const maxDiscrepancies = 20
+
+// MoneyAudit is the money breakdown of a game. Total is what its players hold
+// between them. With the audit on, Expected is what they should hold given
+// the starting money and every payment to and from the bank since the game
+// started (or since the server did, for games started before it), and
+// Discrepancies lists the actions after which the two disagreed. The
+// audit forgets a game once it finishes.
+type MoneyAudit struct {
+	GameID        int64              `json:"gameId"`
+	Status        string             `json:"status"`
+	Players       []PlayerMoney      `json:"players"`
+	Total         int                `json:"total"`
+	Tracked       bool               `json:"tracked"` // false with the audit off, before the game's first check or once it finished
+	Expected      int                `json:"expected,omitempty"`
+	PaidByBank    int                `json:"paidByBank"` // bank payments to players since tracking began
+	PaidToBank    int                `json:"paidToBank"` // player payments to the bank since tracking began
+	Discrepancies []MoneyDiscrepancy `json:"discrepancies"`
+}
+
+// PlayerMoney is one player's cash in a MoneyAudit
+type PlayerMoney struct {
+	UserID     int64  `json:"userId"`
+	Username   string `json:"username"`
+	Money      int    `json:"money"`
+	IsBankrupt bool   `json:"isBankrupt"`
+}
+
+// MoneyDiscrepancy records an action that left players holding a different
+// total than its bank payments account for
+type MoneyDiscrepancy struct {
+	At       time.Time `json:"at"`
+	Events   []string  `json:"events"` // event types of the action, in order
+	Expected int       `json:"expected"`
+	Actual   int       `json:"actual"`
+}
+
+// moneyLedger is what the audit knows about one game's money
+type moneyLedger struct {
+	expected      int
+	paidByBank    int
+	paidToBank    int
+	discrepancies []MoneyDiscrepancy
+}
+
+// moneyAudit holds the ledgers of the games in progress checked since the
+// server started
+type moneyAudit struct {
+	mu      sync.Mutex
+	ledgers map[int64]*moneyLedger // gameID -> ledger
+}
+
+// SetMoneyAudit turns on checking, after every action that moves money, that
+// the players' total changed by exactly what was paid to and from the bank.
+// A mismatch is logged and listed by MoneyAudit. Off by default: each check
+// reads the game's players once more.
+func (e *Engine) SetMoneyAudit(on bool) {
+	if on {
+		e.audit = &moneyAudit{ledgers: make(map[int64]*moneyLedger)}
+	} else {
+		e.audit = nil
+	}
+}
+
+// bankFlows sums what the bank paid out to players and took in from them in
+// an action's events
+func bankFlows(events []*Event) (paidByBank, paidToBank int) {
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+		switch p := event.Payload.(type) {
+		case MoneyTransferredPayload:
+			if p.FromUserID == 0 {
+				paidByBank += p.Amount
+			} else if p.ToUserID == 0 {
+				paidToBank += p.Amount
+			}
+		case PropertyMortgagedPayload:
+			paidByBank += p.Amount
+		case PropertyUnmortgagedPayload:
+			paidToBank += p.Amount
+		case HouseBuiltPayload:
+			paidToBank += p.Cost
+		case HouseSoldPayload:
+			paidByBank += p.Refund
+		}
+	}
+	return paidByBank, paidToBank
+}
+
+// startMoneyLedger begins tracking a game that just started with total
+// dealt out to its players
+func (e *Engine) startMoneyLedger(gameID int64, total int) {
+	if e.audit == nil {
+		return
+	}
+	e.audit.mu.Lock()
+	defer e.audit.mu.Unlock()
+	e.audit.ledgers[gameID] = &moneyLedger{expected: total}
+}
+
+// forgetMoneyLedger drops a finished game's ledger
+func (e *Engine) forgetMoneyLedger(gameID int64) {
+	if e.audit == nil {
+		return
+	}
+	e.audit.mu.Lock()
+	defer e.audit.mu.Unlock()
+	delete(e.audit.ledgers, gameID)
+}
+
+// auditMoney checks the players' total after a committed action against the
+// ledger. The first check of a game the audit hasn't seen start only records
+// the total to check later actions against. An action that finishes the game
+// is checked last and drops the ledger.
+func (e *Engine) auditMoney(gameID int64, events []*Event) {
+	if e.audit == nil {
+		return
+	}
+	players, err := e.store.GetGamePlayers(gameID)
+	if err != nil {
+		log.Printf("Money audit: failed to load players of game %d: %v", gameID, err)
+		return
+	}
+	actual := 0
+	for _, p := range players {
+		actual += p.Money
+	}
+	paidByBank, paidToBank := bankFlows(events)
+	finished := slices.ContainsFunc(events, func(event *Event) bool {
+		return event != nil && event.Type == "game_finished"
+	})
+
+	e.audit.mu.Lock()
+	defer e.audit.mu.Unlock()
+	ledger, ok := e.audit.ledgers[gameID]
+	if finished {
+		delete(e.audit.ledgers, gameID)
+	}
+	if !ok {
+		if !finished {
+			e.audit.ledgers[gameID] = &moneyLedger{expected: actual}
+		}
+		return
+	}
+	ledger.paidByBank += paidByBank
+	ledger.paidToBank += paidToBank
+	ledger.expected += paidByBank - paidToBank
+	if actual == ledger.expected {
+		return
+	}
+
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		if event != nil {
+			types = append(types, event.Type)
+		}
+	}
+	log.Printf("Money audit: game %d holds $%d, expected $%d after %v", gameID, actual, ledger.expected, types)
+	ledger.discrepancies = append(ledger.discrepancies, MoneyDiscrepancy{
+		At:       time.Now(),
+		Events:   types,
+		Expected: ledger.expected,
+		Actual:   actual,
+	})
+	if len(ledger.discrepancies) > maxDiscrepancies {
+		ledger.discrepancies = ledger.discrepancies[len(ledger.discrepancies)-maxDiscrepancies:]
+	}
+	// Report each bug once rather than on every later action
+	ledger.expected = actual
+}
+
+// MoneyAudit returns the game's money breakdown
+func (e *Engine) MoneyAudit(gameID int64) (*MoneyAudit, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &MoneyAudit{
+		GameID:        gameID,
+		Status:        state.Status,
+		Players:       make([]PlayerMoney, 0, len(state.Players)),
+		Discrepancies: []MoneyDiscrepancy{},
+	}
+	for _, p := range state.Players {
+		audit.Players = append(audit.Players, PlayerMoney{
+			UserID:     p.UserID,
+			Username:   p.Username,
+			Money:      p.Money,
+			IsBankrupt: p.IsBankrupt,
+		})
+		audit.Total += p.Money
+	}
+
+	if e.audit == nil {
+		return audit, nil
+	}
+	e.audit.mu.Lock()
+	defer e.audit.mu.Unlock()
+	if ledger, ok := e.audit.ledgers[gameID]; ok {
+		audit.Tracked = true
+		audit.Expected = ledger.expected
+		audit.PaidByBank = ledger.paidByBank
+		audit.PaidToBank = ledger.paidToBank
+		audit.Discrepancies = append(audit.Discrepancies, ledger.discrepancies...)
+	}
+	return audit, nil
+}
@@ -41,6 +41,8 @@ type BoardSpace struct {
 	RentWithHouses [6]int     `json:"rentWithHouses,omitempty"` // Rent with 0-5 houses (5 = hotel)
 }
 
+// Board is the standard board. Games created with a custom board (see
+// ParseCustomBoard) keep its layout but not its names, prices or rents.
 var Board = [40]BoardSpace{
 	{Position: 0, Name: "GO", Type: SpaceGo},
 	{Position: 1, Name: "Mediterranean Ave", Type: SpaceProperty, Color: ColorBrown, Price: 60, Rent: 2, GroupSize: 2, HouseCost: 50, RentWithHouses: [6]int{2, 10, 30, 90, 160, 250}},
@@ -96,7 +98,8 @@ func CalculateRent(space BoardSpace, ownerProperties []int, diceTotal int, impro
 			return space.RentWithHouses[improvements]
 		}
 
-		// Check if owner has monopoly (all properties of same color group)
+		// Check if owner has monopoly (all properties of same color group).
+		// Custom boards keep the standard color layout, so Board will do.
 		colorCount := 0
 		for _, pos := range ownerProperties {
 			if pos >= 0 && pos < 40 && Board[pos].Color == space.Color {
@@ -119,18 +122,11 @@ func CalculateRent(space BoardSpace, ownerProperties []int, diceTotal int, impro
 				}
 			}
 		}
-		switch rrCount {
-		case 1:
-			return 25
-		case 2:
-			return 50
-		case 3:
-			return 100
-		case 4:
-			return 200
-		default:
-			return 25
+		// Rent doubles with each railroad owned: 25, 50, 100, 200 on the standard board
+		if rrCount < 1 {
+			rrCount = 1
 		}
+		return space.Rent << (rrCount - 1)
 
 	case SpaceUtility:
 		utilCount := 0
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"cmp"
+	"monopoly/store"
+)
+
+// The bank's building supply. Official rules stop building when it runs out,
+// which makes hoarding houses a strategy; games may change the limits or, as
+// a house rule, drop them.
+const (
+	DefaultHouseLimit = 32
+	DefaultHotelLimit = 12
+	MaxBuildingLimit  = 100 // per kind, for games with a bigger bank
+)
+
+// buildingSupply is how many houses and hotels a game's bank started with
+type buildingSupply struct {
+	houses, hotels int
+	unlimited      bool
+}
+
+func supplyFor(game *store.Game) buildingSupply {
+	return buildingSupply{
+		houses:    cmp.Or(game.HouseLimit, DefaultHouseLimit),
+		hotels:    cmp.Or(game.HotelLimit, DefaultHotelLimit),
+		unlimited: game.UnlimitedBuilding,
+	}
+}
+
+// buildingsInPlay counts the houses and hotels standing on the board. A hotel
+// (5 improvements) replaces its four houses, which went back to the bank.
+func buildingsInPlay(improvements map[int]int) (houses, hotels int) {
+	for _, count := range improvements {
+		if count == 5 {
+			hotels++
+		} else {
+			houses += count
+		}
+	}
+	return houses, hotels
+}
+
+// left returns what the bank still holds given what's on the board. Both are
+// 0 with unlimited building, where nothing is counted.
+func (s buildingSupply) left(houses, hotels int) (int, int) {
+	if s.unlimited {
+		return 0, 0
+	}
+	return max(s.houses-houses, 0), max(s.hotels-hotels, 0)
+}
@@ -0,0 +1,46 @@
+package game
+
+import "monopoly/errors"
+
+// CancelGame lets the host call off a game that hasn't started, e.g. one
+// created by mistake. The game is finished without a winner and with
+// EndReasonCancelled, so its players are free to join another one.
+func (e *Engine) CancelGame(gameID, hostUserID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireStatus(state.Status, StatusWaiting); err != nil {
+		return nil, err
+	}
+	if hostUserID != state.HostUserID {
+		return nil, errors.New(errors.ErrCodeForbidden, "Only the host can cancel the game")
+	}
+
+	tx, err := e.store.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer e.store.RollbackTx(tx)
+
+	if err := e.store.FinishGameTx(tx, gameID, 0, EndReasonCancelled); err != nil {
+		return nil, err
+	}
+	if err := e.store.CommitTx(tx); err != nil {
+		return nil, err
+	}
+
+	e.forgetGameRand(gameID)
+
+	return &Event{
+		Type:   "game_cancelled",
+		GameID: gameID,
+		Payload: GameCancelledPayload{
+			GameID: gameID,
+			UserID: hostUserID,
+		},
+	}, nil
+}
@@ -67,13 +67,18 @@ var CommunityChestCards = []Card{
 	{16, CardTypeCollectMoney, "You have won second prize in a beauty contest. Collect $10", 10, 0, 0, ""},
 }
 
-// ShuffleDeck returns a shuffled order of card indices
-func ShuffleDeck(numCards int) []int {
+// ShuffleDeck returns a shuffled order of card indices, drawn from rng if
+// given (seeded games) and the global source otherwise
+func ShuffleDeck(numCards int, rng *rand.Rand) []int {
 	order := make([]int, numCards)
 	for i := range order {
 		order[i] = i
 	}
-	rand.Shuffle(len(order), func(i, j int) {
+	shuffle := rand.Shuffle
+	if rng != nil {
+		shuffle = rng.Shuffle
+	}
+	shuffle(len(order), func(i, j int) {
 		order[i], order[j] = order[j], order[i]
 	})
 	return order
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"monopoly/errors"
+	"monopoly/store"
+)
+
+const (
+	// Limits for custom board values, so a board can't overflow money math
+	maxBoardAmount    = 100000
+	maxSpaceNameRunes = 40
+)
+
+// ParseCustomBoard validates a board supplied at game creation and returns it
+// with group sizes filled in. A custom board renames and reprices the standard
+// one: each space must keep its position, type and color group, since cards,
+// jail and rent rules rely on where things are. Names, prices, rents, taxes
+// and house costs are free to change.
+func ParseCustomBoard(data []byte) (*[40]BoardSpace, error) {
+	var spaces []BoardSpace
+	if err := json.Unmarshal(data, &spaces); err != nil {
+		return nil, errors.BadRequest("board must be a JSON array of board spaces")
+	}
+	if len(spaces) != len(Board) {
+		return nil, errors.BadRequest(fmt.Sprintf("board must have exactly %d spaces, got %d", len(Board), len(spaces)))
+	}
+
+	var board [40]BoardSpace
+	for i, space := range spaces {
+		std := Board[i]
+		if space.Position != i {
+			return nil, errors.BadRequest(fmt.Sprintf("board space %d has position %d", i, space.Position))
+		}
+		if space.Type != std.Type || space.Color != std.Color {
+			return nil, errors.BadRequest(fmt.Sprintf("board space %d must be a %s%s like the standard board", i, std.Type, colorSuffix(std.Color)))
+		}
+		if n := len([]rune(space.Name)); n == 0 || n > maxSpaceNameRunes {
+			return nil, errors.BadRequest(fmt.Sprintf("board space %d needs a name of 1-%d characters", i, maxSpaceNameRunes))
+		}
+
+		amounts := append([]int{space.Price, space.Rent, space.TaxAmount, space.HouseCost}, space.RentWithHouses[:]...)
+		for _, amount := range amounts {
+			if amount < 0 || amount > maxBoardAmount {
+				return nil, errors.BadRequest(fmt.Sprintf("board space %d has an amount outside 0-%d", i, maxBoardAmount))
+			}
+		}
+		switch std.Type {
+		case SpaceProperty, SpaceRailroad, SpaceUtility:
+			if space.Price == 0 {
+				return nil, errors.BadRequest(fmt.Sprintf("board space %d needs a price", i))
+			}
+		}
+		if std.Type == SpaceProperty && space.HouseCost == 0 {
+			return nil, errors.BadRequest(fmt.Sprintf("board space %d needs a house cost", i))
+		}
+
+		space.GroupSize = std.GroupSize
+		board[i] = space
+	}
+	return &board, nil
+}
+
+func colorSuffix(color ColorGroup) string {
+	if color == "" {
+		return ""
+	}
+	return " in the " + string(color) + " group"
+}
+
+// boardFor returns the board a game is played on: the custom board it was
+// created with, or the standard Board
+func boardFor(game *store.Game) (*[40]BoardSpace, error) {
+	if game == nil || game.Board == "" {
+		return &Board, nil
+	}
+	var board [40]BoardSpace
+	if err := json.Unmarshal([]byte(game.Board), &board); err != nil {
+		return nil, fmt.Errorf("failed to decode board of game %d: %w", game.ID, err)
+	}
+	return &board, nil
+}
+
+// boardTx loads the game's board within a transaction
+func (e *Engine) boardTx(tx *sql.Tx, gameID int64) (*[40]BoardSpace, error) {
+	game, err := e.store.GetGameTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	return boardFor(game)
+}
+
+// GameBoard returns the board the game is played on
+func (e *Engine) GameBoard(gameID int64) (*[40]BoardSpace, error) {
+	game, err := e.store.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+	return boardFor(game)
+}
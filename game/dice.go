@@ -0,0 +1,23 @@
+package game
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// RandSource produces die rolls. The default is backed by crypto/rand;
+// tests inject a deterministic source via NewEngineWithRand.
+type RandSource interface {
+	Roll() int // a single die, 1-6
+}
+
+type cryptoRandSource struct{}
+
+func (cryptoRandSource) Roll() int {
+	n, err := rand.Int(rand.Reader, big.NewInt(6))
+	if err != nil {
+		// crypto/rand only fails if the OS entropy source is broken
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+	return int(n.Int64()) + 1
+}
@@ -1,24 +1,42 @@
 package game
 
 import (
+	"cmp"
 	"database/sql"
 	"encoding/json"
 	"math/rand"
 	"monopoly/errors"
 	"monopoly/store"
+	"sync"
 )
 
 type Engine struct {
 	store          store.GameStore
+	dice           RandSource
 	doublesCount   map[int64]int      // gameID -> count of consecutive doubles this turn
 	activeAuctions map[int64]*Auction // gameID -> active auction (nil if no auction in progress)
+	turnMu         sync.Mutex         // guards doublesCount and activeAuctions across games
+	games          gameLocks          // serializes mutations per game, see lockGame
+
+	seeded bool                 // draw dice and shuffles from each game's seed, see seed.go
+	rngMu  sync.Mutex           // guards rngs
+	rngs   map[int64]*rand.Rand // gameID -> seeded generator
+
+	audit *moneyAudit // checks money after each action when on, see audit.go
 }
 
 func NewEngine(store store.GameStore) *Engine {
+	return NewEngineWithRand(store, cryptoRandSource{})
+}
+
+// NewEngineWithRand creates an engine that rolls dice from src
+func NewEngineWithRand(store store.GameStore, src RandSource) *Engine {
 	return &Engine{
 		store:          store,
+		dice:           src,
 		doublesCount:   make(map[int64]int),
 		activeAuctions: make(map[int64]*Auction),
+		rngs:           make(map[int64]*rand.Rand),
 	}
 }
 
@@ -34,6 +52,10 @@ func (e *Engine) GetGameState(gameID int64) (*GameState, error) {
 	if game == nil {
 		return nil, errors.GameNotFound()
 	}
+	board, err := boardFor(game)
+	if err != nil {
+		return nil, err
+	}
 
 	players, err := e.store.GetGamePlayers(gameID)
 	if err != nil {
@@ -46,6 +68,7 @@ func (e *Engine) GetGameState(gameID int64) (*GameState, error) {
 		gamePlayers[i] = &Player{
 			UserID:        p.UserID,
 			Username:      p.Username,
+			DisplayName:   displayName(p),
 			Order:         p.PlayerOrder,
 			IsReady:       p.IsReady,
 			IsCurrentTurn: p.IsCurrentTurn,
@@ -56,6 +79,10 @@ func (e *Engine) GetGameState(gameID int64) (*GameState, error) {
 			PendingAction: p.PendingAction,
 			InJail:        p.InJail,
 			JailTurns:     p.JailTurns,
+			Token:         p.Token,
+
+			TurnsTaken:     p.TurnsTaken,
+			AvgTurnSeconds: avgTurnSeconds(p),
 		}
 		if p.IsCurrentTurn {
 			currentPlayerID = p.UserID
@@ -66,41 +93,72 @@ func (e *Engine) GetGameState(gameID int64) (*GameState, error) {
 	if err != nil {
 		return nil, err
 	}
-	properties := make(map[int]int64)
-	mortgagedProperties := make(map[int]bool)
-	for _, p := range props {
-		properties[p.Position] = p.OwnerID
-		if p.IsMortgaged {
-			mortgagedProperties[p.Position] = true
-		}
-	}
+	properties, mortgagedProperties := ownership(props)
 
 	improvements, err := e.store.GetAllImprovements(gameID)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, p := range gamePlayers {
+		p.NetWorth = calculateNetWorth(board, p.UserID, p.Money, properties, mortgagedProperties, improvements)
+		p.TaxBasis = incomeTaxBasis(board, p.UserID, p.Money, properties, improvements)
+	}
+	supply := supplyFor(game)
+	housesLeft, hotelsLeft := supply.left(buildingsInPlay(improvements))
+
+	var turnPhaseValue string
+	for _, p := range gamePlayers {
+		if p.UserID == currentPlayerID && game.Status == StatusInProgress {
+			turnPhaseValue = turnPhase(p)
+		}
+	}
+
+	// Players come back in join order, so the first is the host
+	var hostUserID int64
+	if len(gamePlayers) > 0 {
+		hostUserID = gamePlayers[0].UserID
+	}
+
 	return &GameState{
 		ID:                  game.ID,
 		Status:              game.Status,
 		Players:             gamePlayers,
 		CurrentPlayerID:     currentPlayerID,
+		TurnPhase:           turnPhaseValue,
+		HostUserID:          hostUserID,
+		MinPlayers:          max(game.MinPlayers, minPlayersPerGame),
+		ManualStart:         game.ManualStart,
 		MaxPlayers:          game.MaxPlayers,
 		Properties:          properties,
 		MortgagedProperties: mortgagedProperties,
 		Improvements:        improvements,
-		Board:               Board,
+		Board:               *board,
+		Round:               game.Round,
+		TurnLimit:           game.TurnLimit,
+		TimeLimitMinutes:    game.TimeLimitMinutes,
+		StartedAt:           game.StartedAt,
+		WinnerID:            game.WinnerID,
+		StartingMoney:       cmp.Or(game.StartingMoney, DefaultStartingMoney),
+		HouseLimit:          supply.houses,
+		HotelLimit:          supply.hotels,
+		UnlimitedBuilding:   supply.unlimited,
+		HousesLeft:          housesLeft,
+		HotelsLeft:          hotelsLeft,
+		Seed:                game.Seed,
 	}, nil
 }
 
 func (e *Engine) JoinGame(gameID, userID int64, username string) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusWaiting {
-		return nil, errors.GameAlreadyStarted()
+	if err := requireStatus(state.Status, StatusWaiting); err != nil {
+		return nil, err
 	}
 
 	if len(state.Players) >= state.MaxPlayers {
@@ -113,8 +171,8 @@ func (e *Engine) JoinGame(gameID, userID int64, username string) (*Event, error)
 		}
 	}
 
-	playerOrder := len(state.Players)
-	if err := e.store.JoinGame(gameID, userID, playerOrder); err != nil {
+	playerOrder, err := e.store.JoinGame(gameID, userID)
+	if err != nil {
 		return nil, err
 	}
 
@@ -123,7 +181,7 @@ func (e *Engine) JoinGame(gameID, userID int64, username string) (*Event, error)
 		Username: username,
 		Order:    playerOrder,
 		IsReady:  false,
-		Money:    1500,
+		Money:    state.StartingMoney,
 	}
 
 	return &Event{
@@ -135,81 +193,166 @@ func (e *Engine) JoinGame(gameID, userID int64, username string) (*Event, error)
 	}, nil
 }
 
-func (e *Engine) SetReady(gameID, userID int64, isReady bool) (*Event, error) {
+// ClaimSeat seats a spectator in a waiting game that still has a free seat,
+// with the same checks as joining from the lobby: the game must not have
+// started or filled up in the meantime, and the user must not hold a seat in
+// another game. The new player gets the first free token.
+func (e *Engine) ClaimSeat(gameID, userID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
-
-	if state.Status != StatusWaiting {
-		return nil, errors.GameAlreadyStarted()
+	if err := requireStatus(state.Status, StatusWaiting); err != nil {
+		return nil, err
+	}
+	if len(state.Players) >= state.MaxPlayers {
+		return nil, errors.GameFull()
 	}
-
-	found := false
 	for _, p := range state.Players {
 		if p.UserID == userID {
-			found = true
-			break
+			return nil, errors.AlreadyInGame()
 		}
 	}
-	if !found {
-		return nil, errors.NotInGame()
+
+	if _, err := e.store.SeatPlayer(gameID, userID, PlayerTokens); err != nil {
+		return nil, joinError(err)
 	}
 
-	tx, err := e.store.BeginTx()
+	// Read the seat back for the name and token the player was given
+	state, err = e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
-	defer e.store.RollbackTx(tx)
+	for _, p := range state.Players {
+		if p.UserID == userID {
+			return &Event{
+				Type:    "player_joined",
+				GameID:  gameID,
+				Payload: PlayerJoinedPayload{Player: p},
+			}, nil
+		}
+	}
+	return nil, errors.NotInGame()
+}
 
-	if err := e.store.UpdatePlayerReadyTx(tx, gameID, userID, isReady); err != nil {
+// RejoinGame is the idempotent counterpart of JoinGame used when an existing
+// player reconnects (e.g. after a page refresh). It never modifies the game and
+// returns the current state if the user already holds a seat.
+func (e *Engine) RejoinGame(gameID, userID int64) (*GameState, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
 		return nil, err
 	}
 
-	state, err = e.GetGameState(gameID)
+	for _, p := range state.Players {
+		if p.UserID == userID {
+			return state, nil
+		}
+	}
+	return nil, errors.NotInGame()
+}
+
+// TurnStarted builds a turn_started event describing what the current player
+// may do right now. Returns nil if the game has no active turn.
+func (e *Engine) TurnStarted(gameID int64) (*Event, error) {
+	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
+	if state.Status != StatusInProgress {
+		return nil, nil
+	}
 
-	if len(state.Players) >= 2 {
-		allReady := true
-		for _, p := range state.Players {
-			if !p.IsReady {
-				allReady = false
-				break
-			}
+	var current *Player
+	for _, p := range state.Players {
+		if p.UserID == state.CurrentPlayerID {
+			current = p
+			break
 		}
+	}
+	if current == nil || current.IsBankrupt {
+		return nil, nil
+	}
 
-		if allReady {
-			if err := e.store.UpdateGameStatusTx(tx, gameID, StatusInProgress); err != nil {
-				return nil, err
-			}
+	idle := current.PendingAction == "" && e.GetActiveAuction(gameID) == nil
+	canBuy := current.PendingAction == "buy_or_pass" && current.Money >= state.Board[current.Position].Price
 
-			firstPlayer := state.Players[0]
-			if err := e.store.UpdateCurrentTurnTx(tx, gameID, firstPlayer.UserID); err != nil {
-				return nil, err
-			}
+	return &Event{
+		Type:   "turn_started",
+		GameID: gameID,
+		Payload: TurnStartedPayload{
+			UserID:     current.UserID,
+			CanRoll:    idle && !current.HasRolled,
+			CanBuy:     canBuy,
+			CanEndTurn: idle && current.HasRolled,
+			InJail:     current.InJail,
+		},
+	}, nil
+}
 
-			if err := e.store.CommitTx(tx); err != nil {
-				return nil, err
-			}
+// displayName is the name players see, falling back to the username
+func displayName(p *store.GamePlayer) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.Username
+}
 
-			// Initialize card decks
-			chanceOrder := ShuffleDeck(len(ChanceCards))
-			communityOrder := ShuffleDeck(len(CommunityChestCards))
-			if err := e.store.InitializeDecks(gameID, chanceOrder, communityOrder); err != nil {
-				// Non-fatal, game can continue without cards
-			}
+// turnPhase derives the current player's phase from their persisted turn
+// state rather than anything held in memory, so it survives restarts and
+// every connection agrees on it
+func turnPhase(current *Player) string {
+	switch {
+	case current.PendingAction == "buy_or_pass":
+		return TurnPhaseAwaitingBuyDecision
+	case current.PendingAction == "auction":
+		return TurnPhaseInAuction
+	case current.PendingAction == "tax_choice":
+		return TurnPhaseAwaitingTaxChoice
+	case !current.HasRolled:
+		return TurnPhaseAwaitingRoll
+	default:
+		return TurnPhaseAwaitingEnd
+	}
+}
 
-			return &Event{
-				Type:   "game_started",
-				GameID: gameID,
-				Payload: GameStartedPayload{
-					CurrentPlayerID: firstPlayer.UserID,
-				},
-			}, nil
+// SetReady records whether a player in a waiting game is ready. Starting the
+// game is left to the caller (see AllPlayersReady and StartGameIfReady) so it
+// can run a countdown first.
+func (e *Engine) SetReady(gameID, userID int64, isReady bool) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireStatus(state.Status, StatusWaiting); err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, p := range state.Players {
+		if p.UserID == userID {
+			found = true
+			break
 		}
 	}
+	if !found {
+		return nil, errors.NotInGame()
+	}
+
+	tx, err := e.store.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer e.store.RollbackTx(tx)
+
+	if err := e.store.UpdatePlayerReadyTx(tx, gameID, userID, isReady); err != nil {
+		return nil, err
+	}
 
 	if err := e.store.CommitTx(tx); err != nil {
 		return nil, err
@@ -225,28 +368,127 @@ func (e *Engine) SetReady(gameID, userID int64, isReady bool) (*Event, error) {
 	}, nil
 }
 
+// AllPlayersReady reports whether a waiting game has enough players and all of
+// them are ready. Always false for games the host starts by hand.
+func (e *Engine) AllPlayersReady(gameID int64) (bool, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return false, err
+	}
+	return allReady(state), nil
+}
+
+// PlayersNeeded returns how many more players a waiting game needs before it
+// can start, 0 if it has enough or is no longer waiting.
+func (e *Engine) PlayersNeeded(gameID int64) (int, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return 0, err
+	}
+	if state.Status != StatusWaiting || len(state.Players) >= state.MinPlayers {
+		return 0, nil
+	}
+	return state.MinPlayers - len(state.Players), nil
+}
+
+func allReady(state *GameState) bool {
+	if state.Status != StatusWaiting || state.ManualStart || len(state.Players) < state.MinPlayers {
+		return false
+	}
+	for _, p := range state.Players {
+		if !p.IsReady {
+			return false
+		}
+	}
+	return true
+}
+
+// StartGameIfReady starts the game if everyone is still ready. Returns nil if
+// the game no longer qualifies, e.g. a player un-readied or a new one joined.
+func (e *Engine) StartGameIfReady(gameID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if !allReady(state) {
+		return nil, nil
+	}
+	return e.startGame(state)
+}
+
+// StartGameIfFull starts the game once every seat is taken, unless the host
+// starts it by hand. Returns nil if the game doesn't start.
 func (e *Engine) StartGameIfFull(gameID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusWaiting || len(state.Players) < state.MaxPlayers {
+	if state.Status != StatusWaiting || state.ManualStart || len(state.Players) < state.MaxPlayers {
 		return nil, nil
 	}
 
+	return e.startGame(state)
+}
+
+// StartGame lets the host start a waiting game right away, whether or not
+// everyone is ready, once it has its minimum number of players.
+func (e *Engine) StartGame(gameID, hostUserID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireStatus(state.Status, StatusWaiting); err != nil {
+		return nil, err
+	}
+	if !state.hasPlayer(hostUserID) {
+		return nil, errors.NotInGame()
+	}
+	if hostUserID != state.HostUserID {
+		return nil, errors.New(errors.ErrCodeForbidden, "Only the host can start the game")
+	}
+	if len(state.Players) < state.MinPlayers {
+		return nil, errors.NotEnoughPlayers()
+	}
+
+	return e.startGame(state)
+}
+
+// startGame moves a waiting game to in progress with its first player to move
+func (e *Engine) startGame(state *GameState) (*Event, error) {
+	gameID := state.ID
+	firstPlayerID := state.Players[0].UserID
+
 	tx, err := e.store.BeginTx()
 	if err != nil {
 		return nil, err
 	}
 	defer e.store.RollbackTx(tx)
 
+	// The host and a countdown can both try to start the game; only one may
+	game, err := e.store.GetGameTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+	if err := requireStatus(game.Status, StatusWaiting); err != nil {
+		return nil, err
+	}
+
 	if err := e.store.UpdateGameStatusTx(tx, gameID, StatusInProgress); err != nil {
 		return nil, err
 	}
 
-	firstPlayer := state.Players[0]
-	if err := e.store.UpdateCurrentTurnTx(tx, gameID, firstPlayer.UserID); err != nil {
+	if err := e.store.UpdateCurrentTurnTx(tx, gameID, firstPlayerID); err != nil {
 		return nil, err
 	}
 
@@ -254,9 +496,16 @@ func (e *Engine) StartGameIfFull(gameID int64) (*Event, error) {
 		return nil, err
 	}
 
+	dealt := 0
+	for _, p := range state.Players {
+		dealt += p.Money
+	}
+	e.startMoneyLedger(gameID, dealt)
+
 	// Initialize card decks
-	chanceOrder := ShuffleDeck(len(ChanceCards))
-	communityOrder := ShuffleDeck(len(CommunityChestCards))
+	rng := e.gameRand(gameID, state.Seed)
+	chanceOrder := ShuffleDeck(len(ChanceCards), rng)
+	communityOrder := ShuffleDeck(len(CommunityChestCards), rng)
 	if err := e.store.InitializeDecks(gameID, chanceOrder, communityOrder); err != nil {
 		// Non-fatal, game can continue without cards
 	}
@@ -265,23 +514,25 @@ func (e *Engine) StartGameIfFull(gameID int64) (*Event, error) {
 		Type:   "game_started",
 		GameID: gameID,
 		Payload: GameStartedPayload{
-			CurrentPlayerID: firstPlayer.UserID,
+			CurrentPlayerID: firstPlayerID,
 		},
 	}, nil
 }
 
 func (e *Engine) RollDice(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
-	if state.CurrentPlayerID != userID {
-		return nil, errors.NotYourTurn()
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
 	var currentPlayer *Player
@@ -308,27 +559,28 @@ func (e *Engine) RollDice(gameID, userID int64) ([]*Event, error) {
 		return nil, errors.PendingAction()
 	}
 
-	die1 := rand.Intn(6) + 1
-	die2 := rand.Intn(6) + 1
+	rng := e.gameRand(gameID, state.Seed)
+	die1 := e.rollDie(rng)
+	die2 := e.rollDie(rng)
 	total := die1 + die2
 	isDoubles := die1 == die2
 
 	// Handle jail logic first
 	if currentPlayer.InJail {
-		return e.rollDiceInJail(gameID, userID, currentPlayer, die1, die2, total, isDoubles)
+		return e.rollDiceInJail(gameID, userID, &state.Board, currentPlayer, die1, die2, total, isDoubles)
 	}
 
 	// Track consecutive doubles (only when not in jail)
 	if isDoubles {
-		e.doublesCount[gameID]++
+		e.setDoubles(gameID, e.doubles(gameID)+1)
 	} else {
-		e.doublesCount[gameID] = 0
+		e.setDoubles(gameID, 0)
 	}
-	doublesCount := e.doublesCount[gameID]
+	doublesCount := e.doubles(gameID)
 
 	// Three doubles in a row - go to jail
 	if doublesCount >= 3 {
-		e.doublesCount[gameID] = 0 // Reset for next turn
+		e.setDoubles(gameID, 0) // Reset for next turn
 
 		tx, err := e.store.BeginTx()
 		if err != nil {
@@ -416,7 +668,7 @@ func (e *Engine) RollDice(gameID, userID int64) ([]*Event, error) {
 		}
 	}
 
-	space := Board[newPos]
+	space := state.Board[newPos]
 
 	var events []*Event
 	events = append(events, &Event{
@@ -436,8 +688,11 @@ func (e *Engine) RollDice(gameID, userID int64) ([]*Event, error) {
 			DoublesCount: doublesCount,
 		},
 	})
+	if passedGo {
+		events = append(events, moneyTransferred(gameID, 0, userID, 200, MoneySalary))
+	}
 
-	resolutionEvents, err := e.resolveSpaceLanding(tx, gameID, userID, player.Username, currentMoney, space, total, 1.0)
+	resolutionEvents, err := e.resolveSpaceLanding(tx, &state.Board, gameID, userID, player.Username, currentMoney, space, total, 1.0)
 	if err != nil {
 		return nil, err
 	}
@@ -463,11 +718,12 @@ func (e *Engine) RollDice(gameID, userID int64) ([]*Event, error) {
 		return nil, err
 	}
 
+	e.auditMoney(gameID, events)
 	return events, nil
 }
 
 // rollDiceInJail handles dice rolling when a player is in jail
-func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2, total int, isDoubles bool) ([]*Event, error) {
+func (e *Engine) rollDiceInJail(gameID, userID int64, board *[40]BoardSpace, player *Player, die1, die2, total int, isDoubles bool) ([]*Event, error) {
 	tx, err := e.store.BeginTx()
 	if err != nil {
 		return nil, err
@@ -509,7 +765,7 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 			}
 		}
 
-		space := Board[newPos]
+		space := board[newPos]
 
 		events = append(events, &Event{
 			Type:   "jail_escape",
@@ -537,9 +793,12 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 				DoublesCount: 0, // Reset doubles count after leaving jail
 			},
 		})
+		if passedGo {
+			events = append(events, moneyTransferred(gameID, 0, userID, 200, MoneySalary))
+		}
 
 		// Resolve landing
-		resolutionEvents, err := e.resolveSpaceLanding(tx, gameID, userID, dbPlayer.Username, currentMoney, space, total, 1.0)
+		resolutionEvents, err := e.resolveSpaceLanding(tx, board, gameID, userID, dbPlayer.Username, currentMoney, space, total, 1.0)
 		if err != nil {
 			return nil, err
 		}
@@ -549,8 +808,8 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 		newJailTurns := dbPlayer.JailTurns + 1
 
 		if newJailTurns >= 3 {
-			// 3rd failed roll - forced to pay $50 bail
-			bailAmount := 50
+			// 3rd failed roll - forced to pay bail
+			bailAmount := JailBail
 			if dbPlayer.Money < bailAmount {
 				// Bankrupt from jail bail
 				bankruptEvents, err := e.handleBankruptcyTx(tx, gameID, userID, dbPlayer.Username, "jail_bail", 0)
@@ -574,6 +833,7 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 					},
 				})
 				events = append(events, bankruptEvents...)
+				e.auditMoney(gameID, events)
 				return events, nil
 			}
 
@@ -605,7 +865,7 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 				}
 			}
 
-			space := Board[newPos]
+			space := board[newPos]
 
 			events = append(events, &Event{
 				Type:   "jail_roll_failed",
@@ -628,7 +888,7 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 					Method:   "bail",
 					NewMoney: currentMoney,
 				},
-			})
+			}, moneyTransferred(gameID, userID, 0, bailAmount, MoneyJailFine))
 
 			events = append(events, &Event{
 				Type:   "dice_rolled",
@@ -647,9 +907,12 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 					DoublesCount: 0,
 				},
 			})
+			if passedGo {
+				events = append(events, moneyTransferred(gameID, 0, userID, 200, MoneySalary))
+			}
 
 			// Resolve landing
-			resolutionEvents, err := e.resolveSpaceLanding(tx, gameID, userID, dbPlayer.Username, currentMoney, space, total, 1.0)
+			resolutionEvents, err := e.resolveSpaceLanding(tx, board, gameID, userID, dbPlayer.Username, currentMoney, space, total, 1.0)
 			if err != nil {
 				return nil, err
 			}
@@ -687,22 +950,25 @@ func (e *Engine) rollDiceInJail(gameID, userID int64, player *Player, die1, die2
 		return nil, err
 	}
 
+	e.auditMoney(gameID, events)
 	return events, nil
 }
 
 // UseJailFreeCard allows a player to use a Get Out of Jail Free card
 func (e *Engine) UseJailFreeCard(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
-	if state.CurrentPlayerID != userID {
-		return nil, errors.NotYourTurn()
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
 	var currentPlayer *Player
@@ -776,19 +1042,22 @@ func (e *Engine) UseJailFreeCard(gameID, userID int64) ([]*Event, error) {
 	}, nil
 }
 
-// PayJailBail allows a player to pay $50 to get out of jail before rolling
+// PayJailBail allows a player to pay JailBail to get out of jail before
+// rolling. They stay on Jail and roll normally afterwards.
 func (e *Engine) PayJailBail(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
-	if state.CurrentPlayerID != userID {
-		return nil, errors.NotYourTurn()
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
 	var currentPlayer *Player
@@ -811,7 +1080,7 @@ func (e *Engine) PayJailBail(gameID, userID int64) ([]*Event, error) {
 		return nil, errors.AlreadyRolled()
 	}
 
-	bailAmount := 50
+	bailAmount := JailBail
 	if currentPlayer.Money < bailAmount {
 		return nil, errors.InsufficientFunds()
 	}
@@ -834,7 +1103,7 @@ func (e *Engine) PayJailBail(gameID, userID int64) ([]*Event, error) {
 		return nil, err
 	}
 
-	return []*Event{
+	events := []*Event{
 		{
 			Type:   "jail_escape",
 			GameID: gameID,
@@ -844,18 +1113,26 @@ func (e *Engine) PayJailBail(gameID, userID int64) ([]*Event, error) {
 				NewMoney: newMoney,
 			},
 		},
-	}, nil
+		moneyTransferred(gameID, userID, 0, bailAmount, MoneyJailFine),
+	}
+	e.auditMoney(gameID, events)
+	return events, nil
 }
 
 // MortgageProperty allows a player to mortgage a property they own
 func (e *Engine) MortgageProperty(gameID, userID int64, position int) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
 	// Verify ownership
@@ -869,7 +1146,7 @@ func (e *Engine) MortgageProperty(gameID, userID int64, position int) (*Event, e
 		return nil, errors.PropertyAlreadyMortgaged()
 	}
 
-	space := Board[position]
+	space := state.Board[position]
 	mortgageValue := space.Price / 2
 
 	var player *Player
@@ -902,7 +1179,7 @@ func (e *Engine) MortgageProperty(gameID, userID int64, position int) (*Event, e
 		return nil, err
 	}
 
-	return &Event{
+	event := &Event{
 		Type:   "property_mortgaged",
 		GameID: gameID,
 		Payload: PropertyMortgagedPayload{
@@ -912,18 +1189,25 @@ func (e *Engine) MortgageProperty(gameID, userID int64, position int) (*Event, e
 			Amount:   mortgageValue,
 			NewMoney: newMoney,
 		},
-	}, nil
+	}
+	e.auditMoney(gameID, []*Event{event})
+	return event, nil
 }
 
 // UnmortgageProperty allows a player to unmortgage a property by paying 110% of mortgage value
 func (e *Engine) UnmortgageProperty(gameID, userID int64, position int) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
 	// Verify ownership
@@ -937,7 +1221,7 @@ func (e *Engine) UnmortgageProperty(gameID, userID int64, position int) (*Event,
 		return nil, errors.PropertyNotMortgaged()
 	}
 
-	space := Board[position]
+	space := state.Board[position]
 	mortgageValue := space.Price / 2
 	unmortgageCost := mortgageValue + (mortgageValue / 10) // 110% of mortgage value
 
@@ -975,7 +1259,7 @@ func (e *Engine) UnmortgageProperty(gameID, userID int64, position int) (*Event,
 		return nil, err
 	}
 
-	return &Event{
+	event := &Event{
 		Type:   "property_unmortgaged",
 		GameID: gameID,
 		Payload: PropertyUnmortgagedPayload{
@@ -985,18 +1269,25 @@ func (e *Engine) UnmortgageProperty(gameID, userID int64, position int) (*Event,
 			Amount:   unmortgageCost,
 			NewMoney: newMoney,
 		},
-	}, nil
+	}
+	e.auditMoney(gameID, []*Event{event})
+	return event, nil
 }
 
 // BuyHouse allows a player to buy a house on a property they own
 func (e *Engine) BuyHouse(gameID, userID int64, position int) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
 	// Verify ownership
@@ -1005,7 +1296,7 @@ func (e *Engine) BuyHouse(gameID, userID int64, position int) (*Event, error) {
 		return nil, errors.PropertyNotOwned()
 	}
 
-	space := Board[position]
+	space := state.Board[position]
 	if space.Type != SpaceProperty {
 		return nil, errors.BadRequest("Can only build on properties")
 	}
@@ -1019,7 +1310,7 @@ func (e *Engine) BuyHouse(gameID, userID int64, position int) (*Event, error) {
 	colorCount := 0
 	colorPositions := []int{}
 	for pos, owner := range state.Properties {
-		if owner == userID && Board[pos].Color == space.Color {
+		if owner == userID && state.Board[pos].Color == space.Color {
 			colorCount++
 			colorPositions = append(colorPositions, pos)
 		}
@@ -1028,8 +1319,20 @@ func (e *Engine) BuyHouse(gameID, userID int64, position int) (*Event, error) {
 		return nil, errors.NoMonopoly()
 	}
 
+	// Everything that a concurrent build or payment could change is re-read
+	// inside the transaction, so two builds can't both take the last house
+	// or spend the same money
+	tx, err := e.store.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer e.store.RollbackTx(tx)
+
 	// Check current improvements
-	currentImpr := state.Improvements[position]
+	currentImpr, err := e.store.GetImprovementsTx(tx, gameID, position)
+	if err != nil {
+		return nil, err
+	}
 	if currentImpr >= 5 {
 		return nil, errors.MaxImprovements()
 	}
@@ -1037,44 +1340,42 @@ func (e *Engine) BuyHouse(gameID, userID int64, position int) (*Event, error) {
 	// Check even build rule - new count can't be more than 1 above any other property in group
 	for _, pos := range colorPositions {
 		if pos != position {
-			otherImpr := state.Improvements[pos]
+			otherImpr, err := e.store.GetImprovementsTx(tx, gameID, pos)
+			if err != nil {
+				return nil, err
+			}
 			if currentImpr >= otherImpr+1 {
 				return nil, errors.UnevenBuild()
 			}
 		}
 	}
 
-	// Check house/hotel supply (max 32 houses, 12 hotels)
-	tx, err := e.store.BeginTx()
-	if err != nil {
-		return nil, err
-	}
-	defer e.store.RollbackTx(tx)
-
+	// Check the bank's house/hotel supply
 	totalHouses, totalHotels, err := e.store.GetTotalHousesHotelsTx(tx, gameID)
 	if err != nil {
 		return nil, err
 	}
+	supply := buildingSupply{houses: state.HouseLimit, hotels: state.HotelLimit, unlimited: state.UnlimitedBuilding}
+	housesLeft, hotelsLeft := supply.left(totalHouses, totalHotels)
 
-	if currentImpr == 4 {
-		// Building a hotel
-		if totalHotels >= 12 {
-			return nil, errors.HotelShortage()
-		}
-	} else {
-		// Building a house
-		if totalHouses >= 32 {
-			return nil, errors.HouseShortage()
+	if !supply.unlimited {
+		if currentImpr == 4 {
+			// Building a hotel
+			if hotelsLeft == 0 {
+				return nil, errors.HotelShortage(supply.hotels)
+			}
+		} else {
+			// Building a house
+			if housesLeft == 0 {
+				return nil, errors.HouseShortage(supply.houses)
+			}
 		}
 	}
 
-	// Check funds
-	var player *Player
-	for _, p := range state.Players {
-		if p.UserID == userID {
-			player = p
-			break
-		}
+	// Check funds before charging anything
+	player, err := e.store.GetPlayerTx(tx, gameID, userID)
+	if err != nil {
+		return nil, err
 	}
 	if player == nil {
 		return nil, errors.NotInGame()
@@ -1104,7 +1405,7 @@ func (e *Engine) BuyHouse(gameID, userID int64, position int) (*Event, error) {
 		eventType = "hotel_built"
 	}
 
-	return &Event{
+	event := &Event{
 		Type:   eventType,
 		GameID: gameID,
 		Payload: HouseBuiltPayload{
@@ -1115,18 +1416,27 @@ func (e *Engine) BuyHouse(gameID, userID int64, position int) (*Event, error) {
 			Cost:       space.HouseCost,
 			NewMoney:   newMoney,
 		},
-	}, nil
+	}
+	e.auditMoney(gameID, []*Event{event})
+	return event, nil
 }
 
-// SellHouse allows a player to sell a house from a property they own
+// SellHouse sells one house (or a hotel, leaving four houses) back to the
+// bank for half its cost, returning it to the bank's supply. Selling must
+// keep the color group even, as building does.
 func (e *Engine) SellHouse(gameID, userID int64, position int) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
 	// Verify ownership
@@ -1135,7 +1445,7 @@ func (e *Engine) SellHouse(gameID, userID int64, position int) (*Event, error) {
 		return nil, errors.PropertyNotOwned()
 	}
 
-	space := Board[position]
+	space := state.Board[position]
 	if space.Type != SpaceProperty {
 		return nil, errors.BadRequest("Can only sell houses from properties")
 	}
@@ -1148,9 +1458,13 @@ func (e *Engine) SellHouse(gameID, userID int64, position int) (*Event, error) {
 
 	// Check even build rule - can't sell if it would make this more than 1 below others
 	colorPositions := []int{}
+	ownedPositions := []int{}
 	for pos, owner := range state.Properties {
-		if owner == userID && Board[pos].Color == space.Color {
-			colorPositions = append(colorPositions, pos)
+		if owner == userID {
+			ownedPositions = append(ownedPositions, pos)
+			if state.Board[pos].Color == space.Color {
+				colorPositions = append(colorPositions, pos)
+			}
 		}
 	}
 
@@ -1169,15 +1483,14 @@ func (e *Engine) SellHouse(gameID, userID int64, position int) (*Event, error) {
 	}
 	defer e.store.RollbackTx(tx)
 
-	// If selling a hotel, check if 4 houses are available
-	if currentImpr == 5 {
+	// If selling a hotel, check if 4 houses are available to replace it
+	if currentImpr == 5 && !state.UnlimitedBuilding {
 		houses, _, err := e.store.GetTotalHousesHotelsTx(tx, gameID)
 		if err != nil {
 			return nil, err
 		}
-		availableHouses := 32 - houses
-		if availableHouses < 4 {
-			return nil, errors.HouseShortage()
+		if state.HouseLimit-houses < 4 {
+			return nil, errors.HouseShortage(state.HouseLimit)
 		}
 	}
 
@@ -1208,31 +1521,35 @@ func (e *Engine) SellHouse(gameID, userID int64, position int) (*Event, error) {
 		return nil, err
 	}
 
-	return &Event{
+	event := &Event{
 		Type:   "house_sold",
 		GameID: gameID,
 		Payload: HouseSoldPayload{
-			UserID:     userID,
-			Position:   position,
-			Name:       space.Name,
-			HouseCount: newImpr,
-			Refund:     refund,
-			NewMoney:   newMoney,
+			UserID:       userID,
+			Position:     position,
+			Name:         space.Name,
+			HouseCount:   newImpr,
+			Refund:       refund,
+			NewMoney:     newMoney,
+			PreviousRent: CalculateRent(space, ownedPositions, 0, currentImpr),
+			Rent:         CalculateRent(space, ownedPositions, 0, newImpr),
 		},
-	}, nil
+	}
+	e.auditMoney(gameID, []*Event{event})
+	return event, nil
 }
 
-func (e *Engine) resolveSpaceLanding(tx *sql.Tx, gameID, userID int64, username string, currentMoney int, space BoardSpace, diceTotal int, rentMultiplier float64) ([]*Event, error) {
+func (e *Engine) resolveSpaceLanding(tx *sql.Tx, board *[40]BoardSpace, gameID, userID int64, username string, currentMoney int, space BoardSpace, diceTotal int, rentMultiplier float64) ([]*Event, error) {
 	var events []*Event
 
 	switch space.Type {
 	case SpaceProperty, SpaceRailroad, SpaceUtility:
-		ownerID, err := e.store.GetPropertyOwnerTx(tx, gameID, space.Position)
+		lr, err := e.landingRentTx(tx, gameID, userID, space, diceTotal, rentMultiplier)
 		if err != nil {
 			return nil, err
 		}
 
-		if ownerID == 0 {
+		if lr.ownerID == 0 {
 			// Unowned - prompt to buy
 			if currentMoney >= space.Price {
 				if err := e.store.SetPlayerPendingActionTx(tx, gameID, userID, "buy_or_pass"); err != nil {
@@ -1250,38 +1567,9 @@ func (e *Engine) resolveSpaceLanding(tx *sql.Tx, gameID, userID int64, username
 				})
 			}
 			// If can't afford, nothing happens (no auction in MVP)
-		} else if ownerID != userID {
-			// Owned by someone else - check if mortgaged first
-			prop, err := e.store.GetPropertyTx(tx, gameID, space.Position)
-			if err != nil {
-				return nil, err
-			}
-			if prop != nil && prop.IsMortgaged {
-				break // No rent on mortgaged properties
-			}
-
-			// Pay rent
-			ownerProps, err := e.store.GetPlayerPropertiesTx(tx, gameID, ownerID)
-			if err != nil {
-				return nil, err
-			}
-
-			// Get improvements on this property
-			improvements, err := e.store.GetImprovementsTx(tx, gameID, space.Position)
-			if err != nil {
-				return nil, err
-			}
-
-			rent := int(float64(CalculateRent(space, ownerProps, diceTotal, improvements)) * rentMultiplier)
-
-			// Check if owner is bankrupt (shouldn't be, but safe check)
-			owner, err := e.store.GetPlayerTx(tx, gameID, ownerID)
-			if err != nil {
-				return nil, err
-			}
-			if owner != nil && owner.IsBankrupt {
-				break // Don't pay rent to bankrupt player
-			}
+		} else if lr.due(userID) {
+			// Owned by someone else, not mortgaged - pay rent
+			ownerID, owner, rent := lr.ownerID, lr.owner, lr.rent
 
 			if currentMoney >= rent {
 				// Can afford rent
@@ -1307,7 +1595,7 @@ func (e *Engine) resolveSpaceLanding(tx *sql.Tx, gameID, userID int64, username
 						PayerMoney: payerNewMoney,
 						OwnerMoney: ownerNewMoney,
 					},
-				})
+				}, moneyTransferred(gameID, userID, ownerID, rent, MoneyRent))
 			} else {
 				// Can't afford rent - bankruptcy
 				// Give whatever money they have to the owner
@@ -1315,6 +1603,9 @@ func (e *Engine) resolveSpaceLanding(tx *sql.Tx, gameID, userID int64, username
 				if err := e.store.UpdatePlayerMoneyTx(tx, gameID, ownerID, ownerNewMoney); err != nil {
 					return nil, err
 				}
+				if currentMoney > 0 {
+					events = append(events, moneyTransferred(gameID, userID, ownerID, currentMoney, MoneyRent))
+				}
 
 				bankruptEvents, err := e.handleBankruptcyTx(tx, gameID, userID, username, "rent", ownerID)
 				if err != nil {
@@ -1323,32 +1614,32 @@ func (e *Engine) resolveSpaceLanding(tx *sql.Tx, gameID, userID int64, username
 				events = append(events, bankruptEvents...)
 			}
 		}
-		// If owned by self, nothing happens
+		// If owned by self, mortgaged or held by a bankrupt owner, nothing happens
 
 	case SpaceTax:
-		if currentMoney >= space.TaxAmount {
-			newMoney := currentMoney - space.TaxAmount
-			if err := e.store.UpdatePlayerMoneyTx(tx, gameID, userID, newMoney); err != nil {
+		if space.Position == IncomeTaxPosition {
+			// The player chooses how to pay; see PayIncomeTax
+			if err := e.store.SetPlayerPendingActionTx(tx, gameID, userID, "tax_choice"); err != nil {
 				return nil, err
 			}
 			events = append(events, &Event{
-				Type:   "tax_paid",
+				Type:   "tax_prompt",
 				GameID: gameID,
-				Payload: TaxPaidPayload{
-					UserID:   userID,
-					Position: space.Position,
-					Amount:   space.TaxAmount,
-					NewMoney: newMoney,
+				Payload: TaxPromptPayload{
+					UserID:     userID,
+					Position:   space.Position,
+					Name:       space.Name,
+					FlatAmount: space.TaxAmount,
+					Percent:    IncomeTaxPercent,
 				},
 			})
-		} else {
-			// Can't afford tax - bankruptcy
-			bankruptEvents, err := e.handleBankruptcyTx(tx, gameID, userID, username, "tax", 0)
-			if err != nil {
-				return nil, err
-			}
-			events = append(events, bankruptEvents...)
+			break
 		}
+		taxEvents, err := e.payTaxTx(tx, gameID, userID, username, currentMoney, space.Position, space.TaxAmount, "")
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, taxEvents...)
 
 	case SpaceGoToJail:
 		if err := e.store.UpdatePlayerPositionTx(tx, gameID, userID, 10); err != nil {
@@ -1368,14 +1659,14 @@ func (e *Engine) resolveSpaceLanding(tx *sql.Tx, gameID, userID int64, username
 		})
 
 	case SpaceChance:
-		cardEvents, err := e.drawAndExecuteCard(tx, gameID, userID, username, currentMoney, "chance", space.Position, diceTotal)
+		cardEvents, err := e.drawAndExecuteCard(tx, board, gameID, userID, username, currentMoney, "chance", space.Position, diceTotal)
 		if err != nil {
 			return nil, err
 		}
 		events = append(events, cardEvents...)
 
 	case SpaceCommunityChest:
-		cardEvents, err := e.drawAndExecuteCard(tx, gameID, userID, username, currentMoney, "community", space.Position, diceTotal)
+		cardEvents, err := e.drawAndExecuteCard(tx, board, gameID, userID, username, currentMoney, "community", space.Position, diceTotal)
 		if err != nil {
 			return nil, err
 		}
@@ -1387,7 +1678,7 @@ func (e *Engine) resolveSpaceLanding(tx *sql.Tx, gameID, userID int64, username
 	return events, nil
 }
 
-func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username string, currentMoney int, deckType string, currentPos int, diceTotal int) ([]*Event, error) {
+func (e *Engine) drawAndExecuteCard(tx *sql.Tx, board *[40]BoardSpace, gameID, userID int64, username string, currentMoney int, deckType string, currentPos int, diceTotal int) ([]*Event, error) {
 	var events []*Event
 
 	// Draw a card
@@ -1414,6 +1705,7 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 	var newMoney int = currentMoney
 	var newPos int = currentPos
 	var cardRentMultiplier float64 = 1.0 // Special rent multiplier for advance to nearest cards
+	var transfers []*Event               // money_transferred events, sent after card_drawn
 
 	switch card.Type {
 	case CardTypeCollectMoney:
@@ -1422,6 +1714,7 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 			return nil, err
 		}
 		effect = "Collected $" + itoa(card.Value)
+		transfers = append(transfers, moneyTransferred(gameID, 0, userID, card.Value, MoneyCard))
 
 	case CardTypePayMoney:
 		if currentMoney >= card.Value {
@@ -1430,6 +1723,7 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 				return nil, err
 			}
 			effect = "Paid $" + itoa(card.Value)
+			transfers = append(transfers, moneyTransferred(gameID, userID, 0, card.Value, MoneyCard))
 		} else {
 			// Bankruptcy
 			bankruptEvents, err := e.handleBankruptcyTx(tx, gameID, userID, username, "card", 0)
@@ -1460,18 +1754,19 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 			if err := e.store.UpdatePlayerMoneyTx(tx, gameID, userID, newMoney); err != nil {
 				return nil, err
 			}
+			transfers = append(transfers, moneyTransferred(gameID, 0, userID, 200, MoneySalary))
 		}
 		if err := e.store.UpdatePlayerPositionTx(tx, gameID, userID, newPos); err != nil {
 			return nil, err
 		}
-		effect = "Moved to " + Board[newPos].Name
+		effect = "Moved to " + board[newPos].Name
 
 	case CardTypeMoveBack:
 		newPos = (currentPos - card.Value + 40) % 40
 		if err := e.store.UpdatePlayerPositionTx(tx, gameID, userID, newPos); err != nil {
 			return nil, err
 		}
-		effect = "Moved back " + itoa(card.Value) + " spaces to " + Board[newPos].Name
+		effect = "Moved back " + itoa(card.Value) + " spaces to " + board[newPos].Name
 
 	case CardTypeGoToJail:
 		newPos = 10
@@ -1541,6 +1836,9 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 				return nil, err
 			}
 			effect = "Paid $" + itoa(cost) + " for repairs"
+			if cost > 0 {
+				transfers = append(transfers, moneyTransferred(gameID, userID, 0, cost, MoneyCard))
+			}
 		} else {
 			// Bankruptcy
 			bankruptEvents, err := e.handleBankruptcyTx(tx, gameID, userID, username, "card", 0)
@@ -1590,6 +1888,7 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 						if err := e.store.UpdatePlayerMoneyTx(tx, gameID, p.UserID, pMoney); err != nil {
 							return nil, err
 						}
+						transfers = append(transfers, moneyTransferred(gameID, userID, p.UserID, card.Value, MoneyCard))
 					}
 				}
 				effect = "Paid $" + itoa(card.Value) + " to each player (total: $" + itoa(totalAmount) + ")"
@@ -1628,6 +1927,9 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 						return nil, err
 					}
 					collected += toCollect
+					if toCollect > 0 {
+						transfers = append(transfers, moneyTransferred(gameID, p.UserID, userID, toCollect, MoneyCard))
+					}
 				}
 			}
 			newMoney = currentMoney + collected
@@ -1670,11 +1972,12 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 			if err := e.store.UpdatePlayerMoneyTx(tx, gameID, userID, newMoney); err != nil {
 				return nil, err
 			}
+			transfers = append(transfers, moneyTransferred(gameID, 0, userID, 200, MoneySalary))
 		}
 		if err := e.store.UpdatePlayerPositionTx(tx, gameID, userID, newPos); err != nil {
 			return nil, err
 		}
-		effect = "Advanced to " + Board[newPos].Name
+		effect = "Advanced to " + board[newPos].Name
 	}
 
 	events = append(events, &Event{
@@ -1690,11 +1993,12 @@ func (e *Engine) drawAndExecuteCard(tx *sql.Tx, gameID, userID int64, username s
 			NewPos:   newPos,
 		},
 	})
+	events = append(events, transfers...)
 
 	// If player moved to a new space, resolve that landing
 	if newPos != currentPos && card.Type != CardTypeGoToJail {
-		landingSpace := Board[newPos]
-		landingEvents, err := e.resolveSpaceLanding(tx, gameID, userID, username, newMoney, landingSpace, diceTotal, cardRentMultiplier)
+		landingSpace := board[newPos]
+		landingEvents, err := e.resolveSpaceLanding(tx, board, gameID, userID, username, newMoney, landingSpace, diceTotal, cardRentMultiplier)
 		if err != nil {
 			return nil, err
 		}
@@ -1727,22 +2031,22 @@ func itoa(n int) string {
 func (e *Engine) handleBankruptcyTx(tx *sql.Tx, gameID, userID int64, username, reason string, creditorID int64) ([]*Event, error) {
 	var events []*Event
 
+	// Cash left over when going bankrupt to the bank goes back to it; a
+	// creditor has already been paid what there was
+	player, err := e.store.GetPlayerTx(tx, gameID, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Mark player bankrupt
 	if err := e.store.SetPlayerBankruptTx(tx, gameID, userID); err != nil {
 		return nil, err
 	}
 
-	// Handle properties based on creditor
-	if creditorID != 0 {
-		// Transfer all properties to the creditor (mortgaged properties transfer as-is)
-		if err := e.store.TransferAllPropertiesTx(tx, gameID, userID, creditorID); err != nil {
-			return nil, err
-		}
-	} else {
-		// Bankrupt to bank - properties become unowned
-		if err := e.store.DeletePlayerPropertiesTx(tx, gameID, userID); err != nil {
-			return nil, err
-		}
+	// Properties go to the creditor, or become unowned when bankrupt to the bank
+	released, err := e.releasePropertiesTx(tx, gameID, userID, creditorID)
+	if err != nil {
+		return nil, err
 	}
 
 	events = append(events, &Event{
@@ -1755,66 +2059,43 @@ func (e *Engine) handleBankruptcyTx(tx *sql.Tx, gameID, userID int64, username,
 			CreditorID: creditorID,
 		},
 	})
+	if creditorID == 0 && player != nil && player.Money > 0 {
+		events = append(events, moneyTransferred(gameID, userID, 0, player.Money, MoneyBankruptcy))
+	}
+	events = append(events, released...)
 
 	// Check if only 1 active player remains
-	activeCount, err := e.store.CountActivePlayersTx(tx, gameID)
+	finished, err := e.finishIfLastPlayerTx(tx, gameID)
 	if err != nil {
 		return nil, err
 	}
-
-	if activeCount <= 1 {
-		if err := e.store.UpdateGameStatusTx(tx, gameID, StatusFinished); err != nil {
-			return nil, err
-		}
-
-		activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
-		if err != nil {
-			return nil, err
-		}
-
-		// Get all players for final state
-		allPlayers, err := e.store.GetGamePlayers(gameID)
-		if err != nil {
-			return nil, err
-		}
-
-		var winnerID int64
-		if len(activePlayers) > 0 {
-			winnerID = activePlayers[0].UserID
-		}
-
-		finalPlayers := make([]*Player, len(allPlayers))
-		for i, p := range allPlayers {
-			finalPlayers[i] = &Player{
-				UserID:     p.UserID,
-				Username:   p.Username,
-				Order:      p.PlayerOrder,
-				Money:      p.Money,
-				Position:   p.Position,
-				IsBankrupt: p.IsBankrupt,
-			}
-		}
-
-		events = append(events, &Event{
-			Type:   "game_finished",
-			GameID: gameID,
-			Payload: GameFinishedPayload{
-				Players:  finalPlayers,
-				WinnerID: winnerID,
-			},
-		})
+	if finished != nil {
+		events = append(events, finished)
 	}
 
 	return events, nil
 }
 
 func (e *Engine) BuyProperty(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	tx, err := e.store.BeginTx()
 	if err != nil {
 		return nil, err
 	}
 	defer e.store.RollbackTx(tx)
 
+	game, err := e.store.GetGameTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+	if err := requireStatus(game.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+
 	player, err := e.store.GetPlayerTx(tx, gameID, userID)
 	if err != nil {
 		return nil, err
@@ -1822,12 +2103,19 @@ func (e *Engine) BuyProperty(gameID, userID int64) ([]*Event, error) {
 	if player == nil {
 		return nil, errors.NotInGame()
 	}
+	if err := requireTurn(player.IsCurrentTurn); err != nil {
+		return nil, err
+	}
 
 	if player.PendingAction != "buy_or_pass" {
 		return nil, errors.CannotBuy()
 	}
 
-	space := Board[player.Position]
+	board, err := e.boardTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	space := board[player.Position]
 	if player.Money < space.Price {
 		return nil, errors.InsufficientFunds()
 	}
@@ -1857,10 +2145,12 @@ func (e *Engine) BuyProperty(gameID, userID int64) ([]*Event, error) {
 				NewMoney: newMoney,
 			},
 		},
+		ownershipChanged(gameID, player.Position, 0, userID, OwnershipPurchase),
+		moneyTransferred(gameID, userID, 0, space.Price, MoneyPurchase),
 	}
 
 	// Auto-end turn after buying (unless player has doubles)
-	doublesCount := e.doublesCount[gameID]
+	doublesCount := e.doubles(gameID)
 	if doublesCount == 0 {
 		// End turn and advance to next player
 		turnEvent, err := e.endTurnInternalTx(tx, gameID, userID)
@@ -1876,16 +2166,30 @@ func (e *Engine) BuyProperty(gameID, userID int64) ([]*Event, error) {
 		return nil, err
 	}
 
+	e.auditMoney(gameID, events)
 	return events, nil
 }
 
 func (e *Engine) PassProperty(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	tx, err := e.store.BeginTx()
 	if err != nil {
 		return nil, err
 	}
 	defer e.store.RollbackTx(tx)
 
+	game, err := e.store.GetGameTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+	if err := requireStatus(game.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+
 	player, err := e.store.GetPlayerTx(tx, gameID, userID)
 	if err != nil {
 		return nil, err
@@ -1893,13 +2197,16 @@ func (e *Engine) PassProperty(gameID, userID int64) ([]*Event, error) {
 	if player == nil {
 		return nil, errors.NotInGame()
 	}
+	if err := requireTurn(player.IsCurrentTurn); err != nil {
+		return nil, err
+	}
 
 	if player.PendingAction != "buy_or_pass" {
 		return nil, errors.CannotBuy()
 	}
 
 	// Check if an auction is already in progress
-	if e.activeAuctions[gameID] != nil {
+	if e.auction(gameID) != nil {
 		return nil, errors.AuctionInProgress()
 	}
 
@@ -1914,11 +2221,16 @@ func (e *Engine) PassProperty(gameID, userID int64) ([]*Event, error) {
 		return nil, err
 	}
 
+	board, err := e.boardTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := e.store.CommitTx(tx); err != nil {
 		return nil, err
 	}
 
-	space := Board[player.Position]
+	space := board[player.Position]
 	events := []*Event{
 		{
 			Type:   "property_passed",
@@ -1958,7 +2270,7 @@ func (e *Engine) PassProperty(gameID, userID int64) ([]*Event, error) {
 		CurrentBidder:   0,
 		PassedBidders:   make(map[int64]bool),
 	}
-	e.activeAuctions[gameID] = auction
+	e.setAuction(gameID, auction)
 
 	// Emit auction_started event
 	events = append(events, &Event{
@@ -1978,7 +2290,17 @@ func (e *Engine) PassProperty(gameID, userID int64) ([]*Event, error) {
 
 // PlaceBid allows a player to place a bid in the current auction
 func (e *Engine) PlaceBid(gameID, userID int64, amount int) ([]*Event, error) {
-	auction := e.activeAuctions[gameID]
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+
+	auction := e.auction(gameID)
 	if auction == nil {
 		return nil, errors.NoAuction()
 	}
@@ -1994,11 +2316,6 @@ func (e *Engine) PlaceBid(gameID, userID int64, amount int) ([]*Event, error) {
 	}
 
 	// Check if player has enough money
-	state, err := e.GetGameState(gameID)
-	if err != nil {
-		return nil, err
-	}
-
 	var bidderMoney int
 	var bidderName string
 	for _, p := range state.Players {
@@ -2037,7 +2354,17 @@ func (e *Engine) PlaceBid(gameID, userID int64, amount int) ([]*Event, error) {
 
 // PassAuction allows a player to pass (exit) the current auction
 func (e *Engine) PassAuction(gameID, userID int64) ([]*Event, error) {
-	auction := e.activeAuctions[gameID]
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+
+	auction := e.auction(gameID)
 	if auction == nil {
 		return nil, errors.NoAuction()
 	}
@@ -2051,11 +2378,6 @@ func (e *Engine) PassAuction(gameID, userID int64) ([]*Event, error) {
 	auction.PassedBidders[userID] = true
 
 	// Get player name
-	state, err := e.GetGameState(gameID)
-	if err != nil {
-		return nil, err
-	}
-
 	var passerName string
 	for _, p := range state.Players {
 		if p.UserID == userID {
@@ -2114,6 +2436,30 @@ func (e *Engine) advanceAuctionBidder(auction *Auction) int64 {
 
 // endAuction finalizes the auction and transfers property to winner
 func (e *Engine) endAuction(gameID int64, auction *Auction, state *GameState) ([]*Event, error) {
+	events, err := e.settleAuction(gameID, auction, state)
+	if err != nil {
+		return nil, err
+	}
+
+	// Auto-end turn for the current player after auction ends
+	// The auction was triggered because a player passed on a property,
+	// so after it concludes, we should advance to the next player
+	// (unless the current player had doubles)
+	doublesCount := e.doubles(gameID)
+	if doublesCount == 0 && state.CurrentPlayerID != 0 {
+		turnEvent, err := e.endTurnInternal(gameID, state.CurrentPlayerID, true, "")
+		if err == nil && turnEvent != nil {
+			events = append(events, turnEvent)
+		}
+	}
+
+	e.auditMoney(gameID, events)
+	return events, nil
+}
+
+// settleAuction sells the property to the highest bidder so far, if any,
+// and clears the auction, leaving the turn as it is
+func (e *Engine) settleAuction(gameID int64, auction *Auction, state *GameState) ([]*Event, error) {
 	var events []*Event
 
 	// Clear the original player's pending action
@@ -2166,7 +2512,8 @@ func (e *Engine) endAuction(gameID int64, auction *Auction, state *GameState) ([
 				FinalBid:     auction.HighestBid,
 				NoWinner:     false,
 			},
-		})
+		}, ownershipChanged(gameID, auction.Position, 0, auction.HighestBidderID, OwnershipAuction),
+			moneyTransferred(gameID, auction.HighestBidderID, 0, auction.HighestBid, MoneyPurchase))
 	} else {
 		// No winner - everyone passed
 		if err := e.store.CommitTx(tx); err != nil {
@@ -2188,45 +2535,102 @@ func (e *Engine) endAuction(gameID int64, auction *Auction, state *GameState) ([
 	}
 
 	// Remove auction
-	delete(e.activeAuctions, gameID)
-
-	// Auto-end turn for the current player after auction ends
-	// The auction was triggered because a player passed on a property,
-	// so after it concludes, we should advance to the next player
-	// (unless the current player had doubles)
-	doublesCount := e.doublesCount[gameID]
-	if doublesCount == 0 && state.CurrentPlayerID != 0 {
-		turnEvent, err := e.ForceEndTurn(gameID, state.CurrentPlayerID)
-		if err == nil && turnEvent != nil {
-			events = append(events, turnEvent)
-		}
-	}
-
+	e.setAuction(gameID, nil)
 	return events, nil
 }
 
 // GetActiveAuction returns the active auction for a game, if any
 func (e *Engine) GetActiveAuction(gameID int64) *Auction {
-	return e.activeAuctions[gameID]
+	return e.auction(gameID)
 }
 
 func (e *Engine) EndTurn(gameID, userID int64) (*Event, error) {
-	return e.endTurnInternal(gameID, userID, false)
+	defer e.lockGame(gameID)()
+	return e.endTurnInternal(gameID, userID, false, "")
 }
 
 func (e *Engine) ForceEndTurn(gameID, userID int64) (*Event, error) {
-	return e.endTurnInternal(gameID, userID, true)
+	defer e.lockGame(gameID)()
+	return e.endTurnInternal(gameID, userID, true, "")
 }
 
-// EliminatePlayerForTimeouts removes a player from the game due to consecutive timeouts
-func (e *Engine) EliminatePlayerForTimeouts(gameID, userID int64) (*Event, error) {
+// SkipTurn lets the current player pass, rolled or not, e.g. to sit out a
+// jail turn without trying the dice. An undecided purchase is declined
+// without an auction, a running auction is settled at the highest bid so far
+// and undecided income tax is paid at the flat rate, as when the turn times
+// out. Doubles owe no extra roll. The turn_changed event comes last and
+// carries TurnEndSkipped as the reason.
+func (e *Engine) SkipTurn(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
+	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	var player *Player
+	for _, p := range state.Players {
+		if p.UserID == userID {
+			player = p
+			break
+		}
+	}
+	if player == nil {
+		return nil, errors.NotInGame()
+	}
+
+	var events []*Event
+	switch player.PendingAction {
+	case "tax_choice":
+		taxEvents, err := e.payIncomeTax(gameID, userID, TaxChoiceFlat, false)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, taxEvents...)
+		// Going bankrupt on the tax can end the game
+		for _, event := range taxEvents {
+			if event.Type == "game_finished" {
+				return events, nil
+			}
+		}
+	case "auction":
+		if auction := e.auction(gameID); auction != nil {
+			auctionEvents, err := e.settleAuction(gameID, auction, state)
+			if err != nil {
+				return nil, err
+			}
+			e.auditMoney(gameID, auctionEvents)
+			events = append(events, auctionEvents...)
+		}
+	}
+
+	// Forcing the end clears a pending buy, which leaves the property with the bank
+	turnEvent, err := e.endTurnInternal(gameID, userID, true, TurnEndSkipped)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, turnEvent), nil
+}
+
+// EliminatePlayerForTimeouts removes a player from the game due to consecutive timeouts.
+// Their cash returning to the bank and the events of their properties
+// returning to it come first, then the turn_changed or game_finished event.
+func (e *Engine) EliminatePlayerForTimeouts(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
 	var player *Player
@@ -2252,10 +2656,16 @@ func (e *Engine) EliminatePlayerForTimeouts(gameID, userID int64) (*Event, error
 		return nil, err
 	}
 
-	// Release all their properties
-	if err := e.store.DeletePlayerPropertiesTx(tx, gameID, userID); err != nil {
+	// Their cash goes back to the bank and their properties are released
+	var events []*Event
+	if player.Money > 0 {
+		events = append(events, moneyTransferred(gameID, userID, 0, player.Money, MoneyBankruptcy))
+	}
+	released, err := e.releasePropertiesTx(tx, gameID, userID, 0)
+	if err != nil {
 		return nil, err
 	}
+	events = append(events, released...)
 
 	// Clear pending action
 	if err := e.store.SetPlayerPendingActionTx(tx, gameID, userID, ""); err != nil {
@@ -2263,46 +2673,22 @@ func (e *Engine) EliminatePlayerForTimeouts(gameID, userID int64) (*Event, error
 	}
 
 	// Check if game should end
-	activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
+	finished, err := e.finishIfLastPlayerTx(tx, gameID)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(activePlayers) <= 1 {
-		// Game over - one player left
-		if err := e.store.UpdateGameStatusTx(tx, gameID, StatusFinished); err != nil {
-			return nil, err
-		}
-
+	if finished != nil {
 		if err := e.store.CommitTx(tx); err != nil {
 			return nil, err
 		}
+		events = append(events, finished)
+		e.auditMoney(gameID, events)
+		return events, nil
+	}
 
-		var winnerID int64
-		if len(activePlayers) == 1 {
-			winnerID = activePlayers[0].UserID
-		}
-
-		// Get final player states
-		finalPlayers, _ := e.store.GetGamePlayers(gameID)
-		resultPlayers := make([]*Player, len(finalPlayers))
-		for i, p := range finalPlayers {
-			resultPlayers[i] = &Player{
-				UserID:     p.UserID,
-				Username:   p.Username,
-				Money:      p.Money,
-				IsBankrupt: p.IsBankrupt,
-			}
-		}
-
-		return &Event{
-			Type:   "game_finished",
-			GameID: gameID,
-			Payload: GameFinishedPayload{
-				Players:  resultPlayers,
-				WinnerID: winnerID,
-			},
-		}, nil
+	activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find next player
@@ -2325,7 +2711,7 @@ func (e *Engine) EliminatePlayerForTimeouts(gameID, userID int64) (*Event, error
 	}
 
 	// Reset doubles count
-	e.doublesCount[gameID] = 0
+	e.setDoubles(gameID, 0)
 
 	if err := e.store.ResetPlayerTurnStateTx(tx, gameID, nextPlayer.UserID); err != nil {
 		return nil, err
@@ -2338,26 +2724,29 @@ func (e *Engine) EliminatePlayerForTimeouts(gameID, userID int64) (*Event, error
 	if err := e.store.CommitTx(tx); err != nil {
 		return nil, err
 	}
+	e.auditMoney(gameID, events)
 
-	return &Event{
+	return append(events, &Event{
 		Type:   "turn_changed",
 		GameID: gameID,
 		Payload: TurnChangedPayload{
 			PreviousPlayerID: userID,
 			CurrentPlayerID:  nextPlayer.UserID,
 		},
-	}, nil
+	}), nil
 }
 
 // GiveUp allows a player to voluntarily forfeit the game
 func (e *Engine) GiveUp(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
 	var player *Player
@@ -2388,7 +2777,8 @@ func (e *Engine) GiveUp(gameID, userID int64) ([]*Event, error) {
 	}
 
 	// Release all their properties
-	if err := e.store.DeletePlayerPropertiesTx(tx, gameID, userID); err != nil {
+	released, err := e.releasePropertiesTx(tx, gameID, userID, 0)
+	if err != nil {
 		return nil, err
 	}
 
@@ -2409,52 +2799,30 @@ func (e *Engine) GiveUp(gameID, userID int64) ([]*Event, error) {
 			Reason:   "gave up",
 		},
 	})
+	if player.Money > 0 {
+		events = append(events, moneyTransferred(gameID, userID, 0, player.Money, MoneyBankruptcy))
+	}
+	events = append(events, released...)
 
 	// Check if game should end
-	activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
+	finished, err := e.finishIfLastPlayerTx(tx, gameID)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(activePlayers) <= 1 {
-		// Game over - one player left
-		if err := e.store.UpdateGameStatusTx(tx, gameID, StatusFinished); err != nil {
-			return nil, err
-		}
-
+	if finished != nil {
 		if err := e.store.CommitTx(tx); err != nil {
 			return nil, err
 		}
-
-		var winnerID int64
-		if len(activePlayers) == 1 {
-			winnerID = activePlayers[0].UserID
-		}
-
-		// Get final player states
-		finalPlayers, _ := e.store.GetGamePlayers(gameID)
-		resultPlayers := make([]*Player, len(finalPlayers))
-		for i, p := range finalPlayers {
-			resultPlayers[i] = &Player{
-				UserID:     p.UserID,
-				Username:   p.Username,
-				Money:      p.Money,
-				IsBankrupt: p.IsBankrupt,
-			}
-		}
-
-		events = append(events, &Event{
-			Type:   "game_finished",
-			GameID: gameID,
-			Payload: GameFinishedPayload{
-				Players:  resultPlayers,
-				WinnerID: winnerID,
-			},
-		})
-
+		events = append(events, finished)
+		e.auditMoney(gameID, events)
 		return events, nil
 	}
 
+	activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
 	// If it was this player's turn, advance to next player
 	if state.CurrentPlayerID == userID {
 		// Find next player
@@ -2475,7 +2843,7 @@ func (e *Engine) GiveUp(gameID, userID int64) ([]*Event, error) {
 		}
 
 		// Reset doubles count
-		e.doublesCount[gameID] = 0
+		e.setDoubles(gameID, 0)
 
 		if err := e.store.ResetPlayerTurnStateTx(tx, gameID, nextPlayer.UserID); err != nil {
 			return nil, err
@@ -2499,26 +2867,24 @@ func (e *Engine) GiveUp(gameID, userID int64) ([]*Event, error) {
 		return nil, err
 	}
 
+	e.auditMoney(gameID, events)
 	return events, nil
 }
 
-func (e *Engine) endTurnInternal(gameID, userID int64, force bool) (*Event, error) {
+func (e *Engine) endTurnInternal(gameID, userID int64, force bool, reason string) (*Event, error) {
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
-	if state.CurrentPlayerID != userID {
-		return nil, errors.NotYourTurn()
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
 	}
 
-	// Reset doubles count when turn ends
-	e.doublesCount[gameID] = 0
-
 	var currentPlayer *Player
 	for _, p := range state.Players {
 		if p.UserID == userID {
@@ -2540,6 +2906,10 @@ func (e *Engine) endTurnInternal(gameID, userID int64, force bool) (*Event, erro
 		}
 	}
 
+	// Reset doubles count only once the turn is really ending; a rejected
+	// end_turn between doubles rolls must not wipe the three-doubles count
+	e.setDoubles(gameID, 0)
+
 	tx, err := e.store.BeginTx()
 	if err != nil {
 		return nil, err
@@ -2576,6 +2946,18 @@ func (e *Engine) endTurnInternal(gameID, userID int64, force bool) (*Event, erro
 	nextIdx := (currentIdx + 1) % len(activePlayers)
 	nextPlayer := activePlayers[nextIdx]
 
+	finished, err := e.finishIfLimitReachedTx(tx, gameID, nextIdx <= currentIdx)
+	if err != nil {
+		return nil, err
+	}
+	if finished != nil {
+		if err := e.store.CommitTx(tx); err != nil {
+			return nil, err
+		}
+		e.forgetMoneyLedger(gameID)
+		return finished, nil
+	}
+
 	if err := e.store.ResetPlayerTurnStateTx(tx, gameID, nextPlayer.UserID); err != nil {
 		return nil, err
 	}
@@ -2594,6 +2976,7 @@ func (e *Engine) endTurnInternal(gameID, userID int64, force bool) (*Event, erro
 		Payload: TurnChangedPayload{
 			PreviousPlayerID: userID,
 			CurrentPlayerID:  nextPlayer.UserID,
+			Reason:           reason,
 		},
 	}, nil
 }
@@ -2602,7 +2985,7 @@ func (e *Engine) endTurnInternal(gameID, userID int64, force bool) (*Event, erro
 // This is used for auto-ending turns after certain actions
 func (e *Engine) endTurnInternalTx(tx *sql.Tx, gameID, userID int64) (*Event, error) {
 	// Reset doubles count
-	e.doublesCount[gameID] = 0
+	e.setDoubles(gameID, 0)
 
 	activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
 	if err != nil {
@@ -2628,6 +3011,11 @@ func (e *Engine) endTurnInternalTx(tx *sql.Tx, gameID, userID int64) (*Event, er
 	nextIdx := (currentIdx + 1) % len(activePlayers)
 	nextPlayer := activePlayers[nextIdx]
 
+	finished, err := e.finishIfLimitReachedTx(tx, gameID, nextIdx <= currentIdx)
+	if err != nil || finished != nil {
+		return finished, err
+	}
+
 	if err := e.store.ResetPlayerTurnStateTx(tx, gameID, nextPlayer.UserID); err != nil {
 		return nil, err
 	}
@@ -2648,13 +3036,15 @@ func (e *Engine) endTurnInternalTx(tx *sql.Tx, gameID, userID int64) (*Event, er
 
 // ProposeTrade creates a new trade offer
 func (e *Engine) ProposeTrade(gameID, fromUserID, toUserID int64, offer TradeOffer) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
 	// Verify both players are in the game and not bankrupt
@@ -2676,32 +3066,8 @@ func (e *Engine) ProposeTrade(gameID, fromUserID, toUserID int64, offer TradeOff
 		return nil, errors.PlayerBankrupt()
 	}
 
-	// Verify fromPlayer owns offered properties and they're not mortgaged/improved
-	for _, pos := range offer.OfferedProperties {
-		ownerID, ok := state.Properties[pos]
-		if !ok || ownerID != fromUserID {
-			return nil, errors.PropertyNotOwned()
-		}
-		if state.MortgagedProperties[pos] {
-			return nil, errors.BadRequest("Cannot trade mortgaged properties")
-		}
-		if state.Improvements[pos] > 0 {
-			return nil, errors.BadRequest("Cannot trade properties with houses/hotels")
-		}
-	}
-
-	// Verify toPlayer owns requested properties and they're not mortgaged/improved
-	for _, pos := range offer.RequestedProperties {
-		ownerID, ok := state.Properties[pos]
-		if !ok || ownerID != toUserID {
-			return nil, errors.BadRequest("Other player doesn't own requested property")
-		}
-		if state.MortgagedProperties[pos] {
-			return nil, errors.BadRequest("Cannot trade mortgaged properties")
-		}
-		if state.Improvements[pos] > 0 {
-			return nil, errors.BadRequest("Cannot trade properties with houses/hotels")
-		}
+	if err := checkTradeProperties(state, fromUserID, toUserID, offer); err != nil {
+		return nil, err
 	}
 
 	// Verify fromPlayer has enough money
@@ -2743,13 +3109,15 @@ func (e *Engine) ProposeTrade(gameID, fromUserID, toUserID int64, offer TradeOff
 
 // AcceptTrade accepts a pending trade
 func (e *Engine) AcceptTrade(gameID, userID, tradeID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
 	// Get the trade
@@ -2801,6 +3169,15 @@ func (e *Engine) AcceptTrade(gameID, userID, tradeID int64) ([]*Event, error) {
 		return nil, errors.InsufficientFunds()
 	}
 
+	// Properties may have changed hands, or been mortgaged or built on,
+	// since the offer was made
+	if err := checkTradeProperties(state, dbTrade.FromUserID, dbTrade.ToUserID, offer); err != nil {
+		if err := e.store.UpdateTradeStatus(tradeID, "cancelled"); err != nil {
+			return nil, err
+		}
+		return nil, errors.BadRequest("Trade is no longer valid: the properties involved have changed")
+	}
+
 	// Execute the trade
 	tx, err := e.store.BeginTx()
 	if err != nil {
@@ -2840,7 +3217,7 @@ func (e *Engine) AcceptTrade(gameID, userID, tradeID int64) ([]*Event, error) {
 		return nil, err
 	}
 
-	return []*Event{
+	events := []*Event{
 		{
 			Type:   "trade_accepted",
 			GameID: gameID,
@@ -2853,18 +3230,136 @@ func (e *Engine) AcceptTrade(gameID, userID, tradeID int64) ([]*Event, error) {
 				ToUsername:   toPlayer.Username,
 			},
 		},
-	}, nil
+	}
+
+	for _, pos := range offer.OfferedProperties {
+		events = append(events, ownershipChanged(gameID, pos, dbTrade.FromUserID, dbTrade.ToUserID, OwnershipTrade))
+	}
+	for _, pos := range offer.RequestedProperties {
+		events = append(events, ownershipChanged(gameID, pos, dbTrade.ToUserID, dbTrade.FromUserID, OwnershipTrade))
+	}
+
+	moved := append(append([]int{}, offer.OfferedProperties...), offer.RequestedProperties...)
+	invalidated, err := e.invalidateTradesInvolving(state, moved)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, invalidated...)
+	e.auditMoney(gameID, events)
+	return events, nil
+}
+
+// checkTradeProperties verifies that each side still owns the properties the
+// offer moves and that none of them is mortgaged or improved
+func checkTradeProperties(state *GameState, fromUserID, toUserID int64, offer TradeOffer) error {
+	// Verify fromPlayer owns offered properties and they're not mortgaged/improved
+	for _, pos := range offer.OfferedProperties {
+		ownerID, ok := state.Properties[pos]
+		if !ok || ownerID != fromUserID {
+			return errors.PropertyNotOwned()
+		}
+		if state.MortgagedProperties[pos] {
+			return errors.BadRequest("Cannot trade mortgaged properties")
+		}
+		if state.Improvements[pos] > 0 {
+			return errors.BadRequest("Cannot trade properties with houses/hotels")
+		}
+	}
+
+	// Verify toPlayer owns requested properties and they're not mortgaged/improved
+	for _, pos := range offer.RequestedProperties {
+		ownerID, ok := state.Properties[pos]
+		if !ok || ownerID != toUserID {
+			return errors.BadRequest("Other player doesn't own requested property")
+		}
+		if state.MortgagedProperties[pos] {
+			return errors.BadRequest("Cannot trade mortgaged properties")
+		}
+		if state.Improvements[pos] > 0 {
+			return errors.BadRequest("Cannot trade properties with houses/hotels")
+		}
+	}
+	return nil
+}
+
+// invalidateTradesInvolving cancels the pending trades of the game that offer
+// or request any of the given properties, which an accepted trade has just
+// moved, and returns a trade_cancelled event with status "invalidated" for each
+func (e *Engine) invalidateTradesInvolving(state *GameState, positions []int) ([]*Event, error) {
+	if len(positions) == 0 {
+		return nil, nil
+	}
+	moved := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		moved[pos] = true
+	}
+
+	pending, err := e.store.GetPendingTrades(state.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*Event
+	for _, trade := range pending {
+		var offer TradeOffer
+		if err := json.Unmarshal([]byte(trade.OfferJSON), &offer); err != nil {
+			return nil, err
+		}
+		involved := false
+		for _, pos := range append(append([]int{}, offer.OfferedProperties...), offer.RequestedProperties...) {
+			if moved[pos] {
+				involved = true
+				break
+			}
+		}
+		if !involved {
+			continue
+		}
+
+		if err := e.store.UpdateTradeStatus(trade.ID, "cancelled"); err != nil {
+			return nil, err
+		}
+		fromUsername, toUsername := tradeUsernames(state, trade)
+		events = append(events, &Event{
+			Type:   "trade_cancelled",
+			GameID: state.ID,
+			Payload: TradeResponsePayload{
+				TradeID:      trade.ID,
+				FromUserID:   trade.FromUserID,
+				ToUserID:     trade.ToUserID,
+				Status:       "invalidated",
+				FromUsername: fromUsername,
+				ToUsername:   toUsername,
+			},
+		})
+	}
+	return events, nil
+}
+
+// tradeUsernames looks up the usernames of both sides of a trade
+func tradeUsernames(state *GameState, trade *store.GameTrade) (fromUsername, toUsername string) {
+	for _, p := range state.Players {
+		if p.UserID == trade.FromUserID {
+			fromUsername = p.Username
+		}
+		if p.UserID == trade.ToUserID {
+			toUsername = p.Username
+		}
+	}
+	return fromUsername, toUsername
 }
 
 // DeclineTrade declines a pending trade
 func (e *Engine) DeclineTrade(gameID, userID, tradeID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
 	// Get the trade
@@ -2915,13 +3410,15 @@ func (e *Engine) DeclineTrade(gameID, userID, tradeID int64) (*Event, error) {
 
 // CancelTrade cancels a pending trade (by the proposer)
 func (e *Engine) CancelTrade(gameID, userID, tradeID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
 	state, err := e.GetGameState(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if state.Status != StatusInProgress {
-		return nil, errors.GameNotStarted()
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
 	}
 
 	// Get the trade
@@ -2969,3 +3466,39 @@ func (e *Engine) CancelTrade(gameID, userID, tradeID int64) (*Event, error) {
 		},
 	}, nil
 }
+
+// ExpireTrade auto-declines a trade nobody answered in time. It returns a nil
+// event if the trade was already accepted, declined or cancelled.
+func (e *Engine) ExpireTrade(gameID, tradeID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	dbTrade, err := e.store.GetTrade(tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if dbTrade == nil || dbTrade.GameID != gameID || dbTrade.Status != "pending" {
+		return nil, nil
+	}
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.store.UpdateTradeStatus(tradeID, "expired"); err != nil {
+		return nil, err
+	}
+
+	fromUsername, toUsername := tradeUsernames(state, dbTrade)
+	return &Event{
+		Type:   "trade_expired",
+		GameID: gameID,
+		Payload: TradeExpiredPayload{
+			TradeID:      tradeID,
+			FromUserID:   dbTrade.FromUserID,
+			ToUserID:     dbTrade.ToUserID,
+			FromUsername: fromUsername,
+			ToUsername:   toUsername,
+		},
+	}, nil
+}
@@ -2,8 +2,17 @@ package game
 
 import (
 	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"monopoly/errors"
 	"monopoly/store"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 // MockGameStore implements store.GameStore for testing
@@ -11,6 +20,9 @@ type MockGameStore struct {
 	Games      map[int64]*store.Game
 	Players    map[int64][]*store.GamePlayer
 	Properties map[int64][]*store.GameProperty
+	Events     map[int64][]*store.GameEvent
+	Spectators map[string]int64 // token -> game ID
+	Trades     map[int64]*store.GameTrade
 
 	// Track method calls
 	UpdatePlayerPositionCalled bool
@@ -26,6 +38,9 @@ func NewMockGameStore() *MockGameStore {
 		Games:      make(map[int64]*store.Game),
 		Players:    make(map[int64][]*store.GamePlayer),
 		Properties: make(map[int64][]*store.GameProperty),
+		Events:     make(map[int64][]*store.GameEvent),
+		Spectators: make(map[string]int64),
+		Trades:     make(map[int64]*store.GameTrade),
 	}
 }
 
@@ -38,7 +53,13 @@ func (m *MockGameStore) GetGamePlayers(gameID int64) ([]*store.GamePlayer, error
 	return m.Players[gameID], nil
 }
 
-func (m *MockGameStore) JoinGame(gameID, userID int64, playerOrder int) error {
+func (m *MockGameStore) JoinGame(gameID, userID int64) (int, error) {
+	playerOrder := 1
+	for _, p := range m.Players[gameID] {
+		if p.PlayerOrder >= playerOrder {
+			playerOrder = p.PlayerOrder + 1
+		}
+	}
 	player := &store.GamePlayer{
 		GameID:      gameID,
 		UserID:      userID,
@@ -46,7 +67,14 @@ func (m *MockGameStore) JoinGame(gameID, userID int64, playerOrder int) error {
 		Money:       1500,
 	}
 	m.Players[gameID] = append(m.Players[gameID], player)
-	return nil
+	return playerOrder, nil
+}
+
+func (m *MockGameStore) SeatPlayer(gameID, userID int64, tokens []string) (string, error) {
+	if _, err := m.JoinGame(gameID, userID); err != nil {
+		return "", err
+	}
+	return tokens[0], nil
 }
 
 func (m *MockGameStore) UpdatePlayerReady(gameID, userID int64, isReady bool) error {
@@ -60,6 +88,36 @@ func (m *MockGameStore) UpdateGameStatus(gameID int64, status string) error {
 	return nil
 }
 
+func (m *MockGameStore) UpdateGameSettings(gameID int64, settings store.GameSettings) (bool, error) {
+	g := m.Games[gameID]
+	if g == nil || g.Status != "waiting" || len(m.Players[gameID]) > settings.MaxPlayers {
+		return false, nil
+	}
+	g.MinPlayers, g.MaxPlayers, g.StartingMoney = settings.MinPlayers, settings.MaxPlayers, settings.StartingMoney
+	g.TurnLimit, g.TimeLimitMinutes, g.ManualStart = settings.TurnLimit, settings.TimeLimitMinutes, settings.ManualStart
+	for _, p := range m.Players[gameID] {
+		p.Money = settings.StartingMoney
+	}
+	return true, nil
+}
+
+func (m *MockGameStore) TouchGameActivity(gameID int64) error {
+	if g := m.Games[gameID]; g != nil {
+		g.LastActivityAt = time.Now().Unix()
+	}
+	return nil
+}
+
+func (m *MockGameStore) ListInactiveGames(before time.Time) ([]int64, error) {
+	var ids []int64
+	for id, g := range m.Games {
+		if g.Status == StatusInProgress && max(g.StartedAt, g.LastActivityAt) < before.Unix() {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 func (m *MockGameStore) UpdateCurrentTurn(gameID, userID int64) error {
 	return nil
 }
@@ -111,6 +169,28 @@ func (m *MockGameStore) UpdateGameStatusTx(tx *sql.Tx, gameID int64, status stri
 	return nil
 }
 
+func (m *MockGameStore) GetGameTx(tx *sql.Tx, gameID int64) (*store.Game, error) {
+	return m.Games[gameID], nil
+}
+
+func (m *MockGameStore) IncrementRoundTx(tx *sql.Tx, gameID int64) (int, error) {
+	g := m.Games[gameID]
+	if g == nil {
+		return 0, nil
+	}
+	g.Round++
+	return g.Round, nil
+}
+
+func (m *MockGameStore) FinishGameTx(tx *sql.Tx, gameID, winnerID int64, reason string) error {
+	if g := m.Games[gameID]; g != nil {
+		g.Status = StatusFinished
+		g.WinnerID = winnerID
+		g.EndReason = reason
+	}
+	return nil
+}
+
 func (m *MockGameStore) UpdateCurrentTurnTx(tx *sql.Tx, gameID, userID int64) error {
 	for _, p := range m.Players[gameID] {
 		p.IsCurrentTurn = p.UserID == userID
@@ -357,18 +437,30 @@ func (m *MockGameStore) UseJailCardTx(tx *sql.Tx, gameID, userID int64) (string,
 
 // Trade operations
 func (m *MockGameStore) CreateTrade(gameID, fromUserID, toUserID int64, offerJSON string) (int64, error) {
-	return 1, nil
+	id := int64(len(m.Trades) + 1)
+	m.Trades[id] = &store.GameTrade{ID: id, GameID: gameID, FromUserID: fromUserID, ToUserID: toUserID,
+		OfferJSON: offerJSON, Status: "pending"}
+	return id, nil
 }
 
 func (m *MockGameStore) GetTrade(tradeID int64) (*store.GameTrade, error) {
-	return nil, nil
+	return m.Trades[tradeID], nil
 }
 
 func (m *MockGameStore) GetPendingTrades(gameID int64) ([]*store.GameTrade, error) {
-	return nil, nil
+	var trades []*store.GameTrade
+	for id := int64(1); id <= int64(len(m.Trades)); id++ {
+		if t := m.Trades[id]; t.GameID == gameID && t.Status == "pending" {
+			trades = append(trades, t)
+		}
+	}
+	return trades, nil
 }
 
 func (m *MockGameStore) UpdateTradeStatus(tradeID int64, status string) error {
+	if t := m.Trades[tradeID]; t != nil {
+		t.Status = status
+	}
 	return nil
 }
 
@@ -381,6 +473,71 @@ func (m *MockGameStore) TransferPropertyTx(tx *sql.Tx, gameID int64, position in
 	return nil
 }
 
+// Event log
+func (m *MockGameStore) AppendEvent(gameID int64, eventType, payloadJSON string) (int64, error) {
+	seq := int64(len(m.Events[gameID]) + 1)
+	m.Events[gameID] = append(m.Events[gameID], &store.GameEvent{
+		GameID:      gameID,
+		Seq:         seq,
+		Type:        eventType,
+		PayloadJSON: payloadJSON,
+	})
+	return seq, nil
+}
+
+func (m *MockGameStore) GetEvents(gameID, sinceSeq int64, limit int) ([]*store.GameEvent, error) {
+	var events []*store.GameEvent
+	for _, ev := range m.Events[gameID] {
+		if ev.Seq > sinceSeq && len(events) < limit {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+func (m *MockGameStore) GetLastEvent(gameID int64, eventType string) (*store.GameEvent, error) {
+	events := m.Events[gameID]
+	for i := len(events) - 1; i >= 0; i-- {
+		if eventType == "" || events[i].Type == eventType {
+			return events[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockGameStore) GetRecentEvents(gameID int64, limit int, types ...string) ([]*store.GameEvent, error) {
+	events := m.Events[gameID]
+	recent := []*store.GameEvent{}
+	for i := len(events) - 1; i >= 0 && len(recent) < limit; i-- {
+		if slices.Contains(types, events[i].Type) {
+			recent = append(recent, events[i])
+		}
+	}
+	return recent, nil
+}
+
+// Spectator tokens
+func (m *MockGameStore) CreateSpectatorToken(gameID, createdBy int64, token string) error {
+	m.Spectators[token] = gameID
+	return nil
+}
+
+func (m *MockGameStore) GetSpectatorTokenGame(token string) (int64, error) {
+	gameID := m.Spectators[token]
+	if g := m.Games[gameID]; g == nil || g.Status == StatusFinished {
+		return 0, nil
+	}
+	return gameID, nil
+}
+
+func (m *MockGameStore) RevokeSpectatorToken(gameID int64, token string) (bool, error) {
+	if m.Spectators[token] != gameID {
+		return false, nil
+	}
+	delete(m.Spectators, token)
+	return true, nil
+}
+
 // ============ TESTS ============
 
 func TestNewEngine(t *testing.T) {
@@ -456,6 +613,85 @@ func TestGetGameState_Success(t *testing.T) {
 	if state.CurrentPlayerID != 100 {
 		t.Errorf("Expected current player ID 100, got %d", state.CurrentPlayerID)
 	}
+
+	if state.HostUserID != 100 {
+		t.Errorf("Expected host 100 (first to join), got %d", state.HostUserID)
+	}
+}
+
+func TestGetGameState_TurnPhase(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        string
+		hasRolled     bool
+		pendingAction string
+		want          string
+	}{
+		{"waiting game has no phase", StatusWaiting, false, "", ""},
+		{"before rolling", StatusInProgress, false, "", TurnPhaseAwaitingRoll},
+		{"landed on unowned property", StatusInProgress, true, "buy_or_pass", TurnPhaseAwaitingBuyDecision},
+		{"passed into auction", StatusInProgress, true, "auction", TurnPhaseInAuction},
+		{"rolled and resolved", StatusInProgress, true, "", TurnPhaseAwaitingEnd},
+		{"finished game has no phase", StatusFinished, true, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := NewMockGameStore()
+			engine := NewEngine(mockStore)
+			mockStore.Games[1] = &store.Game{ID: 1, Status: tt.status, MaxPlayers: 4}
+			mockStore.Players[1] = []*store.GamePlayer{
+				{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true,
+					HasRolled: tt.hasRolled, PendingAction: tt.pendingAction},
+				{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500, PendingAction: "buy_or_pass"},
+			}
+
+			state, err := engine.GetGameState(1)
+			if err != nil {
+				t.Fatalf("GetGameState failed: %v", err)
+			}
+			if state.TurnPhase != tt.want {
+				t.Errorf("Expected turn phase %q, got %q", tt.want, state.TurnPhase)
+			}
+		})
+	}
+}
+
+func TestTurnStats_AveragesCompletedTurns(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	if _, err := engine.TurnStats(1); errors.From(err).Code != errors.ErrCodeGameNotFound {
+		t.Fatalf("Expected GAME_NOT_FOUND, got %v", err)
+	}
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 4}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, TurnsTaken: 4, TurnSeconds: 90},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, IsCurrentTurn: true},
+	}
+
+	stats, err := engine.TurnStats(1)
+	if err != nil {
+		t.Fatalf("TurnStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].TurnsTaken != 4 || stats[0].AvgTurnSeconds != 22.5 {
+		t.Fatalf("Expected player1 to average 22.5s over 4 turns, got %+v", stats)
+	}
+	if stats[1].TurnsTaken != 0 || stats[1].AvgTurnSeconds != 0 {
+		t.Errorf("Expected no average before a completed turn, got %+v", stats[1])
+	}
+
+	// Players see the same figures in the standings
+	standings, err := engine.GetStandings(1)
+	if err != nil {
+		t.Fatalf("GetStandings failed: %v", err)
+	}
+	for _, st := range standings {
+		if st.UserID == 100 && (st.TurnsTaken != 4 || st.AvgTurnSeconds != 22.5) {
+			t.Errorf("Expected player1's turn timing in the standings, got %+v", st)
+		}
+	}
 }
 
 func TestJoinGame_Success(t *testing.T) {
@@ -539,198 +775,1057 @@ func TestJoinGame_AlreadyInGame(t *testing.T) {
 	}
 }
 
-func TestRollDice_NotYourTurn(t *testing.T) {
+func TestRejoinGame_ExistingPlayer(t *testing.T) {
 	mockStore := NewMockGameStore()
 	engine := NewEngine(mockStore)
 
-	// Set up in-progress game
 	mockStore.Games[1] = &store.Game{
 		ID:         1,
 		Status:     StatusInProgress,
-		MaxPlayers: 4,
+		MaxPlayers: 2,
 	}
 	mockStore.Players[1] = []*store.GamePlayer{
 		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
 		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
 	}
 
-	// Player 2 tries to roll when it's player 1's turn
-	_, err := engine.RollDice(1, 101)
-	if err == nil {
-		t.Error("Expected error when rolling dice out of turn")
+	// Rejoining twice must succeed and leave the seats untouched
+	for i := 0; i < 2; i++ {
+		state, err := engine.RejoinGame(1, 101)
+		if err != nil {
+			t.Fatalf("Expected rejoin to succeed, got %v", err)
+		}
+		if len(state.Players) != 2 {
+			t.Errorf("Expected 2 players, got %d", len(state.Players))
+		}
 	}
 }
 
-func TestRollDice_AlreadyRolled(t *testing.T) {
+func TestRejoinGame_NotPlayer(t *testing.T) {
 	mockStore := NewMockGameStore()
 	engine := NewEngine(mockStore)
 
-	// Set up in-progress game with player who already rolled
 	mockStore.Games[1] = &store.Game{
 		ID:         1,
 		Status:     StatusInProgress,
-		MaxPlayers: 4,
+		MaxPlayers: 2,
 	}
 	mockStore.Players[1] = []*store.GamePlayer{
-		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true, HasRolled: true},
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500},
 	}
 
-	_, err := engine.RollDice(1, 100)
+	_, err := engine.RejoinGame(1, 999)
 	if err == nil {
-		t.Error("Expected error when rolling dice twice")
+		t.Error("Expected error when rejoining a game you are not in")
 	}
 }
 
-func TestRollDice_GameNotStarted(t *testing.T) {
-	mockStore := NewMockGameStore()
-	engine := NewEngine(mockStore)
+func TestCalculateNetWorth(t *testing.T) {
+	properties := map[int]int64{
+		1:  100, // Mediterranean Ave, $60, 2 houses at $50
+		3:  100, // Baltic Ave, $60, mortgaged
+		39: 101, // Boardwalk, other player
+	}
+	mortgaged := map[int]bool{3: true}
+	improvements := map[int]int{1: 2}
 
-	// Set up waiting game
-	mockStore.Games[1] = &store.Game{
-		ID:         1,
-		Status:     StatusWaiting,
-		MaxPlayers: 4,
+	got := calculateNetWorth(&Board, 100, 1000, properties, mortgaged, improvements)
+	want := 1000 + 60 + 2*50
+	if got != want {
+		t.Errorf("Expected net worth %d, got %d", want, got)
 	}
-	mockStore.Players[1] = []*store.GamePlayer{
-		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+}
+
+func TestIncomeTaxBasis_CountsMortgagedProperties(t *testing.T) {
+	properties := map[int]int64{
+		1:  100, // Mediterranean Ave, $60, 2 houses at $50
+		3:  100, // Baltic Ave, $60, mortgaged
+		39: 101, // Boardwalk, other player
 	}
+	improvements := map[int]int{1: 2}
 
-	_, err := engine.RollDice(1, 100)
-	if err == nil {
-		t.Error("Expected error when rolling dice before game starts")
+	got := incomeTaxBasis(&Board, 100, 1000, properties, improvements)
+	want := 1000 + 60 + 2*50 + 60
+	if got != want {
+		t.Errorf("Expected a tax basis of %d, got %d", want, got)
+	}
+	// Standings still leave the mortgaged property out
+	if worth := calculateNetWorth(&Board, 100, 1000, properties, map[int]bool{3: true}, improvements); worth != want-60 {
+		t.Errorf("Expected net worth %d, got %d", want-60, worth)
 	}
 }
 
-func TestRollDice_BankruptPlayer(t *testing.T) {
+func TestGetStandings_SortedByNetWorth(t *testing.T) {
 	mockStore := NewMockGameStore()
 	engine := NewEngine(mockStore)
 
-	// Set up in-progress game with bankrupt player
 	mockStore.Games[1] = &store.Game{
 		ID:         1,
 		Status:     StatusInProgress,
-		MaxPlayers: 4,
+		MaxPlayers: 2,
 	}
 	mockStore.Players[1] = []*store.GamePlayer{
-		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 0, IsCurrentTurn: true, IsBankrupt: true},
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1000},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 900},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 39, OwnerID: 101}, // Boardwalk, $400
 	}
 
-	_, err := engine.RollDice(1, 100)
-	if err == nil {
-		t.Error("Expected error when bankrupt player tries to roll")
+	standings, err := engine.GetStandings(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if standings[0].UserID != 101 || standings[0].NetWorth != 1300 {
+		t.Errorf("Expected player2 first with 1300, got %+v", standings[0])
+	}
+	if engine.GetPlayerNetWorth(1, 100) != 1000 {
+		t.Errorf("Expected player1 net worth 1000, got %d", engine.GetPlayerNetWorth(1, 100))
 	}
 }
 
-func TestRollDice_PendingAction(t *testing.T) {
+func TestCustomBoard_ReplacesPricesAndRents(t *testing.T) {
 	mockStore := NewMockGameStore()
 	engine := NewEngine(mockStore)
 
-	// Set up in-progress game with pending action
-	mockStore.Games[1] = &store.Game{
-		ID:         1,
-		Status:     StatusInProgress,
-		MaxPlayers: 4,
+	custom := Board
+	custom[39].Name = "Mayfair"
+	custom[39].Price = 1000
+	custom[39].Rent = 100
+	custom[39].GroupSize = 0
+	data, _ := json.Marshal(custom)
+	board, err := ParseCustomBoard(data)
+	if err != nil {
+		t.Fatalf("ParseCustomBoard failed: %v", err)
 	}
-	mockStore.Players[1] = []*store.GamePlayer{
-		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true, PendingAction: "buy_property"},
+	if board[39].GroupSize != 2 {
+		t.Errorf("Expected the group size filled in from the standard board, got %d", board[39].GroupSize)
 	}
+	data, _ = json.Marshal(board)
 
-	_, err := engine.RollDice(1, 100)
-	if err == nil {
-		t.Error("Expected error when player has pending action")
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2, Board: string(data)}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1000},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 900},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 39, OwnerID: 101},
 	}
-}
 
-func TestCalculateRent_BaseRent(t *testing.T) {
-	// Test base rent calculation for Mediterranean Ave (position 1)
-	space := Board[1]
-	rent := calculateBaseRent(space, false, 0)
+	state, err := engine.GetGameState(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state.Board[39].Name != "Mayfair" || Board[39].Name != "Boardwalk" {
+		t.Errorf("Expected the game's board to be custom and the standard one untouched, got %q and %q", state.Board[39].Name, Board[39].Name)
+	}
+	if worth := engine.GetPlayerNetWorth(1, 101); worth != 1900 {
+		t.Errorf("Expected net worth to use the custom price, got %d", worth)
+	}
 
-	if rent != 2 { // Mediterranean Ave base rent is $2
-		t.Errorf("Expected base rent $2, got $%d", rent)
+	details, err := engine.GetPropertyDetails(1, 39)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if details.Name != "Mayfair" || details.CurrentRent != 100 {
+		t.Errorf("Expected Mayfair at $100 rent, got %q at $%d", details.Name, details.CurrentRent)
 	}
 }
 
-func TestCalculateRent_MonopolyBonus(t *testing.T) {
-	// Test that monopoly doubles base rent
-	space := Board[1]
+func TestBuyHouse_RejectsUnaffordableBuild(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
 
-	baseRent := calculateBaseRent(space, false, 0)
-	monopolyRent := calculateBaseRent(space, true, 0)
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 49, IsCurrentTurn: true},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 1, OwnerID: 100}, // Mediterranean
+		{GameID: 1, Position: 3, OwnerID: 100}, // Baltic, completes brown
+	}
 
-	if monopolyRent != baseRent*2 {
-		t.Errorf("Expected monopoly rent $%d (2x base), got $%d", baseRent*2, monopolyRent)
+	_, err := engine.BuyHouse(1, 100, 1)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeInsufficientFunds {
+		t.Fatalf("Expected INSUFFICIENT_FUNDS for a $50 house with $49, got %v", err)
+	}
+	if mockStore.UpdatePlayerMoneyCalled || mockStore.Players[1][0].Money != 49 {
+		t.Errorf("Expected money untouched, got %d", mockStore.Players[1][0].Money)
 	}
 }
 
-func TestCalculateRent_WithHouses(t *testing.T) {
-	// Test rent with houses for Mediterranean Ave
-	space := Board[1]
+func TestSpectatorToken_PlayersIssueAndRevoke(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
 
-	rent0 := calculateBaseRent(space, true, 0)
-	rent1 := calculateBaseRent(space, true, 1)
-	rent2 := calculateBaseRent(space, true, 2)
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500},
+	}
 
-	// Mediterranean Ave rent: base $2 (monopoly $4), 1 house $10, 2 houses $30
-	if rent0 != 4 {
-		t.Errorf("Expected monopoly rent $4, got $%d", rent0)
+	if _, err := engine.CreateSpectatorToken(1, 200); err == nil || err.(*errors.AppError).Code != errors.ErrCodeNotPlayer {
+		t.Fatalf("Expected NOT_PLAYER for an outsider, got %v", err)
 	}
-	if rent1 != 10 {
-		t.Errorf("Expected 1-house rent $10, got $%d", rent1)
+
+	token, err := engine.CreateSpectatorToken(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
 	}
-	if rent2 != 30 {
-		t.Errorf("Expected 2-house rent $30, got $%d", rent2)
+	if gameID, err := engine.SpectatorGame(token); err != nil || gameID != 1 {
+		t.Fatalf("Expected the token to admit to game 1, got %d (%v)", gameID, err)
 	}
-}
 
-func TestCalculateRent_Railroad(t *testing.T) {
-	// Railroad rent depends on number owned: 1=$25, 2=$50, 3=$100, 4=$200
-	expectedRents := map[int]int{1: 25, 2: 50, 3: 100, 4: 200}
+	if err := engine.RevokeSpectatorToken(1, 100, token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := engine.SpectatorGame(token); err == nil || err.(*errors.AppError).Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for a revoked token, got %v", err)
+	}
 
-	for count, expected := range expectedRents {
-		rent := calculateRailroadRent(count)
-		if rent != expected {
-			t.Errorf("Railroad rent for %d owned: expected $%d, got $%d", count, expected, rent)
-		}
+	mockStore.Games[1].Status = StatusFinished
+	if _, err := engine.CreateSpectatorToken(1, 100); err == nil || err.(*errors.AppError).Code != errors.ErrCodeGameFinished {
+		t.Errorf("Expected GAME_FINISHED for a finished game, got %v", err)
 	}
 }
 
-func TestCalculateRent_Utility(t *testing.T) {
-	// Utility rent: 1 owned = 4x dice, 2 owned = 10x dice
-	diceTotal := 7
+func TestGetPropertyDetails_LiveRent(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
 
-	rent1 := calculateUtilityRent(diceTotal, 1)
-	rent2 := calculateUtilityRent(diceTotal, 2)
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 1, OwnerID: 101},                     // Mediterranean
+		{GameID: 1, Position: 3, OwnerID: 101},                     // Baltic, completes brown
+		{GameID: 1, Position: 5, OwnerID: 101},                     // Reading Railroad
+		{GameID: 1, Position: 15, OwnerID: 101},                    // Pennsylvania Railroad
+		{GameID: 1, Position: 12, OwnerID: 100},                    // Electric Company
+		{GameID: 1, Position: 39, OwnerID: 100, IsMortgaged: true}, // Boardwalk
+	}
+
+	cases := []struct {
+		position       int
+		rent           int
+		diceMultiplier int
+		monopoly       bool
+	}{
+		{1, 4, 0, true},   // doubled base rent with monopoly
+		{5, 50, 0, false}, // two railroads
+		{12, 0, 4, false}, // one utility: 4x the roll
+		{39, 0, 0, false}, // mortgaged
+		{6, 0, 0, false},  // unowned
+	}
+	for _, c := range cases {
+		details, err := engine.GetPropertyDetails(1, c.position)
+		if err != nil {
+			t.Fatalf("Unexpected error for position %d: %v", c.position, err)
+		}
+		if details.CurrentRent != c.rent || details.DiceMultiplier != c.diceMultiplier || details.HasMonopoly != c.monopoly {
+			t.Errorf("Position %d: expected rent %d, multiplier %d, monopoly %v, got %+v",
+				c.position, c.rent, c.diceMultiplier, c.monopoly, details)
+		}
+	}
 
-	if rent1 != 28 { // 7 * 4
-		t.Errorf("Utility rent (1 owned) for dice 7: expected $28, got $%d", rent1)
+	details, _ := engine.GetPropertyDetails(1, 1)
+	if details.OwnerID != 101 || details.OwnerUsername != "player2" {
+		t.Errorf("Expected player2 as owner, got %d %q", details.OwnerID, details.OwnerUsername)
 	}
-	if rent2 != 70 { // 7 * 10
-		t.Errorf("Utility rent (2 owned) for dice 7: expected $70, got $%d", rent2)
+
+	if _, err := engine.GetPropertyDetails(1, 40); err == nil {
+		t.Error("Expected an error for an off-board position")
 	}
 }
 
-// Helper functions for rent calculation tests
-// RentWithHouses array: index 0 = base rent, 1 = 1 house, ..., 5 = hotel
-func calculateBaseRent(space BoardSpace, hasMonopoly bool, houses int) int {
-	if space.Type != SpaceProperty {
-		return 0
+func TestTurnStarted_AllowedActions(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
 	}
 
-	// Use RentWithHouses array - index matches house count (0-5)
-	if houses > 0 && houses <= 5 {
-		return space.RentWithHouses[houses]
+	event, err := engine.TurnStarted(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	p := event.Payload.(TurnStartedPayload)
+	if p.UserID != 100 || !p.CanRoll || p.CanBuy || p.CanEndTurn {
+		t.Errorf("Expected fresh turn to allow only rolling, got %+v", p)
 	}
 
-	if hasMonopoly {
-		return space.Rent * 2
+	// Landed on an unowned property: must decide before ending the turn
+	mockStore.Players[1][0].HasRolled = true
+	mockStore.Players[1][0].Position = 39
+	mockStore.Players[1][0].PendingAction = "buy_or_pass"
+
+	event, _ = engine.TurnStarted(1)
+	p = event.Payload.(TurnStartedPayload)
+	if p.CanRoll || !p.CanBuy || p.CanEndTurn {
+		t.Errorf("Expected buy prompt to allow only buying, got %+v", p)
 	}
-	return space.Rent
 }
 
-func calculateRailroadRent(count int) int {
-	switch count {
-	case 1:
+func TestGiveUp_LastPlayerWins(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	events, err := engine.GiveUp(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if last := events[len(events)-1]; last.Type != "game_finished" {
+		t.Fatalf("Expected game_finished, got %s", last.Type)
+	}
+	if payload, ok := events[1].Payload.(MoneyTransferredPayload); !ok ||
+		payload != (MoneyTransferredPayload{FromUserID: 100, Amount: 1500, Reason: MoneyBankruptcy, Bank: true}) {
+		t.Errorf("Expected player1's cash to go back to the bank, got %+v", events[1].Payload)
+	}
+
+	g := mockStore.Games[1]
+	if g.Status != StatusFinished || g.WinnerID != 101 || g.EndReason != EndReasonLastPlayer {
+		t.Errorf("Expected player2 recorded as winner, got status=%s winner=%d reason=%q", g.Status, g.WinnerID, g.EndReason)
+	}
+
+	over, err := engine.GameOver(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	payload := over.Payload.(GameOverPayload)
+	if payload.WinnerUserID != 101 || len(payload.FinalStandings) != 2 {
+		t.Errorf("Unexpected game_over payload: %+v", payload)
+	}
+}
+
+func TestEliminatePlayerForTimeouts_ReturnsCashToTheBank(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 3}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 320, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+		{GameID: 1, UserID: 102, Username: "player3", PlayerOrder: 2, Money: 1500},
+	}
+
+	events, err := engine.EliminatePlayerForTimeouts(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[1].Type != "turn_changed" {
+		t.Fatalf("Expected the cash transfer and turn_changed, got %+v", events)
+	}
+	if payload, ok := events[0].Payload.(MoneyTransferredPayload); !ok ||
+		payload != (MoneyTransferredPayload{FromUserID: 100, Amount: 320, Reason: MoneyBankruptcy, Bank: true}) {
+		t.Errorf("Expected player1's cash to go back to the bank, got %+v", events[0].Payload)
+	}
+}
+
+func TestBankruptcy_EmitsOwnershipChangePerProperty(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 3}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 10, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+		{GameID: 1, UserID: 102, Username: "player3", PlayerOrder: 2, Money: 1500},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 1, OwnerID: 100},
+		{GameID: 1, Position: 3, OwnerID: 100},
+		{GameID: 1, Position: 6, OwnerID: 102},
+	}
+
+	tx, _ := mockStore.BeginTx()
+	events, err := engine.handleBankruptcyTx(tx, 1, 100, "player1", "rent", 101)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var changes []PropertyOwnershipChangedPayload
+	for _, event := range events {
+		if event.Type == "property_ownership_changed" {
+			changes = append(changes, event.Payload.(PropertyOwnershipChangedPayload))
+		}
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected one change per property of the bankrupt player, got %+v", changes)
+	}
+	for _, change := range changes {
+		if change.FromUserID != 100 || change.ToUserID != 101 || change.Reason != OwnershipBankruptcy {
+			t.Errorf("Expected a bankruptcy transfer from 100 to 101, got %+v", change)
+		}
+	}
+
+	// Giving up returns the properties to the bank
+	events, err = engine.GiveUp(1, 101)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	foreclosed := 0
+	for _, event := range events {
+		if change, ok := event.Payload.(PropertyOwnershipChangedPayload); ok {
+			foreclosed++
+			if change.FromUserID != 101 || change.ToUserID != 0 || change.Reason != OwnershipForeclosure {
+				t.Errorf("Expected a foreclosure from 101 to the bank, got %+v", change)
+			}
+		}
+	}
+	if foreclosed != 2 {
+		t.Errorf("Expected both properties foreclosed, got %d changes", foreclosed)
+	}
+}
+
+func TestEndTurn_TurnLimitRichestWins(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+		TurnLimit:  1,
+		Round:      1,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true, HasRolled: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1200},
+	}
+	// player2 has less cash but owns Boardwalk
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 39, OwnerID: 101},
+	}
+
+	event, err := engine.EndTurn(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Type != "turn_changed" {
+		t.Fatalf("Expected turn_changed mid-round, got %s", event.Type)
+	}
+
+	mockStore.Players[1][1].HasRolled = true
+	event, err = engine.EndTurn(1, 101)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Type != "game_finished" {
+		t.Fatalf("Expected game_finished after the last round, got %s", event.Type)
+	}
+
+	g := mockStore.Games[1]
+	if g.WinnerID != 101 || g.EndReason != EndReasonTurnLimit {
+		t.Errorf("Expected player2 to win on turn limit, got winner=%d reason=%q", g.WinnerID, g.EndReason)
+	}
+}
+
+func TestEndTurn_TimeLimit(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{
+		ID:               1,
+		Status:           StatusInProgress,
+		MaxPlayers:       2,
+		TimeLimitMinutes: 30,
+		Round:            1,
+		StartedAt:        time.Now().Add(-time.Hour).Unix(),
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true, HasRolled: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1000},
+	}
+
+	event, err := engine.EndTurn(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Type != "game_finished" {
+		t.Fatalf("Expected game_finished, got %s", event.Type)
+	}
+	if g := mockStore.Games[1]; g.WinnerID != 100 || g.EndReason != EndReasonTimeLimit {
+		t.Errorf("Expected player1 to win on time limit, got winner=%d reason=%q", g.WinnerID, g.EndReason)
+	}
+}
+
+func TestForceFinish_EndsGameWithoutWinner(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+
+	event, err := engine.ForceFinish(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Type != "game_force_finished" {
+		t.Errorf("Expected game_force_finished, got %s", event.Type)
+	}
+	g := mockStore.Games[1]
+	if g.Status != StatusFinished || g.WinnerID != 0 || g.EndReason != EndReasonForced {
+		t.Errorf("Expected finished with no winner, got %+v", g)
+	}
+
+	if _, err := engine.ForceFinish(1); err == nil {
+		t.Error("Expected error force-finishing an already finished game")
+	}
+	if _, err := engine.ForceFinish(99); err == nil {
+		t.Error("Expected error for unknown game")
+	}
+}
+
+func TestEventLog_RecordAndFetchSince(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+
+	for _, eventType := range []string{"dice_rolled", "timer_started", "property_bought", "turn_changed"} {
+		if _, err := engine.RecordEvent(1, eventType, []byte(`{}`)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	all, err := engine.GetEvents(1, 0, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 logged events (timer_started skipped), got %d", len(all))
+	}
+
+	since, _ := engine.GetEvents(1, 1, 0)
+	if len(since) != 2 || since[0].Seq != 2 || since[0].Type != "property_bought" {
+		t.Errorf("Expected events after seq 1 starting with property_bought, got %+v", since)
+	}
+
+	if _, err := engine.GetEvents(99, 0, 0); err == nil {
+		t.Error("Expected error for unknown game")
+	}
+}
+
+func TestGetGameSnapshot_IncludesLastRoll(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1340, Position: 7, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	snapshot, err := engine.GetGameSnapshot(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot.LastRoll != nil || snapshot.LastEventSeq != 0 {
+		t.Errorf("Expected no roll before any events, got %+v at seq %d", snapshot.LastRoll, snapshot.LastEventSeq)
+	}
+
+	engine.RecordEvent(1, "dice_rolled", []byte(`{"userId":100,"die1":3,"die2":4,"total":7,"newPos":7}`))
+	engine.RecordEvent(1, "property_bought", []byte(`{}`))
+
+	snapshot, err = engine.GetGameSnapshot(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot.CurrentPlayerID != 100 || snapshot.Players[0].Money != 1340 || snapshot.Players[0].Position != 7 {
+		t.Errorf("Expected the live state in the snapshot, got %+v", snapshot.GameState)
+	}
+	if snapshot.LastRoll == nil || snapshot.LastRoll.Die1 != 3 || snapshot.LastRoll.Die2 != 4 || snapshot.LastRoll.UserID != 100 {
+		t.Errorf("Expected the last roll 3+4 by player 100, got %+v", snapshot.LastRoll)
+	}
+	if snapshot.LastEventSeq != 2 {
+		t.Errorf("Expected last event seq 2, got %d", snapshot.LastEventSeq)
+	}
+
+	if _, err := engine.GetGameSnapshot(99); err == nil {
+		t.Error("Expected error for unknown game")
+	}
+}
+
+func TestStartGameIfReady_WaitsForEveryone(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusWaiting, MaxPlayers: 4}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	if _, err := engine.SetReady(1, 100, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event, err := engine.StartGameIfReady(1); err != nil || event != nil {
+		t.Fatalf("Expected no start with one player ready, got %v, %v", event, err)
+	}
+	if mockStore.Games[1].Status != StatusWaiting {
+		t.Fatalf("Expected game to stay waiting, got %s", mockStore.Games[1].Status)
+	}
+
+	if _, err := engine.SetReady(1, 101, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ready, err := engine.AllPlayersReady(1)
+	if err != nil || !ready {
+		t.Fatalf("Expected all players ready, got %v, %v", ready, err)
+	}
+
+	event, err := engine.StartGameIfReady(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event == nil || event.Type != "game_started" {
+		t.Fatalf("Expected game_started, got %+v", event)
+	}
+	if mockStore.Games[1].Status != StatusInProgress {
+		t.Errorf("Expected game in progress, got %s", mockStore.Games[1].Status)
+	}
+}
+
+func TestPlayersNeeded_LonePlayer(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusWaiting, MaxPlayers: 4}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500},
+	}
+
+	if _, err := engine.SetReady(1, 100, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	needed, err := engine.PlayersNeeded(1)
+	if err != nil || needed != 1 {
+		t.Fatalf("Expected one more player needed, got %d, %v", needed, err)
+	}
+	if event, err := engine.StartGameIfReady(1); err != nil || event != nil {
+		t.Fatalf("Expected no start with a lone player, got %v, %v", event, err)
+	}
+
+	mockStore.Players[1] = append(mockStore.Players[1],
+		&store.GamePlayer{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500})
+	if needed, err := engine.PlayersNeeded(1); err != nil || needed != 0 {
+		t.Errorf("Expected no more players needed, got %d, %v", needed, err)
+	}
+}
+
+func TestStartGameIfReady_WaitsForMinPlayers(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusWaiting, MinPlayers: 3, MaxPlayers: 4}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsReady: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500, IsReady: true},
+	}
+
+	if ready, err := engine.AllPlayersReady(1); err != nil || ready {
+		t.Fatalf("Expected game not ready below minPlayers, got %v, %v", ready, err)
+	}
+	if needed, err := engine.PlayersNeeded(1); err != nil || needed != 1 {
+		t.Fatalf("Expected one more player needed, got %d, %v", needed, err)
+	}
+
+	mockStore.Players[1] = append(mockStore.Players[1],
+		&store.GamePlayer{GameID: 1, UserID: 102, Username: "player3", PlayerOrder: 2, Money: 1500, IsReady: true})
+	event, err := engine.StartGameIfReady(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event == nil || event.Type != "game_started" {
+		t.Errorf("Expected game_started once minPlayers joined, got %+v", event)
+	}
+}
+
+func TestStartGame_HostStartsWithoutEveryoneReady(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusWaiting, MinPlayers: 3, MaxPlayers: 3, ManualStart: true}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "host", PlayerOrder: 0, Money: 1500, IsReady: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500, IsReady: true},
+	}
+
+	if _, err := engine.StartGame(1, 100); errors.From(err).Code != errors.ErrCodeNotEnoughPlayers {
+		t.Fatalf("Expected NOT_ENOUGH_PLAYERS below minPlayers, got %v", err)
+	}
+
+	mockStore.Players[1] = append(mockStore.Players[1],
+		&store.GamePlayer{GameID: 1, UserID: 102, Username: "player3", PlayerOrder: 2, Money: 1500, IsReady: true})
+
+	// Everyone ready and every seat taken still doesn't start a manual game
+	if ready, err := engine.AllPlayersReady(1); err != nil || ready {
+		t.Fatalf("Expected a manual game never to count as ready, got %v, %v", ready, err)
+	}
+	if event, err := engine.StartGameIfFull(1); err != nil || event != nil {
+		t.Fatalf("Expected a full manual game not to start, got %+v, %v", event, err)
+	}
+
+	if _, err := engine.StartGame(1, 101); errors.From(err).Code != errors.ErrCodeForbidden {
+		t.Fatalf("Expected FORBIDDEN for a non-host, got %v", err)
+	}
+	if _, err := engine.StartGame(1, 999); errors.From(err).Code != errors.ErrCodeNotInGame {
+		t.Fatalf("Expected NOT_IN_GAME for an outsider, got %v", err)
+	}
+
+	event, err := engine.StartGame(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event == nil || event.Type != "game_started" || mockStore.Games[1].Status != StatusInProgress {
+		t.Fatalf("Expected the host to start the game, got %+v with status %s", event, mockStore.Games[1].Status)
+	}
+
+	if _, err := engine.StartGame(1, 100); errors.From(err).Code != errors.ErrCodeGameStarted {
+		t.Errorf("Expected GAME_STARTED on a second start, got %v", err)
+	}
+}
+
+func TestUpdateGameSettings_HostChangesWaitingGame(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := NewEngine(store.NewGameStore(db))
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	carol, _ := auth.CreateUser("carol", "hash")
+	created, err := lobby.CreateGame(4, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+
+	intp := func(n int) *int { return &n }
+	manual := true
+
+	if _, err := engine.UpdateGameSettings(gameID, bob, GameSettings{MaxPlayers: intp(3)}); errors.From(err).Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for a non-host, got %v", err)
+	}
+	if _, err := engine.UpdateGameSettings(gameID, alice, GameSettings{MaxPlayers: intp(4)}); errors.From(err).Code != errors.ErrCodeBadRequest {
+		t.Errorf("Expected BAD_REQUEST when nothing changes, got %v", err)
+	}
+	if _, err := engine.UpdateGameSettings(gameID, alice, GameSettings{MaxPlayers: intp(1)}); errors.From(err).Code != errors.ErrCodeBadRequest {
+		t.Errorf("Expected BAD_REQUEST below the joined players, got %v", err)
+	}
+	if _, err := engine.UpdateGameSettings(gameID, alice, GameSettings{StartingMoney: intp(MaxStartingMoney + 1)}); errors.From(err).Code != errors.ErrCodeBadRequest {
+		t.Errorf("Expected BAD_REQUEST for too much starting money, got %v", err)
+	}
+
+	event, err := engine.UpdateGameSettings(gameID, alice, GameSettings{
+		MaxPlayers: intp(3), StartingMoney: intp(2000), TurnLimit: intp(20), ManualStart: &manual,
+	})
+	if err != nil {
+		t.Fatalf("UpdateGameSettings failed: %v", err)
+	}
+	payload, ok := event.Payload.(SettingsUpdatedPayload)
+	if event.Type != "settings_updated" || !ok || payload.MaxPlayers != 3 || payload.MinPlayers != 2 {
+		t.Fatalf("Expected settings_updated with the new and unchanged settings, got %+v", event)
+	}
+
+	// Seated players and later joiners both get the new starting money
+	if err := lobby.JoinGame(gameID, carol, "carol"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	state, err := engine.GetGameState(gameID)
+	if err != nil {
+		t.Fatalf("GetGameState failed: %v", err)
+	}
+	if state.MaxPlayers != 3 || state.StartingMoney != 2000 || state.TurnLimit != 20 || !state.ManualStart {
+		t.Errorf("Expected the settings to be stored, got %+v", state)
+	}
+	for _, p := range state.Players {
+		if p.Money != 2000 {
+			t.Errorf("Expected %s to hold the new starting money, got %d", p.Username, p.Money)
+		}
+	}
+
+	if _, err := engine.StartGame(gameID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	if _, err := engine.UpdateGameSettings(gameID, alice, GameSettings{MaxPlayers: intp(4)}); errors.From(err).Code != errors.ErrCodeGameStarted {
+		t.Errorf("Expected GAME_STARTED once the game began, got %v", err)
+	}
+}
+
+func TestSeededRandomness_ReproducesDiceAndShuffles(t *testing.T) {
+	draw := func() ([]int, []int) {
+		engine := NewEngine(NewMockGameStore())
+		engine.SetSeededRandomness(true)
+		rng := engine.gameRand(1, 42)
+		deck := ShuffleDeck(len(ChanceCards), rng)
+		rolls := make([]int, 10)
+		for i := range rolls {
+			rolls[i] = engine.rollDie(rng)
+		}
+		return deck, rolls
+	}
+
+	deck1, rolls1 := draw()
+	deck2, rolls2 := draw()
+	if !reflect.DeepEqual(deck1, deck2) || !reflect.DeepEqual(rolls1, rolls2) {
+		t.Errorf("Expected identical draws for the same seed, got %v/%v and %v/%v", deck1, rolls1, deck2, rolls2)
+	}
+
+	engine := NewEngine(NewMockGameStore())
+	if rng := engine.gameRand(1, 42); rng != nil {
+		t.Error("Expected no seeded generator when seeded randomness is off")
+	}
+}
+
+func TestStartCountdown_CancelPreventsStart(t *testing.T) {
+	sc := NewStartCountdown()
+	fired := make(chan struct{}, 1)
+
+	if !sc.Start(1, 20*time.Millisecond, func() { fired <- struct{}{} }) {
+		t.Fatal("Expected countdown to start")
+	}
+	if sc.Start(1, 20*time.Millisecond, func() { fired <- struct{}{} }) {
+		t.Error("Expected second start for the same game to be ignored")
+	}
+	if !sc.Cancel(1) {
+		t.Fatal("Expected cancel to stop a running countdown")
+	}
+
+	select {
+	case <-fired:
+		t.Fatal("Cancelled countdown fired")
+	case <-time.After(60 * time.Millisecond):
+	}
+
+	if !sc.Start(1, 10*time.Millisecond, func() { fired <- struct{}{} }) {
+		t.Fatal("Expected countdown to restart after cancel")
+	}
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("Restarted countdown never fired")
+	}
+	if sc.Running(1) {
+		t.Error("Expected no countdown running after it fired")
+	}
+}
+
+func TestRollDice_NotYourTurn(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	// Set up in-progress game
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 4,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	// Player 2 tries to roll when it's player 1's turn
+	_, err := engine.RollDice(1, 101)
+	if err == nil {
+		t.Error("Expected error when rolling dice out of turn")
+	}
+}
+
+func TestRollDice_AlreadyRolled(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	// Set up in-progress game with player who already rolled
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 4,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true, HasRolled: true},
+	}
+
+	_, err := engine.RollDice(1, 100)
+	if err == nil {
+		t.Error("Expected error when rolling dice twice")
+	}
+}
+
+func TestRollDice_GameNotStarted(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	// Set up waiting game
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusWaiting,
+		MaxPlayers: 4,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+	}
+
+	_, err := engine.RollDice(1, 100)
+	if err == nil {
+		t.Error("Expected error when rolling dice before game starts")
+	}
+}
+
+func TestRollDice_BankruptPlayer(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	// Set up in-progress game with bankrupt player
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 4,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 0, IsCurrentTurn: true, IsBankrupt: true},
+	}
+
+	_, err := engine.RollDice(1, 100)
+	if err == nil {
+		t.Error("Expected error when bankrupt player tries to roll")
+	}
+}
+
+func TestRollDice_PendingAction(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	// Set up in-progress game with pending action
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 4,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true, PendingAction: "buy_property"},
+	}
+
+	_, err := engine.RollDice(1, 100)
+	if err == nil {
+		t.Error("Expected error when player has pending action")
+	}
+}
+
+func TestCalculateRent_BaseRent(t *testing.T) {
+	// Test base rent calculation for Mediterranean Ave (position 1)
+	space := Board[1]
+	rent := calculateBaseRent(space, false, 0)
+
+	if rent != 2 { // Mediterranean Ave base rent is $2
+		t.Errorf("Expected base rent $2, got $%d", rent)
+	}
+}
+
+func TestCalculateRent_MonopolyBonus(t *testing.T) {
+	// Test that monopoly doubles base rent
+	space := Board[1]
+
+	baseRent := calculateBaseRent(space, false, 0)
+	monopolyRent := calculateBaseRent(space, true, 0)
+
+	if monopolyRent != baseRent*2 {
+		t.Errorf("Expected monopoly rent $%d (2x base), got $%d", baseRent*2, monopolyRent)
+	}
+}
+
+func TestCalculateRent_WithHouses(t *testing.T) {
+	// Test rent with houses for Mediterranean Ave
+	space := Board[1]
+
+	rent0 := calculateBaseRent(space, true, 0)
+	rent1 := calculateBaseRent(space, true, 1)
+	rent2 := calculateBaseRent(space, true, 2)
+
+	// Mediterranean Ave rent: base $2 (monopoly $4), 1 house $10, 2 houses $30
+	if rent0 != 4 {
+		t.Errorf("Expected monopoly rent $4, got $%d", rent0)
+	}
+	if rent1 != 10 {
+		t.Errorf("Expected 1-house rent $10, got $%d", rent1)
+	}
+	if rent2 != 30 {
+		t.Errorf("Expected 2-house rent $30, got $%d", rent2)
+	}
+}
+
+func TestCalculateRent_Railroad(t *testing.T) {
+	// Railroad rent depends on number owned: 1=$25, 2=$50, 3=$100, 4=$200
+	expectedRents := map[int]int{1: 25, 2: 50, 3: 100, 4: 200}
+
+	for count, expected := range expectedRents {
+		rent := calculateRailroadRent(count)
+		if rent != expected {
+			t.Errorf("Railroad rent for %d owned: expected $%d, got $%d", count, expected, rent)
+		}
+	}
+}
+
+func TestCalculateRent_Utility(t *testing.T) {
+	// Utility rent: 1 owned = 4x dice, 2 owned = 10x dice
+	diceTotal := 7
+
+	rent1 := calculateUtilityRent(diceTotal, 1)
+	rent2 := calculateUtilityRent(diceTotal, 2)
+
+	if rent1 != 28 { // 7 * 4
+		t.Errorf("Utility rent (1 owned) for dice 7: expected $28, got $%d", rent1)
+	}
+	if rent2 != 70 { // 7 * 10
+		t.Errorf("Utility rent (2 owned) for dice 7: expected $70, got $%d", rent2)
+	}
+}
+
+// Helper functions for rent calculation tests
+// RentWithHouses array: index 0 = base rent, 1 = 1 house, ..., 5 = hotel
+func calculateBaseRent(space BoardSpace, hasMonopoly bool, houses int) int {
+	if space.Type != SpaceProperty {
+		return 0
+	}
+
+	// Use RentWithHouses array - index matches house count (0-5)
+	if houses > 0 && houses <= 5 {
+		return space.RentWithHouses[houses]
+	}
+
+	if hasMonopoly {
+		return space.Rent * 2
+	}
+	return space.Rent
+}
+
+func calculateRailroadRent(count int) int {
+	switch count {
+	case 1:
 		return 25
 	case 2:
 		return 50
@@ -743,128 +1838,1549 @@ func calculateRailroadRent(count int) int {
 	}
 }
 
-func calculateUtilityRent(diceTotal, count int) int {
-	if count == 2 {
-		return diceTotal * 10
+func calculateUtilityRent(diceTotal, count int) int {
+	if count == 2 {
+		return diceTotal * 10
+	}
+	return diceTotal * 4
+}
+
+func TestBoardSetup(t *testing.T) {
+	// Verify board has 40 spaces
+	if len(Board) != 40 {
+		t.Errorf("Expected 40 board spaces, got %d", len(Board))
+	}
+
+	// Verify GO is at position 0
+	if Board[0].Type != SpaceGo {
+		t.Errorf("Expected GO at position 0, got %s", Board[0].Type)
+	}
+
+	// Verify Jail is at position 10
+	if Board[10].Type != SpaceJail {
+		t.Errorf("Expected Jail at position 10, got %s", Board[10].Type)
+	}
+
+	// Verify Free Parking is at position 20
+	if Board[20].Type != SpaceFreeParking {
+		t.Errorf("Expected Free Parking at position 20, got %s", Board[20].Type)
+	}
+
+	// Verify Go To Jail is at position 30
+	if Board[30].Type != SpaceGoToJail {
+		t.Errorf("Expected Go To Jail at position 30, got %s", Board[30].Type)
+	}
+}
+
+func TestPropertyGroups(t *testing.T) {
+	// Count properties in each group
+	groupCounts := make(map[ColorGroup]int)
+
+	for _, space := range Board {
+		if space.Type == SpaceProperty {
+			groupCounts[space.Color]++
+		}
+	}
+
+	// Verify property group sizes
+	expectedGroupSizes := map[ColorGroup]int{
+		ColorBrown:     2,
+		ColorLightBlue: 3,
+		ColorPink:      3,
+		ColorOrange:    3,
+		ColorRed:       3,
+		ColorYellow:    3,
+		ColorGreen:     3,
+		ColorDarkBlue:  2,
+	}
+
+	for group, expected := range expectedGroupSizes {
+		if groupCounts[group] != expected {
+			t.Errorf("Expected %d %s properties, got %d", expected, group, groupCounts[group])
+		}
+	}
+}
+
+func TestRailroads(t *testing.T) {
+	// Count railroads
+	railroadCount := 0
+	railroadPositions := []int{5, 15, 25, 35}
+
+	for _, pos := range railroadPositions {
+		if Board[pos].Type != SpaceRailroad {
+			t.Errorf("Expected railroad at position %d, got %s", pos, Board[pos].Type)
+		}
+		railroadCount++
+	}
+
+	if railroadCount != 4 {
+		t.Errorf("Expected 4 railroads, got %d", railroadCount)
+	}
+}
+
+func TestUtilities(t *testing.T) {
+	// Verify utilities at positions 12 and 28
+	if Board[12].Type != SpaceUtility {
+		t.Errorf("Expected utility at position 12, got %s", Board[12].Type)
+	}
+	if Board[28].Type != SpaceUtility {
+		t.Errorf("Expected utility at position 28, got %s", Board[28].Type)
+	}
+}
+
+func TestChanceAndCommunityChest(t *testing.T) {
+	// Verify Chance positions
+	chancePositions := []int{7, 22, 36}
+	for _, pos := range chancePositions {
+		if Board[pos].Type != SpaceChance {
+			t.Errorf("Expected Chance at position %d, got %s", pos, Board[pos].Type)
+		}
+	}
+
+	// Verify Community Chest positions
+	ccPositions := []int{2, 17, 33}
+	for _, pos := range ccPositions {
+		if Board[pos].Type != SpaceCommunityChest {
+			t.Errorf("Expected Community Chest at position %d, got %s", pos, Board[pos].Type)
+		}
+	}
+}
+
+func TestTaxSpaces(t *testing.T) {
+	// Income Tax at position 4
+	if Board[4].Type != SpaceTax {
+		t.Errorf("Expected Tax at position 4, got %s", Board[4].Type)
+	}
+	if Board[4].TaxAmount != 200 {
+		t.Errorf("Expected Income Tax $200, got $%d", Board[4].TaxAmount)
+	}
+
+	// Luxury Tax at position 38
+	if Board[38].Type != SpaceTax {
+		t.Errorf("Expected Tax at position 38, got %s", Board[38].Type)
+	}
+	if Board[38].TaxAmount != 100 {
+		t.Errorf("Expected Luxury Tax $100, got $%d", Board[38].TaxAmount)
+	}
+}
+
+func TestPayIncomeTax_PercentCountsMortgagedProperties(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	gameStore := store.NewGameStore(db)
+	// 1 + 3 lands on Income Tax
+	engine := NewEngineWithRand(gameStore, &fixedDice{rolls: []int{1, 3}})
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(2, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	if _, err := engine.StartGame(gameID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+
+	// Mediterranean Avenue ($60) with two $50 houses and Baltic Avenue ($60),
+	// mortgaged: the tax counts 1500 + 60 + 100 + 60, the mortgage included
+	tx, _ := gameStore.BeginTx()
+	gameStore.InsertPropertyTx(tx, gameID, 1, alice)
+	gameStore.SetImprovementsTx(tx, gameID, 1, 2)
+	gameStore.InsertPropertyTx(tx, gameID, 3, alice)
+	gameStore.SetPropertyMortgagedTx(tx, gameID, 3, true)
+	if err := gameStore.CommitTx(tx); err != nil {
+		t.Fatalf("CommitTx failed: %v", err)
+	}
+
+	if _, err := engine.PayIncomeTax(gameID, alice, TaxChoiceFlat); errors.From(err).Code != errors.ErrCodeNoTaxDue {
+		t.Fatalf("Expected NO_TAX_DUE before landing on Income Tax, got %v", err)
+	}
+
+	events, err := engine.RollDice(gameID, alice)
+	if err != nil {
+		t.Fatalf("RollDice failed: %v", err)
+	}
+	if last := events[len(events)-1]; last.Type != "tax_prompt" {
+		t.Fatalf("Expected the roll to end on tax_prompt, got %s", last.Type)
+	}
+	state, _ := engine.GetGameState(gameID)
+	if state.TurnPhase != TurnPhaseAwaitingTaxChoice || state.Players[0].Money != 1500 {
+		t.Fatalf("Expected the tax to wait for a choice, got phase %q and $%d", state.TurnPhase, state.Players[0].Money)
+	}
+
+	if _, err := engine.EndTurn(gameID, alice); errors.From(err).Code != errors.ErrCodePendingAction {
+		t.Errorf("Expected PENDING_ACTION ending the turn before paying, got %v", err)
+	}
+	if _, err := engine.PayIncomeTax(gameID, alice, "nothing"); errors.From(err).Code != errors.ErrCodeInvalidTaxChoice {
+		t.Errorf("Expected INVALID_TAX_CHOICE, got %v", err)
+	}
+	if _, err := engine.PayIncomeTax(gameID, bob, TaxChoiceFlat); errors.From(err).Code != errors.ErrCodeNotYourTurn {
+		t.Errorf("Expected NOT_YOUR_TURN for another player, got %v", err)
+	}
+
+	events, err = engine.PayIncomeTax(gameID, alice, TaxChoicePercent)
+	if err != nil {
+		t.Fatalf("PayIncomeTax failed: %v", err)
+	}
+	paid, ok := events[0].Payload.(TaxPaidPayload)
+	if !ok || paid.Amount != 172 || paid.NewMoney != 1328 || paid.Choice != TaxChoicePercent {
+		t.Errorf("Expected 10%% of 1720 to be $172, got %+v", events[0].Payload)
+	}
+	if len(events) != 3 || events[1].Type != "money_transferred" || events[2].Type != "turn_changed" {
+		t.Errorf("Expected the payment to the bank and the end of the turn, got %d events", len(events))
+	}
+}
+
+// fixedDice replays a fixed sequence of die values
+type fixedDice struct {
+	rolls []int
+	next  int
+}
+
+func (d *fixedDice) Roll() int {
+	v := d.rolls[d.next%len(d.rolls)]
+	d.next++
+	return v
+}
+
+func TestRollDice_DeterministicMovement(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngineWithRand(mockStore, &fixedDice{rolls: []int{2, 3}})
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	events, err := engine.RollDice(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	payload, ok := events[0].Payload.(DiceRolledPayload)
+	if !ok {
+		t.Fatalf("Expected dice_rolled payload, got %T", events[0].Payload)
+	}
+	if payload.Die1 != 2 || payload.Die2 != 3 || payload.NewPos != 5 {
+		t.Errorf("Expected 2+3 landing on 5, got %d+%d landing on %d", payload.Die1, payload.Die2, payload.NewPos)
+	}
+	if mockStore.Players[1][0].Position != 5 {
+		t.Errorf("Expected stored position 5, got %d", mockStore.Players[1][0].Position)
+	}
+}
+
+func TestRollDice_ThreeDoublesGoesToJail(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngineWithRand(mockStore, &fixedDice{rolls: []int{5, 5}})
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	// 0 -> 10 (just visiting) -> 20 (free parking) -> third doubles
+	var events []*Event
+	for i := 0; i < 3; i++ {
+		var err error
+		events, err = engine.RollDice(1, 100)
+		if err != nil {
+			t.Fatalf("Roll %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	last := events[len(events)-1]
+	if last.Type != "go_to_jail" {
+		t.Fatalf("Expected go_to_jail after three doubles, got %s", last.Type)
+	}
+	player := mockStore.Players[1][0]
+	if !player.InJail || player.Position != 10 {
+		t.Errorf("Expected player in jail at 10, got inJail=%v position=%d", player.InJail, player.Position)
+	}
+}
+
+func TestSkipTurn_PassesInJailBeforeRolling(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true,
+			InJail: true, JailTurns: 1},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	if _, err := engine.SkipTurn(1, 101); errors.From(err).Code != errors.ErrCodeNotYourTurn {
+		t.Fatalf("Expected NOT_YOUR_TURN for the other player, got %v", err)
+	}
+
+	// No jail roll needed: the player sits the turn out
+	events, err := engine.SkipTurn(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != "turn_changed" {
+		t.Fatalf("Expected only turn_changed, got %d events", len(events))
+	}
+	payload := events[0].Payload.(TurnChangedPayload)
+	if payload.CurrentPlayerID != 101 || payload.Reason != TurnEndSkipped {
+		t.Errorf("Expected the turn to pass to 101 as skipped, got %+v", payload)
+	}
+	player := mockStore.Players[1][0]
+	if !player.InJail || player.Money != 1500 {
+		t.Errorf("Expected skipping to keep the player in jail without paying, got inJail=%v money=%d", player.InJail, player.Money)
+	}
+}
+
+func TestSkipTurn_BeforeRollingClearsDoubles(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+	// The extra roll owed after doubles is given up too
+	engine.setDoubles(1, 1)
+
+	events, err := engine.SkipTurn(1, 100)
+	if err != nil {
+		t.Fatalf("Expected a skip before rolling, got %v", err)
+	}
+	if payload := events[len(events)-1].Payload.(TurnChangedPayload); payload.CurrentPlayerID != 101 {
+		t.Errorf("Expected the turn to pass to 101, got %+v", payload)
+	}
+	if n := engine.doubles(1); n != 0 {
+		t.Errorf("Expected the doubles count cleared, got %d", n)
+	}
+}
+
+func TestSkipTurn_DeclinesAPendingPurchase(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true,
+			Position: 1, HasRolled: true, PendingAction: "buy_or_pass"},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	events, err := engine.SkipTurn(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != "turn_changed" {
+		t.Fatalf("Expected only turn_changed, got %d events", len(events))
+	}
+	player := mockStore.Players[1][0]
+	if player.PendingAction != "" || player.Money != 1500 {
+		t.Errorf("Expected the purchase declined, got pending %q and money %d", player.PendingAction, player.Money)
+	}
+	if engine.GetActiveAuction(1) != nil {
+		t.Error("Expected a declined purchase not to start an auction")
+	}
+	if len(mockStore.Properties[1]) != 0 {
+		t.Error("Expected Mediterranean Avenue to stay with the bank")
+	}
+}
+
+func TestEndTurn_MustRollAgainAfterDoubles(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngineWithRand(mockStore, &fixedDice{rolls: []int{5, 5}})
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	if _, err := engine.EndTurn(1, 100); err == nil || err.(*errors.AppError).Code != errors.ErrCodeMustRoll {
+		t.Fatalf("Expected MUST_ROLL before the first roll, got %v", err)
+	}
+
+	// Doubles earn another roll, so ending the turn is refused in between,
+	// and the refusal must not reset the doubles count
+	for i := 0; i < 2; i++ {
+		if _, err := engine.RollDice(1, 100); err != nil {
+			t.Fatalf("Roll %d: unexpected error: %v", i+1, err)
+		}
+		if _, err := engine.EndTurn(1, 100); err == nil || err.(*errors.AppError).Code != errors.ErrCodeMustRoll {
+			t.Fatalf("Roll %d: expected MUST_ROLL after doubles, got %v", i+1, err)
+		}
+	}
+
+	events, err := engine.RollDice(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if last := events[len(events)-1]; last.Type != "go_to_jail" {
+		t.Fatalf("Expected the third doubles to send the player to jail, got %s", last.Type)
+	}
+
+	// Rolling into jail used up the roll, so the turn can end
+	if _, err := engine.EndTurn(1, 100); err != nil {
+		t.Errorf("Expected the jailed player to end their turn, got %v", err)
+	}
+}
+
+func TestRollDice_DoublesEscapeJail(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngineWithRand(mockStore, &fixedDice{rolls: []int{5, 5}})
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, Position: 10, InJail: true, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	events, err := engine.RollDice(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if events[0].Type != "jail_escape" {
+		t.Fatalf("Expected jail_escape, got %s", events[0].Type)
+	}
+	player := mockStore.Players[1][0]
+	if player.InJail || player.Position != 20 {
+		t.Errorf("Expected player out of jail at 20, got inJail=%v position=%d", player.InJail, player.Position)
+	}
+}
+
+func TestPayJailBail_FreesPlayerToRollNormally(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngineWithRand(mockStore, &fixedDice{rolls: []int{2, 3}})
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, Position: 10,
+			InJail: true, JailTurns: 1, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: JailBail - 1, Position: 10, InJail: true},
+	}
+
+	if _, err := engine.PayJailBail(1, 101); errors.From(err).Code != errors.ErrCodeNotYourTurn {
+		t.Fatalf("Expected NOT_YOUR_TURN for the other player, got %v", err)
+	}
+
+	events, err := engine.PayJailBail(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	payload, ok := events[0].Payload.(JailEscapePayload)
+	if !ok || payload.Method != "bail" || payload.NewMoney != 1500-JailBail {
+		t.Fatalf("Expected jail_escape by bail with the new balance, got %+v", events[0].Payload)
+	}
+	player := mockStore.Players[1][0]
+	if player.InJail || player.Money != 1500-JailBail {
+		t.Errorf("Expected player freed and charged, got inJail=%v money=%d", player.InJail, player.Money)
+	}
+
+	if _, err := engine.PayJailBail(1, 100); errors.From(err).Code != errors.ErrCodeNotInJail {
+		t.Errorf("Expected NOT_IN_JAIL once freed, got %v", err)
+	}
+
+	// A non-doubles roll now moves the player instead of failing a jail roll
+	if _, err := engine.RollDice(1, 100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if player.Position != 15 {
+		t.Errorf("Expected player to move to 15, got %d", player.Position)
+	}
+
+	// The other player can't cover the fine on their own turn
+	player.IsCurrentTurn = false
+	mockStore.Players[1][1].IsCurrentTurn = true
+	if _, err := engine.PayJailBail(1, 101); errors.From(err).Code != errors.ErrCodeInsufficientFunds {
+		t.Errorf("Expected INSUFFICIENT_FUNDS, got %v", err)
+	}
+}
+
+func TestAcceptTrade_InvalidatesTradesForMovedProperties(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 3}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+		{GameID: 1, UserID: 102, Username: "player3", PlayerOrder: 2, Money: 1500},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 1, OwnerID: 100},
+		{GameID: 1, Position: 3, OwnerID: 100},
+	}
+
+	// Player 1 offers the same property to two players
+	if _, err := engine.ProposeTrade(1, 100, 101, TradeOffer{OfferedProperties: []int{1}, RequestedMoney: 100}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := engine.ProposeTrade(1, 100, 102, TradeOffer{OfferedProperties: []int{1}, RequestedMoney: 200}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := engine.ProposeTrade(1, 100, 102, TradeOffer{OfferedProperties: []int{3}, RequestedMoney: 50}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	events, err := engine.AcceptTrade(1, 101, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 3 || events[1].Type != "property_ownership_changed" || events[2].Type != "trade_cancelled" {
+		t.Fatalf("Expected trade_accepted, property_ownership_changed then trade_cancelled, got %d events", len(events))
+	}
+	if moved := events[1].Payload.(PropertyOwnershipChangedPayload); moved != (PropertyOwnershipChangedPayload{SpaceIndex: 1, FromUserID: 100, ToUserID: 101, Reason: OwnershipTrade}) {
+		t.Errorf("Unexpected ownership change: %+v", moved)
+	}
+	payload := events[2].Payload.(TradeResponsePayload)
+	if payload.TradeID != 2 || payload.Status != "invalidated" {
+		t.Errorf("Expected trade 2 invalidated, got %+v", payload)
+	}
+	if mockStore.Trades[2].Status != "cancelled" || mockStore.Trades[3].Status != "pending" {
+		t.Errorf("Expected only the conflicting trade cancelled, got %s and %s",
+			mockStore.Trades[2].Status, mockStore.Trades[3].Status)
+	}
+	if _, err := engine.AcceptTrade(1, 102, 2); err == nil {
+		t.Error("Expected the invalidated trade to be refused")
+	}
+}
+
+func TestAcceptTrade_RefusesWhenPropertyChangedHands(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 1, OwnerID: 100},
+	}
+
+	if _, err := engine.ProposeTrade(1, 100, 101, TradeOffer{OfferedProperties: []int{1}, RequestedMoney: 100}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mockStore.Properties[1][0].IsMortgaged = true
+
+	if _, err := engine.AcceptTrade(1, 101, 1); err == nil {
+		t.Fatal("Expected the trade to be refused once the property is mortgaged")
+	}
+	if mockStore.Trades[1].Status != "cancelled" {
+		t.Errorf("Expected the stale trade cancelled, got %s", mockStore.Trades[1].Status)
+	}
+	if mockStore.Players[1][0].Money != 1500 {
+		t.Errorf("Expected no money to move, got %d", mockStore.Players[1][0].Money)
+	}
+}
+
+func TestExpireTrade_OnlyPendingTrades(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := engine.ProposeTrade(1, 100, 101, TradeOffer{OfferedMoney: 10}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	event, err := engine.ExpireTrade(1, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	payload, ok := event.Payload.(TradeExpiredPayload)
+	if !ok || event.Type != "trade_expired" || payload.TradeID != 1 || payload.ToUsername != "player2" {
+		t.Fatalf("Expected trade_expired for trade 1, got %s %+v", event.Type, event.Payload)
+	}
+	if mockStore.Trades[1].Status != "expired" {
+		t.Errorf("Expected status expired, got %s", mockStore.Trades[1].Status)
+	}
+
+	// An answered trade is left alone
+	if _, err := engine.CancelTrade(1, 100, 2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event, err := engine.ExpireTrade(1, 2); err != nil || event != nil {
+		t.Errorf("Expected no event for a cancelled trade, got %v, %v", event, err)
+	}
+	if mockStore.Trades[2].Status != "cancelled" {
+		t.Errorf("Expected status to stay cancelled, got %s", mockStore.Trades[2].Status)
+	}
+}
+
+// concurrently runs action from n goroutines at once and counts the successes
+func concurrently(n int, action func() error) int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if action() == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return succeeded
+}
+
+func TestEngine_SerializesConcurrentActionsOnOneGame(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	// Never doubles, so each turn allows exactly one roll
+	engine := NewEngineWithRand(store.NewGameStore(db), &fixedDice{rolls: []int{1, 3}})
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(2, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	if _, err := engine.StartGame(gameID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+
+	const hammers = 8
+	for turn := 0; turn < 8; turn++ {
+		state, err := engine.GetGameState(gameID)
+		if err != nil {
+			t.Fatalf("GetGameState failed: %v", err)
+		}
+		current := state.CurrentPlayerID
+
+		if n := concurrently(hammers, func() error {
+			_, err := engine.RollDice(gameID, current)
+			return err
+		}); n != 1 {
+			t.Fatalf("Turn %d: expected exactly one roll to go through, got %d", turn, n)
+		}
+
+		state, _ = engine.GetGameState(gameID)
+		if state.TurnPhase == TurnPhaseAwaitingBuyDecision {
+			if n := concurrently(hammers, func() error {
+				_, err := engine.BuyProperty(gameID, current)
+				return err
+			}); n != 1 {
+				t.Fatalf("Turn %d: expected exactly one purchase, got %d", turn, n)
+			}
+			state, _ = engine.GetGameState(gameID)
+		}
+		if state.TurnPhase == TurnPhaseAwaitingTaxChoice {
+			if n := concurrently(hammers, func() error {
+				_, err := engine.PayIncomeTax(gameID, current, TaxChoiceFlat)
+				return err
+			}); n != 1 {
+				t.Fatalf("Turn %d: expected income tax to be paid once, got %d", turn, n)
+			}
+			state, _ = engine.GetGameState(gameID)
+		}
+
+		// A roll, purchase or tax payment that leaves nothing to do ends the
+		// turn by itself
+		if state.CurrentPlayerID != current {
+			continue
+		}
+		if n := concurrently(hammers, func() error {
+			_, err := engine.EndTurn(gameID, current)
+			return err
+		}); n != 1 {
+			t.Fatalf("Turn %d: expected exactly one end of turn, got %d", turn, n)
+		}
+
+		state, _ = engine.GetGameState(gameID)
+		if state.CurrentPlayerID == current {
+			t.Fatalf("Turn %d: expected the turn to pass on", turn)
+		}
+	}
+}
+
+func TestGameLocks_DifferentGamesDontWait(t *testing.T) {
+	var locks gameLocks
+	unlock := locks.lock(1)
+
+	done := make(chan struct{})
+	go func() {
+		locks.lock(2)()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected another game's lock not to wait")
+	}
+
+	waiting := make(chan struct{})
+	go func() {
+		locks.lock(1)()
+		close(waiting)
+	}()
+	select {
+	case <-waiting:
+		t.Fatal("Expected the same game's lock to wait")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-waiting
+
+	if len(locks.locks) != 0 {
+		t.Errorf("Expected released locks to be dropped, got %d", len(locks.locks))
+	}
+}
+
+func TestFinishAbandoned_RichestWinsOnceNoOneIsActive(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	gameStore := store.NewGameStore(db)
+	engine := NewEngine(gameStore)
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(2, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	if _, err := engine.StartGame(gameID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	tx, _ := gameStore.BeginTx()
+	gameStore.InsertPropertyTx(tx, gameID, 39, bob)
+	if err := gameStore.CommitTx(tx); err != nil {
+		t.Fatalf("CommitTx failed: %v", err)
+	}
+
+	// A cutoff before the start finds nothing to finish
+	if ids, err := engine.InactiveGames(time.Now().Add(-time.Hour)); err != nil || len(ids) != 0 {
+		t.Fatalf("Expected no inactive games yet, got %v, %v", ids, err)
+	}
+
+	cutoff := time.Now().Add(time.Hour)
+	ids, err := engine.InactiveGames(cutoff)
+	if err != nil || len(ids) != 1 || ids[0] != gameID {
+		t.Fatalf("Expected game %d to be inactive, got %v, %v", gameID, ids, err)
+	}
+
+	event, err := engine.FinishAbandoned(gameID, cutoff)
+	if err != nil {
+		t.Fatalf("FinishAbandoned failed: %v", err)
+	}
+	if event == nil || event.Type != "game_finished" {
+		t.Fatalf("Expected game_finished, got %+v", event)
+	}
+
+	over, err := engine.GameOver(gameID)
+	if err != nil {
+		t.Fatalf("GameOver failed: %v", err)
+	}
+	payload := over.Payload.(GameOverPayload)
+	if payload.WinnerUserID != bob || payload.Reason != EndReasonAbandoned {
+		t.Errorf("Expected bob, who owns Boardwalk, to win an abandoned game, got %+v", payload)
+	}
+	games, _, err := lobby.ListGames(alice, store.GameListFilter{}, 0, 0)
+	if err != nil || len(games) != 0 {
+		t.Errorf("Expected the finished game to leave the lobby list, got %v, %v", games, err)
+	}
+
+	// Already finished: nothing more to do
+	if event, err := engine.FinishAbandoned(gameID, cutoff); event != nil || err != nil {
+		t.Errorf("Expected a finished game to be left alone, got %+v, %v", event, err)
+	}
+}
+
+func TestActions_RejectWrongGameStatus(t *testing.T) {
+	turnActions := map[string]func(e *Engine) error{
+		"RollDice":           func(e *Engine) error { _, err := e.RollDice(1, 100); return err },
+		"UseJailFreeCard":    func(e *Engine) error { _, err := e.UseJailFreeCard(1, 100); return err },
+		"PayJailBail":        func(e *Engine) error { _, err := e.PayJailBail(1, 100); return err },
+		"PayIncomeTax":       func(e *Engine) error { _, err := e.PayIncomeTax(1, 100, "flat"); return err },
+		"BuyProperty":        func(e *Engine) error { _, err := e.BuyProperty(1, 100); return err },
+		"PassProperty":       func(e *Engine) error { _, err := e.PassProperty(1, 100); return err },
+		"PlaceBid":           func(e *Engine) error { _, err := e.PlaceBid(1, 100, 10); return err },
+		"PassAuction":        func(e *Engine) error { _, err := e.PassAuction(1, 100); return err },
+		"MortgageProperty":   func(e *Engine) error { _, err := e.MortgageProperty(1, 100, 1); return err },
+		"UnmortgageProperty": func(e *Engine) error { _, err := e.UnmortgageProperty(1, 100, 1); return err },
+		"BuyHouse":           func(e *Engine) error { _, err := e.BuyHouse(1, 100, 1); return err },
+		"SellHouse":          func(e *Engine) error { _, err := e.SellHouse(1, 100, 1); return err },
+		"EndTurn":            func(e *Engine) error { _, err := e.EndTurn(1, 100); return err },
+		"SkipTurn":           func(e *Engine) error { _, err := e.SkipTurn(1, 100); return err },
+		"GiveUp":             func(e *Engine) error { _, err := e.GiveUp(1, 100); return err },
+		"ProposeTrade": func(e *Engine) error {
+			_, err := e.ProposeTrade(1, 100, 200, TradeOffer{OfferedMoney: 10})
+			return err
+		},
+		"AcceptTrade":  func(e *Engine) error { _, err := e.AcceptTrade(1, 200, 1); return err },
+		"DeclineTrade": func(e *Engine) error { _, err := e.DeclineTrade(1, 200, 1); return err },
+		"CancelTrade":  func(e *Engine) error { _, err := e.CancelTrade(1, 100, 1); return err },
+	}
+	lobbyActions := map[string]func(e *Engine) error{
+		"JoinGame":   func(e *Engine) error { _, err := e.JoinGame(1, 300, "carol"); return err },
+		"SetReady":   func(e *Engine) error { _, err := e.SetReady(1, 100, true); return err },
+		"StartGame":  func(e *Engine) error { _, err := e.StartGame(1, 100); return err },
+		"UpdateGame": func(e *Engine) error { _, err := e.UpdateGameSettings(1, 100, GameSettings{}); return err },
+	}
+
+	cases := []struct {
+		status  string
+		actions map[string]func(e *Engine) error
+		code    errors.ErrorCode
+	}{
+		{StatusWaiting, turnActions, errors.ErrCodeGameNotStarted},
+		{StatusFinished, turnActions, errors.ErrCodeGameFinished},
+		{StatusInProgress, lobbyActions, errors.ErrCodeGameStarted},
+		{StatusFinished, lobbyActions, errors.ErrCodeGameFinished},
+	}
+	for _, c := range cases {
+		for name, action := range c.actions {
+			t.Run(c.status+"/"+name, func(t *testing.T) {
+				mockStore := NewMockGameStore()
+				engine := NewEngine(mockStore)
+				mockStore.Games[1] = &store.Game{ID: 1, Status: c.status, MinPlayers: 2, MaxPlayers: 4}
+				mockStore.Players[1] = []*store.GamePlayer{
+					{GameID: 1, UserID: 100, Username: "alice", PlayerOrder: 1, Money: 1500, IsCurrentTurn: true},
+					{GameID: 1, UserID: 200, Username: "bob", PlayerOrder: 2, Money: 1500},
+				}
+				mockStore.Trades[1] = &store.GameTrade{ID: 1, GameID: 1, FromUserID: 100, ToUserID: 200, Status: "pending", OfferJSON: "{}"}
+
+				err := action(engine)
+				if !stderrors.Is(err, ErrWrongGameStatus) {
+					t.Fatalf("Expected ErrWrongGameStatus, got %v", err)
+				}
+				if appErr := errors.From(err); appErr.Code != c.code {
+					t.Errorf("Expected code %s, got %s", c.code, appErr.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestRecentRolls_NewestFirstFromTheEventLog(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := NewEngine(store.NewGameStore(db))
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(2, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+
+	engine.RecordEvent(gameID, "dice_rolled", fmt.Appendf(nil, `{"userId":%d,"die1":3,"die2":4,"total":7}`, alice))
+	engine.RecordEvent(gameID, "property_bought", []byte(`{}`))
+	engine.RecordEvent(gameID, "jail_roll_failed", fmt.Appendf(nil, `{"userId":%d,"die1":1,"die2":5}`, bob))
+	engine.RecordEvent(gameID, "dice_rolled", fmt.Appendf(nil, `{"userId":%d,"die1":6,"die2":6,"total":12}`, alice))
+
+	rolls, err := engine.RecentRolls(gameID, 0)
+	if err != nil {
+		t.Fatalf("RecentRolls failed: %v", err)
+	}
+	if len(rolls) != 3 || rolls[0].Seq != 4 || rolls[1].Seq != 3 || rolls[2].Seq != 1 {
+		t.Fatalf("Expected the three rolls newest first, got %+v", rolls)
+	}
+	if rolls[0].Username != "alice" || rolls[0].Total != 12 || !rolls[0].IsDoubles || rolls[0].InJail {
+		t.Errorf("Expected alice's double sixes, got %+v", rolls[0])
+	}
+	if rolls[1].Username != "bob" || rolls[1].Total != 6 || !rolls[1].InJail {
+		t.Errorf("Expected bob's failed jail roll, got %+v", rolls[1])
+	}
+
+	if rolls, _ := engine.RecentRolls(gameID, 1); len(rolls) != 1 || rolls[0].Seq != 4 {
+		t.Errorf("Expected only the latest roll with limit 1, got %+v", rolls)
+	}
+	snapshot, err := engine.GetGameSnapshot(gameID)
+	if err != nil {
+		t.Fatalf("GetGameSnapshot failed: %v", err)
+	}
+	if len(snapshot.RecentRolls) != 3 {
+		t.Errorf("Expected the snapshot to carry the recent rolls, got %+v", snapshot.RecentRolls)
+	}
+	if _, err := engine.RecentRolls(gameID+1, 0); errors.From(err).Code != errors.ErrCodeGameNotFound {
+		t.Errorf("Expected GAME_NOT_FOUND for an unknown game, got %v", err)
 	}
-	return diceTotal * 4
 }
 
-func TestBoardSetup(t *testing.T) {
-	// Verify board has 40 spaces
-	if len(Board) != 40 {
-		t.Errorf("Expected 40 board spaces, got %d", len(Board))
+func TestPreviewLanding_MatchesTheActualLanding(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngineWithRand(mockStore, &fixedDice{rolls: []int{2, 4}})
+
+	mockStore.Games[1] = &store.Game{
+		ID:         1,
+		Status:     StatusInProgress,
+		MaxPlayers: 2,
+	}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+		{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500},
+	}
+	mockStore.Properties[1] = []*store.GameProperty{
+		{GameID: 1, Position: 5, OwnerID: 100},
+		{GameID: 1, Position: 6, OwnerID: 101}, // light blues: a monopoly
+		{GameID: 1, Position: 8, OwnerID: 101, IsMortgaged: true},
+		{GameID: 1, Position: 9, OwnerID: 101},
+		{GameID: 1, Position: 12, OwnerID: 101},
+	}
+
+	tests := []struct {
+		position int
+		outcome  string
+		amount   int
+		reason   string
+	}{
+		{1, LandingBuyPrompt, 60, ""},
+		{5, LandingNothing, 0, "own_property"},
+		{8, LandingNothing, 0, "mortgaged"},
+		{4, LandingTaxPrompt, 200, ""},
+		{38, LandingTax, 100, ""},
+		{30, LandingGoToJail, 0, ""},
+		{7, LandingDrawCard, 0, ""},
+		{20, LandingNothing, 0, ""},
+	}
+	for _, tt := range tests {
+		preview, err := engine.PreviewLanding(1, 100, tt.position)
+		if err != nil {
+			t.Fatalf("Position %d: unexpected error: %v", tt.position, err)
+		}
+		if preview.Outcome != tt.outcome || preview.Amount != tt.amount || preview.Reason != tt.reason {
+			t.Errorf("Position %d: expected %s %d %q, got %+v", tt.position, tt.outcome, tt.amount, tt.reason, preview)
+		}
 	}
 
-	// Verify GO is at position 0
-	if Board[0].Type != SpaceGo {
-		t.Errorf("Expected GO at position 0, got %s", Board[0].Type)
+	utility, _ := engine.PreviewLanding(1, 100, 12)
+	if utility.Outcome != LandingRent || utility.DiceMultiplier != 4 || utility.Amount != 0 {
+		t.Errorf("Expected utility rent of 4x the roll, got %+v", utility)
 	}
 
-	// Verify Jail is at position 10
-	if Board[10].Type != SpaceJail {
-		t.Errorf("Expected Jail at position 10, got %s", Board[10].Type)
+	preview, err := engine.PreviewLanding(1, 100, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if preview.Outcome != LandingRent || preview.OwnerID != 101 || preview.Amount == 0 {
+		t.Fatalf("Expected rent owed to player2, got %+v", preview)
+	}
+	if mockStore.Players[1][0].Money != 1500 || mockStore.UpdatePlayerMoneyCalled {
+		t.Fatal("Expected previews to leave the game untouched")
+	}
+
+	// 2+4 lands on 6: the rent charged is the rent previewed
+	if _, err := engine.RollDice(1, 100); err != nil {
+		t.Fatalf("RollDice failed: %v", err)
+	}
+	if paid := 1500 - mockStore.Players[1][0].Money; paid != preview.Amount {
+		t.Errorf("Expected landing to charge the previewed %d, charged %d", preview.Amount, paid)
+	}
+
+	if _, err := engine.PreviewLanding(1, 999, 6); errors.From(err).Code != errors.ErrCodeNotInGame {
+		t.Errorf("Expected NOT_IN_GAME for a non-player, got %v", err)
+	}
+	if _, err := engine.PreviewLanding(1, 100, 40); errors.From(err).Code != errors.ErrCodeBadRequest {
+		t.Errorf("Expected BAD_REQUEST for an off-board position, got %v", err)
+	}
+}
+
+func TestTurnActions_RejectPlayersOutOfTurn(t *testing.T) {
+	actions := []struct {
+		name string
+		act  func(e *Engine) error
+	}{
+		{"roll", func(e *Engine) error { _, err := e.RollDice(1, 101); return err }},
+		{"buy", func(e *Engine) error { _, err := e.BuyProperty(1, 101); return err }},
+		{"pass", func(e *Engine) error { _, err := e.PassProperty(1, 101); return err }},
+		{"build", func(e *Engine) error { _, err := e.BuyHouse(1, 101, 1); return err }},
+		{"sell house", func(e *Engine) error { _, err := e.SellHouse(1, 101, 1); return err }},
+		{"mortgage", func(e *Engine) error { _, err := e.MortgageProperty(1, 101, 1); return err }},
+		{"unmortgage", func(e *Engine) error { _, err := e.UnmortgageProperty(1, 101, 5); return err }},
+		{"jail card", func(e *Engine) error { _, err := e.UseJailFreeCard(1, 101); return err }},
+		{"bail", func(e *Engine) error { _, err := e.PayJailBail(1, 101); return err }},
+		{"income tax", func(e *Engine) error { _, err := e.PayIncomeTax(1, 101, TaxChoiceFlat); return err }},
+		{"end turn", func(e *Engine) error { _, err := e.EndTurn(1, 101); return err }},
+	}
+
+	for _, tt := range actions {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := NewMockGameStore()
+			engine := NewEngine(mockStore)
+			mockStore.Games[1] = &store.Game{ID: 1, Status: StatusInProgress, MaxPlayers: 2}
+			// player2 has everything each action needs except the turn
+			mockStore.Players[1] = []*store.GamePlayer{
+				{GameID: 1, UserID: 100, Username: "player1", PlayerOrder: 0, Money: 1500, IsCurrentTurn: true},
+				{GameID: 1, UserID: 101, Username: "player2", PlayerOrder: 1, Money: 1500, Position: 11,
+					PendingAction: "buy_or_pass", InJail: true},
+			}
+			mockStore.Properties[1] = []*store.GameProperty{
+				{GameID: 1, Position: 1, OwnerID: 101},
+				{GameID: 1, Position: 3, OwnerID: 101},
+				{GameID: 1, Position: 5, OwnerID: 101, IsMortgaged: true},
+			}
+
+			err := tt.act(engine)
+			if errors.From(err).Code != errors.ErrCodeNotYourTurn {
+				t.Fatalf("Expected NOT_YOUR_TURN, got %v", err)
+			}
+			player := mockStore.Players[1][1]
+			if mockStore.UpdatePlayerMoneyCalled || mockStore.UpdatePlayerPositionCalled || player.Money != 1500 ||
+				player.PendingAction != "buy_or_pass" || !player.InJail {
+				t.Errorf("Expected the rejected action to change nothing, got %+v", player)
+			}
+			if mockStore.Properties[1][0].IsMortgaged || !mockStore.Properties[1][2].IsMortgaged || len(mockStore.Properties[1]) != 3 {
+				t.Errorf("Expected properties untouched, got %+v", mockStore.Properties[1])
+			}
+		})
+	}
+}
+
+func TestCancelGame_HostOnlyWhileWaiting(t *testing.T) {
+	mockStore := NewMockGameStore()
+	engine := NewEngine(mockStore)
+
+	mockStore.Games[1] = &store.Game{ID: 1, Status: StatusWaiting, MaxPlayers: 4}
+	mockStore.Players[1] = []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "host", PlayerOrder: 0},
+		{GameID: 1, UserID: 101, Username: "guest", PlayerOrder: 1},
+	}
+	mockStore.Games[2] = &store.Game{ID: 2, Status: StatusInProgress, MaxPlayers: 2}
+	mockStore.Players[2] = []*store.GamePlayer{
+		{GameID: 2, UserID: 100, Username: "host", PlayerOrder: 0, IsCurrentTurn: true},
+		{GameID: 2, UserID: 101, Username: "guest", PlayerOrder: 1},
 	}
 
-	// Verify Free Parking is at position 20
-	if Board[20].Type != SpaceFreeParking {
-		t.Errorf("Expected Free Parking at position 20, got %s", Board[20].Type)
+	if _, err := engine.CancelGame(1, 101); errors.From(err).Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for a player who isn't the host, got %v", err)
+	}
+	if _, err := engine.CancelGame(2, 100); errors.From(err).Code != errors.ErrCodeGameStarted {
+		t.Errorf("Expected GAME_STARTED for a game in progress, got %v", err)
+	}
+	if mockStore.Games[1].Status != StatusWaiting || mockStore.Games[2].Status != StatusInProgress {
+		t.Fatal("Expected rejected cancellations to leave the games alone")
 	}
 
-	// Verify Go To Jail is at position 30
-	if Board[30].Type != SpaceGoToJail {
-		t.Errorf("Expected Go To Jail at position 30, got %s", Board[30].Type)
+	event, err := engine.CancelGame(1, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if payload, ok := event.Payload.(GameCancelledPayload); event.Type != "game_cancelled" || !ok || payload.UserID != 100 {
+		t.Errorf("Expected game_cancelled by the host, got %s %+v", event.Type, event.Payload)
+	}
+	if g := mockStore.Games[1]; g.Status != StatusFinished || g.EndReason != EndReasonCancelled || g.WinnerID != 0 {
+		t.Errorf("Expected the game finished as cancelled with no winner, got %+v", g)
+	}
+	if _, err := engine.CancelGame(1, 100); errors.From(err).Code != errors.ErrCodeGameFinished {
+		t.Errorf("Expected GAME_FINISHED when cancelling twice, got %v", err)
 	}
 }
 
-func TestPropertyGroups(t *testing.T) {
-	// Count properties in each group
-	groupCounts := make(map[ColorGroup]int)
+func TestBuildingSupply_LimitedPerGameAndReturnedOnBankruptcy(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := NewEngine(store.NewGameStore(db))
+
+	// start seats two players in a game with the brown monopoly for the host
+	start := func(rules store.GameRules, host, other string) (int64, int64, int64) {
+		hostID, _ := auth.CreateUser(host, "hash")
+		otherID, _ := auth.CreateUser(other, "hash")
+		created, err := lobby.CreateGame(2, rules, hostID, host)
+		if err != nil {
+			t.Fatalf("CreateGame failed: %v", err)
+		}
+		if err := lobby.JoinGame(created.ID, otherID, other); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+		if _, err := engine.StartGame(created.ID, hostID); err != nil {
+			t.Fatalf("StartGame failed: %v", err)
+		}
+		if _, err := db.Exec(`INSERT INTO game_properties (game_id, position, owner_id) VALUES (?, 1, ?), (?, 3, ?)`,
+			created.ID, hostID, created.ID, hostID); err != nil {
+			t.Fatalf("Failed to grant properties: %v", err)
+		}
+		return created.ID, hostID, otherID
+	}
 
-	for _, space := range Board {
-		if space.Type == SpaceProperty {
-			groupCounts[space.Color]++
+	if _, err := lobby.CreateGame(2, store.GameRules{HouseLimit: MaxBuildingLimit + 1}, 999, "nobody"); errors.From(err).Code != errors.ErrCodeBadRequest {
+		t.Errorf("Expected BAD_REQUEST for a house limit over %d, got %v", MaxBuildingLimit, err)
+	}
+
+	gameID, alice, bob := start(store.GameRules{HouseLimit: 3}, "alice", "bob")
+	state, _ := engine.GetGameState(gameID)
+	if state.CurrentPlayerID != alice || state.HouseLimit != 3 || state.HotelLimit != DefaultHotelLimit || state.HousesLeft != 3 {
+		t.Fatalf("Expected alice to move first with 3 houses and %d hotels in the bank, got %+v", DefaultHotelLimit, state)
+	}
+	for _, pos := range []int{1, 3, 1} {
+		if _, err := engine.BuyHouse(gameID, alice, pos); err != nil {
+			t.Fatalf("BuyHouse on %d failed: %v", pos, err)
 		}
 	}
+	if _, err := engine.BuyHouse(gameID, alice, 3); errors.From(err).Code != errors.ErrCodeHouseShortage {
+		t.Fatalf("Expected HOUSE_SHORTAGE with the bank's 3 houses built, got %v", err)
+	}
+	if _, err := engine.SellHouse(gameID, alice, 1); err != nil {
+		t.Fatalf("SellHouse failed: %v", err)
+	}
+	if state, _ := engine.GetGameState(gameID); state.HousesLeft != 1 {
+		t.Errorf("Expected the sold house back in the bank, got %d left", state.HousesLeft)
+	}
 
-	// Verify property group sizes
-	expectedGroupSizes := map[ColorGroup]int{
-		ColorBrown:     2,
-		ColorLightBlue: 3,
-		ColorPink:      3,
-		ColorOrange:    3,
-		ColorRed:       3,
-		ColorYellow:    3,
-		ColorGreen:     3,
-		ColorDarkBlue:  2,
+	bobMoney := func() int {
+		players, _ := engine.store.GetGamePlayers(gameID)
+		for _, p := range players {
+			if p.UserID == bob {
+				return p.Money
+			}
+		}
+		return 0
+	}
+	bobBefore := bobMoney()
+	tx, err := engine.store.BeginTx()
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	if _, err := engine.handleBankruptcyTx(tx, gameID, alice, "alice", "rent", bob); err != nil {
+		t.Fatalf("handleBankruptcyTx failed: %v", err)
+	}
+	if err := engine.store.CommitTx(tx); err != nil {
+		t.Fatalf("CommitTx failed: %v", err)
+	}
+	state, _ = engine.GetGameState(gameID)
+	if state.HousesLeft != 3 || state.Properties[1] != bob || state.Improvements[1] != 0 || state.Improvements[3] != 0 {
+		t.Errorf("Expected bob to get the lots and the houses to go back to the bank, got %d left, %v, %v",
+			state.HousesLeft, state.Properties, state.Improvements)
+	}
+	// The bank buys alice's two houses back at half their cost, for bob
+	if paid := bobMoney() - bobBefore; paid != 2*(Board[1].HouseCost/2) {
+		t.Errorf("Expected bob paid $%d for the houses, got $%d", 2*(Board[1].HouseCost/2), paid)
 	}
 
-	for group, expected := range expectedGroupSizes {
-		if groupCounts[group] != expected {
-			t.Errorf("Expected %d %s properties, got %d", expected, group, groupCounts[group])
+	// The house rule lifts the limit
+	gameID, carol, _ := start(store.GameRules{HouseLimit: 1, UnlimitedBuilding: true}, "carol", "dave")
+	for _, pos := range []int{1, 3} {
+		if _, err := engine.BuyHouse(gameID, carol, pos); err != nil {
+			t.Fatalf("Expected unlimited building past the house limit, got %v", err)
 		}
 	}
+	if state, _ := engine.GetGameState(gameID); !state.UnlimitedBuilding || state.HousesLeft != 0 {
+		t.Errorf("Expected unlimited building reported with no stock counted, got %+v", state)
+	}
 }
 
-func TestRailroads(t *testing.T) {
-	// Count railroads
-	railroadCount := 0
-	railroadPositions := []int{5, 15, 25, 35}
-
-	for _, pos := range railroadPositions {
-		if Board[pos].Type != SpaceRailroad {
-			t.Errorf("Expected railroad at position %d, got %s", pos, Board[pos].Type)
+func TestSellHouse_RefundsHalfAndReportsTheRentChange(t *testing.T) {
+	g := newTestGame(t, store.GameRules{}, "alice", "bob")
+	engine := NewEngine(g.store)
+	g.start(t, engine)
+	gameID, alice := g.id, g.players[0]
+	g.grant(t, alice, 1, 3)
+	for _, pos := range []int{1, 3, 1} {
+		if _, err := engine.BuyHouse(gameID, alice, pos); err != nil {
+			t.Fatalf("BuyHouse on %d failed: %v", pos, err)
 		}
-		railroadCount++
 	}
+	before, _ := engine.GetGameState(gameID)
 
-	if railroadCount != 4 {
-		t.Errorf("Expected 4 railroads, got %d", railroadCount)
+	// Baltic has one house against Mediterranean's two
+	if _, err := engine.SellHouse(gameID, alice, 3); errors.From(err).Code != errors.ErrCodeUnevenBuild {
+		t.Fatalf("Expected UNEVEN_BUILD selling from the lower property, got %v", err)
+	}
+
+	event, err := engine.SellHouse(gameID, alice, 1)
+	if err != nil {
+		t.Fatalf("SellHouse failed: %v", err)
+	}
+	sold := event.Payload.(HouseSoldPayload)
+	mediterranean := Board[1]
+	if sold.HouseCount != 1 || sold.Refund != mediterranean.HouseCost/2 {
+		t.Errorf("Expected one house left and a $%d refund, got %+v", mediterranean.HouseCost/2, sold)
+	}
+	if sold.PreviousRent != mediterranean.RentWithHouses[2] || sold.Rent != mediterranean.RentWithHouses[1] {
+		t.Errorf("Expected rent to drop from $%d to $%d, got %+v",
+			mediterranean.RentWithHouses[2], mediterranean.RentWithHouses[1], sold)
+	}
+	after, _ := engine.GetGameState(gameID)
+	if after.Improvements[1] != 1 || after.HousesLeft != before.HousesLeft+1 {
+		t.Errorf("Expected the house back in the bank, got %v with %d left", after.Improvements, after.HousesLeft)
+	}
+	for _, p := range after.Players {
+		if p.UserID == alice && p.Money != sold.NewMoney {
+			t.Errorf("Expected alice to hold $%d after the sale, got $%d", sold.NewMoney, p.Money)
+		}
 	}
 }
 
-func TestUtilities(t *testing.T) {
-	// Verify utilities at positions 12 and 28
-	if Board[12].Type != SpaceUtility {
-		t.Errorf("Expected utility at position 12, got %s", Board[12].Type)
+func TestTurnTimer_PausesForDisconnectUntilGraceRunsOut(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
 	}
-	if Board[28].Type != SpaceUtility {
-		t.Errorf("Expected utility at position 28, got %s", Board[28].Type)
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := NewEngine(store.NewGameStore(db))
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(2, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	if err := lobby.JoinGame(created.ID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	if _, err := engine.StartGame(created.ID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	gameID := created.ID
+
+	timer := NewTurnTimer(engine)
+	t.Cleanup(timer.CancelAll)
+	timeouts := make(chan *Event, 4)
+	timer.StartTurn(gameID, alice, func(event *Event) { timeouts <- event })
+
+	if _, ok := timer.PauseForDisconnect(gameID, bob, time.Hour); ok {
+		t.Fatal("Expected no pause when a player drops out of turn")
+	}
+	if grace, ok := timer.PauseForDisconnect(gameID, alice, time.Hour); !ok || grace != time.Hour {
+		t.Fatalf("Expected alice's turn to pause for the whole grace when she drops, got %v, %v", grace, ok)
+	}
+	if left, ok := timer.Remaining(gameID); !ok || left <= TurnTimeout {
+		t.Errorf("Expected the clock to show the grace period while paused, got %v", left)
+	}
+	if _, ok := timer.ResumeAfterReconnect(gameID, bob); ok {
+		t.Error("Expected bob reconnecting not to resume alice's turn")
+	}
+	left, ok := timer.ResumeAfterReconnect(gameID, alice)
+	if !ok || left <= TurnTimeout-5*time.Second || left > TurnTimeout {
+		t.Fatalf("Expected alice to get her turn time back, got %v", left)
+	}
+	if _, ok := timer.ResumeAfterReconnect(gameID, alice); ok {
+		t.Error("Expected a second resume to do nothing")
+	}
+
+	// Every drop in the turn draws on the same grace
+	if grace, ok := timer.PauseForDisconnect(gameID, alice, time.Hour); !ok || grace >= time.Hour {
+		t.Fatalf("Expected a second drop to get only the grace left, got %v, %v", grace, ok)
+	}
+	timer.ResumeAfterReconnect(gameID, alice)
+	if _, ok := timer.PauseForDisconnect(gameID, alice, time.Nanosecond); ok {
+		t.Fatal("Expected no pause once the turn's grace is spent")
+	}
+
+	// Still away when the grace runs out: the turn times out as usual
+	if _, ok := timer.PauseForDisconnect(gameID, alice, 10*time.Millisecond); !ok {
+		t.Fatal("Expected alice's turn to pause again")
+	}
+	select {
+	case event := <-timeouts:
+		payload, _ := event.Payload.(map[string]interface{})
+		if event.Type != "turn_timeout" || payload["currentPlayerId"] != bob {
+			t.Errorf("Expected the turn to pass to bob on timeout, got %s %v", event.Type, event.Payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the turn to time out once the grace period ran out")
+	}
+	if _, ok := timer.ResumeAfterReconnect(gameID, alice); ok {
+		t.Error("Expected nothing to resume after the turn timed out")
 	}
 }
 
-func TestChanceAndCommunityChest(t *testing.T) {
-	// Verify Chance positions
-	chancePositions := []int{7, 22, 36}
-	for _, pos := range chancePositions {
-		if Board[pos].Type != SpaceChance {
-			t.Errorf("Expected Chance at position %d, got %s", pos, Board[pos].Type)
+func TestMoneyTransferred_ReconcilesMoneyInCirculation(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	// Never doubles; both players walk the same squares, so the second pays rent
+	engine := NewEngineWithRand(store.NewGameStore(db), &fixedDice{rolls: []int{1, 3}})
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(2, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	if _, err := engine.StartGame(gameID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	// Both draw from Chance on 36; stack it with cards paying out, so no one
+	// is sent to jail before passing GO
+	if _, err := db.Exec(`UPDATE game_card_decks SET card_order = '[15,14]' WHERE game_id = ? AND deck_type = 'chance'`, gameID); err != nil {
+		t.Fatalf("Failed to stack the chance deck: %v", err)
+	}
+
+	circulating := func() int {
+		state, err := engine.GetGameState(gameID)
+		if err != nil {
+			t.Fatalf("GetGameState failed: %v", err)
 		}
+		total := 0
+		for _, p := range state.Players {
+			total += p.Money
+		}
+		return total
 	}
+	before := circulating()
 
-	// Verify Community Chest positions
-	ccPositions := []int{2, 17, 33}
-	for _, pos := range ccPositions {
-		if Board[pos].Type != SpaceCommunityChest {
-			t.Errorf("Expected Community Chest at position %d, got %s", pos, Board[pos].Type)
+	var events []*Event
+	record := func(evs []*Event, err error) {
+		if err != nil {
+			t.Fatalf("Action failed: %v", err)
+		}
+		events = append(events, evs...)
+	}
+	for turn := 0; turn < 24; turn++ {
+		state, _ := engine.GetGameState(gameID)
+		if state.Status != StatusInProgress {
+			break
 		}
+		current := state.CurrentPlayerID
+		record(engine.RollDice(gameID, current))
+
+		state, _ = engine.GetGameState(gameID)
+		switch state.TurnPhase {
+		case TurnPhaseAwaitingBuyDecision:
+			record(engine.BuyProperty(gameID, current))
+		case TurnPhaseAwaitingTaxChoice:
+			record(engine.PayIncomeTax(gameID, current, TaxChoiceFlat))
+		}
+	}
+
+	net := 0
+	reasons := map[string]bool{}
+	for _, event := range events {
+		if event.Type != "money_transferred" {
+			continue
+		}
+		transfer := event.Payload.(MoneyTransferredPayload)
+		if transfer.Amount <= 0 || transfer.Bank != (transfer.FromUserID == 0 || transfer.ToUserID == 0) {
+			t.Errorf("Expected a positive amount flagged as a bank payment iff one side is zero, got %+v", transfer)
+		}
+		if transfer.FromUserID == 0 {
+			net += transfer.Amount
+		}
+		if transfer.ToUserID == 0 {
+			net -= transfer.Amount
+		}
+		reasons[transfer.Reason] = true
+	}
+	for _, reason := range []string{MoneySalary, MoneyTax, MoneyPurchase, MoneyRent} {
+		if !reasons[reason] {
+			t.Errorf("Expected a %s payment in 24 turns, got %v", reason, reasons)
+		}
+	}
+	if after := circulating(); after != before+net {
+		t.Errorf("Expected bank payments to account for the change in money from $%d to $%d, they net $%d", before, after, net)
 	}
 }
 
-func TestTaxSpaces(t *testing.T) {
-	// Income Tax at position 4
-	if Board[4].Type != SpaceTax {
-		t.Errorf("Expected Tax at position 4, got %s", Board[4].Type)
+func TestMoneyAudit_FlagsMoneyThatBankPaymentsDontExplain(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
 	}
-	if Board[4].TaxAmount != 200 {
-		t.Errorf("Expected Income Tax $200, got $%d", Board[4].TaxAmount)
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := NewEngineWithRand(store.NewGameStore(db), &fixedDice{rolls: []int{1, 3}})
+	engine.SetMoneyAudit(true)
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(2, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	if _, err := engine.StartGame(gameID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO game_properties (game_id, position, owner_id) VALUES (?, 1, ?), (?, 3, ?), (?, 6, ?)`,
+		gameID, alice, gameID, alice, gameID, alice); err != nil {
+		t.Fatalf("Failed to grant properties: %v", err)
 	}
 
-	// Luxury Tax at position 38
-	if Board[38].Type != SpaceTax {
-		t.Errorf("Expected Tax at position 38, got %s", Board[38].Type)
+	// Both land on Income Tax; alice builds, sells and mortgages before paying
+	if _, err := engine.RollDice(gameID, alice); err != nil {
+		t.Fatalf("RollDice failed: %v", err)
 	}
-	if Board[38].TaxAmount != 100 {
-		t.Errorf("Expected Luxury Tax $100, got $%d", Board[38].TaxAmount)
+	for _, pos := range []int{1, 3} {
+		if _, err := engine.BuyHouse(gameID, alice, pos); err != nil {
+			t.Fatalf("BuyHouse failed: %v", err)
+		}
+	}
+	if _, err := engine.SellHouse(gameID, alice, 1); err != nil {
+		t.Fatalf("SellHouse failed: %v", err)
+	}
+	if _, err := engine.MortgageProperty(gameID, alice, 6); err != nil {
+		t.Fatalf("MortgageProperty failed: %v", err)
+	}
+	if _, err := engine.PayIncomeTax(gameID, alice, TaxChoiceFlat); err != nil {
+		t.Fatalf("PayIncomeTax failed: %v", err)
+	}
+	if _, err := engine.RollDice(gameID, bob); err != nil {
+		t.Fatalf("RollDice failed: %v", err)
+	}
+	if _, err := engine.PayIncomeTax(gameID, bob, TaxChoicePercent); err != nil {
+		t.Fatalf("PayIncomeTax failed: %v", err)
+	}
+
+	audit, err := engine.MoneyAudit(gameID)
+	if err != nil {
+		t.Fatalf("MoneyAudit failed: %v", err)
+	}
+	if !audit.Tracked || audit.Expected != audit.Total || len(audit.Discrepancies) != 0 {
+		t.Fatalf("Expected the tracked total to match with no discrepancies, got %+v", audit)
+	}
+	if audit.PaidByBank == 0 || audit.PaidToBank == 0 || len(audit.Players) != 2 {
+		t.Errorf("Expected bank payments both ways for both players, got %+v", audit)
+	}
+
+	// Money appearing outside any action is flagged after the next one
+	if _, err := db.Exec(`UPDATE game_players SET money = money + 500 WHERE game_id = ? AND user_id = ?`, gameID, bob); err != nil {
+		t.Fatalf("Failed to add money: %v", err)
+	}
+	if _, err := engine.RollDice(gameID, alice); err != nil {
+		t.Fatalf("RollDice failed: %v", err)
+	}
+	audit, _ = engine.MoneyAudit(gameID)
+	if len(audit.Discrepancies) != 1 || audit.Discrepancies[0].Actual-audit.Discrepancies[0].Expected != 500 {
+		t.Fatalf("Expected the extra $500 flagged once, got %+v", audit.Discrepancies)
+	}
+	if audit.Discrepancies[0].Events[0] != "dice_rolled" || audit.Expected != audit.Total {
+		t.Errorf("Expected the discrepancy to name the roll and checking to carry on from the actual total, got %+v", audit)
+	}
+}
+
+func TestMoneyAudit_AccountsForPlayersGivingUpAndForgetsFinishedGames(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := NewEngine(store.NewGameStore(db))
+	engine.SetMoneyAudit(true)
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	carol, _ := auth.CreateUser("carol", "hash")
+	created, err := lobby.CreateGame(3, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	for _, id := range []int64{bob, carol} {
+		if err := lobby.JoinGame(gameID, id, "player"); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+	}
+	if _, err := engine.StartGame(gameID, alice); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+
+	if _, err := engine.GiveUp(gameID, bob); err != nil {
+		t.Fatalf("GiveUp failed: %v", err)
+	}
+	audit, err := engine.MoneyAudit(gameID)
+	if err != nil {
+		t.Fatalf("MoneyAudit failed: %v", err)
+	}
+	if !audit.Tracked || audit.Expected != audit.Total || len(audit.Discrepancies) != 0 {
+		t.Fatalf("Expected bob's cash returning to the bank to be accounted for, got %+v", audit)
+	}
+	if audit.PaidToBank != 1500 {
+		t.Errorf("Expected bob's $1500 paid to the bank, got %d", audit.PaidToBank)
+	}
+
+	// The last give-up finishes the game, and the audit forgets it
+	if _, err := engine.GiveUp(gameID, carol); err != nil {
+		t.Fatalf("GiveUp failed: %v", err)
+	}
+	if audit, _ := engine.MoneyAudit(gameID); audit.Tracked {
+		t.Errorf("Expected the finished game's ledger to be dropped, got %+v", audit)
+	}
+	if n := len(engine.audit.ledgers); n != 0 {
+		t.Errorf("Expected no ledgers left, got %d", n)
 	}
 }
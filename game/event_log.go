@@ -0,0 +1,62 @@
+package game
+
+import (
+	"encoding/json"
+	"monopoly/errors"
+)
+
+// MaxEventsPageSize caps how many logged events a single fetch returns
+const MaxEventsPageSize = 1000
+
+// unloggedEvents are broadcasts that only matter to currently connected
+// clients and are left out of the replay log.
+var unloggedEvents = map[string]bool{
+	"timer_started":       true,
+	"server_shutdown":     true,
+	"presence_changed":    true,
+	"player_reconnecting": true,
+}
+
+// RecordEvent appends a broadcast event to the game's log and returns its
+// sequence number, or 0 if the event type isn't logged.
+func (e *Engine) RecordEvent(gameID int64, eventType string, payloadJSON []byte) (int64, error) {
+	if unloggedEvents[eventType] {
+		return 0, nil
+	}
+	return e.store.AppendEvent(gameID, eventType, string(payloadJSON))
+}
+
+// GetEvents returns the game's logged events after sinceSeq, oldest first.
+// A non-positive limit or one above MaxEventsPageSize returns a full page.
+func (e *Engine) GetEvents(gameID, sinceSeq int64, limit int) ([]LoggedEvent, error) {
+	game, err := e.store.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+
+	if limit <= 0 || limit > MaxEventsPageSize {
+		limit = MaxEventsPageSize
+	}
+	if sinceSeq < 0 {
+		sinceSeq = 0
+	}
+
+	rows, err := e.store.GetEvents(gameID, sinceSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]LoggedEvent, len(rows))
+	for i, r := range rows {
+		events[i] = LoggedEvent{
+			Seq:       r.Seq,
+			Type:      r.Type,
+			Payload:   json.RawMessage(r.PayloadJSON),
+			CreatedAt: r.CreatedAt,
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,79 @@
+package game
+
+import "sync"
+
+// gameLocks hands out one mutex per game so that actions on the same game
+// run one at a time while different games stay concurrent. Entries are
+// dropped once nobody holds or waits for them.
+type gameLocks struct {
+	mu    sync.Mutex
+	locks map[int64]*gameLock
+}
+
+type gameLock struct {
+	mu   sync.Mutex
+	refs int // holders plus waiters; guarded by gameLocks.mu
+}
+
+// lock blocks until the game is free and returns the matching unlock
+func (gl *gameLocks) lock(gameID int64) func() {
+	gl.mu.Lock()
+	if gl.locks == nil {
+		gl.locks = make(map[int64]*gameLock)
+	}
+	l := gl.locks[gameID]
+	if l == nil {
+		l = &gameLock{}
+		gl.locks[gameID] = l
+	}
+	l.refs++
+	gl.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		gl.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(gl.locks, gameID)
+		}
+		gl.mu.Unlock()
+	}
+}
+
+// lockGame serializes an engine mutation with every other one on the same
+// game. Use as: defer e.lockGame(gameID)()
+func (e *Engine) lockGame(gameID int64) func() {
+	return e.games.lock(gameID)
+}
+
+// doubles returns the game's count of consecutive doubles this turn
+func (e *Engine) doubles(gameID int64) int {
+	e.turnMu.Lock()
+	defer e.turnMu.Unlock()
+	return e.doublesCount[gameID]
+}
+
+func (e *Engine) setDoubles(gameID int64, count int) {
+	e.turnMu.Lock()
+	defer e.turnMu.Unlock()
+	e.doublesCount[gameID] = count
+}
+
+// auction returns the game's running auction, or nil
+func (e *Engine) auction(gameID int64) *Auction {
+	e.turnMu.Lock()
+	defer e.turnMu.Unlock()
+	return e.activeAuctions[gameID]
+}
+
+// setAuction records the game's running auction; nil clears it
+func (e *Engine) setAuction(gameID int64, auction *Auction) {
+	e.turnMu.Lock()
+	defer e.turnMu.Unlock()
+	if auction == nil {
+		delete(e.activeAuctions, gameID)
+		return
+	}
+	e.activeAuctions[gameID] = auction
+}
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"database/sql"
+	"monopoly/errors"
+	"monopoly/store"
+)
+
+// Landing outcomes reported by PreviewLanding, named after what landing does
+const (
+	LandingBuyPrompt = "buy_prompt" // unowned and affordable: the player is offered it
+	LandingRent      = "rent"       // rent is paid to the owner
+	LandingBankrupt  = "bankrupt"   // rent or tax the player can't afford
+	LandingTax       = "tax"
+	LandingTaxPrompt = "tax_prompt" // income tax: flat amount or a percentage
+	LandingGoToJail  = "go_to_jail"
+	LandingDrawCard  = "draw_card"
+	LandingNothing   = "none"
+)
+
+// LandingPreview is what landing on a space would do to a player right now
+type LandingPreview struct {
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Outcome  string `json:"outcome"`
+	// Amount is the rent, tax or purchase price involved. For income tax
+	// it's the flat amount, with PercentAmount the alternative.
+	Amount        int   `json:"amount"`
+	PercentAmount int   `json:"percentAmount,omitempty"`
+	OwnerID       int64 `json:"ownerId,omitempty"`
+	// Utility rent depends on the roll: it's DiceMultiplier times the dice
+	// total, and Amount is 0
+	DiceMultiplier int `json:"diceMultiplier,omitempty"`
+	// Why an ownable space costs nothing: "own_property", "mortgaged",
+	// "owner_bankrupt" or "cannot_afford" (too poor to be offered it)
+	Reason string `json:"reason,omitempty"`
+}
+
+// landingRent is the rent situation of an ownable space for a player landing
+// on it, as resolveSpaceLanding charges it and PreviewLanding reports it
+type landingRent struct {
+	ownerID   int64             // 0 = unowned
+	owner     *store.GamePlayer // set once rent is looked up; only by landingRentTx
+	ownerOut  bool              // the owner is bankrupt or gone, so collects nothing
+	mortgaged bool
+	rent      int // owed to owner when due
+}
+
+// due reports whether userID pays rent: someone else owns the space, it isn't
+// mortgaged and the owner is still in the game
+func (lr landingRent) due(userID int64) bool {
+	return lr.ownerID != 0 && lr.ownerID != userID && !lr.mortgaged && !lr.ownerOut
+}
+
+// landingRentTx loads what rent depends on inside the landing's transaction
+// and works it out with rentOn
+func (e *Engine) landingRentTx(tx *sql.Tx, gameID, userID int64, space BoardSpace, diceTotal int, rentMultiplier float64) (landingRent, error) {
+	props, err := e.store.GetGamePropertiesTx(tx, gameID)
+	if err != nil {
+		return landingRent{}, err
+	}
+	owners, mortgaged := ownership(props)
+	houses, err := e.store.GetImprovementsTx(tx, gameID, space.Position)
+	if err != nil {
+		return landingRent{}, err
+	}
+
+	var owner *store.GamePlayer
+	if ownerID := owners[space.Position]; ownerID != 0 && ownerID != userID {
+		if owner, err = e.store.GetPlayerTx(tx, gameID, ownerID); err != nil {
+			return landingRent{}, err
+		}
+	}
+	ownerOut := func(int64) bool { return owner == nil || owner.IsBankrupt }
+
+	lr := rentOn(space, userID, diceTotal, rentMultiplier, owners, mortgaged, houses, ownerOut)
+	lr.owner = owner
+	return lr, nil
+}
+
+// rentOn is the one place rent on a landing is worked out, from loaded
+// ownership: owners maps positions to owner IDs, houses is the space's
+// improvements and ownerOut reports whether an owner collects nothing because
+// they are bankrupt or gone. resolveSpaceLanding charges it and
+// PreviewLanding reports it.
+func rentOn(space BoardSpace, userID int64, diceTotal int, rentMultiplier float64, owners map[int]int64, mortgaged map[int]bool, houses int, ownerOut func(ownerID int64) bool) landingRent {
+	lr := landingRent{ownerID: owners[space.Position]}
+	if lr.ownerID == 0 || lr.ownerID == userID {
+		return lr
+	}
+	if mortgaged[space.Position] {
+		lr.mortgaged = true
+		return lr
+	}
+
+	var ownerProps []int
+	for pos, ownerID := range owners {
+		if ownerID == lr.ownerID {
+			ownerProps = append(ownerProps, pos)
+		}
+	}
+	lr.rent = int(float64(CalculateRent(space, ownerProps, diceTotal, houses)) * rentMultiplier)
+	lr.ownerOut = ownerOut(lr.ownerID)
+	return lr
+}
+
+// ownership splits a game's properties into owners and mortgages by position
+func ownership(props []*store.GameProperty) (owners map[int]int64, mortgaged map[int]bool) {
+	owners = make(map[int]int64)
+	mortgaged = make(map[int]bool)
+	for _, p := range props {
+		owners[p.Position] = p.OwnerID
+		if p.IsMortgaged {
+			mortgaged[p.Position] = true
+		}
+	}
+	return owners, mortgaged
+}
+
+// PreviewLanding reports what landing on spaceIndex would do to the player
+// right now, by the same rules as an actual landing, without changing
+// anything. Cards aren't drawn, so chance and community chest only say a
+// card would be.
+func (e *Engine) PreviewLanding(gameID, userID int64, spaceIndex int) (*LandingPreview, error) {
+	if spaceIndex < 0 || spaceIndex >= len(Board) {
+		return nil, errors.BadRequest("Invalid board position")
+	}
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+	var player *Player
+	for _, p := range state.Players {
+		if p.UserID == userID {
+			player = p
+			break
+		}
+	}
+	if player == nil {
+		return nil, errors.NotInGame()
+	}
+
+	space := state.Board[spaceIndex]
+	preview := &LandingPreview{Position: space.Position, Name: space.Name, Type: string(space.Type), Outcome: LandingNothing}
+
+	switch space.Type {
+	case SpaceProperty, SpaceRailroad, SpaceUtility:
+		// A roll of 1 leaves just the multiplier in a utility's rent
+		diceTotal := 0
+		if space.Type == SpaceUtility {
+			diceTotal = 1
+		}
+		// Read from the state rather than a transaction, which would take the
+		// database's write lock
+		lr := rentOn(space, userID, diceTotal, 1.0, state.Properties, state.MortgagedProperties,
+			state.Improvements[space.Position], func(ownerID int64) bool {
+				for _, p := range state.Players {
+					if p.UserID == ownerID {
+						return p.IsBankrupt
+					}
+				}
+				return true
+			})
+		preview.OwnerID = lr.ownerID
+
+		switch {
+		case lr.ownerID == 0:
+			preview.Amount = space.Price
+			if player.Money >= space.Price {
+				preview.Outcome = LandingBuyPrompt
+			} else {
+				preview.Reason = "cannot_afford"
+			}
+		case lr.ownerID == userID:
+			preview.Reason = "own_property"
+		case lr.mortgaged:
+			preview.Reason = "mortgaged"
+		case !lr.due(userID):
+			preview.Reason = "owner_bankrupt"
+		case space.Type == SpaceUtility:
+			preview.Outcome = LandingRent
+			preview.DiceMultiplier = lr.rent
+		default:
+			preview.Outcome = LandingRent
+			preview.Amount = lr.rent
+			if player.Money < lr.rent {
+				preview.Outcome = LandingBankrupt
+			}
+		}
+
+	case SpaceTax:
+		preview.Amount = space.TaxAmount
+		switch {
+		case space.Position == IncomeTaxPosition:
+			preview.Outcome = LandingTaxPrompt
+			preview.PercentAmount = incomeTax(space.TaxAmount, player.TaxBasis, TaxChoicePercent)
+		case player.Money < space.TaxAmount:
+			preview.Outcome = LandingBankrupt
+		default:
+			preview.Outcome = LandingTax
+		}
+
+	case SpaceGoToJail:
+		preview.Outcome = LandingGoToJail
+
+	case SpaceChance, SpaceCommunityChest:
+		preview.Outcome = LandingDrawCard
+	}
+
+	return preview, nil
+}
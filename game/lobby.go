@@ -1,77 +1,195 @@
 package game
 
 import (
+	"cmp"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"monopoly/errors"
 	"monopoly/store"
 )
 
 const (
 	minPlayersPerGame = 2
 	maxPlayersPerGame = 8
+
+	// DefaultMaxPlayers is used when a create request doesn't say how many seats
+	DefaultMaxPlayers = 4
+
+	// Lobby game list paging
+	DefaultGamesPageSize = 20
+	MaxGamesPageSize     = 100
+
+	// Upper bounds for the optional victory limits
+	MaxTurnLimit        = 500
+	MaxTimeLimitMinutes = 24 * 60
 )
 
 type Lobby struct {
-	store store.LobbyStore
+	store          store.LobbyStore
+	maxActiveGames int // per user, counted when creating a game
 }
 
-func NewLobby(store store.LobbyStore) *Lobby {
-	return &Lobby{store: store}
+func NewLobby(store store.LobbyStore, maxActiveGames int) *Lobby {
+	return &Lobby{store: store, maxActiveGames: maxActiveGames}
 }
 
-// CreateGame creates a new game and automatically joins the creator
-func (l *Lobby) CreateGame(maxPlayers int, userID int64, username string) (*store.LobbyGameDTO, error) {
-	if maxPlayers < minPlayersPerGame {
-		maxPlayers = minPlayersPerGame
+// CreateGame creates a new game and automatically joins the creator, who
+// like any player can be in only one unfinished game at a time, and who gets
+// TOO_MANY_GAMES once in the lobby's limit of unfinished games.
+// rules sets how many players must join before it can start (default 2),
+// whether only the host can start it, and optionally ends the game after a
+// number of rounds or minutes. rules.Board, if set, is a custom board as JSON
+// and is rejected unless ParseCustomBoard accepts it. The bank holds the
+// standard 32 houses and 12 hotels unless rules say otherwise.
+func (l *Lobby) CreateGame(maxPlayers int, rules store.GameRules, userID int64, username string) (*store.LobbyGameDTO, error) {
+	if maxPlayers < minPlayersPerGame || maxPlayers > maxPlayersPerGame {
+		return nil, errors.BadRequest(fmt.Sprintf("maxPlayers must be between %d and %d", minPlayersPerGame, maxPlayersPerGame))
+	}
+	if rules.TurnLimit < 0 || rules.TurnLimit > MaxTurnLimit {
+		return nil, errors.BadRequest(fmt.Sprintf("turnLimit must be between 0 and %d", MaxTurnLimit))
 	}
-	if maxPlayers > maxPlayersPerGame {
-		maxPlayers = maxPlayersPerGame
+	if rules.TimeLimitMinutes < 0 || rules.TimeLimitMinutes > MaxTimeLimitMinutes {
+		return nil, errors.BadRequest(fmt.Sprintf("timeLimitMinutes must be between 0 and %d", MaxTimeLimitMinutes))
 	}
 
-	gameID, err := l.store.CreateGame(maxPlayers)
-	if err != nil {
-		return nil, err
+	if rules.MinPlayers == 0 {
+		rules.MinPlayers = minPlayersPerGame
+	}
+	if rules.MinPlayers < minPlayersPerGame || rules.MinPlayers > maxPlayersPerGame {
+		return nil, errors.BadRequest(fmt.Sprintf("minPlayers must be between %d and %d", minPlayersPerGame, maxPlayersPerGame))
+	}
+	if rules.MinPlayers > maxPlayers {
+		return nil, errors.BadRequest("minPlayers cannot exceed maxPlayers")
 	}
 
-	// Automatically join the creator
-	err = l.store.JoinGame(gameID, userID, username)
+	if rules.HouseLimit < 0 || rules.HouseLimit > MaxBuildingLimit {
+		return nil, errors.BadRequest(fmt.Sprintf("houseLimit must be between 1 and %d", MaxBuildingLimit))
+	}
+	if rules.HotelLimit < 0 || rules.HotelLimit > MaxBuildingLimit {
+		return nil, errors.BadRequest(fmt.Sprintf("hotelLimit must be between 1 and %d", MaxBuildingLimit))
+	}
+	rules.HouseLimit = cmp.Or(rules.HouseLimit, DefaultHouseLimit)
+	rules.HotelLimit = cmp.Or(rules.HotelLimit, DefaultHotelLimit)
+
+	if rules.Board != "" {
+		board, err := ParseCustomBoard([]byte(rules.Board))
+		if err != nil {
+			return nil, err
+		}
+		normalized, err := json.Marshal(board)
+		if err != nil {
+			return nil, err
+		}
+		rules.Board = string(normalized)
+	}
+
+	rules.Seed = NewGameSeed()
+	gameID, token, err := l.store.CreateGameWithHost(maxPlayers, rules, userID, l.maxActiveGames, PlayerTokens)
+	if stderrors.Is(err, store.ErrTooManyGames) {
+		return nil, errors.TooManyGames(l.maxActiveGames)
+	}
 	if err != nil {
-		return nil, err
+		return nil, joinError(err)
 	}
 
 	// Return the created game with the creator as a player
 	return &store.LobbyGameDTO{
-		ID:         gameID,
-		Status:     "waiting",
-		MaxPlayers: maxPlayers,
+		ID:               gameID,
+		Status:           "waiting",
+		MinPlayers:       rules.MinPlayers,
+		MaxPlayers:       maxPlayers,
+		TurnLimit:        rules.TurnLimit,
+		TimeLimitMinutes: rules.TimeLimitMinutes,
+		ManualStart:      rules.ManualStart,
+		CustomBoard:      rules.Board != "",
 		Players: []store.LobbyPlayerDTO{
 			{
 				UserID:   userID,
 				Username: username,
+				Token:    token,
 			},
 		},
-		IsJoined: true,
+		PlayerCount: 1,
+		IsJoined:    true,
 	}, nil
 }
 
-func (l *Lobby) ListGames(userID int64) ([]*store.LobbyGameDTO, error) {
-	games, err := l.store.ListGames(userID)
+// ListGames returns a page of active games matching the filter and the total number of matches.
+// A non-positive limit uses the default page size; limits above the max are capped.
+func (l *Lobby) ListGames(userID int64, filter store.GameListFilter, limit, offset int) ([]*store.LobbyGameDTO, int, error) {
+	if limit <= 0 {
+		limit = DefaultGamesPageSize
+	}
+	if limit > MaxGamesPageSize {
+		limit = MaxGamesPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	games, total, err := l.store.ListGames(userID, filter, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return games, nil
+	return games, total, nil
 }
 
 func (l *Lobby) JoinGame(gameID, userID int64, username string) error {
-	return l.store.JoinGame(gameID, userID, username)
+	_, err := l.JoinGameWithToken(gameID, userID, username, "")
+	return err
+}
+
+// JoinGameWithToken joins the game as the given token, which must be one of
+// PlayerTokens and not taken by another player in the game. With no token
+// the player gets the first free one. Returns the token the player has.
+func (l *Lobby) JoinGameWithToken(gameID, userID int64, username, token string) (string, error) {
+	tokens, err := tokenChoices(token)
+	if err != nil {
+		return "", err
+	}
+	token, err = l.store.JoinGame(gameID, userID, username, tokens)
+	if err != nil {
+		return "", joinError(err)
+	}
+	return token, nil
+}
+
+// joinError turns the store's reasons for turning a player away into app errors
+func joinError(err error) error {
+	switch {
+	case stderrors.Is(err, store.ErrGameNotFound):
+		return errors.GameNotFound()
+	case stderrors.Is(err, store.ErrGameFull):
+		return errors.GameFull()
+	case stderrors.Is(err, store.ErrGameStarted):
+		return errors.GameAlreadyStarted()
+	case stderrors.Is(err, store.ErrAlreadyInGame):
+		return errors.AlreadyInGame()
+	case stderrors.Is(err, store.ErrTokenTaken):
+		return errors.New(errors.ErrCodeConflict, "That token is already taken")
+	}
+	return err
 }
 
 func (l *Lobby) LeaveGame(gameID, userID int64) error {
-	return l.store.LeaveGame(gameID, userID)
+	err := l.store.LeaveGame(gameID, userID)
+	if stderrors.Is(err, store.ErrNotInGame) {
+		return errors.NotInGame()
+	}
+	return err
 }
 
 func (l *Lobby) GetUserCurrentGame(userID int64) (*store.LobbyGameDTO, error) {
 	return l.store.GetUserCurrentGame(userID)
 }
 
+// GetGamesForUser lists the unfinished games the user has joined, so they can
+// rejoin one without paging through the lobby
+func (l *Lobby) GetGamesForUser(userID int64) ([]*store.UserGameDTO, error) {
+	return l.store.GetGamesForUser(userID)
+}
+
 func (l *Lobby) GetGameWithPlayers(gameID, userID int64) (*store.LobbyGameDTO, error) {
 	return l.store.GetGameWithPlayers(gameID, userID)
 }
@@ -0,0 +1,290 @@
+package game
+
+import (
+	"database/sql"
+	"encoding/json"
+	"monopoly/errors"
+	"monopoly/store"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestLobby(t *testing.T) (*Lobby, store.AuthStore) {
+	t.Helper()
+	db := newTestDB(t)
+	return NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+}
+
+// testGame is a game on its own database, for tests that drive an engine
+// against real storage
+type testGame struct {
+	db      *sql.DB
+	lobby   *Lobby
+	auth    store.AuthStore
+	store   store.GameStore
+	id      int64
+	players []int64 // user IDs in seat order, the host first
+}
+
+// newTestGame creates a user per name and a game hosted by the first with the
+// rest seated. The game is left waiting, so the test can build its engine on
+// g.store and then call start.
+func newTestGame(t *testing.T, rules store.GameRules, names ...string) *testGame {
+	t.Helper()
+	db := newTestDB(t)
+	g := &testGame{
+		db:    db,
+		lobby: NewLobby(store.NewSQLiteLobbyStore(db), 3),
+		auth:  store.NewAuthStore(db),
+		store: store.NewGameStore(db),
+	}
+	for _, name := range names {
+		userID, err := g.auth.CreateUser(name, "hash")
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		g.players = append(g.players, userID)
+	}
+	created, err := g.lobby.CreateGame(len(names), rules, g.players[0], names[0])
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	g.id = created.ID
+	for i, name := range names[1:] {
+		if err := g.lobby.JoinGame(g.id, g.players[i+1], name); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+	}
+	return g
+}
+
+// start has the host start the game through the engine
+func (g *testGame) start(t *testing.T, engine *Engine) {
+	t.Helper()
+	if _, err := engine.StartGame(g.id, g.players[0]); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+}
+
+// grant gives ownerID the properties at positions without buying them
+func (g *testGame) grant(t *testing.T, ownerID int64, positions ...int) {
+	t.Helper()
+	for _, pos := range positions {
+		if _, err := g.db.Exec(`INSERT INTO game_properties (game_id, position, owner_id) VALUES (?, ?, ?)`, g.id, pos, ownerID); err != nil {
+			t.Fatalf("Failed to grant property %d: %v", pos, err)
+		}
+	}
+}
+
+func TestCreateGame_RejectsOutOfRangeMaxPlayers(t *testing.T) {
+	lobby, auth := newTestLobby(t)
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	for _, maxPlayers := range []int{0, 1, 9, 100} {
+		_, err := lobby.CreateGame(maxPlayers, store.GameRules{}, userID, "alice")
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeBadRequest {
+			t.Errorf("maxPlayers=%d: expected BAD_REQUEST, got %v", maxPlayers, err)
+		}
+	}
+
+	game, err := lobby.CreateGame(maxPlayersPerGame, store.GameRules{}, userID, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	if game.MaxPlayers != maxPlayersPerGame {
+		t.Errorf("Expected %d seats, got %d", maxPlayersPerGame, game.MaxPlayers)
+	}
+}
+
+func TestCreateGame_RejectsCreatorInAnotherGameWithoutLeavingOneBehind(t *testing.T) {
+	lobby, auth := newTestLobby(t)
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	first, err := lobby.CreateGame(4, store.GameRules{}, userID, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	_, err = lobby.CreateGame(4, store.GameRules{}, userID, "alice")
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeAlreadyInGame {
+		t.Fatalf("Expected ALREADY_IN_GAME for a second game, got %v", err)
+	}
+
+	games, total, err := lobby.ListGames(userID, store.GameListFilter{}, 0, 0)
+	if err != nil {
+		t.Fatalf("ListGames failed: %v", err)
+	}
+	if total != 1 || len(games) != 1 || games[0].ID != first.ID {
+		t.Errorf("Expected only the first game in the lobby, got %d games", total)
+	}
+
+	// Once the first game is gone the player may create another
+	if err := lobby.LeaveGame(first.ID, userID); err != nil {
+		t.Fatalf("LeaveGame failed: %v", err)
+	}
+	if _, err := lobby.CreateGame(4, store.GameRules{}, userID, "alice"); err != nil {
+		t.Errorf("Expected a new game once the first was left, got %v", err)
+	}
+}
+
+func TestCreateGame_LimitsActiveGamesEvenWhenCreatesRace(t *testing.T) {
+	db := newTestDB(t)
+	lobby, auth := NewLobby(store.NewSQLiteLobbyStore(db), 1), store.NewAuthStore(db)
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	const attempts = 8
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := lobby.CreateGame(4, store.GameRules{}, userID, "alice")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeTooManyGames {
+			t.Errorf("Expected TOO_MANY_GAMES, got %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one game created at the limit, got %d", created)
+	}
+	if active, err := store.NewSQLiteLobbyStore(db).CountActiveGamesForUser(userID); err != nil || active != 1 {
+		t.Errorf("Expected one active game, got %d (%v)", active, err)
+	}
+}
+
+func TestCreateGame_ValidatesCustomBoard(t *testing.T) {
+	lobby, auth := newTestLobby(t)
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	encode := func(edit func(spaces []BoardSpace) []BoardSpace) string {
+		spaces := append([]BoardSpace(nil), Board[:]...)
+		data, err := json.Marshal(edit(spaces))
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		return string(data)
+	}
+
+	malformed := map[string]string{
+		"not an array":   `{"spaces": []}`,
+		"too short":      encode(func(s []BoardSpace) []BoardSpace { return s[:39] }),
+		"negative price": encode(func(s []BoardSpace) []BoardSpace { s[39].Price = -1; return s }),
+		"moved jail":     encode(func(s []BoardSpace) []BoardSpace { s[10].Type = SpaceFreeParking; return s }),
+		"wrong position": encode(func(s []BoardSpace) []BoardSpace { s[5].Position = 6; return s }),
+		"free property":  encode(func(s []BoardSpace) []BoardSpace { s[1].Price = 0; return s }),
+	}
+	for name, board := range malformed {
+		_, err := lobby.CreateGame(4, store.GameRules{Board: board}, userID, "alice")
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeBadRequest {
+			t.Errorf("%s: expected BAD_REQUEST, got %v", name, err)
+		}
+	}
+
+	board := encode(func(s []BoardSpace) []BoardSpace {
+		s[39].Name = "Mayfair"
+		s[39].GroupSize = 0 // filled back in from the standard board
+		return s
+	})
+	game, err := lobby.CreateGame(4, store.GameRules{Board: board}, userID, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	stored, err := lobby.GetGameWithPlayers(game.ID, userID)
+	if err != nil {
+		t.Fatalf("GetGameWithPlayers failed: %v", err)
+	}
+	if !game.CustomBoard || !stored.CustomBoard {
+		t.Errorf("Expected the game to report a custom board, got %v and %v", game.CustomBoard, stored.CustomBoard)
+	}
+}
+
+func TestJoinGameWithToken_AssignsFreeTokensAndRejectsTakenOnes(t *testing.T) {
+	lobby, auth := newTestLobby(t)
+	ids := make(map[string]int64)
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		id, err := auth.CreateUser(name, "hash")
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		ids[name] = id
+	}
+
+	game, err := lobby.CreateGame(4, store.GameRules{}, ids["alice"], "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	if got := game.Players[0].Token; got != PlayerTokens[0] {
+		t.Errorf("Expected the host to get %q, got %q", PlayerTokens[0], got)
+	}
+
+	_, err = lobby.JoinGameWithToken(game.ID, ids["bob"], "bob", "rocket")
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeBadRequest {
+		t.Errorf("Expected an unknown token to be a bad request, got %v", err)
+	}
+	token, err := lobby.JoinGameWithToken(game.ID, ids["bob"], "bob", "dog")
+	if err != nil || token != "dog" {
+		t.Fatalf("Expected bob to join as dog, got %q, %v", token, err)
+	}
+	_, err = lobby.JoinGameWithToken(game.ID, ids["carol"], "carol", "dog")
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeConflict {
+		t.Errorf("Expected a taken token to be rejected, got %v", err)
+	}
+	// Joining again is a no-op that reports the token already held
+	if token, err := lobby.JoinGameWithToken(game.ID, ids["bob"], "bob", ""); err != nil || token != "dog" {
+		t.Errorf("Expected bob to still be the dog, got %q, %v", token, err)
+	}
+
+	token, err = lobby.JoinGameWithToken(game.ID, ids["carol"], "carol", "")
+	if err != nil || token != PlayerTokens[1] {
+		t.Fatalf("Expected carol to get %q, got %q, %v", PlayerTokens[1], token, err)
+	}
+	if err := lobby.JoinGame(game.ID, ids["dave"], "dave"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+
+	stored, err := lobby.GetGameWithPlayers(game.ID, ids["alice"])
+	if err != nil {
+		t.Fatalf("GetGameWithPlayers failed: %v", err)
+	}
+	want := []string{PlayerTokens[0], "dog", PlayerTokens[1], PlayerTokens[3]}
+	for i, p := range stored.Players {
+		if p.Token != want[i] {
+			t.Errorf("Player %s: expected token %q, got %q", p.Username, want[i], p.Token)
+		}
+	}
+}
@@ -1,14 +1,27 @@
 package game
 
+import "encoding/json"
+
 const (
 	StatusWaiting    = "waiting"
 	StatusInProgress = "in_progress"
 	StatusFinished   = "finished"
 )
 
+// Turn phases tell a client what the current player has left to do, so a
+// reconnecting tab restores the same prompt as every other tab
+const (
+	TurnPhaseAwaitingRoll        = "awaiting_roll"         // hasn't rolled, or rolled doubles
+	TurnPhaseAwaitingBuyDecision = "awaiting_buy_decision" // landed on an unowned property
+	TurnPhaseAwaitingTaxChoice   = "awaiting_tax_choice"   // landed on Income Tax, choosing flat or percent
+	TurnPhaseInAuction           = "in_auction"            // passed on it; the auction is running
+	TurnPhaseAwaitingEnd         = "awaiting_end"          // rolled and resolved, may build, trade or end the turn
+)
+
 type Player struct {
 	UserID        int64  `json:"userId"`
 	Username      string `json:"username"`
+	DisplayName   string `json:"displayName"` // what to show; the username unless the user set one
 	Order         int    `json:"order"`
 	IsReady       bool   `json:"isReady"`
 	IsCurrentTurn bool   `json:"isCurrentTurn"`
@@ -19,6 +32,13 @@ type Player struct {
 	PendingAction string `json:"pendingAction"`
 	InJail        bool   `json:"inJail"`
 	JailTurns     int    `json:"jailTurns"`
+	NetWorth      int    `json:"netWorth"` // cash + unmortgaged property + improvements
+	TaxBasis      int    `json:"-"`        // what income tax's percentage is of, see incomeTaxBasis
+	IsOnline      bool   `json:"isOnline"` // has a live game socket; filled in by the ws layer
+	Token         string `json:"token"`    // board piece, see PlayerTokens
+	// Completed turns and their average length, see TurnStats
+	TurnsTaken     int     `json:"turnsTaken"`
+	AvgTurnSeconds float64 `json:"avgTurnSeconds"`
 }
 
 type GameState struct {
@@ -26,11 +46,28 @@ type GameState struct {
 	Status              string           `json:"status"`
 	Players             []*Player        `json:"players"`
 	CurrentPlayerID     int64            `json:"currentPlayerId"`
+	TurnPhase           string           `json:"turnPhase,omitempty"` // see turnPhase; empty unless in progress
+	HostUserID          int64            `json:"hostUserId"` // earliest-joined remaining player
+	MinPlayers          int              `json:"minPlayers"`
+	ManualStart         bool             `json:"manualStart"` // only the host can start; readiness doesn't
 	MaxPlayers          int              `json:"maxPlayers"`
 	Properties          map[int]int64    `json:"properties"`
 	MortgagedProperties map[int]bool     `json:"mortgagedProperties"`
 	Improvements        map[int]int      `json:"improvements"` // position -> house count (1-4 houses, 5 = hotel)
 	Board               [40]BoardSpace   `json:"board"`
+	Round               int              `json:"round"`
+	TurnLimit           int              `json:"turnLimit"`        // 0 = no limit
+	TimeLimitMinutes    int              `json:"timeLimitMinutes"` // 0 = no limit
+	StartedAt           int64            `json:"startedAt"`        // unix seconds, 0 before start
+	WinnerID            int64            `json:"winnerId"`         // set once finished
+	StartingMoney       int              `json:"startingMoney"`    // money players join with
+	HouseLimit          int              `json:"houseLimit"`       // houses the bank started with
+	HotelLimit          int              `json:"hotelLimit"`
+	UnlimitedBuilding   bool             `json:"unlimitedBuilding"` // house rule: the limits don't apply
+	HousesLeft          int              `json:"housesLeft"` // in the bank; 0 with unlimited building
+	HotelsLeft          int              `json:"hotelsLeft"`
+	Seed                int64            `json:"-"`                // drives seeded randomness; admin-only, see seed.go
+	Protocol            string           `json:"protocol,omitempty"` // negotiated ws protocol, only on the game_state sent on connect
 }
 
 type Event struct {
@@ -48,13 +85,72 @@ type PlayerReadyPayload struct {
 	IsReady bool  `json:"isReady"`
 }
 
+// SettingsUpdatedPayload carries a waiting game's settings after the host
+// changed them. Players already seated now hold StartingMoney.
+type SettingsUpdatedPayload struct {
+	UpdatedBy        int64 `json:"updatedBy"`
+	MinPlayers       int   `json:"minPlayers"`
+	MaxPlayers       int   `json:"maxPlayers"`
+	StartingMoney    int   `json:"startingMoney"`
+	TurnLimit        int   `json:"turnLimit"`
+	TimeLimitMinutes int   `json:"timeLimitMinutes"`
+	ManualStart      bool  `json:"manualStart"`
+}
+
+// GameForceFinishedPayload tells clients an operator ended the game
+type GameForceFinishedPayload struct {
+	GameID int64  `json:"gameId"`
+	Reason string `json:"reason"`
+}
+
+// GameCancelledPayload tells clients the host called the game off before it started
+type GameCancelledPayload struct {
+	GameID int64 `json:"gameId"`
+	UserID int64 `json:"userId"` // the host who cancelled it
+}
+
+// StartCountdownPayload announces that the game starts in Seconds unless someone un-readies
+type StartCountdownPayload struct {
+	GameID  int64 `json:"gameId"`
+	Seconds int   `json:"seconds"`
+}
+
+// CountdownCancelledPayload is sent when a pending start is called off
+type CountdownCancelledPayload struct {
+	GameID int64 `json:"gameId"`
+	UserID int64 `json:"userId"` // player whose action cancelled it; 0 if the game stopped qualifying
+}
+
+// WaitingForPlayersPayload tells a readied player the game needs Needed more players to start
+type WaitingForPlayersPayload struct {
+	GameID int64 `json:"gameId"`
+	Needed int   `json:"needed"`
+}
+
 type GameStartedPayload struct {
 	CurrentPlayerID int64 `json:"currentPlayerId"`
 }
 
 type TurnChangedPayload struct {
-	PreviousPlayerID int64 `json:"previousPlayerId"`
-	CurrentPlayerID  int64 `json:"currentPlayerId"`
+	PreviousPlayerID int64  `json:"previousPlayerId"`
+	CurrentPlayerID  int64  `json:"currentPlayerId"`
+	Reason           string `json:"reason,omitempty"` // TurnEndSkipped, or empty for a normal end
+}
+
+// TurnEndSkipped marks a turn the player explicitly passed with skip_turn
+const TurnEndSkipped = "skipped"
+
+// JailBail is the fine for leaving jail without rolling doubles, paid by
+// choice before rolling or forced after the third failed roll
+const JailBail = 50
+
+// TurnStartedPayload lists the legal actions of the player whose turn it is
+type TurnStartedPayload struct {
+	UserID     int64 `json:"userId"`
+	CanRoll    bool  `json:"canRoll"`
+	CanBuy     bool  `json:"canBuy"`
+	CanEndTurn bool  `json:"canEndTurn"`
+	InJail     bool  `json:"inJail"`
 }
 
 type GameFinishedPayload struct {
@@ -91,6 +187,29 @@ type PropertyBoughtPayload struct {
 	NewMoney int    `json:"newMoney"`
 }
 
+// PropertyOwnershipChangedPayload is sent for each space that changes hands,
+// however it happened. Reason is one of the Ownership* constants; a zero
+// FromUserID or ToUserID is the bank.
+type PropertyOwnershipChangedPayload struct {
+	SpaceIndex int    `json:"spaceIndex"`
+	FromUserID int64  `json:"fromUserId"`
+	ToUserID   int64  `json:"toUserId"`
+	Reason     string `json:"reason"`
+}
+
+// MoneyTransferredPayload is sent for each payment an action makes, next to
+// the event describing the action. Reason is one of the Money* constants; a
+// zero FromUserID or ToUserID is the bank, and Bank is set when either side
+// is, so money entering or leaving circulation can be told from money
+// changing hands between players.
+type MoneyTransferredPayload struct {
+	FromUserID int64  `json:"fromUserId"`
+	ToUserID   int64  `json:"toUserId"`
+	Amount     int    `json:"amount"`
+	Reason     string `json:"reason"`
+	Bank       bool   `json:"bank"`
+}
+
 type PropertyPassedPayload struct {
 	UserID   int64  `json:"userId"`
 	Position int    `json:"position"`
@@ -110,8 +229,18 @@ type RentPaidPayload struct {
 type TaxPaidPayload struct {
 	UserID   int64  `json:"userId"`
 	Position int    `json:"position"`
-	Amount   int    `json:"amount"`
+	Amount   int    `json:"amount"` // what was actually charged
 	NewMoney int    `json:"newMoney"`
+	Choice   string `json:"choice,omitempty"` // income tax only: TaxChoiceFlat or TaxChoicePercent
+}
+
+// TaxPromptPayload asks a player who landed on Income Tax how to pay it
+type TaxPromptPayload struct {
+	UserID     int64  `json:"userId"`
+	Position   int    `json:"position"`
+	Name       string `json:"name"`
+	FlatAmount int    `json:"flatAmount"`
+	Percent    int    `json:"percent"`
 }
 
 type PlayerBankruptPayload struct {
@@ -168,12 +297,14 @@ type HouseBuiltPayload struct {
 }
 
 type HouseSoldPayload struct {
-	UserID     int64  `json:"userId"`
-	Position   int    `json:"position"`
-	Name       string `json:"name"`
-	HouseCount int    `json:"houseCount"`
-	Refund     int    `json:"refund"`
-	NewMoney   int    `json:"newMoney"`
+	UserID       int64  `json:"userId"`
+	Position     int    `json:"position"`
+	Name         string `json:"name"`
+	HouseCount   int    `json:"houseCount"` // a sold hotel leaves 4 houses
+	Refund       int    `json:"refund"`
+	NewMoney     int    `json:"newMoney"`
+	PreviousRent int    `json:"previousRent"` // rent on the property before the sale
+	Rent         int    `json:"rent"`         // rent landing on it charges now
 }
 
 type CardDrawnPayload struct {
@@ -201,7 +332,7 @@ type Trade struct {
 	FromUserID int64      `json:"fromUserId"`
 	ToUserID   int64      `json:"toUserId"`
 	Offer      TradeOffer `json:"offer"`
-	Status     string     `json:"status"` // "pending", "accepted", "declined", "cancelled"
+	Status     string     `json:"status"` // "pending", "accepted", "declined", "cancelled", "expired"
 }
 
 type TradeProposedPayload struct {
@@ -214,7 +345,16 @@ type TradeResponsePayload struct {
 	TradeID      int64  `json:"tradeId"`
 	FromUserID   int64  `json:"fromUserId"`
 	ToUserID     int64  `json:"toUserId"`
-	Status       string `json:"status"`
+	Status       string `json:"status"` // on trade_cancelled, "invalidated" if another trade took the properties
+	FromUsername string `json:"fromUsername"`
+	ToUsername   string `json:"toUsername"`
+}
+
+// TradeExpiredPayload is broadcast when a trade went unanswered for the trade TTL
+type TradeExpiredPayload struct {
+	TradeID      int64  `json:"tradeId"`
+	FromUserID   int64  `json:"fromUserId"`
+	ToUserID     int64  `json:"toUserId"`
 	FromUsername string `json:"fromUsername"`
 	ToUsername   string `json:"toUsername"`
 }
@@ -263,3 +403,42 @@ type AuctionEndedPayload struct {
 	FinalBid     int    `json:"finalBid"`
 	NoWinner     bool   `json:"noWinner"` // True if everyone passed
 }
+
+// TurnStat is how long a player has taken over their completed turns
+type TurnStat struct {
+	UserID         int64   `json:"userId"`
+	Username       string  `json:"username"`
+	TurnsTaken     int     `json:"turnsTaken"`
+	AvgTurnSeconds float64 `json:"avgTurnSeconds"` // 0 until a turn is completed
+}
+
+// Standing is one row of the in-game leaderboard
+type Standing struct {
+	UserID     int64  `json:"userId"`
+	Username   string `json:"username"`
+	Money      int    `json:"money"`
+	NetWorth   int    `json:"netWorth"`
+	IsBankrupt bool   `json:"isBankrupt"`
+	// Completed turns and their average length, see TurnStats
+	TurnsTaken     int     `json:"turnsTaken"`
+	AvgTurnSeconds float64 `json:"avgTurnSeconds"`
+}
+
+type StandingsUpdatedPayload struct {
+	Standings []Standing `json:"standings"` // sorted by net worth, highest first
+}
+
+// LoggedEvent is one entry of a game's replay log, as returned by the events endpoint
+type LoggedEvent struct {
+	Seq       int64           `json:"seq"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+// GameOverPayload is broadcast once a game finishes, after the result is committed
+type GameOverPayload struct {
+	WinnerUserID   int64      `json:"winnerUserId"` // 0 if nobody won
+	Reason         string     `json:"reason"`       // one of the EndReason* constants
+	FinalStandings []Standing `json:"finalStandings"`
+}
@@ -0,0 +1,28 @@
+package game
+
+// Why money moved, sent as MoneyTransferredPayload.Reason
+const (
+	MoneySalary     = "salary"     // $200 from the bank for passing GO
+	MoneyTax        = "tax"        // Income or Luxury Tax paid to the bank
+	MoneyPurchase   = "purchase"   // an unowned property bought from the bank, outright or at auction
+	MoneyJailFine   = "jail_fine"  // bail paid to the bank to leave jail
+	MoneyCard       = "card"       // a card paying out or charging, to or from the bank or other players
+	MoneyRent       = "rent"       // rent paid to the owner of the space landed on
+	MoneyBankruptcy = "bankruptcy" // cash left behind by a player going bankrupt to the bank, giving up or eliminated, or their buildings sold for their creditor
+)
+
+// moneyTransferred is the event sent alongside the event of an action that
+// moved money. A zero from or to is the bank.
+func moneyTransferred(gameID, from, to int64, amount int, reason string) *Event {
+	return &Event{
+		Type:   "money_transferred",
+		GameID: gameID,
+		Payload: MoneyTransferredPayload{
+			FromUserID: from,
+			ToUserID:   to,
+			Amount:     amount,
+			Reason:     reason,
+			Bank:       from == 0 || to == 0,
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package game
+
+import "database/sql"
+
+// Why a property changed hands, sent as PropertyOwnershipChangedPayload.Reason
+const (
+	OwnershipPurchase    = "purchase"    // bought from the bank after landing on it
+	OwnershipAuction     = "auction"     // won at auction
+	OwnershipTrade       = "trade"       // moved by an accepted trade
+	OwnershipBankruptcy  = "bankruptcy"  // handed to the creditor of a bankrupt player
+	OwnershipForeclosure = "foreclosure" // returned to the bank by a player who went bankrupt, gave up or timed out
+)
+
+// ownershipChanged is the event every transfer of a space emits. A zero
+// from or to is the bank.
+func ownershipChanged(gameID int64, position int, from, to int64, reason string) *Event {
+	return &Event{
+		Type:   "property_ownership_changed",
+		GameID: gameID,
+		Payload: PropertyOwnershipChangedPayload{
+			SpaceIndex: position,
+			FromUserID: from,
+			ToUserID:   to,
+			Reason:     reason,
+		},
+	}
+}
+
+// releasePropertiesTx takes all of a player's properties away, to the
+// creditor if there is one or back to the bank otherwise. Their houses and
+// hotels go back to the bank's supply either way. The creditor gets the bare
+// properties and what the bank pays for the buildings, half their cost as
+// when selling them.
+func (e *Engine) releasePropertiesTx(tx *sql.Tx, gameID, userID, creditorID int64) ([]*Event, error) {
+	positions, err := e.store.GetPlayerPropertiesTx(tx, gameID, userID)
+	if err != nil {
+		return nil, err
+	}
+	board, err := e.boardTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	proceeds := 0
+	for _, pos := range positions {
+		count, err := e.store.GetImprovementsTx(tx, gameID, pos)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			if err := e.store.SetImprovementsTx(tx, gameID, pos, 0); err != nil {
+				return nil, err
+			}
+			proceeds += count * (board[pos].HouseCost / 2)
+		}
+	}
+
+	reason := OwnershipForeclosure
+	if creditorID != 0 {
+		// Mortgaged properties transfer as-is
+		reason = OwnershipBankruptcy
+		err = e.store.TransferAllPropertiesTx(tx, gameID, userID, creditorID)
+	} else {
+		err = e.store.DeletePlayerPropertiesTx(tx, gameID, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*Event, 0, len(positions)+1)
+	for _, pos := range positions {
+		events = append(events, ownershipChanged(gameID, pos, userID, creditorID, reason))
+	}
+
+	if creditorID != 0 && proceeds > 0 {
+		creditor, err := e.store.GetPlayerTx(tx, gameID, creditorID)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.store.UpdatePlayerMoneyTx(tx, gameID, creditorID, creditor.Money+proceeds); err != nil {
+			return nil, err
+		}
+		events = append(events, moneyTransferred(gameID, 0, creditorID, proceeds, MoneyBankruptcy))
+	}
+	return events, nil
+}
@@ -0,0 +1,78 @@
+package game
+
+import "monopoly/errors"
+
+// PropertyDetails is a board space merged with its live ownership and rent
+type PropertyDetails struct {
+	BoardSpace
+	OwnerID       int64  `json:"ownerId"` // 0 = unowned
+	OwnerUsername string `json:"ownerUsername,omitempty"`
+	IsMortgaged   bool   `json:"isMortgaged"`
+	Improvements  int    `json:"improvements"` // 1-4 houses, 5 = hotel
+	HasMonopoly   bool   `json:"hasMonopoly"`  // owner holds the whole color group
+	// CurrentRent is what landing here costs right now: 0 if unowned,
+	// mortgaged or the owner is bankrupt. Utility rent depends on the roll,
+	// so utilities report DiceMultiplier instead.
+	CurrentRent    int `json:"currentRent"`
+	DiceMultiplier int `json:"diceMultiplier,omitempty"`
+}
+
+// GetPropertyDetails returns the space at position with its owner,
+// improvements and the rent a player landing on it would pay, computed the
+// same way as resolveSpaceLanding.
+func (e *Engine) GetPropertyDetails(gameID int64, position int) (*PropertyDetails, error) {
+	if position < 0 || position >= len(Board) {
+		return nil, errors.BadRequest("Invalid board position")
+	}
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	space := state.Board[position]
+	details := &PropertyDetails{
+		BoardSpace:   space,
+		OwnerID:      state.Properties[position],
+		IsMortgaged:  state.MortgagedProperties[position],
+		Improvements: state.Improvements[position],
+	}
+	if details.OwnerID == 0 {
+		return details, nil
+	}
+
+	var owner *Player
+	for _, p := range state.Players {
+		if p.UserID == details.OwnerID {
+			owner = p
+			break
+		}
+	}
+	if owner != nil {
+		details.OwnerUsername = owner.Username
+	}
+
+	var ownerProps []int
+	colorCount := 0
+	for pos, ownerID := range state.Properties {
+		if ownerID != details.OwnerID {
+			continue
+		}
+		ownerProps = append(ownerProps, pos)
+		if space.Type == SpaceProperty && state.Board[pos].Color == space.Color {
+			colorCount++
+		}
+	}
+	details.HasMonopoly = space.Type == SpaceProperty && colorCount >= space.GroupSize
+
+	// Landing on a mortgaged property or one held by a bankrupt owner is free
+	if details.IsMortgaged || (owner != nil && owner.IsBankrupt) {
+		return details, nil
+	}
+	if space.Type == SpaceUtility {
+		details.DiceMultiplier = CalculateRent(space, ownerProps, 1, details.Improvements)
+		return details, nil
+	}
+	details.CurrentRent = CalculateRent(space, ownerProps, 0, details.Improvements)
+	return details, nil
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"encoding/json"
+	"monopoly/errors"
+)
+
+const (
+	// SnapshotRolls is how many recent rolls a game snapshot carries
+	SnapshotRolls = 10
+	// MaxRecentRolls caps how many rolls a single fetch returns
+	MaxRecentRolls = 100
+)
+
+// rollEvents are the logged events that carry a dice result. A failed roll
+// in jail is still a roll, so it counts.
+var rollEvents = []string{"dice_rolled", "jail_roll_failed"}
+
+// DiceRoll is one entry of a game's roll history, taken from the event log
+type DiceRoll struct {
+	Seq       int64  `json:"seq"` // event log position of the roll
+	UserID    int64  `json:"userId"`
+	Username  string `json:"username"`
+	Die1      int    `json:"die1"`
+	Die2      int    `json:"die2"`
+	Total     int    `json:"total"`
+	IsDoubles bool   `json:"isDoubles"`
+	InJail    bool   `json:"inJail"` // a failed attempt to roll out of jail
+	CreatedAt string `json:"createdAt"`
+}
+
+// RecentRolls returns the game's latest dice rolls, newest first. A
+// non-positive limit or one above MaxRecentRolls returns MaxRecentRolls.
+func (e *Engine) RecentRolls(gameID int64, limit int) ([]DiceRoll, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > MaxRecentRolls {
+		limit = MaxRecentRolls
+	}
+	return e.recentRolls(state, limit)
+}
+
+// recentRolls reads the last limit rolls from the event log, naming each
+// roller from state
+func (e *Engine) recentRolls(state *GameState, limit int) ([]DiceRoll, error) {
+	rows, err := e.store.GetRecentEvents(state.ID, limit, rollEvents...)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int64]string, len(state.Players))
+	for _, p := range state.Players {
+		names[p.UserID] = p.Username
+	}
+
+	rolls := make([]DiceRoll, 0, len(rows))
+	for _, r := range rows {
+		var payload struct {
+			UserID int64 `json:"userId"`
+			Die1   int   `json:"die1"`
+			Die2   int   `json:"die2"`
+		}
+		if err := json.Unmarshal([]byte(r.PayloadJSON), &payload); err != nil {
+			return nil, errors.Wrap(err, errors.ErrCodeInternal, "Failed to read roll history")
+		}
+		rolls = append(rolls, DiceRoll{
+			Seq:       r.Seq,
+			UserID:    payload.UserID,
+			Username:  names[payload.UserID],
+			Die1:      payload.Die1,
+			Die2:      payload.Die2,
+			Total:     payload.Die1 + payload.Die2,
+			IsDoubles: payload.Die1 == payload.Die2,
+			InJail:    r.Type == "jail_roll_failed",
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	return rolls, nil
+}
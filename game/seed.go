@@ -0,0 +1,68 @@
+package game
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+)
+
+// NewGameSeed returns a random seed to store with a new game. It fits in 53
+// bits so it survives a round trip through JSON numbers.
+func NewGameSeed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		// crypto/rand only fails if the OS entropy source is broken
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]) >> 11)
+}
+
+// SetSeededRandomness makes dice and deck shuffles come from each game's
+// stored seed, so a game can be replayed locally from its seed and event log.
+// Off by default: anyone who learns a seed can predict that game's rolls.
+func (e *Engine) SetSeededRandomness(on bool) {
+	e.seeded = on
+}
+
+// GameSeed returns the seed stored for the game
+func (e *Engine) GameSeed(gameID int64) (int64, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return 0, err
+	}
+	return state.Seed, nil
+}
+
+// gameRand returns the game's seeded generator, creating it on first use, or
+// nil if seeded randomness is off. The sequence restarts from the seed when
+// the server restarts.
+func (e *Engine) gameRand(gameID, seed int64) *rand.Rand {
+	if !e.seeded {
+		return nil
+	}
+
+	e.rngMu.Lock()
+	defer e.rngMu.Unlock()
+	rng, ok := e.rngs[gameID]
+	if !ok {
+		rng = rand.New(rand.NewSource(seed))
+		e.rngs[gameID] = rng
+	}
+	return rng
+}
+
+// forgetGameRand drops a finished game's generator
+func (e *Engine) forgetGameRand(gameID int64) {
+	e.rngMu.Lock()
+	delete(e.rngs, gameID)
+	e.rngMu.Unlock()
+}
+
+// rollDie rolls one die from the game's seeded generator if there is one,
+// otherwise from the engine's RandSource
+func (e *Engine) rollDie(rng *rand.Rand) int {
+	if rng != nil {
+		return rng.Intn(6) + 1
+	}
+	return e.dice.Roll()
+}
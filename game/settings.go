@@ -0,0 +1,138 @@
+package game
+
+import (
+	"fmt"
+	"monopoly/errors"
+	"monopoly/store"
+)
+
+const (
+	// DefaultStartingMoney is what players join a new game with
+	DefaultStartingMoney = 1500
+
+	// Bounds for the starting money the host can choose
+	MinStartingMoney = 100
+	MaxStartingMoney = 10000
+)
+
+// GameSettings is a change to a waiting game's settings. Nil fields keep
+// their current value.
+type GameSettings struct {
+	MinPlayers       *int
+	MaxPlayers       *int
+	StartingMoney    *int
+	TurnLimit        *int
+	TimeLimitMinutes *int
+	ManualStart      *bool
+}
+
+// apply returns current with the changed fields replaced
+func (s GameSettings) apply(current store.GameSettings) store.GameSettings {
+	if s.MinPlayers != nil {
+		current.MinPlayers = *s.MinPlayers
+	}
+	if s.MaxPlayers != nil {
+		current.MaxPlayers = *s.MaxPlayers
+	}
+	if s.StartingMoney != nil {
+		current.StartingMoney = *s.StartingMoney
+	}
+	if s.TurnLimit != nil {
+		current.TurnLimit = *s.TurnLimit
+	}
+	if s.TimeLimitMinutes != nil {
+		current.TimeLimitMinutes = *s.TimeLimitMinutes
+	}
+	if s.ManualStart != nil {
+		current.ManualStart = *s.ManualStart
+	}
+	return current
+}
+
+// checkGameSettings applies the same limits as Lobby.CreateGame
+func checkGameSettings(s store.GameSettings) error {
+	if s.MaxPlayers < minPlayersPerGame || s.MaxPlayers > maxPlayersPerGame {
+		return errors.BadRequest(fmt.Sprintf("maxPlayers must be between %d and %d", minPlayersPerGame, maxPlayersPerGame))
+	}
+	if s.MinPlayers < minPlayersPerGame || s.MinPlayers > maxPlayersPerGame {
+		return errors.BadRequest(fmt.Sprintf("minPlayers must be between %d and %d", minPlayersPerGame, maxPlayersPerGame))
+	}
+	if s.MinPlayers > s.MaxPlayers {
+		return errors.BadRequest("minPlayers cannot exceed maxPlayers")
+	}
+	if s.StartingMoney < MinStartingMoney || s.StartingMoney > MaxStartingMoney {
+		return errors.BadRequest(fmt.Sprintf("startingMoney must be between %d and %d", MinStartingMoney, MaxStartingMoney))
+	}
+	if s.TurnLimit < 0 || s.TurnLimit > MaxTurnLimit {
+		return errors.BadRequest(fmt.Sprintf("turnLimit must be between 0 and %d", MaxTurnLimit))
+	}
+	if s.TimeLimitMinutes < 0 || s.TimeLimitMinutes > MaxTimeLimitMinutes {
+		return errors.BadRequest(fmt.Sprintf("timeLimitMinutes must be between 0 and %d", MaxTimeLimitMinutes))
+	}
+	return nil
+}
+
+// UpdateGameSettings lets the host change a game's seats, starting money and
+// rules until it starts. Players who already joined get the new starting
+// money. maxPlayers can't go below the number of players who have joined.
+func (e *Engine) UpdateGameSettings(gameID, hostUserID int64, settings GameSettings) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireStatus(state.Status, StatusWaiting); err != nil {
+		return nil, err
+	}
+	if !state.hasPlayer(hostUserID) {
+		return nil, errors.NotInGame()
+	}
+	if hostUserID != state.HostUserID {
+		return nil, errors.New(errors.ErrCodeForbidden, "Only the host can change the settings")
+	}
+
+	current := store.GameSettings{
+		MinPlayers:       state.MinPlayers,
+		MaxPlayers:       state.MaxPlayers,
+		StartingMoney:    state.StartingMoney,
+		TurnLimit:        state.TurnLimit,
+		TimeLimitMinutes: state.TimeLimitMinutes,
+		ManualStart:      state.ManualStart,
+	}
+	updated := settings.apply(current)
+	if updated == current {
+		return nil, errors.BadRequest("No settings changed")
+	}
+	if err := checkGameSettings(updated); err != nil {
+		return nil, err
+	}
+	if updated.MaxPlayers < len(state.Players) {
+		return nil, errors.BadRequest(fmt.Sprintf("maxPlayers cannot be below the %d players who joined", len(state.Players)))
+	}
+
+	// Joins go through the lobby without this lock, so the store re-checks
+	// the seats and status
+	ok, err := e.store.UpdateGameSettings(gameID, updated)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(errors.ErrCodeConflict, "The game changed while updating its settings; please try again")
+	}
+
+	return &Event{
+		Type:   "settings_updated",
+		GameID: gameID,
+		Payload: SettingsUpdatedPayload{
+			UpdatedBy:        hostUserID,
+			MinPlayers:       updated.MinPlayers,
+			MaxPlayers:       updated.MaxPlayers,
+			StartingMoney:    updated.StartingMoney,
+			TurnLimit:        updated.TurnLimit,
+			TimeLimitMinutes: updated.TimeLimitMinutes,
+			ManualStart:      updated.ManualStart,
+		},
+	}, nil
+}
@@ -0,0 +1,46 @@
+package game
+
+import "encoding/json"
+
+// GameSnapshot is everything an observer needs to draw a game in one call:
+// the live state plus what the room has seen but the state doesn't keep
+type GameSnapshot struct {
+	*GameState
+	LastRoll     *DiceRolledPayload `json:"lastRoll,omitempty"` // most recent roll in the game
+	RecentRolls  []DiceRoll         `json:"recentRolls"`        // up to SnapshotRolls, newest first
+	LastEventSeq int64              `json:"lastEventSeq"`       // poll /events?since= from here
+}
+
+// GetGameSnapshot returns the game's state with its recent dice rolls and
+// event log position. Works for games in any status.
+func (e *Engine) GetGameSnapshot(gameID int64) (*GameSnapshot, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &GameSnapshot{GameState: state}
+
+	last, err := e.store.GetLastEvent(gameID, "")
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		snapshot.LastEventSeq = last.Seq
+	}
+
+	roll, err := e.store.GetLastEvent(gameID, "dice_rolled")
+	if err != nil {
+		return nil, err
+	}
+	if roll != nil {
+		snapshot.LastRoll = &DiceRolledPayload{}
+		if err := json.Unmarshal([]byte(roll.PayloadJSON), snapshot.LastRoll); err != nil {
+			return nil, err
+		}
+	}
+
+	if snapshot.RecentRolls, err = e.recentRolls(state, SnapshotRolls); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
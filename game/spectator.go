@@ -0,0 +1,80 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"monopoly/errors"
+)
+
+// spectatorTokenBytes is the entropy behind a spectator token
+const spectatorTokenBytes = 24
+
+// CreateSpectatorToken issues a token that lets anyone watch the game
+// read-only without an account, e.g. an audience on a stream. Only the game's
+// players can issue one; it works until revoked or the game finishes.
+func (e *Engine) CreateSpectatorToken(gameID, userID int64) (string, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return "", err
+	}
+	if state.Status == StatusFinished {
+		return "", errors.GameFinished()
+	}
+	if !state.hasPlayer(userID) {
+		return "", errors.NotPlayer()
+	}
+
+	b := make([]byte, spectatorTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if err := e.store.CreateSpectatorToken(gameID, userID, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeSpectatorToken stops a token from admitting new spectators. Any of
+// the game's players can revoke any of its tokens.
+func (e *Engine) RevokeSpectatorToken(gameID, userID int64, token string) error {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return err
+	}
+	if !state.hasPlayer(userID) {
+		return errors.NotPlayer()
+	}
+
+	revoked, err := e.store.RevokeSpectatorToken(gameID, token)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New(errors.ErrCodeNotFound, "Spectator link not found")
+	}
+	return nil
+}
+
+// SpectatorGame returns the game a spectator token admits to
+func (e *Engine) SpectatorGame(token string) (int64, error) {
+	gameID, err := e.store.GetSpectatorTokenGame(token)
+	if err != nil {
+		return 0, err
+	}
+	if gameID == 0 {
+		return 0, errors.New(errors.ErrCodeForbidden, "This spectator link is invalid or has expired")
+	}
+	return gameID, nil
+}
+
+// hasPlayer reports whether the user has a seat in the game
+func (s *GameState) hasPlayer(userID int64) bool {
+	for _, p := range s.Players {
+		if p.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
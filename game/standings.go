@@ -0,0 +1,121 @@
+package game
+
+import (
+	"monopoly/errors"
+	"monopoly/store"
+	"sort"
+)
+
+// standingsEvents are the event types that move money or property ownership
+// and therefore warrant a fresh standings snapshot.
+var standingsEvents = map[string]bool{
+	"dice_rolled":                true, // passing GO
+	"rent_paid":                  true,
+	"tax_paid":                   true,
+	"card_drawn":                 true,
+	"jail_escape":                true, // bail
+	"property_bought":            true,
+	"auction_ended":              true,
+	"house_built":                true,
+	"hotel_built":                true,
+	"house_sold":                 true,
+	"property_mortgaged":         true,
+	"property_unmortgaged":       true,
+	"trade_accepted":             true,
+	"player_bankrupt":            true,
+	"property_ownership_changed": true,
+	"money_transferred":          true,
+}
+
+// AffectsStandings reports whether an event can change players' net worth
+func AffectsStandings(eventType string) bool {
+	return standingsEvents[eventType]
+}
+
+// calculateNetWorth sums a player's cash, the price of their unmortgaged
+// properties and the build cost of their houses/hotels (a hotel counts as 5 houses).
+func calculateNetWorth(board *[40]BoardSpace, userID int64, money int, properties map[int]int64, mortgaged map[int]bool, improvements map[int]int) int {
+	total := money
+	for pos, ownerID := range properties {
+		if ownerID != userID {
+			continue
+		}
+		if !mortgaged[pos] {
+			total += board[pos].Price
+		}
+		total += improvements[pos] * board[pos].HouseCost
+	}
+	return total
+}
+
+// GetPlayerNetWorth returns the player's net worth, or 0 if the game or player can't be found
+func (e *Engine) GetPlayerNetWorth(gameID, userID int64) int {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return 0
+	}
+	for _, p := range state.Players {
+		if p.UserID == userID {
+			return p.NetWorth
+		}
+	}
+	return 0
+}
+
+// GetStandings returns every player ordered by net worth, highest first
+func (e *Engine) GetStandings(gameID int64) ([]Standing, error) {
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := make([]Standing, len(state.Players))
+	for i, p := range state.Players {
+		standings[i] = Standing{
+			UserID:     p.UserID,
+			Username:   p.Username,
+			Money:      p.Money,
+			NetWorth:   p.NetWorth,
+			IsBankrupt: p.IsBankrupt,
+
+			TurnsTaken:     p.TurnsTaken,
+			AvgTurnSeconds: p.AvgTurnSeconds,
+		}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].NetWorth > standings[j].NetWorth
+	})
+	return standings, nil
+}
+
+// TurnStats returns each player's completed turns and average turn length, in
+// turn order. A turn is timed from when it's handed to the player until it's
+// handed on, so turns that end the game aren't counted.
+func (e *Engine) TurnStats(gameID int64) ([]TurnStat, error) {
+	game, err := e.store.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+
+	players, err := e.store.GetGamePlayers(gameID)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]TurnStat, len(players))
+	for i, p := range players {
+		stats[i] = TurnStat{UserID: p.UserID, Username: p.Username, TurnsTaken: p.TurnsTaken, AvgTurnSeconds: avgTurnSeconds(p)}
+	}
+	return stats, nil
+}
+
+// avgTurnSeconds is the player's average completed turn length, 0 until they
+// complete one
+func avgTurnSeconds(p *store.GamePlayer) float64 {
+	if p.TurnsTaken == 0 {
+		return 0
+	}
+	return float64(p.TurnSeconds) / float64(p.TurnsTaken)
+}
@@ -0,0 +1,68 @@
+package game
+
+import (
+	"sync"
+	"time"
+)
+
+// StartCountdown delays the start of a game once every player is ready,
+// so anyone can un-ready and cancel. At most one countdown runs per game.
+type StartCountdown struct {
+	mu     sync.Mutex
+	timers map[int64]*time.Timer // gameID -> pending start
+}
+
+func NewStartCountdown() *StartCountdown {
+	return &StartCountdown{
+		timers: make(map[int64]*time.Timer),
+	}
+}
+
+// Start schedules onFire after d. It returns false and does nothing if a
+// countdown is already running for the game.
+func (sc *StartCountdown) Start(gameID int64, d time.Duration, onFire func()) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if _, running := sc.timers[gameID]; running {
+		return false
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		sc.mu.Lock()
+		// A cancel (or cancel + restart) may have raced with this timer firing
+		if sc.timers[gameID] != timer {
+			sc.mu.Unlock()
+			return
+		}
+		delete(sc.timers, gameID)
+		sc.mu.Unlock()
+
+		onFire()
+	})
+	sc.timers[gameID] = timer
+	return true
+}
+
+// Cancel stops the game's countdown. It reports whether one was running.
+func (sc *StartCountdown) Cancel(gameID int64) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	timer, running := sc.timers[gameID]
+	if !running {
+		return false
+	}
+	timer.Stop()
+	delete(sc.timers, gameID)
+	return true
+}
+
+// Running reports whether a countdown is pending for the game
+func (sc *StartCountdown) Running(gameID int64) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	_, running := sc.timers[gameID]
+	return running
+}
@@ -0,0 +1,45 @@
+package game
+
+import (
+	stderrors "errors"
+	"fmt"
+	"monopoly/errors"
+)
+
+// ErrWrongGameStatus is in the chain of every error requireStatus returns,
+// so callers can tell an action sent to a game in the wrong state (a stale
+// client rolling in a finished game, say) from other rejections with
+// errors.Is
+var ErrWrongGameStatus = stderrors.New("wrong game status")
+
+// requireStatus rejects an action that needs the game to be in status want.
+// The code says where the game actually is, as clients already expect:
+// GAME_FINISHED, GAME_NOT_STARTED or GAME_STARTED.
+func requireStatus(status, want string) error {
+	if status == want {
+		return nil
+	}
+
+	var err *errors.AppError
+	switch {
+	case status == StatusFinished:
+		err = errors.GameFinished()
+	case want == StatusInProgress:
+		err = errors.GameNotStarted()
+	default:
+		err = errors.GameAlreadyStarted()
+	}
+	err.Detail = fmt.Sprintf("game is %s, action needs %s", status, want)
+	err.Err = ErrWrongGameStatus
+	return err
+}
+
+// requireTurn rejects a board action (rolling, buying, building, mortgaging,
+// paying tax or bail, ending the turn) from anyone but the player whose turn
+// it is. Trades and auction bids are open to every player and don't use it.
+func requireTurn(isCurrentTurn bool) error {
+	if isCurrentTurn {
+		return nil
+	}
+	return errors.NotYourTurn()
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"database/sql"
+	"monopoly/errors"
+)
+
+const (
+	// IncomeTaxPosition is the one tax space where players choose between the
+	// flat amount and IncomeTaxPercent of their tax basis
+	IncomeTaxPosition = 4
+	IncomeTaxPercent  = 10
+
+	TaxChoiceFlat    = "flat"
+	TaxChoicePercent = "percent"
+)
+
+// incomeTax is what a player pays for their choice. The percentage is of
+// their tax basis (see incomeTaxBasis), rounded to the nearest dollar.
+func incomeTax(flatAmount, taxBasis int, choice string) int {
+	if choice == TaxChoicePercent {
+		return (taxBasis*IncomeTaxPercent + 50) / 100
+	}
+	return flatAmount
+}
+
+// incomeTaxBasis is what the percentage option is taken of under the official
+// rules: cash, the printed price of every property the player owns, mortgaged
+// or not, and the cost of their buildings (a hotel counts as 5 houses). Unlike
+// net worth in the standings, mortgaging doesn't lower it.
+func incomeTaxBasis(board *[40]BoardSpace, userID int64, money int, properties map[int]int64, improvements map[int]int) int {
+	total := money
+	for pos, ownerID := range properties {
+		if ownerID != userID {
+			continue
+		}
+		total += board[pos].Price + improvements[pos]*board[pos].HouseCost
+	}
+	return total
+}
+
+// payTaxTx charges a tax, or bankrupts the player to the bank if they can't
+// afford it. choice is only set for income tax.
+func (e *Engine) payTaxTx(tx *sql.Tx, gameID, userID int64, username string, currentMoney, position, amount int, choice string) ([]*Event, error) {
+	if currentMoney < amount {
+		return e.handleBankruptcyTx(tx, gameID, userID, username, "tax", 0)
+	}
+
+	newMoney := currentMoney - amount
+	if err := e.store.UpdatePlayerMoneyTx(tx, gameID, userID, newMoney); err != nil {
+		return nil, err
+	}
+	return []*Event{{
+		Type:   "tax_paid",
+		GameID: gameID,
+		Payload: TaxPaidPayload{
+			UserID:   userID,
+			Position: position,
+			Amount:   amount,
+			NewMoney: newMoney,
+			Choice:   choice,
+		},
+	}, moneyTransferred(gameID, userID, 0, amount, MoneyTax)}, nil
+}
+
+// PayIncomeTax settles the income tax the current player was prompted for,
+// either the flat amount or IncomeTaxPercent of their tax basis. Like buying
+// a property it ends the turn unless the player rolled doubles.
+func (e *Engine) PayIncomeTax(gameID, userID int64, choice string) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+	return e.payIncomeTax(gameID, userID, choice, true)
+}
+
+// chargeUndecidedIncomeTax makes a player whose turn timed out before they
+// chose pay the flat amount, leaving the turn for ForceEndTurn to end.
+func (e *Engine) chargeUndecidedIncomeTax(gameID, userID int64) ([]*Event, error) {
+	defer e.lockGame(gameID)()
+	events, err := e.payIncomeTax(gameID, userID, TaxChoiceFlat, false)
+	if err != nil && errors.From(err).Code == errors.ErrCodeNoTaxDue {
+		return nil, nil
+	}
+	return events, err
+}
+
+func (e *Engine) payIncomeTax(gameID, userID int64, choice string, endTurn bool) ([]*Event, error) {
+	if choice != TaxChoiceFlat && choice != TaxChoicePercent {
+		return nil, errors.InvalidTaxChoice()
+	}
+
+	state, err := e.GetGameState(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStatus(state.Status, StatusInProgress); err != nil {
+		return nil, err
+	}
+	if err := requireTurn(state.CurrentPlayerID == userID); err != nil {
+		return nil, err
+	}
+
+	var player *Player
+	for _, p := range state.Players {
+		if p.UserID == userID {
+			player = p
+			break
+		}
+	}
+	if player == nil {
+		return nil, errors.NotInGame()
+	}
+	if player.PendingAction != "tax_choice" {
+		return nil, errors.NoTaxDue()
+	}
+
+	space := state.Board[player.Position]
+	amount := incomeTax(space.TaxAmount, player.TaxBasis, choice)
+
+	tx, err := e.store.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer e.store.RollbackTx(tx)
+
+	if err := e.store.SetPlayerPendingActionTx(tx, gameID, userID, ""); err != nil {
+		return nil, err
+	}
+	events, err := e.payTaxTx(tx, gameID, userID, player.Username, player.Money, space.Position, amount, choice)
+	if err != nil {
+		return nil, err
+	}
+
+	// A bankrupt player's turn ends even after doubles
+	if endTurn && (e.doubles(gameID) == 0 || player.Money < amount) {
+		game, err := e.store.GetGameTx(tx, gameID)
+		if err != nil {
+			return nil, err
+		}
+		if game != nil && game.Status == StatusInProgress {
+			turnEvent, err := e.endTurnInternalTx(tx, gameID, userID)
+			if err != nil {
+				return nil, err
+			}
+			if turnEvent != nil {
+				events = append(events, turnEvent)
+			}
+		}
+	}
+
+	if err := e.store.CommitTx(tx); err != nil {
+		return nil, err
+	}
+	e.auditMoney(gameID, events)
+	return events, nil
+}
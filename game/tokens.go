@@ -0,0 +1,34 @@
+package game
+
+import (
+	"fmt"
+	"monopoly/errors"
+	"slices"
+	"strings"
+)
+
+// PlayerTokens are the pieces players can move around the board, one per player
+// in a game. There are as many as a game has seats; players who don't pick
+// one get the first nobody else in the game has.
+var PlayerTokens = []string{
+	"top_hat",
+	"car",
+	"dog",
+	"ship",
+	"boot",
+	"thimble",
+	"iron",
+	"wheelbarrow",
+}
+
+// tokenChoices is what a player joining with token may end up with: that
+// token, or any when they didn't pick one
+func tokenChoices(token string) ([]string, error) {
+	if token == "" {
+		return PlayerTokens, nil
+	}
+	if !slices.Contains(PlayerTokens, token) {
+		return nil, errors.BadRequest(fmt.Sprintf("token must be one of %s", strings.Join(PlayerTokens, ", ")))
+	}
+	return []string{token}, nil
+}
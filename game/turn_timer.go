@@ -14,6 +14,11 @@ type TurnTimer struct {
 	timers           map[int64]*time.Timer    // gameID -> timer
 	timeoutCounts    map[int64]map[int64]int  // gameID -> userID -> consecutive timeout count
 	currentPlayerIDs map[int64]int64          // gameID -> current player ID (for tracking)
+	deadlines        map[int64]time.Time      // gameID -> when the running turn timer fires
+	callbacks        map[int64]func(*Event)   // gameID -> onTimeout of the running timer
+	paused           map[int64]time.Duration  // gameID -> turn time left when the player dropped
+	pausedAt         map[int64]time.Time      // gameID -> when the paused turn's player dropped
+	graceUsed        map[int64]time.Duration  // gameID -> reconnect grace spent this turn
 	mu               sync.Mutex
 	engine           *Engine
 }
@@ -24,6 +29,11 @@ func NewTurnTimer(engine *Engine) *TurnTimer {
 		timers:           make(map[int64]*time.Timer),
 		timeoutCounts:    make(map[int64]map[int64]int),
 		currentPlayerIDs: make(map[int64]int64),
+		deadlines:        make(map[int64]time.Time),
+		callbacks:        make(map[int64]func(*Event)),
+		paused:           make(map[int64]time.Duration),
+		pausedAt:         make(map[int64]time.Time),
+		graceUsed:        make(map[int64]time.Duration),
 		engine:           engine,
 	}
 }
@@ -35,10 +45,8 @@ func (tt *TurnTimer) StartTurn(gameID, currentPlayerID int64, onTimeout func(*Ev
 	defer tt.mu.Unlock()
 
 	// Cancel any existing timer for this game
-	if existingTimer, ok := tt.timers[gameID]; ok {
-		existingTimer.Stop()
-		delete(tt.timers, gameID)
-	}
+	tt.stopLocked(gameID)
+	delete(tt.graceUsed, gameID)
 
 	// Track current player
 	tt.currentPlayerIDs[gameID] = currentPlayerID
@@ -48,68 +56,182 @@ func (tt *TurnTimer) StartTurn(gameID, currentPlayerID int64, onTimeout func(*Ev
 		tt.timeoutCounts[gameID] = make(map[int64]int)
 	}
 
-	// Create new timer
-	timer := time.AfterFunc(TurnTimeout, func() {
-		log.Printf("Turn timeout for game %d, player %d", gameID, currentPlayerID)
+	tt.scheduleLocked(gameID, currentPlayerID, TurnTimeout, onTimeout)
+}
 
+// scheduleLocked arms the timer that times out currentPlayerID's turn after
+// d. The caller holds tt.mu.
+func (tt *TurnTimer) scheduleLocked(gameID, currentPlayerID int64, d time.Duration, onTimeout func(*Event)) {
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
 		tt.mu.Lock()
-		// Increment timeout count for this player
-		if tt.timeoutCounts[gameID] == nil {
-			tt.timeoutCounts[gameID] = make(map[int64]int)
+		// A newer timer replaced this one as it fired
+		if tt.timers[gameID] != timer {
+			tt.mu.Unlock()
+			return
 		}
-		tt.timeoutCounts[gameID][currentPlayerID]++
-		timeoutCount := tt.timeoutCounts[gameID][currentPlayerID]
+		delete(tt.timers, gameID)
+		delete(tt.paused, gameID)
+		delete(tt.pausedAt, gameID)
 		tt.mu.Unlock()
 
-		log.Printf("Player %d has %d consecutive timeouts", currentPlayerID, timeoutCount)
+		tt.expire(gameID, currentPlayerID, onTimeout)
+	})
 
-		var event *Event
-		var err error
+	tt.timers[gameID] = timer
+	tt.deadlines[gameID] = time.Now().Add(d)
+	tt.callbacks[gameID] = onTimeout
+}
 
-		// Check if player should be eliminated (3 consecutive timeouts)
-		if timeoutCount >= MaxConsecutiveTimeouts {
-			log.Printf("Player %d eliminated due to %d consecutive timeouts", currentPlayerID, timeoutCount)
-			event, err = tt.engine.EliminatePlayerForTimeouts(gameID, currentPlayerID)
-		} else {
-			// Auto-skip the turn (force=true bypasses has_rolled/pending_action checks)
-			event, err = tt.engine.ForceEndTurn(gameID, currentPlayerID)
-		}
+// stopLocked stops the game's timer and forgets a paused turn. The caller
+// holds tt.mu.
+func (tt *TurnTimer) stopLocked(gameID int64) {
+	if timer, ok := tt.timers[gameID]; ok {
+		timer.Stop()
+		delete(tt.timers, gameID)
+	}
+	delete(tt.deadlines, gameID)
+	delete(tt.callbacks, gameID)
+	delete(tt.paused, gameID)
+	delete(tt.pausedAt, gameID)
+}
 
-		if err != nil {
-			log.Printf("Failed to handle timeout: %v", err)
-			return
-		}
+// expire times out currentPlayerID's turn: the turn is skipped, or the player
+// eliminated after MaxConsecutiveTimeouts in a row
+func (tt *TurnTimer) expire(gameID, currentPlayerID int64, onTimeout func(*Event)) {
+	log.Printf("Turn timeout for game %d, player %d", gameID, currentPlayerID)
+
+	tt.mu.Lock()
+	// Increment timeout count for this player
+	if tt.timeoutCounts[gameID] == nil {
+		tt.timeoutCounts[gameID] = make(map[int64]int)
+	}
+	tt.timeoutCounts[gameID][currentPlayerID]++
+	timeoutCount := tt.timeoutCounts[gameID][currentPlayerID]
+	tt.mu.Unlock()
+
+	log.Printf("Player %d has %d consecutive timeouts", currentPlayerID, timeoutCount)
+
+	var event *Event
+	var err error
+
+	// Check if player should be eliminated (3 consecutive timeouts)
+	if timeoutCount >= MaxConsecutiveTimeouts {
+		log.Printf("Player %d eliminated due to %d consecutive timeouts", currentPlayerID, timeoutCount)
+		event, err = tt.eliminate(gameID, currentPlayerID, onTimeout)
+	} else {
+		tt.chargeUndecidedIncomeTax(gameID, currentPlayerID, onTimeout)
+		// Auto-skip the turn (force=true bypasses has_rolled/pending_action checks)
+		event, err = tt.engine.ForceEndTurn(gameID, currentPlayerID)
+	}
+
+	if err != nil {
+		log.Printf("Failed to handle timeout: %v", err)
+		return
+	}
 
-		// Modify event to indicate it was a timeout
-		if event != nil {
-			// Add timeout flag to payload if possible
-			if payload, ok := event.Payload.(TurnChangedPayload); ok {
-				event.Type = "turn_timeout"
-				event.Payload = map[string]interface{}{
-					"previousPlayerId": payload.PreviousPlayerID,
-					"currentPlayerId":  payload.CurrentPlayerID,
-					"reason":           "timeout",
-					"timeoutCount":     timeoutCount,
-				}
-			} else if payload, ok := event.Payload.(GameFinishedPayload); ok {
-				// Game finished with timeout
-				event.Type = "game_finished"
-				event.Payload = payload
+	// Modify event to indicate it was a timeout
+	if event != nil {
+		// Add timeout flag to payload if possible
+		if payload, ok := event.Payload.(TurnChangedPayload); ok {
+			event.Type = "turn_timeout"
+			event.Payload = map[string]interface{}{
+				"previousPlayerId": payload.PreviousPlayerID,
+				"currentPlayerId":  payload.CurrentPlayerID,
+				"reason":           "timeout",
+				"timeoutCount":     timeoutCount,
 			}
+		} else if payload, ok := event.Payload.(GameFinishedPayload); ok {
+			// Game finished with timeout
+			event.Type = "game_finished"
+			event.Payload = payload
 		}
+	}
+
+	// Call the callback to broadcast the event
+	if onTimeout != nil && event != nil {
+		onTimeout(event)
+	}
+}
+
+// PauseForDisconnect stops the turn clock of a player whose socket dropped
+// during their turn. The turn is timed out as usual only if they are still
+// away once the grace runs out; ResumeAfterReconnect gives them back the time
+// they had. grace is shared by every drop in a turn, so dropping and
+// reconnecting can't hold the turn indefinitely. Returns the grace granted;
+// ok is false if userID's turn wasn't running or its grace is spent.
+func (tt *TurnTimer) PauseForDisconnect(gameID, userID int64, grace time.Duration) (granted time.Duration, ok bool) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	if _, running := tt.timers[gameID]; !running || tt.currentPlayerIDs[gameID] != userID {
+		return 0, false
+	}
+	if _, paused := tt.paused[gameID]; paused {
+		return 0, false
+	}
+	granted = grace - tt.graceUsed[gameID]
+	if granted <= 0 {
+		return 0, false
+	}
 
-		// Call the callback to broadcast the event
-		if onTimeout != nil && event != nil {
+	left := max(time.Until(tt.deadlines[gameID]), 0)
+	onTimeout := tt.callbacks[gameID]
+	tt.timers[gameID].Stop()
+	tt.scheduleLocked(gameID, userID, granted, onTimeout)
+	tt.paused[gameID] = left
+	tt.pausedAt[gameID] = time.Now()
+	return granted, true
+}
+
+// ResumeAfterReconnect restarts the turn clock paused by PauseForDisconnect
+// with the time the player had left, and returns it. ok is false if userID's
+// turn wasn't paused.
+func (tt *TurnTimer) ResumeAfterReconnect(gameID, userID int64) (left time.Duration, ok bool) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	left, ok = tt.paused[gameID]
+	if !ok || tt.currentPlayerIDs[gameID] != userID {
+		return 0, false
+	}
+	tt.graceUsed[gameID] += time.Since(tt.pausedAt[gameID])
+
+	onTimeout := tt.callbacks[gameID]
+	tt.stopLocked(gameID)
+	tt.scheduleLocked(gameID, userID, left, onTimeout)
+	return left, true
+}
+
+// chargeUndecidedIncomeTax charges the flat income tax to a player who timed
+// out before choosing, so running out the clock never skips the tax
+func (tt *TurnTimer) chargeUndecidedIncomeTax(gameID, userID int64, onTimeout func(*Event)) {
+	events, err := tt.engine.chargeUndecidedIncomeTax(gameID, userID)
+	if err != nil {
+		log.Printf("Failed to charge income tax on timeout: %v", err)
+		return
+	}
+	if onTimeout != nil {
+		for _, event := range events {
 			onTimeout(event)
 		}
+	}
+}
 
-		// Clean up timer reference
-		tt.mu.Lock()
-		delete(tt.timers, gameID)
-		tt.mu.Unlock()
-	})
-
-	tt.timers[gameID] = timer
+// eliminate removes a player for timeouts. The cash and properties they lose
+// are broadcast straight away; the event ending the turn or the game is returned
+// for the caller to mark as a timeout.
+func (tt *TurnTimer) eliminate(gameID, userID int64, onTimeout func(*Event)) (*Event, error) {
+	events, err := tt.engine.EliminatePlayerForTimeouts(gameID, userID)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	if onTimeout != nil {
+		for _, event := range events[:len(events)-1] {
+			onTimeout(event)
+		}
+	}
+	return events[len(events)-1], nil
 }
 
 // CancelTurn stops the timer for a game (called when turn ends normally)
@@ -117,10 +239,8 @@ func (tt *TurnTimer) CancelTurn(gameID int64) {
 	tt.mu.Lock()
 	defer tt.mu.Unlock()
 
-	if timer, ok := tt.timers[gameID]; ok {
-		timer.Stop()
-		delete(tt.timers, gameID)
-	}
+	tt.stopLocked(gameID)
+	delete(tt.graceUsed, gameID)
 }
 
 // ResetPlayerTimeouts resets the consecutive timeout count for a player
@@ -144,10 +264,7 @@ func (tt *TurnTimer) RestartTurn(gameID, currentPlayerID int64, onTimeout func(*
 	defer tt.mu.Unlock()
 
 	// Cancel existing timer for this game
-	if existingTimer, ok := tt.timers[gameID]; ok {
-		existingTimer.Stop()
-		delete(tt.timers, gameID)
-	}
+	tt.stopLocked(gameID)
 
 	// Reset consecutive timeouts for this player since they took action
 	if tt.timeoutCounts[gameID] != nil {
@@ -165,66 +282,19 @@ func (tt *TurnTimer) RestartTurn(gameID, currentPlayerID int64, onTimeout func(*
 		tt.timeoutCounts[gameID] = make(map[int64]int)
 	}
 
-	// Create new timer
-	timer := time.AfterFunc(TurnTimeout, func() {
-		log.Printf("Turn timeout for game %d, player %d", gameID, currentPlayerID)
-
-		tt.mu.Lock()
-		// Increment timeout count for this player
-		if tt.timeoutCounts[gameID] == nil {
-			tt.timeoutCounts[gameID] = make(map[int64]int)
-		}
-		tt.timeoutCounts[gameID][currentPlayerID]++
-		timeoutCount := tt.timeoutCounts[gameID][currentPlayerID]
-		tt.mu.Unlock()
-
-		log.Printf("Player %d has %d consecutive timeouts", currentPlayerID, timeoutCount)
-
-		var event *Event
-		var err error
-
-		// Check if player should be eliminated (3 consecutive timeouts)
-		if timeoutCount >= MaxConsecutiveTimeouts {
-			log.Printf("Player %d eliminated due to %d consecutive timeouts", currentPlayerID, timeoutCount)
-			event, err = tt.engine.EliminatePlayerForTimeouts(gameID, currentPlayerID)
-		} else {
-			// Auto-skip the turn (force=true bypasses has_rolled/pending_action checks)
-			event, err = tt.engine.ForceEndTurn(gameID, currentPlayerID)
-		}
-
-		if err != nil {
-			log.Printf("Failed to handle timeout: %v", err)
-			return
-		}
-
-		// Modify event to indicate it was a timeout
-		if event != nil {
-			if payload, ok := event.Payload.(TurnChangedPayload); ok {
-				event.Type = "turn_timeout"
-				event.Payload = map[string]interface{}{
-					"previousPlayerId": payload.PreviousPlayerID,
-					"currentPlayerId":  payload.CurrentPlayerID,
-					"reason":           "timeout",
-					"timeoutCount":     timeoutCount,
-				}
-			} else if payload, ok := event.Payload.(GameFinishedPayload); ok {
-				event.Type = "game_finished"
-				event.Payload = payload
-			}
-		}
-
-		// Call the callback to broadcast the event
-		if onTimeout != nil && event != nil {
-			onTimeout(event)
-		}
+	tt.scheduleLocked(gameID, currentPlayerID, TurnTimeout, onTimeout)
+}
 
-		// Clean up timer reference
-		tt.mu.Lock()
-		delete(tt.timers, gameID)
-		tt.mu.Unlock()
-	})
+// Remaining returns how long until the game's running timer fires, the
+// grace period's end while a turn is paused. ok is false with no timer.
+func (tt *TurnTimer) Remaining(gameID int64) (left time.Duration, ok bool) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
 
-	tt.timers[gameID] = timer
+	if _, ok := tt.timers[gameID]; !ok {
+		return 0, false
+	}
+	return max(time.Until(tt.deadlines[gameID]), 0), true
 }
 
 // GetTimeoutCount returns the current consecutive timeout count for a player
@@ -243,8 +313,7 @@ func (tt *TurnTimer) CancelAll() {
 	tt.mu.Lock()
 	defer tt.mu.Unlock()
 
-	for gameID, timer := range tt.timers {
-		timer.Stop()
-		delete(tt.timers, gameID)
+	for gameID := range tt.timers {
+		tt.stopLocked(gameID)
 	}
 }
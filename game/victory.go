@@ -0,0 +1,223 @@
+package game
+
+import (
+	"database/sql"
+	"monopoly/errors"
+	"time"
+)
+
+// Reasons a game can end, recorded on the game and sent in game_over
+const (
+	EndReasonLastPlayer = "last_player_standing"
+	EndReasonTurnLimit  = "turn_limit"
+	EndReasonTimeLimit  = "time_limit"
+	EndReasonForced     = "force_finished" // ended by an operator, no winner
+	EndReasonAbandoned  = "abandoned"      // no player connected for a while; the richest wins
+	EndReasonCancelled  = "cancelled"      // called off by the host before it started, no winner
+)
+
+// finishGameTx records the result and returns the game_finished event.
+// game_over (with final standings) is built separately via GameOver once
+// the transaction has been committed.
+func (e *Engine) finishGameTx(tx *sql.Tx, gameID, winnerID int64, reason string) (*Event, error) {
+	if err := e.store.FinishGameTx(tx, gameID, winnerID, reason); err != nil {
+		return nil, err
+	}
+	e.forgetGameRand(gameID)
+
+	allPlayers, err := e.store.GetGamePlayers(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	finalPlayers := make([]*Player, len(allPlayers))
+	for i, p := range allPlayers {
+		finalPlayers[i] = &Player{
+			UserID:      p.UserID,
+			Username:    p.Username,
+			DisplayName: displayName(p),
+			Order:       p.PlayerOrder,
+			Money:       p.Money,
+			Position:    p.Position,
+			IsBankrupt:  p.IsBankrupt,
+		}
+	}
+
+	return &Event{
+		Type:   "game_finished",
+		GameID: gameID,
+		Payload: GameFinishedPayload{
+			Players:  finalPlayers,
+			WinnerID: winnerID,
+		},
+	}, nil
+}
+
+// finishIfLastPlayerTx ends the game when at most one player is still solvent.
+// Returns nil if the game goes on.
+func (e *Engine) finishIfLastPlayerTx(tx *sql.Tx, gameID int64) (*Event, error) {
+	activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if len(activePlayers) > 1 {
+		return nil, nil
+	}
+
+	var winnerID int64
+	if len(activePlayers) == 1 {
+		winnerID = activePlayers[0].UserID
+	}
+	return e.finishGameTx(tx, gameID, winnerID, EndReasonLastPlayer)
+}
+
+// finishIfLimitReachedTx is called as the turn passes to the next player.
+// newRound is true when play wrapped back to the first player. If the game's
+// turn or time limit has been reached, the richest player wins.
+func (e *Engine) finishIfLimitReachedTx(tx *sql.Tx, gameID int64, newRound bool) (*Event, error) {
+	game, err := e.store.GetGameTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil || (game.TurnLimit == 0 && game.TimeLimitMinutes == 0) {
+		return nil, nil
+	}
+
+	round := game.Round
+	if newRound {
+		if round, err = e.store.IncrementRoundTx(tx, gameID); err != nil {
+			return nil, err
+		}
+	}
+
+	var reason string
+	switch {
+	case game.TurnLimit > 0 && round > game.TurnLimit:
+		reason = EndReasonTurnLimit
+	case game.TimeLimitMinutes > 0 && game.StartedAt > 0 &&
+		time.Since(time.Unix(game.StartedAt, 0)) >= time.Duration(game.TimeLimitMinutes)*time.Minute:
+		reason = EndReasonTimeLimit
+	default:
+		return nil, nil
+	}
+
+	winnerID, err := e.richestActivePlayerTx(tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	return e.finishGameTx(tx, gameID, winnerID, reason)
+}
+
+// richestActivePlayerTx returns the solvent player with the highest net worth.
+// Ties go to the player earliest in turn order.
+func (e *Engine) richestActivePlayerTx(tx *sql.Tx, gameID int64) (int64, error) {
+	activePlayers, err := e.store.GetActivePlayersTx(tx, gameID)
+	if err != nil {
+		return 0, err
+	}
+
+	props, err := e.store.GetGamePropertiesTx(tx, gameID)
+	if err != nil {
+		return 0, err
+	}
+	properties := make(map[int]int64)
+	mortgaged := make(map[int]bool)
+	for _, p := range props {
+		properties[p.Position] = p.OwnerID
+		mortgaged[p.Position] = p.IsMortgaged
+	}
+
+	improvements, err := e.store.GetAllImprovements(gameID)
+	if err != nil {
+		return 0, err
+	}
+
+	board, err := e.boardTx(tx, gameID)
+	if err != nil {
+		return 0, err
+	}
+
+	var winnerID int64
+	var best int
+	for _, p := range activePlayers {
+		worth := calculateNetWorth(board, p.UserID, p.Money, properties, mortgaged, improvements)
+		if winnerID == 0 || worth > best {
+			best = worth
+			winnerID = p.UserID
+		}
+	}
+	return winnerID, nil
+}
+
+// ForceFinish ends a waiting or in-progress game without a winner, for
+// operators cleaning up abandoned games.
+func (e *Engine) ForceFinish(gameID int64) (*Event, error) {
+	defer e.lockGame(gameID)()
+
+	game, err := e.store.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+	if game.Status == StatusFinished {
+		return nil, errors.GameFinished()
+	}
+
+	tx, err := e.store.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer e.store.RollbackTx(tx)
+
+	if err := e.store.FinishGameTx(tx, gameID, 0, EndReasonForced); err != nil {
+		return nil, err
+	}
+	if err := e.store.CommitTx(tx); err != nil {
+		return nil, err
+	}
+
+	e.setAuction(gameID, nil)
+	e.forgetGameRand(gameID)
+	e.forgetMoneyLedger(gameID)
+
+	return &Event{
+		Type:   "game_force_finished",
+		GameID: gameID,
+		Payload: GameForceFinishedPayload{
+			GameID: gameID,
+			Reason: EndReasonForced,
+		},
+	}, nil
+}
+
+// GameOver builds the game_over event for a finished game from its committed
+// result. Returns nil if the game hasn't finished.
+func (e *Engine) GameOver(gameID int64) (*Event, error) {
+	game, err := e.store.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, errors.GameNotFound()
+	}
+	if game.Status != StatusFinished {
+		return nil, nil
+	}
+
+	standings, err := e.GetStandings(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Type:   "game_over",
+		GameID: gameID,
+		Payload: GameOverPayload{
+			WinnerUserID:   game.WinnerID,
+			Reason:         game.EndReason,
+			FinalStandings: standings,
+		},
+	}, nil
+}
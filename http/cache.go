@@ -0,0 +1,86 @@
+package http
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// boardMaxAge is how long clients may reuse a board response before
+// revalidating it with its ETag. Boards don't change during a game, only the
+// default one between deploys.
+const boardMaxAge = 300
+
+// writeCachedJSON writes v as JSON that clients may cache for maxAge seconds.
+// The weak ETag is a hash of the JSON itself, so different content (another
+// game's custom board, say) never shares a tag, and a matching If-None-Match
+// gets a bodiless 304. The body is gzipped for clients that accept it.
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, maxAge int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	body = append(body, '\n') // as json.Encoder writes it in writeJSON
+
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !acceptsGzip(r) {
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(body); err != nil {
+			log.Printf("Failed to write JSON response: %v", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.WriteHeader(http.StatusOK)
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(body); err != nil {
+		log.Printf("Failed to write JSON response: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		log.Printf("Failed to write JSON response: %v", err)
+	}
+}
+
+// etagMatches compares If-None-Match against etag the weak way, as RFC 9110
+// asks for GET: W/ prefixes are ignored and "*" matches anything
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"monopoly/game"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetBoard_ETagRevalidationAndGzip(t *testing.T) {
+	h := &Handlers{}
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/board", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.GetBoard(rec, req)
+		return rec
+	}
+
+	first := get(nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Cache-Control") != "private, max-age=300" {
+		t.Fatalf("Expected a cacheable 200 with an ETag, got %d %v", first.Code, first.Header())
+	}
+	var board []game.BoardSpace
+	if err := json.Unmarshal(first.Body.Bytes(), &board); err != nil || len(board) != 40 {
+		t.Fatalf("Expected the 40-space board, got %d spaces, %v", len(board), err)
+	}
+
+	if again := get(nil); again.Header().Get("ETag") != etag {
+		t.Errorf("Expected the same board to keep its ETag, got %q then %q", etag, again.Header().Get("ETag"))
+	}
+	if cached := get(map[string]string{"If-None-Match": `"other", ` + etag}); cached.Code != http.StatusNotModified || cached.Body.Len() != 0 {
+		t.Errorf("Expected a bodiless 304 for a matching If-None-Match, got %d with %d bytes", cached.Code, cached.Body.Len())
+	}
+	if stale := get(map[string]string{"If-None-Match": `W/"stale"`}); stale.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a stale ETag, got %d", stale.Code)
+	}
+
+	zipped := get(map[string]string{"Accept-Encoding": "gzip, deflate"})
+	if zipped.Header().Get("Content-Encoding") != "gzip" || zipped.Header().Get("ETag") != etag {
+		t.Fatalf("Expected a gzipped body under the same ETag, got %v", zipped.Header())
+	}
+	gz, err := gzip.NewReader(zipped.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip body: %v", err)
+	}
+	unzipped, _ := io.ReadAll(gz)
+	if string(unzipped) != first.Body.String() {
+		t.Error("Expected the gzipped body to decompress to the plain one")
+	}
+}
@@ -2,28 +2,62 @@ package http
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"io"
 	"log"
 	"monopoly/auth"
+	"monopoly/config"
 	"monopoly/errors"
 	"monopoly/game"
 	"monopoly/store"
 	"monopoly/ws"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		origin := r.Header.Get("Origin")
-		// In production, check against allowed origins
-		// For now, only allow same origin
-		return origin == "" || origin == "http://"+r.Host || origin == "https://"+r.Host
-	},
+// newUpgrader builds the WebSocket upgrader. With compression on it offers
+// permessage-deflate; clients that don't ask for it in the handshake are
+// served uncompressed as before. See newOriginCheck for which origins may connect.
+func newUpgrader(readBufferSize, writeBufferSize int, compression bool, checkOrigin func(r *http.Request) bool) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:    readBufferSize,
+		WriteBufferSize:   writeBufferSize,
+		EnableCompression: compression,
+		CheckOrigin:       checkOrigin,
+		Subprotocols:      ws.Protocols,
+	}
+}
+
+// upgrade completes the WebSocket handshake. A client that offered only
+// protocol versions the server doesn't speak gets UNSUPPORTED_PROTOCOL and
+// CloseUnsupportedProtocol instead, and upgrade returns nil. Its first offer
+// is echoed back, since browsers drop a handshake that picks none of theirs
+// before the close code can be read.
+func (h *Handlers) upgrade(w http.ResponseWriter, r *http.Request) *websocket.Conn {
+	offered := websocket.Subprotocols(r)
+	if !ws.AcceptsProtocols(offered) {
+		rejecter := *h.upgrader
+		rejecter.Subprotocols = nil
+		conn, err := rejecter.Upgrade(w, r, http.Header{"Sec-Websocket-Protocol": {offered[0]}})
+		if err != nil {
+			logRequestf(r, "WebSocket upgrade error: %v", err)
+			return nil
+		}
+		logRequestf(r, "Rejected WebSocket client offering protocols %v", offered)
+		h.wsManager.RejectConnection(conn, ws.CloseUnsupportedProtocol, errors.UnsupportedProtocol(ws.Protocols))
+		return nil
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logRequestf(r, "WebSocket upgrade error: %v", err)
+		return nil
+	}
+	return conn
 }
 
 type Handlers struct {
@@ -33,9 +67,12 @@ type Handlers struct {
 	engine       *game.Engine
 	wsManager    *ws.Manager
 	lobbyManager *ws.LobbyManager
+	upgrader     *websocket.Upgrader
+	createKeys   *idempotencyCache // Idempotency-Key -> game for POST /lobby/create
+	metrics      *metrics
 }
 
-func NewHandlers(authService *auth.Service, authStore store.AuthStore, lobby *game.Lobby, engine *game.Engine, wsManager *ws.Manager, lobbyManager *ws.LobbyManager) *Handlers {
+func NewHandlers(cfg *config.Config, authService *auth.Service, authStore store.AuthStore, lobby *game.Lobby, engine *game.Engine, wsManager *ws.Manager, lobbyManager *ws.LobbyManager) *Handlers {
 	return &Handlers{
 		authService:  authService,
 		authStore:    authStore,
@@ -43,6 +80,9 @@ func NewHandlers(authService *auth.Service, authStore store.AuthStore, lobby *ga
 		engine:       engine,
 		wsManager:    wsManager,
 		lobbyManager: lobbyManager,
+		upgrader:     newUpgrader(cfg.WSReadBufferSize, cfg.WSWriteBufferSize, cfg.WSCompression, newOriginCheck(cfg.WSAllowedOrigins, cfg.WSAppOriginSchemes)),
+		createKeys:   newIdempotencyCache(cfg.IdempotencyKeyTTL),
+		metrics:      newMetrics(),
 	}
 }
 
@@ -54,21 +94,27 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	}
 }
 
-// writeError writes an error response with proper handling of AppError types
-func writeError(w http.ResponseWriter, err error) {
-	var appErr *errors.AppError
-	if e, ok := err.(*errors.AppError); ok {
-		appErr = e
-	} else {
-		// Wrap unknown errors
-		appErr = errors.InternalError(err.Error())
-	}
+// errorBody is the JSON shape of every error response:
+// {"error": {"code": "GAME_NOT_FOUND", "message": "Game not found"}}
+// Rate-limited responses add retryAfter, the seconds until a retry can succeed.
+// VALIDATION_FAILED adds fields: [{field, code, message}], one per problem.
+type errorBody struct {
+	Code       errors.ErrorCode    `json:"code"`
+	Message    string              `json:"message"`
+	RetryAfter int                 `json:"retryAfter,omitempty"`
+	Fields     []errors.FieldError `json:"fields,omitempty"`
+}
+
+// writeError writes an error response with proper handling of AppError types.
+// All handlers and middleware report errors through it so clients see one shape.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := errors.From(err)
 
 	// Log internal details
 	if appErr.Detail != "" {
-		log.Printf("Error [%s]: %s (detail: %s)", appErr.Code, appErr.Message, appErr.Detail)
+		logRequestf(r, "Error [%s]: %s (detail: %s)", appErr.Code, appErr.Message, appErr.Detail)
 	} else {
-		log.Printf("Error [%s]: %s", appErr.Code, appErr.Message)
+		logRequestf(r, "Error [%s]: %s", appErr.Code, appErr.Message)
 	}
 
 	// Determine HTTP status code based on error code
@@ -80,64 +126,83 @@ func writeError(w http.ResponseWriter, err error) {
 		statusCode = http.StatusUnauthorized
 	case errors.ErrCodeNotFound, errors.ErrCodeGameNotFound, errors.ErrCodeUserNotFound:
 		statusCode = http.StatusNotFound
-	case errors.ErrCodeBadRequest, errors.ErrCodeInvalidUsername, errors.ErrCodeInvalidPassword:
+	case errors.ErrCodeBadRequest, errors.ErrCodeInvalidUsername, errors.ErrCodeInvalidPassword, errors.ErrCodeInvalidDisplayName,
+		errors.ErrCodeUnsupportedProtocol, errors.ErrCodeValidationFailed:
 		statusCode = http.StatusBadRequest
 	case errors.ErrCodeForbidden, errors.ErrCodeNotPlayer:
 		statusCode = http.StatusForbidden
-	case errors.ErrCodeGameFull, errors.ErrCodeGameStarted, errors.ErrCodeAlreadyInGame,
+	case errors.ErrCodeGameStarted, errors.ErrCodeGameFinished,
 		errors.ErrCodeNotInGame, errors.ErrCodeNotYourTurn, errors.ErrCodeUserExists,
 		errors.ErrCodeAlreadyRolled, errors.ErrCodeMustRoll, errors.ErrCodePendingAction,
 		errors.ErrCodeCannotBuy, errors.ErrCodeInsufficientFunds, errors.ErrCodePlayerBankrupt:
 		statusCode = http.StatusBadRequest
+	case errors.ErrCodeTooManyGames, errors.ErrCodeRateLimited:
+		statusCode = http.StatusTooManyRequests
+	case errors.ErrCodeConflict, errors.ErrCodeGameFull, errors.ErrCodeAlreadyInGame:
+		statusCode = http.StatusConflict
 	}
 
-	writeJSON(w, statusCode, map[string]interface{}{
-		"error":   string(appErr.Code),
-		"message": appErr.UserMessage(),
+	if appErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(appErr.RetryAfter))
+	}
+	writeJSON(w, statusCode, map[string]errorBody{
+		"error": {Code: appErr.Code, Message: appErr.UserMessage(), RetryAfter: appErr.RetryAfter, Fields: appErr.Fields},
 	})
 }
 
+// queryInt parses an optional integer query parameter, returning def when absent
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
+
 // getUserOrError retrieves a user by ID and writes an HTTP error if not found
-func (h *Handlers) getUserOrError(w http.ResponseWriter, userID int64) (*store.User, bool) {
+func (h *Handlers) getUserOrError(w http.ResponseWriter, r *http.Request, userID int64) (*store.User, bool) {
 	user, err := h.authStore.GetUserByID(userID)
 	if err != nil {
-		log.Printf("Failed to get user info for ID %d: %v", userID, err)
-		http.Error(w, "Failed to get user info", http.StatusInternalServerError)
+		logRequestf(r, "Failed to get user info for ID %d: %v", userID, err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to get user info"))
 		return nil, false
 	}
 	if user == nil {
-		log.Printf("User not found after auth: ID %d", userID)
-		http.Error(w, "User not found", http.StatusNotFound)
+		logRequestf(r, "User not found after auth: ID %d", userID)
+		writeError(w, r, errors.UserNotFound())
 		return nil, false
 	}
 	return user, true
 }
 
-// checkUserInGame verifies if user is a player in the specified game
-// Returns true if user is in game, false otherwise
-func (h *Handlers) checkUserInGame(gameID, userID int64) (bool, error) {
-	gameState, err := h.engine.GetGameState(gameID)
-	if err != nil {
-		return false, err
-	}
-	if gameState == nil {
-		return false, errors.GameNotFound()
-	}
-
-	for _, player := range gameState.Players {
-		if player.UserID == userID {
-			return true, nil
-		}
-	}
-	return false, nil
-}
-
 // broadcastLobbyUpdate sends personalized full state updates to all lobby clients
 // This is kept for backward compatibility but should be avoided in favor of specific events
 func (h *Handlers) broadcastLobbyUpdate() {
 	h.lobbyManager.BroadcastUpdate()
 }
 
+// Healthz reports that the process is up. It never touches the database.
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz reports whether the server can serve traffic, i.e. the database is
+// reachable, along with its schema version for diagnostics.
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	if err := h.authStore.Ping(); err != nil {
+		logRequestf(r, "Readiness check failed: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable"})
+		return
+	}
+	version, err := h.authStore.SchemaVersion()
+	if err != nil {
+		logRequestf(r, "Readiness check failed: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "schemaVersion": version})
+}
+
 // Register Auth handlers
 func (h *Handlers) Register(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -146,14 +211,15 @@ func (h *Handlers) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, errors.BadRequest("Invalid request body"))
+		writeError(w, r, errors.BadRequest("Invalid request body"))
 		return
 	}
 
 	if err := h.authService.Register(req.Username, req.Password); err != nil {
-		writeError(w, err)
+		writeError(w, r, err)
 		return
 	}
+	h.metrics.registrations.Add(1)
 
 	writeJSON(w, http.StatusCreated, map[string]string{"message": "User registered successfully"})
 }
@@ -165,35 +231,134 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, errors.BadRequest("Invalid request body"))
+		writeError(w, r, errors.BadRequest("Invalid request body"))
 		return
 	}
 
 	sessionID, err := h.authService.Login(req.Username, req.Password)
 	if err != nil {
-		writeError(w, err)
+		writeError(w, r, err)
 		return
 	}
+	h.metrics.logins.Add(1)
 
 	h.authService.GetSessionManager().SetSessionCookie(w, sessionID)
 
 	user, err := h.authStore.GetUserByUsername(req.Username)
 	if err != nil {
-		log.Printf("Login: Failed to get user info for %s: %v", req.Username, err)
-		http.Error(w, "Failed to get user info", http.StatusInternalServerError)
+		logRequestf(r, "Login: Failed to get user info for %s: %v", req.Username, err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to get user info"))
 		return
 	}
 	if user == nil {
-		log.Printf("Login: User not found after successful auth: %s", req.Username)
-		http.Error(w, "User not found", http.StatusNotFound)
+		logRequestf(r, "Login: User not found after successful auth: %s", req.Username)
+		writeError(w, r, errors.UserNotFound())
+		return
+	}
+
+	logRequestf(r, "Login successful for user %s (ID: %d)", user.Username, user.ID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":     "Login successful",
+		"userId":      user.ID,
+		"username":    user.Username,
+		"displayName": user.DisplayName,
+	})
+}
+
+// Guest signs in as a new passwordless guest with a generated username and a
+// short-lived session
+func (h *Handlers) Guest(w http.ResponseWriter, r *http.Request) {
+	sessionID, user, err := h.authService.CreateGuest()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.metrics.guests.Add(1)
+
+	h.authService.GetSessionManager().SetSessionCookie(w, sessionID)
+
+	logRequestf(r, "Guest created: %s (ID: %d)", user.Username, user.ID)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"userId":      user.ID,
+		"username":    user.Username,
+		"displayName": user.DisplayName,
+		"guest":       true,
+	})
+}
+
+// ClaimGuest makes the current guest a regular account by setting a password
+// and optionally choosing a username. The account keeps its games.
+func (h *Handlers) ClaimGuest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errors.BadRequest("Invalid request body"))
+		return
+	}
+
+	username, err := h.authService.ClaimGuest(userID, req.Username, req.Password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.metrics.registrations.Add(1)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"userId":   userID,
+		"username": username,
+		"guest":    false,
+	})
+}
+
+// SetDisplayName sets or clears (with "") the current user's display name.
+// The username is unchanged and still used to log in.
+func (h *Handlers) SetDisplayName(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	var req struct {
+		DisplayName string `json:"displayName"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errors.BadRequest("Invalid request body"))
+		return
+	}
+
+	name, err := h.authService.SetDisplayName(userID, req.DisplayName)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Printf("Login successful for user %s (ID: %d)", user.Username, user.ID)
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"message":  "Login successful",
-		"userId":   user.ID,
-		"username": user.Username,
+		"userId":      userID,
+		"displayName": name,
+	})
+}
+
+// WSTicket mints a single-use ticket for opening a WebSocket as ?token=, for
+// clients whose upgrade requests don't carry the session cookie
+func (h *Handlers) WSTicket(w http.ResponseWriter, r *http.Request) {
+	ticket, err := h.authService.IssueWSTicket(auth.GetSessionFromRequest(r))
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to issue ticket"))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"ticket":    ticket,
+		"expiresIn": int(auth.WSTicketTTL.Seconds()),
 	})
 }
 
@@ -207,50 +372,266 @@ func (h *Handlers) Logout(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
 }
 
-// ListGames returns a list of active games
+// Sessions lists the user's live sessions, marking the one making the request
+func (h *Handlers) Sessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if sessionID := auth.GetSessionFromRequest(r); sessionID != "" {
+		current := auth.SessionKey(sessionID)
+		for i := range sessions {
+			sessions[i].Current = sessions[i].Key == current
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// LogoutAll ends every session of the user, including this one
+func (h *Handlers) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	ended, err := h.authService.LogoutAll(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.authService.GetSessionManager().ClearSessionCookie(w)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":       "Logged out everywhere",
+		"sessionsEnded": ended,
+	})
+}
+
+// DeleteAccount permanently removes the current user after re-checking their password.
+// A waiting game is left; an in-progress game is forfeited as if the player gave up.
+func (h *Handlers) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errors.BadRequest("Invalid request body"))
+		return
+	}
+
+	// Check the password before touching any games
+	if err := h.authService.VerifyPassword(userID, req.Password); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.leaveActiveGame(userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.authService.DeleteAccount(userID, req.Password); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.authService.GetSessionManager().ClearSessionCookie(w)
+	logRequestf(r, "Deleted account for user %d", userID)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Account deleted"})
+}
+
+// leaveActiveGame takes the user out of their non-finished game, if any
+func (h *Handlers) leaveActiveGame(userID int64) error {
+	current, err := h.lobby.GetUserCurrentGame(userID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+
+	switch current.Status {
+	case game.StatusWaiting:
+		if err := h.lobby.LeaveGame(current.ID, userID); err != nil {
+			return err
+		}
+		g, err := h.lobby.GetGameWithPlayers(current.ID, 0)
+		if err := h.wsManager.PlayerLeft(current.ID, userID, err == nil && g == nil); err != nil {
+			return err
+		}
+	case game.StatusInProgress:
+		err := h.wsManager.ForfeitPlayer(current.ID, userID)
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodePlayerBankrupt {
+			return nil // already out of the game
+		}
+		return err
+	}
+	return nil
+}
+
+// ListGames returns a page of active games, newest first.
+// Query params: limit (default 20, max 100), offset (default 0),
+// status (waiting/in_progress), joinable=true (waiting and not full).
 func (h *Handlers) ListGames(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	limit, err := queryInt(r, "limit", game.DefaultGamesPageSize)
+	if err != nil || limit < 1 {
+		writeError(w, r, errors.BadRequest("Invalid limit"))
+		return
+	}
+	if limit > game.MaxGamesPageSize {
+		limit = game.MaxGamesPageSize
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, r, errors.BadRequest("Invalid offset"))
+		return
+	}
+
+	var filter store.GameListFilter
+	switch status := r.URL.Query().Get("status"); status {
+	case "", "waiting", "in_progress":
+		filter.Status = status
+	default:
+		writeError(w, r, errors.BadRequest("Invalid status filter"))
+		return
+	}
+	if joinable := r.URL.Query().Get("joinable"); joinable != "" {
+		filter.Joinable, err = strconv.ParseBool(joinable)
+		if err != nil {
+			writeError(w, r, errors.BadRequest("Invalid joinable filter"))
+			return
+		}
+	}
+
+	games, total, err := h.lobby.ListGames(userID, filter, limit, offset)
+	if err != nil {
+		logRequestf(r, "ListGames error: %v", err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to list games"))
+		return
+	}
+	h.wsManager.FillConnectedCounts(games)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"games":  games,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// MyGames lists the caller's unfinished games and whether it's their turn in
+// each, so a returning player can rejoin without searching the lobby
+func (h *Handlers) MyGames(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
-	games, err := h.lobby.ListGames(userID)
+	games, err := h.lobby.GetGamesForUser(userID)
 	if err != nil {
-		log.Printf("ListGames error: %v", err)
-		http.Error(w, "Failed to list games", http.StatusInternalServerError)
+		logRequestf(r, "MyGames error: %v", err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to list your games"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, games)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"games": games,
+	})
 }
 
 func (h *Handlers) CreateGame(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		MaxPlayers int `json:"maxPlayers"`
+		MaxPlayers        *int            `json:"maxPlayers"`        // optional, nil when omitted
+		MinPlayers        int             `json:"minPlayers"`        // optional, players needed to start
+		TurnLimit         int             `json:"turnLimit"`         // optional, rounds
+		TimeLimitMinutes  int             `json:"timeLimitMinutes"`  // optional
+		ManualStart       bool            `json:"manualStart"`       // optional, only the host starts the game
+		Board             json.RawMessage `json:"board"`             // optional, custom board; see game.ParseCustomBoard
+		HouseLimit        int             `json:"houseLimit"`        // optional, houses in the bank; 0 = 32
+		HotelLimit        int             `json:"hotelLimit"`        // optional, hotels in the bank; 0 = 12
+		UnlimitedBuilding bool            `json:"unlimitedBuilding"` // optional house rule: the bank never runs out
+	}
+
+	// An empty body means all defaults; anything else must be valid JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, r, errors.BadRequest("Invalid request body"))
+		return
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		req.MaxPlayers = 4 // default
+	maxPlayers := game.DefaultMaxPlayers
+	if req.MaxPlayers != nil {
+		maxPlayers = *req.MaxPlayers
 	}
 
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
-	user, ok := h.getUserOrError(w, userID)
+	user, ok := h.getUserOrError(w, r, userID)
 	if !ok {
 		return
 	}
 
-	game, err := h.lobby.CreateGame(req.MaxPlayers, userID, user.Username)
+	// A repeated Idempotency-Key returns the game the first request created
+	key := r.Header.Get("Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLength {
+		writeError(w, r, errors.BadRequest("Idempotency-Key is too long"))
+		return
+	}
+	if key != "" {
+		gameID, reserved := h.createKeys.claim(userID, key, time.Now())
+		if !reserved {
+			h.replayCreateGame(w, r, userID, gameID)
+			return
+		}
+	}
+
+	rules := store.GameRules{
+		MinPlayers:        req.MinPlayers,
+		TurnLimit:         req.TurnLimit,
+		TimeLimitMinutes:  req.TimeLimitMinutes,
+		ManualStart:       req.ManualStart,
+		HouseLimit:        req.HouseLimit,
+		HotelLimit:        req.HotelLimit,
+		UnlimitedBuilding: req.UnlimitedBuilding,
+	}
+	if len(req.Board) > 0 && string(req.Board) != "null" {
+		rules.Board = string(req.Board)
+	}
+	game, err := h.lobby.CreateGame(maxPlayers, rules, userID, user.Username)
 	if err != nil {
-		log.Printf("CreateGame error: %v", err)
-		http.Error(w, "Failed to create game", http.StatusInternalServerError)
+		if key != "" {
+			h.createKeys.release(userID, key)
+		}
+		logRequestf(r, "CreateGame error: %v", err)
+		writeError(w, r, err)
 		return
 	}
+	if key != "" {
+		h.createKeys.complete(userID, key, game.ID)
+	}
 
 	// Broadcast game_created event to all connected clients
 	go h.lobbyManager.BroadcastGameCreated(game.ID)
@@ -258,53 +639,90 @@ func (h *Handlers) CreateGame(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, game)
 }
 
+// replayCreateGame answers a create request whose Idempotency-Key was already
+// used. gameID is 0 while the first request with that key is still running.
+func (h *Handlers) replayCreateGame(w http.ResponseWriter, r *http.Request, userID, gameID int64) {
+	if gameID == 0 {
+		writeError(w, r, errors.New(errors.ErrCodeConflict, "A request with this Idempotency-Key is still in progress"))
+		return
+	}
+
+	existing, err := h.lobby.GetGameWithPlayers(gameID, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if existing == nil {
+		// Everyone left the game created for this key, so it was deleted
+		writeError(w, r, errors.GameNotFound())
+		return
+	}
+
+	logRequestf(r, "Replayed create for user %d: game %d", userID, gameID)
+	w.Header().Set("Idempotent-Replayed", "true")
+	writeJSON(w, http.StatusCreated, existing)
+}
+
 func (h *Handlers) JoinGame(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid game ID", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	var req struct {
+		Token string `json:"token"` // optional, see game.PlayerTokens; a free one when omitted
+	}
+	// An empty body joins with any free token
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, r, errors.BadRequest("Invalid request body"))
 		return
 	}
 
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
-	user, ok := h.getUserOrError(w, userID)
+	user, ok := h.getUserOrError(w, r, userID)
 	if !ok {
 		return
 	}
 
 	// Join game using lobby store
-	err = h.lobby.JoinGame(gameID, userID, user.Username)
+	token, err := h.lobby.JoinGameWithToken(gameID, userID, user.Username, req.Token)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	// Broadcast player_joined event to all connected clients
-	go h.lobbyManager.BroadcastPlayerJoined(gameID, userID, user.Username)
+	go h.lobbyManager.BroadcastPlayerJoined(gameID, userID, user.Username, token)
+
+	// The new player isn't ready yet, so any pending start is called off
+	if err := h.wsManager.UpdateStartCountdown(gameID, userID); err != nil {
+		logRequestf(r, "Error updating start countdown: %v", err)
+	}
 
 	// Check if game should start (when game is full)
 	event, err := h.engine.StartGameIfFull(gameID)
 	if err != nil {
-		log.Printf("Error starting game: %v", err)
+		logRequestf(r, "Error starting game: %v", err)
 	} else if event != nil {
 		// Game started! Broadcast to game room and lobby
-		log.Printf("Game %d started (full)", gameID)
+		logRequestf(r, "Game %d started (full)", gameID)
 
-		// Broadcast to game room with turn timer handling
+		// Broadcast to game room with turn timer handling; this also
+		// tells the lobby the game is in progress
 		go h.wsManager.BroadcastGameEvent(gameID, event)
-
-		// Broadcast status change to lobby
-		go h.lobbyManager.BroadcastGameStatusChange(gameID, "in_progress")
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Joined game successfully",
 		"gameId":  gameID,
+		"token":   token,
 	})
 }
 
@@ -312,39 +730,27 @@ func (h *Handlers) LeaveGame(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid game ID", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
 		return
 	}
 
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
 	err = h.lobby.LeaveGame(gameID, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	// Broadcast player_left event to all connected clients
-	go h.lobbyManager.BroadcastPlayerLeft(gameID, userID)
-
-	// Check if game still exists (it gets deleted if empty)
-	games, err := h.lobby.ListGames(0)
-	if err == nil {
-		gameExists := false
-		for _, g := range games {
-			if g.ID == gameID {
-				gameExists = true
-				break
-			}
-		}
-		// If game doesn't exist anymore, broadcast game_deleted
-		if !gameExists {
-			go h.lobbyManager.BroadcastGameDeleted(gameID)
-		}
+	// Tell lobby clients and re-check the start countdown. The game is
+	// deleted once its last player leaves.
+	g, err := h.lobby.GetGameWithPlayers(gameID, 0)
+	if err := h.wsManager.PlayerLeft(gameID, userID, err == nil && g == nil); err != nil {
+		logRequestf(r, "Error updating start countdown: %v", err)
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
@@ -356,23 +762,394 @@ func (h *Handlers) GetGame(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid game ID", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
 		return
 	}
 
 	gameState, err := h.engine.GetGameState(gameID)
 	if err != nil {
-		log.Printf("GetGame error: %v", err)
-		http.Error(w, "Failed to get game", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	if gameState == nil {
-		http.Error(w, "Game not found", http.StatusNotFound)
+	h.wsManager.FillPresence(gameState)
+	writeJSON(w, http.StatusOK, gameState)
+}
+
+// GetGameSnapshot returns the full game for viewers without a socket, e.g.
+// someone opening a shared link: state, last dice roll and event log position.
+func (h *Handlers) GetGameSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
 		return
 	}
 
-	writeJSON(w, http.StatusOK, gameState)
+	snapshot, err := h.engine.GetGameSnapshot(gameID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.wsManager.FillPresence(snapshot.GameState)
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// GetProperty returns one board space with its owner, improvements and the
+// rent a player would pay for landing on it right now.
+func (h *Handlers) GetProperty(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+	position, err := strconv.Atoi(vars["spaceIndex"])
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid board position"))
+		return
+	}
+
+	details, err := h.engine.GetPropertyDetails(gameID, position)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, details)
+}
+
+// PreviewLanding reports what landing on a space would do to the calling
+// player right now, without changing the game
+func (h *Handlers) PreviewLanding(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+	position, err := strconv.Atoi(vars["spaceIndex"])
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid board position"))
+		return
+	}
+
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	preview, err := h.engine.PreviewLanding(gameID, userID, position)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, preview)
+}
+
+// SetReady marks the user ready (or not) in a waiting game. Once everyone is
+// ready the game starts after a short countdown that un-readying cancels.
+func (h *Handlers) SetReady(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	var req struct {
+		Ready bool `json:"ready"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errors.BadRequest("Invalid request body"))
+		return
+	}
+
+	if err := h.wsManager.SetReady(gameID, userID, req.Ready); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId": gameID,
+		"ready":  req.Ready,
+	})
+}
+
+// GetBoard returns the standard board, or with ?gameId= the board that game
+// is played on. The standard board is the starting point for a custom one.
+// Both are cacheable and revalidated by ETag, see writeCachedJSON.
+func (h *Handlers) GetBoard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("gameId") == "" {
+		writeCachedJSON(w, r, boardMaxAge, game.Board)
+		return
+	}
+
+	gameID, err := strconv.ParseInt(r.URL.Query().Get("gameId"), 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+	board, err := h.engine.GameBoard(gameID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeCachedJSON(w, r, boardMaxAge, board)
+}
+
+// StartGame lets the host start a waiting game without waiting for everyone
+// to be ready. The game still needs its minimum number of players.
+func (h *Handlers) StartGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	if err := h.wsManager.StartGame(gameID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId": gameID,
+		"status": game.StatusInProgress,
+	})
+}
+
+// CancelGame lets the host call off a game that hasn't started yet
+func (h *Handlers) CancelGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	if err := h.wsManager.CancelGame(gameID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId":    gameID,
+		"status":    game.StatusFinished,
+		"endReason": game.EndReasonCancelled,
+	})
+}
+
+// AdminFinishGame force-finishes a stuck game and disconnects its players.
+// Routed behind AdminMiddleware.
+func (h *Handlers) AdminFinishGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	if err := h.wsManager.ForceFinishGame(gameID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID, _ := GetUserIDFromContext(r.Context())
+	logRequestf(r, "Admin %d force-finished game %d", userID, gameID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId": gameID,
+		"status": game.StatusFinished,
+	})
+}
+
+// AdminGetGame returns debugging details that players must not see, such as
+// the game's random seed, and per-player turn timing for spotting AFK players.
+func (h *Handlers) AdminGetGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	seed, err := h.engine.GameSeed(gameID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	turnStats, err := h.engine.TurnStats(gameID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId":    gameID,
+		"seed":      seed,
+		"turnStats": turnStats,
+	})
+}
+
+// AdminMoneyAudit returns a game's money breakdown and, with MONEY_AUDIT
+// on, any actions after which money went missing or appeared from nowhere.
+// Routed behind AdminMiddleware.
+func (h *Handlers) AdminMoneyAudit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	audit, err := h.engine.MoneyAudit(gameID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, audit)
+}
+
+// AdminConnections lists every game room with its connected players and
+// spectators, and the lobby's connected users. Routed behind AdminMiddleware.
+func (h *Handlers) AdminConnections(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.wsManager.Connections())
+}
+
+// AdminArchiveGame archives a finished game ahead of the periodic job.
+// Routed behind AdminMiddleware.
+func (h *Handlers) AdminArchiveGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	if err := h.lobby.ArchiveGame(gameID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID, _ := GetUserIDFromContext(r.Context())
+	logRequestf(r, "Admin %d archived game %d", userID, gameID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId":   gameID,
+		"archived": true,
+	})
+}
+
+// AdminListArchivedGames pages through archived games, most recently
+// finished first. Routed behind AdminMiddleware.
+func (h *Handlers) AdminListArchivedGames(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", game.DefaultGamesPageSize)
+	if err != nil || limit < 1 {
+		writeError(w, r, errors.BadRequest("Invalid limit"))
+		return
+	}
+	if limit > game.MaxGamesPageSize {
+		limit = game.MaxGamesPageSize
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, r, errors.BadRequest("Invalid offset"))
+		return
+	}
+
+	games, total, err := h.lobby.ListArchivedGames(limit, offset)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"games":  games,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetGameEvents returns the game's event log in order. since=<seq> returns only
+// later events, so a reconnecting client can replay what it missed.
+func (h *Handlers) GetGameEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	since, err := queryInt(r, "since", 0)
+	if err != nil || since < 0 {
+		writeError(w, r, errors.BadRequest("Invalid since"))
+		return
+	}
+	limit, err := queryInt(r, "limit", game.MaxEventsPageSize)
+	if err != nil || limit < 1 {
+		writeError(w, r, errors.BadRequest("Invalid limit"))
+		return
+	}
+
+	events, err := h.engine.GetEvents(gameID, int64(since), limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId": gameID,
+		"events": events,
+	})
+}
+
+// GetGameRolls returns the game's latest dice rolls, newest first, with who
+// rolled them
+func (h *Handlers) GetGameRolls(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	limit, err := queryInt(r, "limit", game.MaxRecentRolls)
+	if err != nil || limit < 1 {
+		writeError(w, r, errors.BadRequest("Invalid limit"))
+		return
+	}
+
+	rolls, err := h.engine.RecentRolls(gameID, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gameId": gameID,
+		"rolls":  rolls,
+	})
 }
 
 // WebSocket handler for game rooms
@@ -380,50 +1157,147 @@ func (h *Handlers) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid game ID", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+
+	// Existing players may (re)connect any number of times, e.g. after a refresh
+	if _, err := h.engine.RejoinGame(gameID, userID); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			switch appErr.Code {
+			case errors.ErrCodeNotInGame:
+				logRequestf(r, "User %d attempted to access game %d without being a player", userID, gameID)
+				writeError(w, r, errors.NotPlayer())
+				return
+			case errors.ErrCodeGameNotFound:
+				// Upgrade anyway so the client gets a typed error and close code
+				// instead of an opaque handshake failure it would keep retrying
+				conn, upgradeErr := h.upgrader.Upgrade(w, r, nil)
+				if upgradeErr != nil {
+					logRequestf(r, "WebSocket upgrade error: %v", upgradeErr)
+					return
+				}
+				h.wsManager.RejectConnection(conn, ws.CloseGameNotFound, err)
+				return
+			}
+		}
+		logRequestf(r, "Failed to check game authorization: %v", err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to verify game access"))
 		return
 	}
 
+	conn := h.upgrade(w, r)
+	if conn == nil {
+		return
+	}
+
+	h.wsManager.HandleConnection(conn, gameID, userID)
+}
+
+// CreateSpectatorToken issues a read-only viewing link for one of the
+// caller's games. The link needs no account.
+func (h *Handlers) CreateSpectatorToken(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+	gameID, err := strconv.ParseInt(mux.Vars(r)["gameId"], 10, 64)
+	if err != nil {
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
+		return
+	}
+
+	token, err := h.engine.CreateSpectatorToken(gameID, userID)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	// Check if user is a player in this game
-	isPlayer, err := h.checkUserInGame(gameID, userID)
+	logRequestf(r, "User %d created a spectator link for game %d", userID, gameID)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token": token,
+		"url":   "/ws/spectate/" + token,
+	})
+}
+
+// RevokeSpectatorToken stops a spectator link from admitting new viewers.
+// Spectators already watching stay connected.
+func (h *Handlers) RevokeSpectatorToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errors.Unauthorized())
+		return
+	}
+	vars := mux.Vars(r)
+	gameID, err := strconv.ParseInt(vars["gameId"], 10, 64)
 	if err != nil {
-		log.Printf("Failed to check game authorization: %v", err)
-		http.Error(w, "Failed to verify game access", http.StatusInternalServerError)
+		writeError(w, r, errors.BadRequest("Invalid game ID"))
 		return
 	}
 
-	if !isPlayer {
-		log.Printf("User %d attempted to access game %d without being a player", userID, gameID)
-		http.Error(w, "You are not a player in this game", http.StatusForbidden)
+	if err := h.engine.RevokeSpectatorToken(gameID, userID, vars["token"]); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	logRequestf(r, "User %d revoked a spectator link for game %d", userID, gameID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Spectator link revoked",
+	})
+}
+
+// HandleSpectatorWebSocket upgrades an anonymous viewer holding a spectator
+// token into the game's room, read-only. It is routed without AuthMiddleware.
+func (h *Handlers) HandleSpectatorWebSocket(w http.ResponseWriter, r *http.Request) {
+	gameID, err := h.engine.SpectatorGame(mux.Vars(r)["token"])
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		if errors.From(err).Code != errors.ErrCodeForbidden {
+			writeError(w, r, err)
+			return
+		}
+		// Upgrade anyway so the viewer gets a typed error and close code
+		// instead of an opaque handshake failure it would keep retrying
+		conn, upgradeErr := h.upgrader.Upgrade(w, r, nil)
+		if upgradeErr != nil {
+			logRequestf(r, "WebSocket upgrade error: %v", upgradeErr)
+			return
+		}
+		h.wsManager.RejectConnection(conn, ws.CloseSpectateDenied, err)
 		return
 	}
 
-	h.wsManager.HandleConnection(conn, gameID, userID)
+	conn := h.upgrade(w, r)
+	if conn == nil {
+		return
+	}
+
+	// Signed-in viewers are known so they can take a free seat
+	var userID int64
+	if sessionID := auth.GetSessionFromRequest(r); sessionID != "" {
+		userID, _ = h.authService.ValidateSession(sessionID)
+	}
+
+	logRequestf(r, "Spectator joined game %d", gameID)
+	h.wsManager.HandleSpectator(conn, gameID, userID)
 }
 
 // WebSocket handler for lobby
 func (h *Handlers) HandleLobbyWebSocket(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("Lobby WebSocket upgrade error: %v", err)
+	conn := h.upgrade(w, r)
+	if conn == nil {
 		return
 	}
 
@@ -435,7 +1309,7 @@ func (h *Handlers) HandleLobbyWebSocket(w http.ResponseWriter, r *http.Request)
 func (h *Handlers) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
@@ -447,8 +1321,8 @@ func (h *Handlers) SearchUsers(w http.ResponseWriter, r *http.Request) {
 
 	users, err := h.authStore.SearchUsers(query, userID, 10)
 	if err != nil {
-		log.Printf("SearchUsers error: %v", err)
-		http.Error(w, "Failed to search users", http.StatusInternalServerError)
+		logRequestf(r, "SearchUsers error: %v", err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to search users"))
 		return
 	}
 
@@ -464,10 +1338,24 @@ func (h *Handlers) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+// friendError turns the friend store's refusals into coded errors; anything
+// else is reported as an internal error without its detail
+func friendError(err error) error {
+	switch {
+	case stderrors.Is(err, store.ErrFriendUnknown):
+		return errors.UserNotFound()
+	case stderrors.Is(err, store.ErrFriendshipExists):
+		return errors.New(errors.ErrCodeConflict, "You are already friends or a request is pending")
+	case stderrors.Is(err, store.ErrNoFriendRequest):
+		return errors.New(errors.ErrCodeNotFound, "No pending friend request found")
+	}
+	return err
+}
+
 func (h *Handlers) SendFriendRequest(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
@@ -475,19 +1363,19 @@ func (h *Handlers) SendFriendRequest(w http.ResponseWriter, r *http.Request) {
 		UserID int64 `json:"userId"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Invalid request"))
 		return
 	}
 
 	if req.UserID == userID {
-		http.Error(w, "Cannot send friend request to yourself", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Cannot send friend request to yourself"))
 		return
 	}
 
 	err := h.authStore.SendFriendRequest(userID, req.UserID)
 	if err != nil {
-		log.Printf("SendFriendRequest error: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		logRequestf(r, "SendFriendRequest error: %v", err)
+		writeError(w, r, friendError(err))
 		return
 	}
 
@@ -497,21 +1385,21 @@ func (h *Handlers) SendFriendRequest(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) AcceptFriendRequest(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
 	vars := mux.Vars(r)
 	friendID, err := strconv.ParseInt(vars["friendId"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid friend ID", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Invalid friend ID"))
 		return
 	}
 
 	err = h.authStore.AcceptFriendRequest(userID, friendID)
 	if err != nil {
-		log.Printf("AcceptFriendRequest error: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		logRequestf(r, "AcceptFriendRequest error: %v", err)
+		writeError(w, r, friendError(err))
 		return
 	}
 
@@ -521,21 +1409,21 @@ func (h *Handlers) AcceptFriendRequest(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) DeclineFriendRequest(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
 	vars := mux.Vars(r)
 	friendID, err := strconv.ParseInt(vars["friendId"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid friend ID", http.StatusBadRequest)
+		writeError(w, r, errors.BadRequest("Invalid friend ID"))
 		return
 	}
 
 	err = h.authStore.DeclineFriendRequest(userID, friendID)
 	if err != nil {
-		log.Printf("DeclineFriendRequest error: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		logRequestf(r, "DeclineFriendRequest error: %v", err)
+		writeError(w, r, friendError(err))
 		return
 	}
 
@@ -545,14 +1433,14 @@ func (h *Handlers) DeclineFriendRequest(w http.ResponseWriter, r *http.Request)
 func (h *Handlers) GetFriends(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
 	friends, err := h.authStore.GetFriends(userID)
 	if err != nil {
-		log.Printf("GetFriends error: %v", err)
-		http.Error(w, "Failed to get friends", http.StatusInternalServerError)
+		logRequestf(r, "GetFriends error: %v", err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to get friends"))
 		return
 	}
 
@@ -570,14 +1458,14 @@ func (h *Handlers) GetFriends(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) GetPendingRequests(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, r, errors.Unauthorized())
 		return
 	}
 
 	requests, err := h.authStore.GetPendingRequests(userID)
 	if err != nil {
-		log.Printf("GetPendingRequests error: %v", err)
-		http.Error(w, "Failed to get requests", http.StatusInternalServerError)
+		logRequestf(r, "GetPendingRequests error: %v", err)
+		writeError(w, r, errors.Wrap(err, errors.ErrCodeInternal, "Failed to get requests"))
 		return
 	}
 
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"monopoly/config"
+	"monopoly/errors"
+	"monopoly/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteError_ValidationFailedListsFields(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeError(rec, httptest.NewRequest("POST", "/api/auth/register", nil), errors.ValidationFailed(
+		errors.FieldError{Field: "username", Code: errors.ErrCodeUserExists, Message: "Username already taken."},
+		errors.FieldError{Field: "password", Code: errors.ErrCodeTooShort, Message: "Password must be at least 8 characters."},
+	))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", rec.Code)
+	}
+	var body map[string]errorBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	got := body["error"]
+	if got.Code != errors.ErrCodeValidationFailed || len(got.Fields) != 2 {
+		t.Fatalf("Expected VALIDATION_FAILED with two fields, got %+v", got)
+	}
+	if got.Fields[0].Field != "username" || got.Fields[0].Code != errors.ErrCodeUserExists || got.Fields[1].Field != "password" {
+		t.Errorf("Expected the username then the password problem, got %+v", got.Fields)
+	}
+	if got.Message != "Username already taken. Password must be at least 8 characters." {
+		t.Errorf("Expected the field messages joined, got %q", got.Message)
+	}
+}
+
+func TestWriteError_JoinConflictsAre409(t *testing.T) {
+	cases := map[*errors.AppError]int{
+		errors.AlreadyInGame():               http.StatusConflict,
+		errors.GameFull():                    http.StatusConflict,
+		errors.GameNotFound():                http.StatusNotFound,
+		errors.BadRequest("Invalid game ID"): http.StatusBadRequest,
+	}
+	for err, want := range cases {
+		rec := httptest.NewRecorder()
+		writeError(rec, httptest.NewRequest("POST", "/api/lobby/join/1", nil), err)
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", err.Code, want, rec.Code)
+		}
+	}
+}
+
+func TestFriendError_CodesRefusalsAndHidesTheRest(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{store.ErrFriendUnknown, http.StatusNotFound},
+		{store.ErrFriendshipExists, http.StatusConflict},
+		{fmt.Errorf("wrapped: %w", store.ErrNoFriendRequest), http.StatusNotFound},
+		{fmt.Errorf("failed to send friend request: database is locked"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		writeError(rec, httptest.NewRequest("POST", "/api/friends/request", nil), friendError(c.err))
+		if rec.Code != c.status {
+			t.Errorf("%v: expected %d, got %d", c.err, c.status, rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "database") {
+			t.Errorf("%v: expected the internal detail kept out of the response, got %s", c.err, rec.Body)
+		}
+	}
+}
+
+func TestAdminMiddleware_FollowsUserIDs(t *testing.T) {
+	cfg := &config.Config{AdminUserIDs: []int64{7}}
+	handler := AdminMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// User 8 may have registered the username user 7 had before deleting
+	// their account; the rights stay with ID 7
+	cases := map[int64]int{7: http.StatusNoContent, 8: http.StatusForbidden}
+	for userID, want := range cases {
+		req := httptest.NewRequest("GET", "/api/admin/connections", nil)
+		req = req.WithContext(context.WithValue(req.Context(), userIDKey, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("User %d: expected %d, got %d", userID, want, rec.Code)
+		}
+	}
+}
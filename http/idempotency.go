@@ -0,0 +1,74 @@
+package http
+
+import (
+	"sync"
+	"time"
+)
+
+// maxIdempotencyKeyLength bounds client-supplied Idempotency-Key values
+const maxIdempotencyKeyLength = 255
+
+type idempotencyKey struct {
+	userID int64
+	key    string
+}
+
+type idempotencyEntry struct {
+	gameID  int64 // 0 while the first request is still creating the game
+	expires time.Time
+}
+
+// idempotencyCache remembers which game a user's Idempotency-Key created so a
+// retried or double-clicked create returns that game instead of a new one.
+// Keys are scoped per user and forgotten after the TTL.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[idempotencyKey]*idempotencyEntry
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:     ttl,
+		entries: make(map[idempotencyKey]*idempotencyEntry),
+	}
+}
+
+// claim looks up a key. If a game was already created with it, that game's
+// ID is returned. Otherwise the key is reserved for the caller (reserved is
+// true), who must call complete or release. A key whose first request is
+// still running returns neither.
+func (c *idempotencyCache) claim(userID int64, key string, now time.Time) (gameID int64, reserved bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Prune on the way in; creates are rare enough that a sweep is cheap
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	k := idempotencyKey{userID, key}
+	if entry, ok := c.entries[k]; ok {
+		return entry.gameID, false
+	}
+	c.entries[k] = &idempotencyEntry{expires: now.Add(c.ttl)}
+	return 0, true
+}
+
+// complete records the game created under a reserved key
+func (c *idempotencyCache) complete(userID int64, key string, gameID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[idempotencyKey{userID, key}]; ok {
+		entry.gameID = gameID
+	}
+}
+
+// release frees a reserved key after a failed create so the client can retry
+func (c *idempotencyCache) release(userID int64, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, idempotencyKey{userID, key})
+}
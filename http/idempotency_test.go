@@ -0,0 +1,35 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyCache_ReplaysPerUserUntilExpiry(t *testing.T) {
+	cache := newIdempotencyCache(5 * time.Minute)
+	now := time.Now()
+
+	if _, reserved := cache.claim(1, "abc", now); !reserved {
+		t.Fatal("Expected the first request to reserve the key")
+	}
+	if gameID, reserved := cache.claim(1, "abc", now); reserved || gameID != 0 {
+		t.Errorf("Expected an in-progress key to be neither reserved nor resolved, got %d, %v", gameID, reserved)
+	}
+
+	cache.complete(1, "abc", 42)
+	if gameID, reserved := cache.claim(1, "abc", now.Add(time.Minute)); reserved || gameID != 42 {
+		t.Errorf("Expected a repeat to return game 42, got %d, %v", gameID, reserved)
+	}
+	if _, reserved := cache.claim(2, "abc", now); !reserved {
+		t.Error("Expected keys to be scoped per user")
+	}
+	if _, reserved := cache.claim(1, "abc", now.Add(6*time.Minute)); !reserved {
+		t.Error("Expected the key to be reusable after it expires")
+	}
+
+	cache.claim(3, "retry", now)
+	cache.release(3, "retry")
+	if _, reserved := cache.claim(3, "retry", now); !reserved {
+		t.Error("Expected a released key to be reusable")
+	}
+}
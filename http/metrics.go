@@ -0,0 +1,122 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"monopoly/errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// metrics counts requests and auth outcomes for GET /metrics, which serves
+// them with the ws gauges in the Prometheus text format
+type metrics struct {
+	logins        atomic.Uint64
+	registrations atomic.Uint64
+	guests        atomic.Uint64
+
+	mu       sync.Mutex
+	requests map[int]uint64 // HTTP status -> responses sent
+}
+
+func newMetrics() *metrics {
+	return &metrics{requests: make(map[int]uint64)}
+}
+
+// Middleware counts every response by status code. WebSocket upgrades count
+// as 101 when they succeed.
+func (m *metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		m.mu.Lock()
+		m.requests[rec.status]++
+		m.mu.Unlock()
+	})
+}
+
+// writeCounter and writeGauge emit one metric in the text exposition format
+func writeCounter(w io.Writer, name, help string, value uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
+
+func writeGauge(w io.Writer, name, help string, value int) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
+}
+
+// Metrics serves the counters and the ws gauges. The gauges are read from the
+// managers at scrape time rather than tracked on every connect.
+func (h *Handlers) Metrics(w http.ResponseWriter, r *http.Request) {
+	stats := h.wsManager.Stats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeGauge(w, "monopoly_active_games", "Games with at least one connected player.", stats.ActiveGames)
+	writeGauge(w, "monopoly_rooms", "Game rooms held in memory.", stats.Rooms)
+
+	fmt.Fprint(w, "# HELP monopoly_ws_clients Connected WebSocket clients by socket kind.\n# TYPE monopoly_ws_clients gauge\n")
+	fmt.Fprintf(w, "monopoly_ws_clients{socket=\"game\"} %d\n", stats.Players)
+	fmt.Fprintf(w, "monopoly_ws_clients{socket=\"spectator\"} %d\n", stats.Spectators)
+	fmt.Fprintf(w, "monopoly_ws_clients{socket=\"lobby\"} %d\n", h.lobbyManager.ClientCount())
+
+	writeCounter(w, "monopoly_logins_total", "Successful logins.", h.metrics.logins.Load())
+	writeCounter(w, "monopoly_registrations_total", "Successful registrations.", h.metrics.registrations.Load())
+	writeCounter(w, "monopoly_guests_total", "Guest accounts created.", h.metrics.guests.Load())
+
+	h.metrics.mu.Lock()
+	statuses := make([]int, 0, len(h.metrics.requests))
+	for status := range h.metrics.requests {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	counts := make([]uint64, len(statuses))
+	for i, status := range statuses {
+		counts[i] = h.metrics.requests[status]
+	}
+	h.metrics.mu.Unlock()
+
+	fmt.Fprint(w, "# HELP monopoly_http_requests_total HTTP responses by status code.\n# TYPE monopoly_http_requests_total counter\n")
+	for i, status := range statuses {
+		fmt.Fprintf(w, "monopoly_http_requests_total{code=\"%d\"} %d\n", status, counts[i])
+	}
+}
+
+// metricsNets parses METRICS_ALLOWED_NETS, which Validate has checked,
+// defaulting to loopback
+func metricsNets(cidrs []string) []*net.IPNet {
+	if len(cidrs) == 0 {
+		cidrs = []string{"127.0.0.0/8", "::1/128"}
+	}
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			nets = append(nets, network)
+		}
+	}
+	return nets
+}
+
+// MetricsAccessMiddleware only lets clients from the allowed networks read the
+// metrics. RemoteAddr is used as is; see getIP. Requests relayed by a reverse
+// proxy carry the proxy's address, so one on the same host passes the
+// loopback default for every client.
+func MetricsAccessMiddleware(allowed []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := net.ParseIP(getIP(r))
+			for _, network := range allowed {
+				if ip != nil && network.Contains(ip) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			logRequestf(r, "Metrics access denied for %s", getIP(r))
+			writeError(w, r, errors.New(errors.ErrCodeForbidden, "Metrics are only available internally"))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"monopoly/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics_CountsResponsesByStatus(t *testing.T) {
+	h := &Handlers{
+		wsManager:    ws.NewManager(nil, nil, ws.Options{}),
+		lobbyManager: ws.NewLobbyManager(nil),
+		metrics:      newMetrics(),
+	}
+	handler := h.metrics.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	for _, path := range []string{"/", "/", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	h.metrics.logins.Add(1)
+
+	rec := httptest.NewRecorder()
+	h.Metrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"monopoly_http_requests_total{code=\"200\"} 2\n",
+		"monopoly_http_requests_total{code=\"404\"} 1\n",
+		"monopoly_logins_total 1\n",
+		"monopoly_registrations_total 0\n",
+		"monopoly_ws_clients{socket=\"lobby\"} 0\n",
+		"# TYPE monopoly_active_games gauge\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsAccessMiddleware_LoopbackByDefault(t *testing.T) {
+	handler := MetricsAccessMiddleware(metricsNets(nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for addr, want := range map[string]int{
+		"127.0.0.1:5000": http.StatusOK,
+		"[::1]:5000":     http.StatusOK,
+		"10.1.2.3:5000":  http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.RemoteAddr = addr
+		// Forwarding headers are ignored, as for rate limiting
+		req.Header.Set("X-Forwarded-For", "127.0.0.1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", addr, want, rec.Code)
+		}
+	}
+}
@@ -1,26 +1,134 @@
 package http
 
 import (
+	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
 	"log"
 	"monopoly/auth"
+	"monopoly/config"
+	"monopoly/errors"
+	"net"
 	"net/http"
 	"time"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	userIDKey    contextKey = "userID"
+	requestIDKey contextKey = "requestID"
+)
+
+// maxRequestIDLength bounds client-supplied X-Request-ID values
+const maxRequestIDLength = 64
+
+// RequestIDMiddleware tags every request with a correlation ID. An incoming
+// X-Request-ID header is reused when it looks sane, otherwise a random ID is
+// generated. The ID is stored in the context and echoed in the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = generateRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID accepts short IDs made of URL-safe characters so that
+// client-supplied values can't inject anything into log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func GetRequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey).(string)
+	return requestID, ok
+}
+
+// logRequestf logs a message prefixed with the request's correlation ID
+func logRequestf(r *http.Request, format string, args ...interface{}) {
+	requestID, ok := GetRequestIDFromContext(r.Context())
+	if !ok {
+		requestID = "-"
+	}
+	log.Printf("["+requestID+"] "+format, args...)
+}
+
+// statusRecorder wraps a ResponseWriter to capture the status code and
+// number of bytes written for access logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Hijack lets WebSocket upgrades work through the recorder
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, stderrors.New("response writer does not support hijacking")
+	}
+	rec.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
 
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
 		duration := time.Since(start)
-		log.Printf("%s %s %v", r.Method, r.URL.Path, duration)
+		logRequestf(r, "%s %s %s %d %dB %v", getIP(r), r.Method, r.URL.Path, rec.status, rec.bytes, duration)
 	})
 }
 
+// generateRequestID returns a short random hex ID for correlating log lines
+func generateRequestID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(bytes)
+}
+
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
@@ -30,8 +138,8 @@ func CORSMiddleware(next http.Handler) http.Handler {
 			if origin == "http://"+r.Host || origin == "https://"+r.Host {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Idempotency-Key")
 			}
 		}
 
@@ -49,13 +157,39 @@ func AuthMiddleware(authService *auth.Service) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			sessionID := auth.GetSessionFromRequest(r)
 			if sessionID == "" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				writeError(w, r, errors.Unauthorized())
 				return
 			}
 
 			userID, valid := authService.ValidateSession(sessionID)
 			if !valid {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				writeError(w, r, errors.Unauthorized())
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WebSocketAuthMiddleware authenticates a WebSocket handshake by the session
+// cookie or, failing that, by a ?token= ticket from POST /api/auth/ws-ticket.
+// The ticket is consumed either way, so it can't be replayed.
+func WebSocketAuthMiddleware(authService *auth.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, valid := int64(0), false
+			if sessionID := auth.GetSessionFromRequest(r); sessionID != "" {
+				userID, valid = authService.ValidateSession(sessionID)
+			}
+			if token := r.URL.Query().Get("token"); token != "" {
+				if ticketUserID, ok := authService.RedeemWSTicket(token); ok && !valid {
+					userID, valid = ticketUserID, true
+				}
+			}
+			if !valid {
+				writeError(w, r, errors.Unauthorized())
 				return
 			}
 
@@ -65,6 +199,27 @@ func AuthMiddleware(authService *auth.Service) func(http.Handler) http.Handler {
 	}
 }
 
+// AdminMiddleware only lets through users listed in ADMIN_USER_IDS.
+// It must run after AuthMiddleware.
+func AdminMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok {
+				writeError(w, r, errors.Unauthorized())
+				return
+			}
+			if !cfg.IsAdmin(userID) {
+				logRequestf(r, "User %d denied admin access", userID)
+				writeError(w, r, errors.New(errors.ErrCodeForbidden, "Admin access required"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func GetUserIDFromContext(ctx context.Context) (int64, bool) {
 	userID, ok := ctx.Value(userIDKey).(int64)
 	return userID, ok
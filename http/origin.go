@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// newOriginCheck builds the WebSocket upgrader's CheckOrigin. Browsers always
+// send Origin, so the default only lets the site itself connect: anything
+// else would let another page open sockets with the player's session cookie.
+// Native clients that send no Origin are allowed. allowed adds exact origins
+// (e.g. "https://play.example.com"), appSchemes adds custom schemes that
+// app webviews use (e.g. "capacitor" for "capacitor://localhost") on any host.
+func newOriginCheck(allowed, appSchemes []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || origin == "http://"+r.Host || origin == "https://"+r.Host {
+			return true
+		}
+		for _, o := range allowed {
+			if strings.EqualFold(origin, o) {
+				return true
+			}
+		}
+		if len(appSchemes) > 0 {
+			if u, err := url.Parse(origin); err == nil {
+				for _, scheme := range appSchemes {
+					if strings.EqualFold(u.Scheme, scheme) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+}
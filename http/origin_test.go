@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginCheck(t *testing.T) {
+	check := newOriginCheck([]string{"https://play.example.com"}, []string{"capacitor"})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true}, // native clients that send no Origin
+		{"http://game.local:8080", true},
+		{"https://game.local:8080", true},
+		{"https://play.example.com", true},
+		{"HTTPS://PLAY.EXAMPLE.COM", true},
+		{"capacitor://localhost", true},
+		{"https://evil.example.com", false},
+		{"https://play.example.com.evil.com", false},
+		{"http://game.local:9090", false},
+		{"null", false},
+		{"ionic://localhost", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://game.local:8080/ws/lobby", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := check(r); got != tt.want {
+			t.Errorf("Origin %q: expected allowed=%v, got %v", tt.origin, tt.want, got)
+		}
+	}
+}
+
+func TestOriginCheck_DefaultsToSameSite(t *testing.T) {
+	check := newOriginCheck(nil, nil)
+
+	r := httptest.NewRequest("GET", "http://game.local:8080/ws/lobby", nil)
+	r.Header.Set("Origin", "capacitor://localhost")
+	if check(r) {
+		t.Error("Expected app origins to be refused unless configured")
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"encoding/json"
+	"monopoly/errors"
+	"monopoly/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestUpgrade_NegotiatesProtocolVersion(t *testing.T) {
+	h := &Handlers{
+		wsManager: ws.NewManager(nil, nil, ws.Options{}),
+		upgrader:  newUpgrader(1024, 1024, false, func(*http.Request) bool { return true }),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn := h.upgrade(w, r); conn != nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dial := func(protocols ...string) *websocket.Conn {
+		t.Helper()
+		dialer := websocket.Dialer{Subprotocols: protocols}
+		conn, _, err := dialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Dial with %v failed: %v", protocols, err)
+		}
+		return conn
+	}
+
+	conn := dial("monopoly.v9", ws.ProtocolV1)
+	if conn.Subprotocol() != ws.ProtocolV1 {
+		t.Errorf("Expected %s to be picked, got %q", ws.ProtocolV1, conn.Subprotocol())
+	}
+	conn.Close()
+
+	// Clients from before versioning offer nothing and are still served
+	conn = dial()
+	if conn.Subprotocol() != "" {
+		t.Errorf("Expected no protocol header for a legacy client, got %q", conn.Subprotocol())
+	}
+	conn.Close()
+
+	conn = dial("monopoly.v9")
+	defer conn.Close()
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Expected an error message before the close, got %v", err)
+	}
+	var msg struct {
+		Type    string          `json:"type"`
+		Payload ws.ErrorPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Payload.Code != errors.ErrCodeUnsupportedProtocol {
+		t.Errorf("Expected UNSUPPORTED_PROTOCOL, got %s (%v)", data, err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, ws.CloseUnsupportedProtocol) {
+		t.Errorf("Expected close code %d, got %v", ws.CloseUnsupportedProtocol, err)
+	}
+}
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"math"
+	"monopoly/errors"
 	"net"
 	"net/http"
 	"sync"
@@ -70,8 +72,8 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 		ip := getIP(r)
 		limiter := rl.getLimiter(ip)
 
-		if !limiter.Allow() {
-			http.Error(w, "Too many requests. Please try again later.", http.StatusTooManyRequests)
+		if allowed, wait := reserve(limiter, time.Now()); !allowed {
+			writeRateLimited(w, r, wait)
 			return
 		}
 
@@ -79,6 +81,31 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// reserve takes a token if one is free. Otherwise the reservation is handed
+// back, so a rejected request doesn't push the next token further out, and the
+// wait until a token frees up is returned.
+func reserve(limiter *rate.Limiter, now time.Time) (bool, time.Duration) {
+	res := limiter.ReserveN(now, 1)
+	if !res.OK() {
+		// A zero burst never allows anything; there's no wait worth reporting
+		return false, 0
+	}
+	wait := res.DelayFrom(now)
+	if wait == 0 {
+		return true, 0
+	}
+	res.CancelAt(now)
+	return false, wait
+}
+
+// writeRateLimited rejects a request with a Retry-After header, also sent as
+// retryAfter in the error body, in whole seconds rounded up
+func writeRateLimited(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	appErr := errors.New(errors.ErrCodeRateLimited, "Too many requests. Please try again later.")
+	appErr.RetryAfter = max(int(math.Ceil(wait.Seconds())), 1)
+	writeError(w, r, appErr)
+}
+
 func getIP(r *http.Request) string {
 	// Only trust RemoteAddr — X-Forwarded-For and X-Real-IP are trivially
 	// spoofable without a trusted reverse proxy in front of this server.
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestReserve_RejectionsDontConsumeTokens(t *testing.T) {
+	limiter := rate.NewLimiter(rate.Every(time.Second), 1)
+	now := time.Now()
+
+	if allowed, _ := reserve(limiter, now); !allowed {
+		t.Fatal("Expected the first request to use the burst")
+	}
+	for i := 0; i < 5; i++ {
+		allowed, wait := reserve(limiter, now)
+		if allowed || wait != time.Second {
+			t.Fatalf("Rejection %d: expected a 1s wait, got %v, %v", i, allowed, wait)
+		}
+	}
+	if allowed, _ := reserve(limiter, now.Add(time.Second)); !allowed {
+		t.Error("Expected a request once the reported wait passed, rejections must not push it back")
+	}
+}
+
+func TestRateLimiterMiddleware_SendsRetryAfter(t *testing.T) {
+	rl := NewRateLimiter(rate.Every(time.Minute), 1)
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/api/auth/login", nil))
+		return rec
+	}
+
+	if rec := send(); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request through, got %d", rec.Code)
+	}
+	rec := send()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", got)
+	}
+
+	var body map[string]errorBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if body["error"].Code != "RATE_LIMITED" || body["error"].RetryAfter != 60 {
+		t.Errorf("Expected RATE_LIMITED with retryAfter 60, got %+v", body["error"])
+	}
+}
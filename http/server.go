@@ -1,7 +1,10 @@
 package http
 
 import (
+	"io/fs"
 	"monopoly/auth"
+	"monopoly/config"
+	"monopoly/errors"
 	"monopoly/game"
 	"monopoly/store"
 	"monopoly/ws"
@@ -9,20 +12,26 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
 )
 
 type Server struct {
 	router   *mux.Router
 	handlers *Handlers
+	cfg      *config.Config
+	static   fs.FS // frontend files, index.html at the root
 }
 
-func NewServer(authService *auth.Service, authStore store.AuthStore, lobby *game.Lobby, engine *game.Engine, wsManager *ws.Manager, lobbyManager *ws.LobbyManager) *Server {
+// NewServer serves the API, the sockets and the frontend in static
+func NewServer(cfg *config.Config, static fs.FS, authService *auth.Service, authStore store.AuthStore, lobby *game.Lobby, engine *game.Engine, wsManager *ws.Manager, lobbyManager *ws.LobbyManager) *Server {
 	router := mux.NewRouter()
-	handlers := NewHandlers(authService, authStore, lobby, engine, wsManager, lobbyManager)
+	handlers := NewHandlers(cfg, authService, authStore, lobby, engine, wsManager, lobbyManager)
 
 	server := &Server{
 		router:   router,
 		handlers: handlers,
+		cfg:      cfg,
+		static:   static,
 	}
 
 	server.setupRoutes(authService)
@@ -30,8 +39,10 @@ func NewServer(authService *auth.Service, authStore store.AuthStore, lobby *game
 }
 
 func (s *Server) setupRoutes(authService *auth.Service) {
-	// Apply global middleware
+	// Apply global middleware (request ID first so logging can see it)
+	s.router.Use(RequestIDMiddleware)
 	s.router.Use(LoggingMiddleware)
+	s.router.Use(s.handlers.metrics.Middleware)
 	s.router.Use(SecurityHeadersMiddleware)
 	s.router.Use(CORSMiddleware)
 
@@ -39,24 +50,61 @@ func (s *Server) setupRoutes(authService *auth.Service) {
 	// requests from including the cookie, providing CSRF protection for all
 	// state-changing endpoints without needing a token-based scheme.
 
+	// Health checks (public, registered before the SPA fallback)
+	s.router.HandleFunc("/healthz", s.handlers.Healthz).Methods("GET")
+	s.router.HandleFunc("/readyz", s.handlers.Readyz).Methods("GET")
+	if s.cfg.MetricsEnabled {
+		metricsAccess := MetricsAccessMiddleware(metricsNets(s.cfg.MetricsAllowedNets))
+		s.router.Handle("/metrics", metricsAccess(http.HandlerFunc(s.handlers.Metrics))).Methods("GET")
+	}
+
 	// Rate limiters for auth endpoints
-	loginLimiter := NewRateLimiter(5.0/60.0, 5)
-	registerLimiter := NewRateLimiter(3.0/60.0, 3)
+	loginLimiter := NewRateLimiter(rate.Limit(s.cfg.LoginRatePerMin/60.0), s.cfg.LoginBurst)
+	registerLimiter := NewRateLimiter(rate.Limit(s.cfg.RegisterRatePerMin/60.0), s.cfg.RegisterBurst)
 
 	// Auth routes (public) with rate limiting
 	s.router.Handle("/api/auth/register", registerLimiter.Middleware(http.HandlerFunc(s.handlers.Register))).Methods("POST")
 	s.router.Handle("/api/auth/login", loginLimiter.Middleware(http.HandlerFunc(s.handlers.Login))).Methods("POST")
+	s.router.Handle("/api/auth/guest", registerLimiter.Middleware(http.HandlerFunc(s.handlers.Guest))).Methods("POST")
 
 	// Protected routes
 	protected := s.router.PathPrefix("/api").Subrouter()
 	protected.Use(AuthMiddleware(authService))
 
 	protected.HandleFunc("/auth/logout", s.handlers.Logout).Methods("POST")
+	protected.HandleFunc("/auth/logout-all", s.handlers.LogoutAll).Methods("POST")
+	protected.HandleFunc("/auth/sessions", s.handlers.Sessions).Methods("GET")
+	protected.HandleFunc("/auth/account", s.handlers.DeleteAccount).Methods("DELETE")
+	protected.HandleFunc("/auth/display-name", s.handlers.SetDisplayName).Methods("PUT")
+	protected.HandleFunc("/auth/claim", s.handlers.ClaimGuest).Methods("POST")
+	protected.HandleFunc("/auth/ws-ticket", s.handlers.WSTicket).Methods("POST")
+	protected.HandleFunc("/board", s.handlers.GetBoard).Methods("GET")
 	protected.HandleFunc("/lobby/games", s.handlers.ListGames).Methods("GET")
+	protected.HandleFunc("/lobby/my-games", s.handlers.MyGames).Methods("GET")
 	protected.HandleFunc("/lobby/create", s.handlers.CreateGame).Methods("POST")
 	protected.HandleFunc("/lobby/join/{gameId}", s.handlers.JoinGame).Methods("POST")
 	protected.HandleFunc("/lobby/leave/{gameId}", s.handlers.LeaveGame).Methods("POST")
+	protected.HandleFunc("/lobby/ready/{gameId}", s.handlers.SetReady).Methods("POST")
+	protected.HandleFunc("/lobby/start/{gameId}", s.handlers.StartGame).Methods("POST")
 	protected.HandleFunc("/lobby/games/{gameId}", s.handlers.GetGame).Methods("GET")
+	protected.HandleFunc("/lobby/games/{gameId}", s.handlers.CancelGame).Methods("DELETE")
+	protected.HandleFunc("/lobby/games/{gameId}/full", s.handlers.GetGameSnapshot).Methods("GET")
+	protected.HandleFunc("/lobby/games/{gameId}/events", s.handlers.GetGameEvents).Methods("GET")
+	protected.HandleFunc("/lobby/games/{gameId}/rolls", s.handlers.GetGameRolls).Methods("GET")
+	protected.HandleFunc("/lobby/games/{gameId}/properties/{spaceIndex}", s.handlers.GetProperty).Methods("GET")
+	protected.HandleFunc("/lobby/games/{gameId}/landing/{spaceIndex}", s.handlers.PreviewLanding).Methods("GET")
+	protected.HandleFunc("/lobby/games/{gameId}/spectators", s.handlers.CreateSpectatorToken).Methods("POST")
+	protected.HandleFunc("/lobby/games/{gameId}/spectators/{token}", s.handlers.RevokeSpectatorToken).Methods("DELETE")
+
+	// Admin routes
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(AdminMiddleware(s.cfg))
+	admin.HandleFunc("/connections", s.handlers.AdminConnections).Methods("GET")
+	admin.HandleFunc("/games/archived", s.handlers.AdminListArchivedGames).Methods("GET")
+	admin.HandleFunc("/games/{gameId}", s.handlers.AdminGetGame).Methods("GET")
+	admin.HandleFunc("/games/{gameId}/audit", s.handlers.AdminMoneyAudit).Methods("GET")
+	admin.HandleFunc("/games/{gameId}/archive", s.handlers.AdminArchiveGame).Methods("POST")
+	admin.HandleFunc("/games/{gameId}/finish", s.handlers.AdminFinishGame).Methods("POST")
 
 	// Friends routes
 	protected.HandleFunc("/users/search", s.handlers.SearchUsers).Methods("GET")
@@ -66,21 +114,32 @@ func (s *Server) setupRoutes(authService *auth.Service) {
 	protected.HandleFunc("/friends/accept/{friendId}", s.handlers.AcceptFriendRequest).Methods("POST")
 	protected.HandleFunc("/friends/decline/{friendId}", s.handlers.DeclineFriendRequest).Methods("POST")
 
+	// Spectator sockets authenticate with their token, not a session, so
+	// they're registered ahead of the protected /ws subrouter
+	s.router.HandleFunc("/ws/spectate/{token}", s.handlers.HandleSpectatorWebSocket)
+
 	// WebSocket routes (protected)
 	wsRouter := s.router.PathPrefix("/ws").Subrouter()
-	wsRouter.Use(AuthMiddleware(authService))
+	wsRouter.Use(WebSocketAuthMiddleware(authService))
 	wsRouter.HandleFunc("/lobby", s.handlers.HandleLobbyWebSocket)
 	wsRouter.HandleFunc("/game/{gameId}", s.handlers.HandleWebSocket)
 
 	// Catch-all for unmatched API routes — return JSON 404 instead of SPA HTML
 	s.router.PathPrefix("/api/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		writeError(w, r, errors.New(errors.ErrCodeNotFound, "Not found"))
 	})
 
+	s.setupStaticRoutes()
+}
+
+// setupStaticRoutes serves the frontend. Registered last: the SPA fallback
+// matches everything.
+func (s *Server) setupStaticRoutes() {
 	// Static files with cache-control (no-cache forces revalidation via If-Modified-Since)
-	s.router.PathPrefix("/css/").Handler(noCacheHandler(http.StripPrefix("/css/", http.FileServer(http.Dir("./static/css")))))
-	s.router.PathPrefix("/js/").Handler(noCacheHandler(http.StripPrefix("/js/", http.FileServer(http.Dir("./static/js")))))
-	s.router.PathPrefix("/templates/").Handler(noCacheHandler(http.StripPrefix("/templates/", http.FileServer(http.Dir("./static/templates")))))
+	files := noCacheHandler(http.FileServerFS(s.static))
+	s.router.PathPrefix("/css/").Handler(files)
+	s.router.PathPrefix("/js/").Handler(files)
+	s.router.PathPrefix("/templates/").Handler(files)
 
 	// SPA fallback - serve index.html for all other routes
 	s.router.PathPrefix("/").HandlerFunc(s.serveSPA)
@@ -95,7 +154,7 @@ func noCacheHandler(h http.Handler) http.Handler {
 
 func (s *Server) serveSPA(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
-	http.ServeFile(w, r, "./static/index.html")
+	http.ServeFileFS(w, r, s.static, "index.html")
 }
 
 func (s *Server) GetHTTPServer(addr string) *http.Server {
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gorilla/mux"
+)
+
+func TestStaticRoutes_ServeFromConfiguredRoot(t *testing.T) {
+	s := &Server{
+		router: mux.NewRouter(),
+		static: fstest.MapFS{
+			"index.html":        {Data: []byte("spa")},
+			"js/app.js":         {Data: []byte("app")},
+			"css/style.css":     {Data: []byte("style")},
+			"templates/game.ht": {Data: []byte("tmpl")},
+		},
+	}
+	s.setupStaticRoutes()
+
+	for path, want := range map[string]string{
+		"/js/app.js":         "app",
+		"/css/style.css":     "style",
+		"/templates/game.ht": "tmpl",
+		"/":                  "spa",
+		"/game/12":           "spa",
+	} {
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("%s: expected 200 %q, got %d %q", path, want, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Cache-Control"); got != "no-cache" {
+			t.Errorf("%s: expected Cache-Control no-cache, got %q", path, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest("GET", "/js/missing.js", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing asset, got %d", rec.Code)
+	}
+}
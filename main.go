@@ -12,6 +12,7 @@ import (
 	stdhttp "net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
 	"time"
 )
@@ -21,10 +22,13 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
-	log.Printf("Configuration loaded - Server port: %s, DB path: %s", cfg.ServerPort, cfg.DBPath)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	log.Printf("Configuration loaded - Server port: %s, DB driver: %s", cfg.ServerPort, cfg.DBDriver)
 
 	// Initialize database
-	db, err := store.InitDB(cfg.DBPath, cfg.MaxOpenConns, cfg.MaxIdleConns)
+	db, err := store.Open(cfg.DBDriver, cfg.DBDSN, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.DBBusyTimeout)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -37,15 +41,58 @@ func main() {
 	gameStore := store.NewGameStore(db)
 
 	// Initialize services
-	sessionManager := auth.NewSessionManager(db)
+	sessionManager := auth.NewSessionManager(store.NewSessionStore(db), auth.SessionOptions{
+		TTL:             cfg.SessionTTL,
+		IdleTTL:         cfg.SessionIdleTTL,
+		CleanupInterval: cfg.SessionCleanupInterval,
+		GuestTTL:        cfg.GuestSessionTTL,
+	})
 	authService := auth.NewService(authStore, sessionManager)
-	lobby := game.NewLobby(lobbyStore)
+	authService.SetUsernamePolicy(auth.UsernamePolicy{
+		MinLength: cfg.UsernameMinLength,
+		MaxLength: cfg.UsernameMaxLength,
+		Pattern:   regexp.MustCompile(cfg.UsernamePattern),
+	})
+	authService.StartGuestCleanup(cfg.SessionCleanupInterval)
+	lobby := game.NewLobby(lobbyStore, cfg.MaxActiveGamesPerUser)
+	if cfg.GameArchiveAfter > 0 {
+		lobby.StartArchiver(cfg.GameArchiveInterval, cfg.GameArchiveAfter)
+	}
 	engine := game.NewEngine(gameStore)
+	if cfg.SeededRandomness {
+		log.Printf("Seeded randomness enabled: dice and card shuffles follow each game's seed")
+		engine.SetSeededRandomness(true)
+	}
+	if cfg.MoneyAudit {
+		log.Printf("Money audit enabled: money is checked after every action that moves it")
+		engine.SetMoneyAudit(true)
+	}
 	lobbyManager := ws.NewLobbyManager(lobby)
-	wsManager := ws.NewManager(engine, lobbyManager)
+	wsManager := ws.NewManager(engine, lobbyManager, ws.Options{
+		MaxMessageSize:    int64(cfg.WSMaxMessageSize),
+		SendBufferSize:    cfg.WSSendBufferSize,
+		StartCountdown:    time.Duration(cfg.StartCountdownSeconds) * time.Second,
+		MessageRate:       cfg.WSMessageRate,
+		MessageBurst:      cfg.WSMessageBurst,
+		RoomIdleTimeout:   cfg.RoomIdleTimeout,
+		FineGrainedEvents: cfg.WSFineGrainedEvents,
+		TradeTTL:          cfg.TradeTTL,
+		AbandonAfter:      cfg.GameAbandonAfter,
+		ReconnectGrace:    cfg.TurnReconnectGrace,
+	})
+	if cfg.RoomIdleTimeout > 0 {
+		wsManager.StartRoomSweeper(cfg.RoomSweepInterval)
+	}
+	if cfg.GameAbandonAfter > 0 {
+		wsManager.StartAbandonedGameSweeper(cfg.GameAbandonInterval)
+	}
 
 	// Initialize HTTP server
-	server := httpserver.NewServer(authService, authStore, lobby, engine, wsManager, lobbyManager)
+	static, err := staticFiles(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load static files: %v", err)
+	}
+	server := httpserver.NewServer(cfg, static, authService, authStore, lobby, engine, wsManager, lobbyManager)
 	srv := server.GetHTTPServer(cfg.ServerPort)
 
 	// Start server in a goroutine
@@ -72,5 +119,13 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Close WebSocket connections (hijacked, so srv.Shutdown doesn't track them)
+	if err := wsManager.Shutdown(ctx); err != nil {
+		log.Printf("Game WebSocket shutdown incomplete: %v", err)
+	}
+	if err := lobbyManager.Shutdown(ctx); err != nil {
+		log.Printf("Lobby WebSocket shutdown incomplete: %v", err)
+	}
+
 	log.Println("Server stopped")
 }
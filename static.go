@@ -0,0 +1,27 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"monopoly/config"
+	"os"
+)
+
+// embeddedStatic is the frontend compiled into the binary, so it can run
+// without the static directory next to it
+//
+//go:embed static
+var embeddedStatic embed.FS
+
+// staticFiles picks where the frontend is served from: the embedded copy
+// with EMBED_STATIC, otherwise STATIC_DIR on disk so edits show up without
+// a rebuild
+func staticFiles(cfg *config.Config) (fs.FS, error) {
+	if cfg.EmbedStatic {
+		log.Println("Serving embedded static files")
+		return fs.Sub(embeddedStatic, "static")
+	}
+	log.Printf("Serving static files from %s", cfg.StaticDir)
+	return os.DirFS(cfg.StaticDir), nil
+}
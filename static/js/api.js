@@ -28,12 +28,18 @@ class ApiClient {
                 // Try to parse error as JSON first
                 const contentType = response.headers.get('content-type');
                 let errorMessage = `HTTP ${response.status}`;
+                let errorCode;
+                let retryAfter;
+                let fields;
 
                 try {
                     if (contentType && contentType.includes('application/json')) {
                         const errorData = await response.json();
-                        // Use the user-friendly message from structured error
-                        errorMessage = errorData.message || errorData.error || errorMessage;
+                        // Errors are {error: {code, message}}; show the user-friendly message
+                        errorMessage = errorData.error?.message || errorMessage;
+                        errorCode = errorData.error?.code;
+                        retryAfter = errorData.error?.retryAfter;
+                        fields = errorData.error?.fields;
                     } else {
                         errorMessage = await response.text() || errorMessage;
                     }
@@ -45,12 +51,17 @@ class ApiClient {
                 // Handle unauthorized specially
                 if (response.status === 401) {
                     // Don't redirect on login/register endpoints
-                    if (endpoint !== '/api/auth/login' && endpoint !== '/api/auth/register') {
+                    if (endpoint !== '/api/auth/login' && endpoint !== '/api/auth/register' && endpoint !== '/api/auth/guest') {
                         this.handleUnauthorized();
                     }
                 }
 
-                throw new Error(errorMessage);
+                const error = new Error(errorMessage);
+                error.code = errorCode;
+                error.status = response.status;
+                error.retryAfter = retryAfter; // seconds, on RATE_LIMITED
+                error.fields = fields || []; // [{field, code, message}], on VALIDATION_FAILED
+                throw error;
             }
 
             const contentType = response.headers.get('content-type');
@@ -92,15 +103,35 @@ class ApiClient {
             body: JSON.stringify({ username, password }),
         });
 
+        this.saveUser(data);
+        return data;
+    }
+
+    saveUser(data) {
         if (data.userId && data.username) {
             try {
                 localStorage.setItem('userId', data.userId);
                 localStorage.setItem('username', data.username);
+                localStorage.setItem('displayName', data.displayName || '');
+                localStorage.setItem('guest', data.guest ? '1' : '');
             } catch (e) {
                 console.warn('Failed to save to localStorage:', e);
             }
         }
+    }
 
+    async playAsGuest() {
+        const data = await this.request('/api/auth/guest', { method: 'POST' });
+        this.saveUser(data);
+        return data;
+    }
+
+    async claimGuest(username, password) {
+        const data = await this.request('/api/auth/claim', {
+            method: 'POST',
+            body: JSON.stringify({ username, password }),
+        });
+        this.saveUser(data);
         return data;
     }
 
@@ -116,24 +147,94 @@ class ApiClient {
         }
     }
 
-    async listGames() {
-        return this.request('/api/lobby/games');
+    // Signs out every device, this one included
+    async logoutAll() {
+        try {
+            return await this.request('/api/auth/logout-all', { method: 'POST' });
+        } finally {
+            try {
+                localStorage.clear();
+            } catch (e) {
+                console.warn('Failed to clear localStorage:', e);
+            }
+        }
+    }
+
+    async getSessions() {
+        return this.request('/api/auth/sessions');
+    }
+
+    async deleteAccount(password) {
+        return this.request('/api/auth/account', {
+            method: 'DELETE',
+            body: JSON.stringify({ password }),
+        });
+    }
+
+    // An empty name clears it; other players then see the username again
+    async setDisplayName(displayName) {
+        const data = await this.request('/api/auth/display-name', {
+            method: 'PUT',
+            body: JSON.stringify({ displayName }),
+        });
+        try {
+            localStorage.setItem('displayName', data.displayName);
+        } catch (e) {
+            console.warn('Failed to save to localStorage:', e);
+        }
+        return data;
+    }
+
+    async listGames({ limit, offset, status, joinable } = {}) {
+        const params = new URLSearchParams();
+        if (limit !== undefined) params.set('limit', limit);
+        if (offset !== undefined) params.set('offset', offset);
+        if (status) params.set('status', status);
+        if (joinable) params.set('joinable', 'true');
+        const query = params.toString();
+        return this.request('/api/lobby/games' + (query ? `?${query}` : ''));
     }
 
     async getGame(gameId) {
         return this.request(`/api/lobby/games/${gameId}`);
     }
 
-    async createGame(maxPlayers = 4) {
+    async getProperty(gameId, position) {
+        return this.request(`/api/lobby/games/${gameId}/properties/${position}`);
+    }
+
+    // What landing on a space would do to the current user, without playing it
+    async previewLanding(gameId, position) {
+        return this.request(`/api/lobby/games/${gameId}/landing/${position}`);
+    }
+
+    async getGameEvents(gameId, since = 0) {
+        return this.request(`/api/lobby/games/${gameId}/events?since=${since}`);
+    }
+
+    async getGameRolls(gameId, limit = 20) {
+        return this.request(`/api/lobby/games/${gameId}/rolls?limit=${limit}`);
+    }
+
+    // board is an optional custom board: getBoard()'s spaces with new names, prices or rents
+    async createGame(maxPlayers = 4, { minPlayers = 2, turnLimit = 0, timeLimitMinutes = 0, manualStart = false, board, houseLimit = 0, hotelLimit = 0, unlimitedBuilding = false } = {}, idempotencyKey) {
         return this.request('/api/lobby/create', {
             method: 'POST',
-            body: JSON.stringify({ maxPlayers }),
+            headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : {},
+            body: JSON.stringify({ maxPlayers, minPlayers, turnLimit, timeLimitMinutes, manualStart, board, houseLimit, hotelLimit, unlimitedBuilding }),
         });
     }
 
-    async joinGame(gameId) {
+    // The standard board, or the one a game is played on
+    async getBoard(gameId) {
+        return this.request(gameId ? `/api/board?gameId=${gameId}` : '/api/board');
+    }
+
+    // token is optional; the server picks a free one without it
+    async joinGame(gameId, token) {
         return this.request(`/api/lobby/join/${gameId}`, {
             method: 'POST',
+            ...(token ? { body: JSON.stringify({ token }) } : {}),
         });
     }
 
@@ -143,13 +244,74 @@ class ApiClient {
         });
     }
 
-    getWebSocketURL(target) {
+    async setReady(gameId, ready) {
+        return this.request(`/api/lobby/ready/${gameId}`, {
+            method: 'POST',
+            body: JSON.stringify({ ready }),
+        });
+    }
+
+    // Host only: starts a waiting game without waiting for everyone to be ready
+    async startGame(gameId) {
+        return this.request(`/api/lobby/start/${gameId}`, {
+            method: 'POST',
+        });
+    }
+
+    // Host only: calls off a waiting game for everyone in it
+    async cancelGame(gameId) {
+        return this.request(`/api/lobby/games/${gameId}`, {
+            method: 'DELETE',
+        });
+    }
+
+    async createSpectatorLink(gameId) {
+        const { token } = await this.request(`/api/lobby/games/${gameId}/spectators`, {
+            method: 'POST',
+        });
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        return { token, url: `${protocol}//${window.location.host}/ws/spectate/${token}` };
+    }
+
+    async revokeSpectatorLink(gameId, token) {
+        return this.request(`/api/lobby/games/${gameId}/spectators/${encodeURIComponent(token)}`, {
+            method: 'DELETE',
+        });
+    }
+
+    // Message protocol version offered in Sec-WebSocket-Protocol; the server
+    // closes with 4010 if it no longer speaks it
+    get wsProtocol() {
+        return 'monopoly.v1';
+    }
+
+    // A single-use ticket for clients whose WebSocket upgrades arrive without
+    // the session cookie; pass it to getWebSocketURL within 30 seconds
+    async getWebSocketTicket() {
+        const data = await this.request('/api/auth/ws-ticket', { method: 'POST' });
+        return data.ticket;
+    }
+
+    // getWebSocketURL with a fresh ticket, for each connect and reconnect.
+    // If the ticket can't be had the socket still tries the cookie.
+    async getTicketedWebSocketURL(target) {
+        let ticket = null;
+        try {
+            ticket = await this.getWebSocketTicket();
+        } catch (error) {
+            console.warn('Failed to get a WebSocket ticket:', error);
+        }
+        return this.getWebSocketURL(target, ticket);
+    }
+
+    getWebSocketURL(target, ticket) {
         const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const query = ticket ? `?token=${encodeURIComponent(ticket)}` : '';
         if (target === 'lobby') {
-            return `${protocol}//${window.location.host}/ws/lobby`;
+            return `${protocol}//${window.location.host}/ws/lobby${query}`;
         }
         // Assume target is a gameId
-        return `${protocol}//${window.location.host}/ws/game/${target}`;
+        return `${protocol}//${window.location.host}/ws/game/${target}${query}`;
     }
 
     isAuthenticated() {
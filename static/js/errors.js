@@ -0,0 +1,37 @@
+// Stable error codes sent by the server (see errors/errors.go). Branch on
+// these rather than on the message text, which may change.
+export const ErrorCode = Object.freeze({
+    GAME_NOT_FOUND: 'GAME_NOT_FOUND',
+    GAME_NOT_STARTED: 'GAME_NOT_STARTED',
+    GAME_FINISHED: 'GAME_FINISHED',
+    NOT_YOUR_TURN: 'NOT_YOUR_TURN',
+    ALREADY_ROLLED: 'ALREADY_ROLLED',
+    MUST_ROLL: 'MUST_ROLL',
+    PENDING_ACTION: 'PENDING_ACTION',
+    NO_AUCTION: 'NO_AUCTION',
+    NOT_YOUR_BID: 'NOT_YOUR_BID',
+    RATE_LIMITED: 'RATE_LIMITED',
+    INTERNAL_ERROR: 'INTERNAL_ERROR',
+});
+
+// Codes that mean the client acted on an outdated view of the game
+const staleStateCodes = new Set([
+    ErrorCode.GAME_NOT_STARTED,
+    ErrorCode.NOT_YOUR_TURN,
+    ErrorCode.ALREADY_ROLLED,
+    ErrorCode.MUST_ROLL,
+    ErrorCode.PENDING_ACTION,
+    ErrorCode.NO_AUCTION,
+    ErrorCode.NOT_YOUR_BID,
+]);
+
+export function isStaleStateError(code) {
+    return staleStateCodes.has(code);
+}
+
+// Localized text by code; codes not listed fall back to the server's message
+const messages = {};
+
+export function errorText(payload) {
+    return messages[payload.code] || payload.message;
+}
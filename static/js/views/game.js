@@ -1,5 +1,6 @@
 import { api } from '../api.js';
 import { templateLoader } from '../template.js';
+import { errorText, isStaleStateError } from '../errors.js';
 
 let ws = null;
 let gameState = null;
@@ -12,6 +13,8 @@ let turnTimerEnd = null; // When the current turn timer expires
 let turnTimerDuration = 60; // Total duration in seconds
 let activeAuction = null; // Current auction state
 let reconnectAttempts = 0; // Reconnection attempts counter
+let connectGeneration = 0; // Bumped on cleanup so a connect awaiting its ticket gives up
+let lastEventSeq = 0; // Seq of the last logged event received, for replay after reconnect
 const maxReconnectAttempts = 10; // Maximum reconnection attempts
 const baseReconnectDelay = 1000; // Base delay in ms
 
@@ -35,11 +38,17 @@ export async function render(container, router) {
     container.querySelector('#rollDiceBtn').addEventListener('click', rollDice);
     container.querySelector('#buyBtn').addEventListener('click', buyProperty);
     container.querySelector('#passBtn').addEventListener('click', passProperty);
+    container.querySelector('#taxFlatBtn').addEventListener('click', () => payTax('flat'));
+    container.querySelector('#taxPercentBtn').addEventListener('click', () => payTax('percent'));
     container.querySelector('#payBailBtn').addEventListener('click', payJailBail);
     container.querySelector('#useJailCardBtn').addEventListener('click', useJailCard);
     container.querySelector('#auctionBidBtn').addEventListener('click', placeBid);
     container.querySelector('#auctionPassBtn').addEventListener('click', passAuction);
 
+    container.querySelectorAll('[data-space]').forEach(el => {
+        el.addEventListener('click', (e) => showPropertyCard(e, gameId, parseInt(el.dataset.space), container));
+    });
+
     const chatInput = container.querySelector('#chatInput');
     const sendChatBtn = container.querySelector('#sendChatBtn');
 
@@ -58,6 +67,7 @@ export async function render(container, router) {
 }
 
 export function cleanup() {
+    connectGeneration++;
     if (reconnectTimeout) {
         clearTimeout(reconnectTimeout);
         reconnectTimeout = null;
@@ -81,21 +91,28 @@ export function cleanup() {
     turnTimerEnd = null;
     activeAuction = null;
     reconnectAttempts = 0;
+    lastEventSeq = 0;
 }
 
-function connectWebSocket(gameId, userId, container) {
-    const wsURL = api.getWebSocketURL(gameId);
-    ws = new WebSocket(wsURL);
+async function connectWebSocket(gameId, userId, container) {
+    const generation = connectGeneration;
+    const wsURL = await api.getTicketedWebSocketURL(gameId);
+    if (generation !== connectGeneration) return; // left the game meanwhile
+    ws = new WebSocket(wsURL, api.wsProtocol);
 
     ws.onopen = () => {
         addLog('Connected to game', 'system', container);
         reconnectAttempts = 0; // Reset reconnect attempts on successful connection
         hideReconnectIndicator(container);
-        loadGameState(gameId, userId, container);
+        // The server sends a game_state snapshot right after connecting
+        if (lastEventSeq > 0) {
+            replayMissedEvents(gameId, container);
+        }
     };
 
     ws.onmessage = (event) => {
         const message = JSON.parse(event.data);
+        if (message.seq) lastEventSeq = Math.max(lastEventSeq, message.seq);
         handleWebSocketMessage(message, gameId, userId, container);
     };
 
@@ -104,7 +121,36 @@ function connectWebSocket(gameId, userId, container) {
         console.error('WebSocket error:', error);
     };
 
-    ws.onclose = () => {
+    ws.onclose = (event) => {
+        if (event.code === 4001) {
+            // Replaced by a newer connection (another tab); don't fight over the seat
+            addLog('Game opened in another tab. Refresh to continue here.', 'system', container);
+            ws = null;
+            return;
+        }
+        if (event.code === 4002) {
+            // Game force-finished by an operator or cancelled by its host; nothing to reconnect to
+            ws = null;
+            return;
+        }
+        if (event.code === 4004) {
+            // Game doesn't exist (bad link or deleted); retrying won't help
+            addLog('Game not found.', 'system', container);
+            ws = null;
+            return;
+        }
+        if (event.code === 4010) {
+            // This page speaks a protocol version the server dropped
+            addLog('The game was updated. Refresh the page to continue.', 'system', container);
+            ws = null;
+            return;
+        }
+        if (event.code === 4029) {
+            // Closed for flooding the socket; reconnecting would just repeat it
+            addLog('Disconnected for sending too many messages. Refresh to rejoin.', 'system', container);
+            ws = null;
+            return;
+        }
         addLog('Disconnected from game', 'system', container);
         if (ws !== null && reconnectAttempts < maxReconnectAttempts) {
             reconnectAttempts++;
@@ -125,10 +171,30 @@ function connectWebSocket(gameId, userId, container) {
     };
 }
 
+// Fill the activity log with what happened while disconnected. Board and
+// players come from the fresh snapshot, so events are only logged, not applied.
+async function replayMissedEvents(gameId, container) {
+    try {
+        const { events } = await api.getGameEvents(gameId, lastEventSeq);
+        for (const ev of events) {
+            lastEventSeq = Math.max(lastEventSeq, ev.seq);
+            if (ev.type === 'chat') {
+                addLog(ev.payload.message, 'chat', container, ev.payload.userId, ev.payload.username);
+            }
+        }
+        if (events.length > 0) {
+            addLog(`Caught up on ${events.length} missed event(s)`, 'system', container);
+        }
+    } catch (error) {
+        console.error('Failed to replay missed events:', error);
+    }
+}
+
 async function loadGameState(gameId, userId, container) {
     try {
         gameState = await api.getGame(gameId);
         updateBoard(gameState, container);
+        restoreTurnPhase(gameState, userId, container);
         updateUI(gameState, userId, container);
     } catch (error) {
         console.error('Failed to load game state:', error);
@@ -137,6 +203,26 @@ async function loadGameState(gameId, userId, container) {
 
 function handleWebSocketMessage(message, gameId, userId, container) {
     switch (message.type) {
+        case 'game_state':
+            gameState = message.payload;
+            updateBoard(gameState, container);
+            restoreTurnPhase(gameState, userId, container);
+            updateUI(gameState, userId, container);
+            break;
+
+        case 'turn_update':
+            // Everything one action caused: handle the events in order for
+            // the log and prompts, then take the resulting state as-is
+            for (const ev of message.payload.events) {
+                handleWebSocketMessage(ev, gameId, userId, container);
+            }
+            if (message.payload.state && gameState) {
+                Object.assign(gameState, message.payload.state);
+                updateBoard(gameState, container);
+                updateUI(gameState, userId, container);
+            }
+            break;
+
         case 'player_joined':
             addLog('joined the game', 'event', container, message.payload.player.userId, message.payload.player.username);
             loadGameState(gameId, userId, container);
@@ -147,11 +233,20 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             loadGameState(gameId, userId, container);
             break;
 
+        case 'settings_updated':
+            addLog(`Host changed the settings: ${message.payload.maxPlayers} seats, $${message.payload.startingMoney} to start`, 'event', container);
+            loadGameState(gameId, userId, container);
+            break;
+
         case 'turn_changed': {
             const tcPayload = message.payload;
             updateTurnFromPayload(tcPayload, userId, container);
+            if (tcPayload.reason === 'skipped') {
+                const skipper = gameState?.players.find(p => p.userId === tcPayload.previousPlayerId);
+                addLog('skipped their turn', 'event', container, tcPayload.previousPlayerId, skipper?.displayName || getPlayerName(tcPayload.previousPlayerId));
+            }
             const tcPlayer = gameState?.players.find(p => p.userId === tcPayload.currentPlayerId);
-            addLog("'s turn", 'event', container, tcPayload.currentPlayerId, tcPlayer?.username || getPlayerName(tcPayload.currentPlayerId));
+            addLog("'s turn", 'event', container, tcPayload.currentPlayerId, tcPlayer?.displayName || getPlayerName(tcPayload.currentPlayerId));
             // Send browser notification if it's the user's turn and tab is not focused
             if (tcPayload.currentPlayerId === userId) {
                 sendTurnNotification();
@@ -167,7 +262,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'dice_rolled': {
             const p = message.payload;
             const drPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            const drName = drPlayer?.username || getPlayerName(p.userId);
+            const drName = drPlayer?.displayName || getPlayerName(p.userId);
             let rollMsg = `rolled ${p.die1} + ${p.die2} = ${p.total}`;
             if (p.isDoubles) {
                 rollMsg += ' DOUBLES!';
@@ -205,7 +300,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             if (p.userId === userId) {
                 showBuyPrompt(p.name, p.price, container);
             } else {
-                addLog(`can buy ${p.name} for $${p.price}`, 'event', container, p.userId, bpPlayer?.username || getPlayerName(p.userId));
+                addLog(`can buy ${p.name} for $${p.price}`, 'event', container, p.userId, bpPlayer?.displayName || getPlayerName(p.userId));
             }
             updateControls(userId, container);
             break;
@@ -214,7 +309,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'property_bought': {
             const p = message.payload;
             const pbPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`bought ${p.name} for $${p.price}`, 'event', container, p.userId, pbPlayer?.username || getPlayerName(p.userId));
+            addLog(`bought ${p.name} for $${p.price}`, 'event', container, p.userId, pbPlayer?.displayName || getPlayerName(p.userId));
             if (gameState) {
                 if (pbPlayer) {
                     pbPlayer.money = p.newMoney;
@@ -232,7 +327,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'property_passed': {
             const p = message.payload;
             const ppPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`passed on ${p.name}`, 'event', container, p.userId, ppPlayer?.username || getPlayerName(p.userId));
+            addLog(`passed on ${p.name}`, 'event', container, p.userId, ppPlayer?.displayName || getPlayerName(p.userId));
             if (gameState && ppPlayer) {
                 ppPlayer.pendingAction = 'auction';
             }
@@ -286,11 +381,25 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             break;
         }
 
+        case 'property_ownership_changed': {
+            const p = message.payload;
+            if (gameState) {
+                if (!gameState.properties) gameState.properties = {};
+                if (p.toUserId) {
+                    gameState.properties[p.spaceIndex] = p.toUserId;
+                } else {
+                    delete gameState.properties[p.spaceIndex];
+                }
+                updateBoard(gameState, container);
+            }
+            break;
+        }
+
         case 'rent_paid': {
             const p = message.payload;
             const rpPayer = gameState?.players.find(pl => pl.userId === p.payerId);
             const rpOwner = gameState?.players.find(pl => pl.userId === p.ownerId);
-            addLog(`paid $${p.amount} rent to ${rpOwner?.username || getPlayerName(p.ownerId)} for ${p.name}`, 'event', container, p.payerId, rpPayer?.username || getPlayerName(p.payerId));
+            addLog(`paid $${p.amount} rent to ${rpOwner?.displayName || getPlayerName(p.ownerId)} for ${p.name}`, 'event', container, p.payerId, rpPayer?.displayName || getPlayerName(p.payerId));
             if (gameState) {
                 if (rpPayer) rpPayer.money = p.payerMoney;
                 if (rpOwner) rpOwner.money = p.ownerMoney;
@@ -299,12 +408,30 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             break;
         }
 
+        case 'tax_prompt': {
+            const p = message.payload;
+            const txPlayer = gameState?.players.find(pl => pl.userId === p.userId);
+            if (txPlayer) txPlayer.pendingAction = 'tax_choice';
+            if (p.userId === userId) {
+                showTaxPrompt(p.flatAmount, p.percent, container);
+            } else {
+                addLog(`is choosing how to pay ${p.name}`, 'event', container, p.userId, txPlayer?.displayName || getPlayerName(p.userId));
+            }
+            updateControls(userId, container);
+            break;
+        }
+
         case 'tax_paid': {
             const p = message.payload;
             const tpPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`paid $${p.amount} in taxes`, 'event', container, p.userId, tpPlayer?.username || getPlayerName(p.userId));
+            const how = p.choice === 'percent' ? ' (10% of net worth)' : '';
+            addLog(`paid $${p.amount} in taxes${how}`, 'event', container, p.userId, tpPlayer?.displayName || getPlayerName(p.userId));
+            if (p.userId === userId) hideTaxPrompt(container);
             if (gameState) {
-                if (tpPlayer) tpPlayer.money = p.newMoney;
+                if (tpPlayer) {
+                    tpPlayer.money = p.newMoney;
+                    if (p.choice) tpPlayer.pendingAction = '';
+                }
                 updateUI(gameState, userId, container);
             }
             break;
@@ -314,7 +441,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             const p = message.payload;
             const gtjPlayer = gameState?.players.find(pl => pl.userId === p.userId);
             const reason = p.reason === 'three_doubles' ? ' (rolled three doubles!)' : '';
-            addLog(`was sent to Jail!${reason}`, 'event', container, p.userId, gtjPlayer?.username || getPlayerName(p.userId));
+            addLog(`was sent to Jail!${reason}`, 'event', container, p.userId, gtjPlayer?.displayName || getPlayerName(p.userId));
             if (gameState && gtjPlayer) {
                 gtjPlayer.position = 10;
                 gtjPlayer.inJail = true;
@@ -329,7 +456,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             const p = message.payload;
             const jePlayer = gameState?.players.find(pl => pl.userId === p.userId);
             const methodText = p.method === 'doubles' ? 'by rolling doubles' : 'by paying $50 bail';
-            addLog(`escaped from Jail ${methodText}!`, 'event', container, p.userId, jePlayer?.username || getPlayerName(p.userId));
+            addLog(`escaped from Jail ${methodText}!`, 'event', container, p.userId, jePlayer?.displayName || getPlayerName(p.userId));
             if (gameState && jePlayer) {
                 jePlayer.inJail = false;
                 jePlayer.jailTurns = 0;
@@ -342,7 +469,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'jail_roll_failed': {
             const p = message.payload;
             const jrfPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            const jrfName = jrfPlayer?.username || getPlayerName(p.userId);
+            const jrfName = jrfPlayer?.displayName || getPlayerName(p.userId);
             addLog(`rolled ${p.die1} + ${p.die2} - no doubles, still in jail (attempt ${p.jailTurns}/3)`, 'event', container, p.userId, jrfName);
             if (p.forcedBail) {
                 addLog('was forced to pay $50 bail after 3 failed attempts', 'event', container, p.userId, jrfName);
@@ -362,7 +489,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'property_mortgaged': {
             const p = message.payload;
             const pmPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`mortgaged ${p.name} for $${p.amount}`, 'event', container, p.userId, pmPlayer?.username || getPlayerName(p.userId));
+            addLog(`mortgaged ${p.name} for $${p.amount}`, 'event', container, p.userId, pmPlayer?.displayName || getPlayerName(p.userId));
             if (gameState) {
                 if (!gameState.mortgagedProperties) gameState.mortgagedProperties = {};
                 gameState.mortgagedProperties[p.position] = true;
@@ -376,7 +503,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'property_unmortgaged': {
             const p = message.payload;
             const pumPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`unmortgaged ${p.name} for $${p.amount}`, 'event', container, p.userId, pumPlayer?.username || getPlayerName(p.userId));
+            addLog(`unmortgaged ${p.name} for $${p.amount}`, 'event', container, p.userId, pumPlayer?.displayName || getPlayerName(p.userId));
             if (gameState) {
                 if (gameState.mortgagedProperties) {
                     delete gameState.mortgagedProperties[p.position];
@@ -391,7 +518,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'house_built': {
             const p = message.payload;
             const hbPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`built a house on ${p.name} (${p.houseCount}/4)`, 'event', container, p.userId, hbPlayer?.username || getPlayerName(p.userId));
+            addLog(`built a house on ${p.name} (${p.houseCount}/4)`, 'event', container, p.userId, hbPlayer?.displayName || getPlayerName(p.userId));
             if (gameState) {
                 if (!gameState.improvements) gameState.improvements = {};
                 gameState.improvements[p.position] = p.houseCount;
@@ -405,7 +532,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'hotel_built': {
             const p = message.payload;
             const htbPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`built a HOTEL on ${p.name}!`, 'event', container, p.userId, htbPlayer?.username || getPlayerName(p.userId));
+            addLog(`built a HOTEL on ${p.name}!`, 'event', container, p.userId, htbPlayer?.displayName || getPlayerName(p.userId));
             if (gameState) {
                 if (!gameState.improvements) gameState.improvements = {};
                 gameState.improvements[p.position] = p.houseCount; // 5 = hotel
@@ -419,7 +546,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'house_sold': {
             const p = message.payload;
             const hsPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog(`sold a house from ${p.name} for $${p.refund}`, 'event', container, p.userId, hsPlayer?.username || getPlayerName(p.userId));
+            addLog(`sold a ${p.houseCount === 4 ? 'hotel' : 'house'} from ${p.name} for $${p.refund} (rent now $${p.rent})`, 'event', container, p.userId, hsPlayer?.displayName || getPlayerName(p.userId));
             if (gameState) {
                 if (!gameState.improvements) gameState.improvements = {};
                 if (p.houseCount > 0) {
@@ -438,7 +565,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             const p = message.payload;
             const cdPlayer = gameState?.players.find(pl => pl.userId === p.userId);
             const deckName = p.deckType === 'chance' ? 'Chance' : 'Community Chest';
-            addLog(`drew a ${deckName} card: "${p.cardText}"`, 'event', container, p.userId, cdPlayer?.username || getPlayerName(p.userId));
+            addLog(`drew a ${deckName} card: "${p.cardText}"`, 'event', container, p.userId, cdPlayer?.displayName || getPlayerName(p.userId));
             if (p.effect) {
                 addLog(`  -> ${p.effect}`, 'event', container);
             }
@@ -468,7 +595,7 @@ function handleWebSocketMessage(message, gameId, userId, container) {
         case 'card_used': {
             const p = message.payload;
             const cuPlayer = gameState?.players.find(pl => pl.userId === p.userId);
-            addLog('used a Get Out of Jail Free card', 'event', container, p.userId, cuPlayer?.username || getPlayerName(p.userId));
+            addLog('used a Get Out of Jail Free card', 'event', container, p.userId, cuPlayer?.displayName || getPlayerName(p.userId));
             if (p.userId === userId) {
                 hasJailCard = false;
             }
@@ -506,6 +633,22 @@ function handleWebSocketMessage(message, gameId, userId, container) {
             showGameOver(message.payload, container);
             break;
 
+        case 'game_over':
+            showFinalStandings(message.payload, container);
+            break;
+
+        case 'game_force_finished':
+            addLog('Game was ended by an administrator', 'system', container);
+            if (gameState) gameState.status = 'finished';
+            stopTurnTimerDisplay(container);
+            showGameForceFinished(container);
+            break;
+
+        case 'game_cancelled':
+            addLog('The host cancelled the game', 'system', container);
+            if (gameState) gameState.status = 'finished';
+            break;
+
         case 'chat': {
             const p = message.payload;
             addLog(p.message, 'chat', container, p.userId, p.username);
@@ -550,14 +693,82 @@ function handleWebSocketMessage(message, gameId, userId, container) {
 
         case 'trade_cancelled': {
             const p = message.payload;
-            addLog(`Trade cancelled by ${p.fromUsername}`, 'event', container);
+            if (p.status === 'invalidated') {
+                addLog(`Trade from ${p.fromUsername} to ${p.toUsername} is no longer valid`, 'event', container);
+            } else {
+                addLog(`Trade cancelled by ${p.fromUsername}`, 'event', container);
+            }
             pendingTrades = pendingTrades.filter(t => t.trade.id !== p.tradeId);
             hideTradeNotification(container);
             break;
         }
 
+        case 'trade_expired': {
+            const p = message.payload;
+            addLog(`Trade from ${p.fromUsername} to ${p.toUsername} expired`, 'event', container);
+            pendingTrades = pendingTrades.filter(t => t.trade.id !== p.tradeId);
+            hideTradeNotification(container);
+            break;
+        }
+
+        case 'turn_started': {
+            // Server-authoritative list of legal actions for the active player
+            const p = message.payload;
+            if (p.userId === userId) {
+                const rollBtn = container.querySelector('#rollDiceBtn');
+                if (rollBtn) rollBtn.disabled = !p.canRoll;
+            }
+            break;
+        }
+
+        case 'standings_updated': {
+            if (!gameState) break;
+            for (const st of message.payload.standings) {
+                const stPlayer = gameState.players.find(pl => pl.userId === st.userId);
+                if (stPlayer) {
+                    stPlayer.money = st.money;
+                    stPlayer.netWorth = st.netWorth;
+                }
+            }
+            updateUI(gameState, userId, container);
+            break;
+        }
+
+        case 'server_shutdown':
+            addLog('Server is restarting, reconnecting shortly...', 'system', container);
+            break;
+
+        case 'presence_changed': {
+            if (!gameState) break;
+            const pcPlayer = gameState.players.find(pl => pl.userId === message.payload.userId);
+            if (pcPlayer && pcPlayer.isOnline !== message.payload.online) {
+                pcPlayer.isOnline = message.payload.online;
+                if (message.payload.userId !== userId) {
+                    addLog(message.payload.online ? 'reconnected' : 'disconnected', 'system', container, pcPlayer.userId, pcPlayer.username);
+                }
+                updateUI(gameState, userId, container);
+            }
+            break;
+        }
+
+        case 'player_reconnecting': {
+            // Their turn clock is paused; the countdown shown is the grace period
+            const p = message.payload;
+            addLog(`dropped on their turn - waiting ${p.graceSeconds}s for them to reconnect`, 'system', container, p.userId, getPlayerName(p.userId));
+            startTurnTimerDisplay(p.userId, p.graceSeconds, container);
+            break;
+        }
+
+        case 'pong_latency':
+            updateLatencyIndicator(message.payload.millis, container);
+            break;
+
         case 'error':
-            addLog(`Error: ${message.payload.message}`, 'system', container);
+            addLog(`Error: ${errorText(message.payload)}`, 'system', container);
+            if (isStaleStateError(message.payload.code)) {
+                // Our view of the game was out of date; resync so the controls match
+                loadGameState(gameId, userId, container);
+            }
             break;
     }
 }
@@ -704,7 +915,7 @@ function updateBoard(state, container) {
         players.forEach(p => {
             const token = document.createElement('div');
             token.className = `player-token color-${p.colorIndex}`;
-            token.title = p.username;
+            token.title = (p.displayName || p.username) + (p.token ? ` (${tokenName(p.token)})` : '');
             tokensDiv.appendChild(token);
         });
         el.appendChild(tokensDiv);
@@ -779,14 +990,18 @@ function updateUI(state, userId, container) {
 
     const playersListDiv = container.querySelector('#playersList');
     playersListDiv.innerHTML = state.players.map((player, idx) => `
-        <div class="player-item ${player.isCurrentTurn ? 'current-turn' : ''} ${player.isBankrupt ? 'bankrupt' : ''}"
-             data-user-id="${player.userId}" data-username="${player.username}">
+        <div class="player-item ${player.isCurrentTurn ? 'current-turn' : ''} ${player.isBankrupt ? 'bankrupt' : ''} ${player.isOnline ? '' : 'offline'}"
+             data-user-id="${player.userId}">
             <div class="player-name">
                 <span class="player-color-dot" style="background-color:${['#FF4444','#4444FF','#44FF44','#FFFF44'][idx]}"></span>
-                ${player.username}${player.userId === userId ? ' (You)' : ''}
+                ${escapeHtml(player.displayName || player.username)}${player.userId === userId ? ' (You)' : ''}
+                ${player.token ? `<span class="player-token-name" title="Token">${tokenName(player.token)}</span>` : ''}
+                ${player.userId === state.hostUserId ? '<span class="player-host" title="Host">HOST</span>' : ''}
+                ${state.status === 'waiting' ? `<span class="player-ready ${player.isReady ? 'ready' : ''}">${player.isReady ? 'READY' : 'NOT READY'}</span>` : ''}
             </div>
             <div class="player-info-row">
                 <span class="player-money">${player.isBankrupt ? 'BANKRUPT' : '$' + player.money}</span>
+                ${!player.isBankrupt && player.netWorth !== undefined ? `<span class="player-networth" title="Net worth">NW $${player.netWorth}</span>` : ''}
                 ${player.isCurrentTurn ? '<span class="player-timer" id="playerTimer"></span>' : ''}
             </div>
         </div>
@@ -803,7 +1018,7 @@ function updateUI(state, userId, container) {
                 if (itemUserId === userId) {
                     showPlayerActionPopup(e, 'self', container);
                 } else {
-                    openTradeModalWithPlayer(itemUserId, item.dataset.username, container);
+                    openTradeModalWithPlayer(itemUserId, getPlayerName(itemUserId), container);
                 }
             });
         }
@@ -886,6 +1101,42 @@ function hideBuyPrompt(container) {
     if (prompt) prompt.style.display = 'none';
 }
 
+function showTaxPrompt(flatAmount, percent, container) {
+    const prompt = container.querySelector('#taxPrompt');
+    const text = container.querySelector('#taxPromptText');
+    if (prompt && text) {
+        text.textContent = `Income Tax: pay $${flatAmount} or ${percent}% of your net worth?`;
+        container.querySelector('#taxFlatBtn').textContent = `Pay $${flatAmount}`;
+        container.querySelector('#taxPercentBtn').textContent = `Pay ${percent}%`;
+        prompt.style.display = 'block';
+    }
+}
+
+function hideTaxPrompt(container) {
+    const prompt = container.querySelector('#taxPrompt');
+    if (prompt) prompt.style.display = 'none';
+}
+
+// Re-show the prompt the server says we're on, so a reload or reconnect
+// mid-decision doesn't leave the turn stuck without buttons
+function restoreTurnPhase(state, userId, container) {
+    const me = state.players?.find(p => p.userId === userId);
+    if (state.turnPhase === 'awaiting_tax_choice' && state.currentPlayerId === userId && me) {
+        const space = state.board?.[me.position];
+        showTaxPrompt(space?.taxAmount ?? 200, 10, container);
+    } else {
+        hideTaxPrompt(container);
+    }
+    if (state.turnPhase === 'awaiting_buy_decision' && state.currentPlayerId === userId && me) {
+        const space = state.board?.[me.position];
+        if (space) {
+            showBuyPrompt(space.name, space.price, container);
+            return;
+        }
+    }
+    hideBuyPrompt(container);
+}
+
 function rollDice() {
     if (!ws || ws.readyState !== WebSocket.OPEN) return;
     ws.send(JSON.stringify({ type: 'roll_dice', payload: {} }));
@@ -901,6 +1152,11 @@ function passProperty() {
     ws.send(JSON.stringify({ type: 'pass_property', payload: {} }));
 }
 
+function payTax(choice) {
+    if (!ws || ws.readyState !== WebSocket.OPEN) return;
+    ws.send(JSON.stringify({ type: 'pay_tax', payload: { tax_choice: choice } }));
+}
+
 function endTurn() {
     if (!ws || ws.readyState !== WebSocket.OPEN) return;
     ws.send(JSON.stringify({ type: 'end_turn', payload: {} }));
@@ -946,6 +1202,58 @@ function showPlayerActionPopup(event, type, container) {
     setTimeout(() => document.addEventListener('click', closeHandler), 0);
 }
 
+async function showPropertyCard(event, gameId, position, container) {
+    const space = gameState?.board?.[position];
+    if (!space || !['property', 'railroad', 'utility'].includes(space.type)) return;
+
+    let details;
+    try {
+        details = await api.getProperty(gameId, position);
+    } catch (error) {
+        console.error('Failed to load property:', error);
+        return;
+    }
+
+    hidePropertyCard(container);
+
+    let rentText = 'No rent due';
+    if (details.diceMultiplier) {
+        rentText = `${details.diceMultiplier}x dice roll`;
+    } else if (details.currentRent) {
+        rentText = `$${details.currentRent}`;
+    }
+    const buildings = details.improvements === 5 ? 'Hotel' : `${details.improvements} house${details.improvements === 1 ? '' : 's'}`;
+
+    const card = document.createElement('div');
+    card.className = 'property-card-popup';
+    card.id = 'propertyCardPopup';
+    card.innerHTML = `
+        ${details.color ? `<div class="space-color ${details.color}"></div>` : ''}
+        <div class="property-card-title">${escapeHtml(details.name)}</div>
+        <div>Price: $${details.price}</div>
+        <div>Owner: ${details.ownerId ? escapeHtml(details.ownerUsername || 'Unknown') : 'Bank'}</div>
+        ${details.type === 'property' ? `<div>Buildings: ${buildings}${details.hasMonopoly ? ' (monopoly)' : ''}</div>` : ''}
+        ${details.isMortgaged ? '<div class="property-card-mortgaged">MORTGAGED</div>' : ''}
+        <div class="property-card-rent">Rent now: ${rentText}</div>
+    `;
+    card.style.left = `${event.clientX}px`;
+    card.style.top = `${event.clientY}px`;
+    container.appendChild(card);
+
+    const closeHandler = (e) => {
+        if (!card.contains(e.target)) {
+            hidePropertyCard(container);
+            document.removeEventListener('click', closeHandler);
+        }
+    };
+    setTimeout(() => document.addEventListener('click', closeHandler), 0);
+}
+
+function hidePropertyCard(container) {
+    const card = container.querySelector('#propertyCardPopup');
+    if (card) card.remove();
+}
+
 function hidePlayerActionPopup(container) {
     const popup = container.querySelector('#playerActionPopup');
     if (popup) popup.remove();
@@ -1046,7 +1354,7 @@ function sendChatMessage(input, container) {
 function getPlayerName(userId) {
     if (!gameState) return `Player ${userId}`;
     const player = gameState.players.find(p => p.userId === userId);
-    return player ? player.username : `Player ${userId}`;
+    return player ? (player.displayName || player.username) : `Player ${userId}`;
 }
 
 function getPlayerColor(userId) {
@@ -1055,6 +1363,11 @@ function getPlayerColor(userId) {
     return idx >= 0 ? ['#FF4444','#4444FF','#44FF44','#FFFF44'][idx] : '#c7731a';
 }
 
+// Board piece names as shown, e.g. top_hat -> "top hat"
+function tokenName(token) {
+    return token.replace(/_/g, ' ');
+}
+
 function escapeHtml(text) {
     const div = document.createElement('div');
     div.textContent = text;
@@ -1095,7 +1408,7 @@ function showGameOver(payload, container) {
     hideBuyPrompt(container);
 
     const winner = payload.players.find(p => p.userId === payload.winnerId);
-    const winnerName = winner ? winner.username : 'Unknown';
+    const winnerName = winner ? escapeHtml(winner.displayName || winner.username) : 'Unknown';
 
     const overlay = document.createElement('div');
     overlay.className = 'game-over-overlay';
@@ -1109,7 +1422,7 @@ function showGameOver(payload, container) {
                     .map((player, index) => `
                     <div class="result-item">
                         <span class="result-rank">#${index + 1}</span>
-                        <span class="result-name">${player.username}</span>
+                        <span class="result-name">${escapeHtml(player.displayName || player.username)}</span>
                         <span class="result-money" style="margin-left:auto;color:#858585;">$${player.money || 0}</span>
                     </div>
                 `).join('')}
@@ -1127,6 +1440,54 @@ function showGameOver(payload, container) {
     });
 }
 
+function showGameForceFinished(container) {
+    hideBuyPrompt(container);
+
+    const overlay = document.createElement('div');
+    overlay.className = 'game-over-overlay';
+    overlay.innerHTML = `
+        <div class="game-over-modal">
+            <h2>Game Ended</h2>
+            <div class="results-list">
+                <div class="hint">This game was ended by an administrator.</div>
+            </div>
+            <button id="returnToLobbyBtn" class="primary-btn">Return to Lobby</button>
+        </div>
+    `;
+
+    container.appendChild(overlay);
+
+    overlay.querySelector('#returnToLobbyBtn').addEventListener('click', () => {
+        cleanup();
+        window.location.hash = '#/lobby';
+    });
+}
+
+const gameOverReasons = {
+    last_player_standing: 'Last player standing',
+    turn_limit: 'Round limit reached - richest player wins',
+    time_limit: 'Time limit reached - richest player wins',
+};
+
+// Replace the cash-only results with the server's final standings by net worth
+function showFinalStandings(payload, container) {
+    const list = container.querySelector('.game-over-modal .results-list');
+    if (!list) return;
+
+    const reason = gameOverReasons[payload.reason];
+    list.innerHTML = `
+        ${reason ? `<div class="hint">${reason}</div>` : ''}
+        ${payload.finalStandings.map((st, index) => `
+            <div class="result-item">
+                <span class="result-rank">#${index + 1}</span>
+                <span class="result-name">${st.username}</span>
+                ${st.turnsTaken > 0 ? `<span class="hint" title="Average turn">${Math.round(st.avgTurnSeconds)}s/turn</span>` : ''}
+                <span class="result-money" style="margin-left:auto;color:#858585;">$${st.netWorth}</span>
+            </div>
+        `).join('')}
+    `;
+}
+
 // Trading functions
 function openTradeModal(container) {
     if (!gameState || gameState.status !== 'in_progress') return;
@@ -1167,7 +1528,7 @@ function openTradeModal(container) {
                 <div class="trade-section">
                     <label>Trade with:</label>
                     <select id="tradeTargetPlayer">
-                        ${otherPlayers.map(p => `<option value="${p.userId}">${p.username} ($${p.money})</option>`).join('')}
+                        ${otherPlayers.map(p => `<option value="${p.userId}">${escapeHtml(p.displayName || p.username)} ($${p.money})</option>`).join('')}
                     </select>
                 </div>
                 <div class="trade-columns">
@@ -1659,7 +2020,8 @@ function showPropertyInfoPanel(space, state, userId, container) {
     const unmortgageCost = Math.floor(mortgageValue * 1.1);
 
     let actionButtons = '';
-    if (isOwnedByUser && state.status === 'in_progress') {
+    // Building and mortgaging are turn actions, like rolling
+    if (isOwnedByUser && state.status === 'in_progress' && state.currentPlayerId === userId) {
         if (isMortgaged) {
             actionButtons = `<button class="btn primary" onclick="window.unmortgageProperty(${space.position})">Unmortgage ($${unmortgageCost})</button>`;
         } else {
@@ -1682,7 +2044,7 @@ function showPropertyInfoPanel(space, state, userId, container) {
         </div>
         <div class="panel-content">
             <p><strong>Price:</strong> $${space.price}</p>
-            <p><strong>Owner:</strong> ${owner ? owner.username : 'Unowned'}</p>
+            <p><strong>Owner:</strong> ${owner ? escapeHtml(owner.displayName || owner.username) : 'Unowned'}</p>
             ${isMortgaged ? '<p class="mortgaged-label">MORTGAGED</p>' : ''}
             ${improvements > 0 ? `<p><strong>Improvements:</strong> ${improvements === 5 ? 'Hotel' : improvements + ' house(s)'}</p>` : ''}
             <p><strong>Mortgage value:</strong> $${mortgageValue}</p>
@@ -1757,6 +2119,17 @@ function getColorForGroup(color) {
     return colorMap[color] || 'var(--accent-color)';
 }
 
+// Connection quality from the server's ping/pong round trip
+function updateLatencyIndicator(millis, container) {
+    const indicator = container.querySelector('#connectionLatency');
+    if (!indicator) return;
+
+    const quality = millis < 150 ? 'good' : millis < 400 ? 'fair' : 'poor';
+    indicator.textContent = `${millis}ms`;
+    indicator.className = `connection-latency ${quality}`;
+    indicator.title = `Connection: ${quality}`;
+}
+
 // Reconnection Indicator
 function showReconnectIndicator(attempt, delay, container) {
     let indicator = container.querySelector('#reconnectIndicator');
@@ -3,6 +3,8 @@ import { templateLoader } from '../template.js';
 
 let ws = null;
 let reconnectTimeout = null;
+let connectGeneration = 0; // bumped on cleanup so a connect awaiting its ticket gives up
+const countdownIntervals = new Map(); // gameId -> interval ticking the start countdown
 
 export async function render(container, router) {
     if (!api.isAuthenticated()) {
@@ -32,6 +34,10 @@ export async function render(container, router) {
 }
 
 export function cleanup() {
+    connectGeneration++;
+    countdownIntervals.forEach(interval => clearInterval(interval));
+    countdownIntervals.clear();
+
     if (reconnectTimeout) {
         clearTimeout(reconnectTimeout);
         reconnectTimeout = null;
@@ -44,11 +50,13 @@ export function cleanup() {
     }
 }
 
-function connectLobbyWebSocket(container, router) {
-    const wsURL = api.getWebSocketURL('lobby');
+async function connectLobbyWebSocket(container, router) {
+    const generation = connectGeneration;
+    const wsURL = await api.getTicketedWebSocketURL('lobby');
+    if (generation !== connectGeneration) return; // left the lobby meanwhile
 
     try {
-        ws = new WebSocket(wsURL);
+        ws = new WebSocket(wsURL, api.wsProtocol);
     } catch (error) {
         console.error('WebSocket connection error:', error);
         scheduleReconnect(container, router);
@@ -72,9 +80,17 @@ function connectLobbyWebSocket(container, router) {
         console.error('Lobby WebSocket error:', error);
     };
 
-    ws.onclose = () => {
+    ws.onclose = (event) => {
         console.log('Lobby WebSocket disconnected');
         ws = null;
+        if (event.code === 4010) {
+            // Outdated page; reconnecting would be refused again
+            return;
+        }
+        if (event.code === 4001) {
+            // The lobby was opened in another tab; reconnecting would take it back
+            return;
+        }
         scheduleReconnect(container, router);
     };
 }
@@ -111,6 +127,21 @@ function handleWebSocketMessage(message, container, router) {
         case 'game_status_changed':
             handleGameStatusChanged(container, message.payload, router);
             break;
+        case 'player_ready':
+            handlePlayerReady(container, message.payload);
+            break;
+        case 'start_countdown':
+            handleStartCountdown(container, message.payload);
+            break;
+        case 'countdown_cancelled':
+            handleCountdownCancelled(container, message.payload);
+            break;
+        case 'waiting_for_players':
+            handleWaitingForPlayers(container, message.payload);
+            break;
+        case 'server_shutdown':
+            console.log('Server is shutting down, lobby will reconnect');
+            break;
         default:
             console.log('Unknown message type:', message.type);
     }
@@ -118,8 +149,8 @@ function handleWebSocketMessage(message, container, router) {
 
 async function loadGames(container, router) {
     try {
-        const games = await api.listGames();
-        displayGames(container, games, router);
+        const page = await api.listGames();
+        displayGames(container, page.games, router);
     } catch (error) {
         console.error('Failed to load games:', error);
         const gamesListDiv = container.querySelector('#gamesList');
@@ -129,10 +160,28 @@ async function loadGames(container, router) {
     }
 }
 
+function playerNameHTML(player) {
+    return `<span class="player-name ${player.isReady ? 'ready' : ''}" data-user-id="${player.userId}">${player.username}</span>`;
+}
+
+function readyButtonHTML(gameId, isReady) {
+    return `<button class="ready-game-btn ${isReady ? 'is-ready' : ''}" data-game-id="${gameId}" data-ready="${isReady}">${isReady ? 'NOT READY' : 'READY'}</button>`;
+}
+
 function getGameButtonHTML(game) {
     if (game.status === 'waiting') {
         if (game.isJoined) {
-            return `<button class="leave-game-btn" data-game-id="${game.id}">LEAVE</button>`;
+            const me = game.players.find(p => p.userId === api.getCurrentUser().userId);
+            // Players are listed in join order, so the first is the host
+            const isHost = game.players[0]?.userId === me?.userId;
+            const startBtn = game.manualStart && isHost
+                ? `<button class="start-game-btn" data-game-id="${game.id}">START</button>`
+                : '';
+            const cancelBtn = isHost
+                ? `<button class="cancel-game-btn" data-game-id="${game.id}">CANCEL</button>`
+                : '';
+            return startBtn + readyButtonHTML(game.id, !!me?.isReady) +
+                `<button class="leave-game-btn" data-game-id="${game.id}">LEAVE</button>` + cancelBtn;
         } else {
             const isFull = game.players.length >= game.maxPlayers;
             return `<button class="join-game-btn" data-game-id="${game.id}" ${isFull ? 'disabled' : ''}>JOIN</button>`;
@@ -163,11 +212,11 @@ function displayGames(container, games, router) {
             <div class="game-info">
                 <div class="game-name">GAME #${game.id}</div>
                 <div class="game-meta">
-                    <span class="players-count">PLAYERS: ${game.players.length}/${game.maxPlayers}</span>
+                    <span class="players-count">PLAYERS: ${game.players.length}/${game.maxPlayers}${minPlayersLabel(game)}${connectedLabel(game)}${customBoardLabel(game)}</span>
                     <span class="game-status ${game.status === 'in_progress' ? 'in-progress' : game.status === 'finished' ? 'finished' : 'waiting'}">${game.status.toUpperCase()}</span>
                 </div>
                 <div class="players-list">
-                    ${game.players.map(p => `<span class="player-name" data-user-id="${p.userId}">${p.username}</span>`).join(', ')}
+                    ${game.players.map(playerNameHTML).join(', ')}
                 </div>
             </div>
             ${getGameButtonHTML(game)}
@@ -189,6 +238,22 @@ function displayGames(container, games, router) {
         });
     });
 
+    container.querySelectorAll('.ready-game-btn').forEach(btn => attachReadyListener(btn, container));
+
+    container.querySelectorAll('.start-game-btn').forEach(btn => {
+        btn.addEventListener('click', () => {
+            const gameId = parseInt(btn.dataset.gameId);
+            startGame(gameId, container);
+        });
+    });
+
+    container.querySelectorAll('.cancel-game-btn').forEach(btn => {
+        btn.addEventListener('click', () => {
+            const gameId = parseInt(btn.dataset.gameId);
+            cancelGame(gameId, container);
+        });
+    });
+
     container.querySelectorAll('.enter-game-btn').forEach(btn => {
         btn.addEventListener('click', () => {
             const gameId = parseInt(btn.dataset.gameId);
@@ -201,12 +266,26 @@ function showCreateGameModal(container, router) {
     const modal = container.querySelector('#createGameModal');
     const form = container.querySelector('#createGameForm');
     const maxPlayersInput = container.querySelector('#maxPlayers');
+    const minPlayersInput = container.querySelector('#minPlayers');
+    const turnLimitInput = container.querySelector('#turnLimit');
+    const timeLimitInput = container.querySelector('#timeLimit');
+    const manualStartInput = container.querySelector('#manualStart');
+    const unlimitedBuildingInput = container.querySelector('#unlimitedBuilding');
     const decreaseBtn = container.querySelector('#decreasePlayersBtn');
     const increaseBtn = container.querySelector('#increasePlayersBtn');
     const cancelBtn = container.querySelector('#cancelCreateBtn');
 
     // Reset to default
     maxPlayersInput.value = 4;
+    minPlayersInput.value = 2;
+    turnLimitInput.value = 0;
+    timeLimitInput.value = 0;
+    manualStartInput.checked = false;
+    unlimitedBuildingInput.checked = false;
+
+    // One key per opening of the modal, so a double submit or a retry
+    // returns the same game (randomUUID is missing outside secure contexts)
+    const idempotencyKey = crypto.randomUUID?.();
 
     // Show modal
     modal.style.display = 'flex';
@@ -222,6 +301,9 @@ function showCreateGameModal(container, router) {
         const current = parseInt(maxPlayersInput.value);
         if (current > 2) {
             maxPlayersInput.value = current - 1;
+            if (parseInt(minPlayersInput.value) > current - 1) {
+                minPlayersInput.value = current - 1;
+            }
         }
     };
 
@@ -236,8 +318,15 @@ function showCreateGameModal(container, router) {
     form.onsubmit = async (e) => {
         e.preventDefault();
         const maxPlayers = parseInt(maxPlayersInput.value);
+        const rules = {
+            minPlayers: Math.min(parseInt(minPlayersInput.value) || 2, maxPlayers),
+            turnLimit: parseInt(turnLimitInput.value) || 0,
+            timeLimitMinutes: parseInt(timeLimitInput.value) || 0,
+            manualStart: manualStartInput.checked,
+            unlimitedBuilding: unlimitedBuildingInput.checked,
+        };
         closeModal();
-        await createGame(container, router, maxPlayers);
+        await createGame(container, router, maxPlayers, rules, idempotencyKey);
     };
 
     // Handle cancel
@@ -259,11 +348,11 @@ function showCreateGameModal(container, router) {
     document.addEventListener('keydown', escHandler);
 }
 
-async function createGame(container, router, maxPlayers = 4) {
+async function createGame(container, router, maxPlayers = 4, rules = {}, idempotencyKey) {
     showError(container, '');
 
     try {
-        await api.createGame(maxPlayers);
+        await api.createGame(maxPlayers, rules, idempotencyKey);
         // Don't navigate - stay in lobby
         // WebSocket will update the game list automatically
     } catch (error) {
@@ -295,6 +384,49 @@ async function leaveGame(gameId, container, router) {
     }
 }
 
+async function toggleReady(gameId, ready, container) {
+    showError(container, '');
+
+    try {
+        await api.setReady(gameId, ready);
+        // WebSocket will handle UI updates via player_ready event
+    } catch (error) {
+        console.error('Failed to set ready:', error);
+        showError(container, error.message || 'Failed to set ready');
+    }
+}
+
+async function startGame(gameId, container) {
+    showError(container, '');
+
+    try {
+        await api.startGame(gameId);
+        // WebSocket will handle UI updates via game_status_changed event
+    } catch (error) {
+        console.error('Failed to start game:', error);
+        showError(container, error.message || 'Failed to start game');
+    }
+}
+
+async function cancelGame(gameId, container) {
+    showError(container, '');
+
+    try {
+        await api.cancelGame(gameId);
+        // WebSocket will handle UI updates via game_deleted event
+    } catch (error) {
+        console.error('Failed to cancel game:', error);
+        showError(container, error.message || 'Failed to cancel game');
+    }
+}
+
+function attachReadyListener(button, container) {
+    button.addEventListener('click', () => {
+        const gameId = parseInt(button.dataset.gameId);
+        toggleReady(gameId, button.dataset.ready !== 'true', container);
+    });
+}
+
 function showError(container, message) {
     let errorDiv = container.querySelector('.lobby-error');
 
@@ -360,6 +492,8 @@ function handlePlayerJoined(container, payload, router) {
     const gameElement = container.querySelector(`[data-game-id="${payload.gameId}"]`);
     if (!gameElement) return;
 
+    gameElement.querySelector('.waiting-for-players')?.remove();
+
     // Update players list
     const playersList = gameElement.querySelector('.players-list');
     if (playersList) {
@@ -392,8 +526,10 @@ function handlePlayerJoined(container, payload, router) {
         gameElement.classList.add('current-game');
         const button = gameElement.querySelector('.join-game-btn');
         if (button) {
-            button.outerHTML = `<button class="leave-game-btn" data-game-id="${payload.gameId}">LEAVE</button>`;
-            // Re-attach event listener
+            button.outerHTML = readyButtonHTML(payload.gameId, false) +
+                `<button class="leave-game-btn" data-game-id="${payload.gameId}">LEAVE</button>`;
+            // Re-attach event listeners
+            attachReadyListener(gameElement.querySelector('.ready-game-btn'), container);
             const newButton = gameElement.querySelector('.leave-game-btn');
             newButton.addEventListener('click', () => {
                 leaveGame(payload.gameId, container, router);
@@ -460,6 +596,7 @@ function handlePlayerLeft(container, payload, router) {
     // If this is you leaving, replace button
     if (payload.isYou) {
         gameElement.classList.remove('current-game');
+        gameElement.querySelector('.ready-game-btn')?.remove();
         const button = gameElement.querySelector('.leave-game-btn');
         if (button) {
             const gameStatus = gameElement.querySelector('.game-status')?.textContent.toLowerCase();
@@ -519,6 +656,9 @@ function handleGameStatusChanged(container, payload, router) {
 
     // Update buttons based on status
     if (payload.status === 'in_progress') {
+        stopCountdown(gameElement, payload.gameId);
+        gameElement.querySelector('.ready-game-btn')?.remove();
+
         // Replace JOIN button with SPECTATE button (disabled)
         const joinBtn = gameElement.querySelector('.join-game-btn');
         if (joinBtn) {
@@ -539,6 +679,96 @@ function handleGameStatusChanged(container, payload, router) {
     }
 }
 
+function handlePlayerReady(container, payload) {
+    const gameElement = container.querySelector(`[data-game-id="${payload.gameId}"]`);
+    if (!gameElement) return;
+
+    const playerSpan = gameElement.querySelector(`.player-name[data-user-id="${payload.userId}"]`);
+    if (playerSpan) {
+        playerSpan.classList.toggle('ready', payload.isReady);
+    }
+
+    if (payload.userId === api.getCurrentUser().userId) {
+        const button = gameElement.querySelector('.ready-game-btn');
+        if (button) {
+            button.dataset.ready = String(payload.isReady);
+            button.classList.toggle('is-ready', payload.isReady);
+            button.textContent = payload.isReady ? 'NOT READY' : 'READY';
+        }
+        if (!payload.isReady) {
+            gameElement.querySelector('.waiting-for-players')?.remove();
+        }
+    }
+}
+
+function handleWaitingForPlayers(container, payload) {
+    const gameElement = container.querySelector(`[data-game-id="${payload.gameId}"]`);
+    if (!gameElement) return;
+
+    const meta = gameElement.querySelector('.game-meta');
+    if (!meta) return;
+    let hint = meta.querySelector('.waiting-for-players');
+    if (!hint) {
+        hint = document.createElement('span');
+        hint.className = 'waiting-for-players';
+        meta.appendChild(hint);
+    }
+    const more = payload.needed === 1 ? 'ONE MORE PLAYER' : `${payload.needed} MORE PLAYERS`;
+    hint.textContent = `WAITING FOR AT LEAST ${more}`;
+}
+
+function handleStartCountdown(container, payload) {
+    const gameElement = container.querySelector(`[data-game-id="${payload.gameId}"]`);
+    if (!gameElement) return;
+
+    stopCountdown(gameElement, payload.gameId);
+
+    const meta = gameElement.querySelector('.game-meta');
+    if (!meta) return;
+    const indicator = document.createElement('span');
+    indicator.className = 'start-countdown';
+    meta.appendChild(indicator);
+
+    let remaining = payload.seconds;
+    const tick = () => {
+        indicator.textContent = `STARTING IN ${remaining}s`;
+        if (remaining <= 0) {
+            clearInterval(countdownIntervals.get(payload.gameId));
+            countdownIntervals.delete(payload.gameId);
+        }
+        remaining--;
+    };
+    tick();
+    countdownIntervals.set(payload.gameId, setInterval(tick, 1000));
+}
+
+function handleCountdownCancelled(container, payload) {
+    const gameElement = container.querySelector(`[data-game-id="${payload.gameId}"]`);
+    if (!gameElement) return;
+
+    stopCountdown(gameElement, payload.gameId);
+}
+
+function stopCountdown(gameElement, gameId) {
+    if (countdownIntervals.has(gameId)) {
+        clearInterval(countdownIntervals.get(gameId));
+        countdownIntervals.delete(gameId);
+    }
+    gameElement.querySelector('.start-countdown')?.remove();
+}
+
+function customBoardLabel(game) {
+    return game.customBoard ? ' · CUSTOM BOARD' : '';
+}
+
+function minPlayersLabel(game) {
+    return game.minPlayers > 2 ? ` (MIN ${game.minPlayers})` : '';
+}
+
+function connectedLabel(game) {
+    return game.connectedCount > 0 ? ` · ${game.connectedCount} ONLINE` : '';
+}
+
 function createGameElement(game, router) {
     const div = document.createElement('div');
     div.className = `game-item ${game.isJoined ? 'current-game' : ''}`;
@@ -548,11 +778,11 @@ function createGameElement(game, router) {
         <div class="game-info">
             <div class="game-name">GAME #${game.id}</div>
             <div class="game-meta">
-                <span class="players-count">PLAYERS: ${game.players.length}/${game.maxPlayers}</span>
+                <span class="players-count">PLAYERS: ${game.players.length}/${game.maxPlayers}${minPlayersLabel(game)}${connectedLabel(game)}${customBoardLabel(game)}</span>
                 <span class="game-status ${game.status === 'in_progress' ? 'in-progress' : game.status === 'finished' ? 'finished' : 'waiting'}">${game.status.toUpperCase()}</span>
             </div>
             <div class="players-list">
-                ${game.players.map(p => `<span class="player-name" data-user-id="${p.userId}">${p.username}</span>`).join(', ')}
+                ${game.players.map(playerNameHTML).join(', ')}
             </div>
         </div>
         ${getGameButtonHTML(game)}
@@ -566,6 +796,11 @@ function createGameElement(game, router) {
         });
     }
 
+    const readyBtn = div.querySelector('.ready-game-btn');
+    if (readyBtn) {
+        attachReadyListener(readyBtn, div.closest('.container') || document.body);
+    }
+
     const leaveBtn = div.querySelector('.leave-game-btn');
     if (leaveBtn) {
         leaveBtn.addEventListener('click', () => {
@@ -24,6 +24,19 @@ export async function render(container, router) {
             errorDiv.style.display = 'block';
         }
     });
+
+    container.querySelector('#guestButton').addEventListener('click', async () => {
+        errorDiv.textContent = '';
+        errorDiv.style.display = 'none';
+
+        try {
+            await api.playAsGuest();
+            router.navigate('/lobby');
+        } catch (error) {
+            errorDiv.textContent = error.message || 'Could not start a guest session';
+            errorDiv.style.display = 'block';
+        }
+    });
 }
 
 export function cleanup() {
@@ -16,6 +16,7 @@ export async function render(container, router) {
 
         errorDiv.textContent = '';
         errorDiv.style.display = 'none';
+        form.querySelectorAll('.invalid').forEach(input => input.classList.remove('invalid'));
         successDiv.textContent = '';
         successDiv.style.display = 'none';
 
@@ -29,6 +30,9 @@ export async function render(container, router) {
         } catch (error) {
             errorDiv.textContent = error.message || 'Registration failed';
             errorDiv.style.display = 'block';
+            for (const { field } of error.fields || []) {
+                form.querySelector(`#${field}`)?.classList.add('invalid');
+            }
         }
     });
 }
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrGameNotFound    = errors.New("game not found")
+	ErrGameNotFinished = errors.New("game not finished")
+)
+
+// ArchivedGameDTO summarizes an archived game for the admin history list
+type ArchivedGameDTO struct {
+	ID         int64    `json:"id"`
+	MaxPlayers int      `json:"maxPlayers"`
+	Rounds     int      `json:"rounds"`
+	StartedAt  int64    `json:"startedAt,omitempty"`  // unix seconds
+	FinishedAt int64    `json:"finishedAt,omitempty"` // unix seconds
+	WinnerID   int64    `json:"winnerId,omitempty"`
+	EndReason  string   `json:"endReason"`
+	Players    []string `json:"players"` // usernames in turn order
+
+	AvgTurnSeconds float64 `json:"avgTurnSeconds"` // over every player's timed turns; 0 if none
+}
+
+// ArchiveGame marks a finished game archived. Its row, players and result
+// are kept; archival only takes it out of the active tables' working set.
+func (s *SQLiteLobbyStore) ArchiveGame(gameID int64) error {
+	var status string
+	err := s.db.QueryRow(`SELECT status FROM games WHERE id = ?`, gameID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrGameNotFound
+	}
+	if err != nil {
+		return wrapDBError("get game status", err)
+	}
+	if status != "finished" {
+		return ErrGameNotFinished
+	}
+
+	if _, err := s.db.Exec(`UPDATE games SET archived = 1 WHERE id = ?`, gameID); err != nil {
+		return wrapDBError("archive game", err)
+	}
+	return nil
+}
+
+// ArchiveFinishedGames archives every game that finished before the cutoff
+// and returns how many were archived
+func (s *SQLiteLobbyStore) ArchiveFinishedGames(finishedBefore time.Time) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE games SET archived = 1
+		WHERE status = 'finished' AND archived = 0 AND finished_at < ?
+	`, finishedBefore.Unix())
+	if err != nil {
+		return 0, wrapDBError("archive finished games", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListArchivedGames returns one page of archived games (most recently
+// finished first) along with the total number archived
+func (s *SQLiteLobbyStore) ListArchivedGames(limit, offset int) ([]*ArchivedGameDTO, int, error) {
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM games WHERE archived = 1`).Scan(&total); err != nil {
+		return nil, 0, wrapDBError("count archived games", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, max_players, round, COALESCE(started_at, 0), COALESCE(finished_at, 0),
+		       COALESCE(winner_id, 0), end_reason,
+		       COALESCE((SELECT CAST(SUM(turn_seconds) AS REAL) / NULLIF(SUM(turns_taken), 0)
+		                 FROM game_players WHERE game_id = games.id), 0)
+		FROM games
+		WHERE archived = 1
+		ORDER BY finished_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, wrapDBError("list archived games", err)
+	}
+	defer rows.Close()
+
+	gamesMap := make(map[int64]*ArchivedGameDTO)
+	var gameIDs []int64
+	for rows.Next() {
+		game := &ArchivedGameDTO{Players: []string{}}
+		if err := rows.Scan(&game.ID, &game.MaxPlayers, &game.Rounds, &game.StartedAt, &game.FinishedAt,
+			&game.WinnerID, &game.EndReason, &game.AvgTurnSeconds); err != nil {
+			return nil, 0, wrapDBError("scan archived game", err)
+		}
+		gamesMap[game.ID] = game
+		gameIDs = append(gameIDs, game.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate archived games: %w", err)
+	}
+	if len(gameIDs) == 0 {
+		return []*ArchivedGameDTO{}, total, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(gameIDs))
+	placeholders = placeholders[:len(placeholders)-1]
+	args := make([]interface{}, len(gameIDs))
+	for i, id := range gameIDs {
+		args[i] = id
+	}
+	playerRows, err := s.db.Query(`
+		SELECT gp.game_id, u.username
+		FROM game_players gp
+		JOIN users u ON gp.user_id = u.id
+		WHERE gp.game_id IN (`+placeholders+`)
+		ORDER BY gp.game_id, gp.player_order
+	`, args...)
+	if err != nil {
+		return nil, 0, wrapDBError("query archived game players", err)
+	}
+	defer playerRows.Close()
+
+	for playerRows.Next() {
+		var gameID int64
+		var username string
+		if err := playerRows.Scan(&gameID, &username); err != nil {
+			return nil, 0, wrapDBError("scan archived game player", err)
+		}
+		if game, exists := gamesMap[gameID]; exists {
+			game.Players = append(game.Players, username)
+		}
+	}
+	if err := playerRows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate archived game players: %w", err)
+	}
+
+	games := make([]*ArchivedGameDTO, 0, len(gameIDs))
+	for _, gameID := range gameIDs {
+		games = append(games, gamesMap[gameID])
+	}
+	return games, total, nil
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArchiveFinishedGames_KeepsResults(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	aliceID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	bobID, err := auth.CreateUser("bob", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	// One game finished long ago, one just now, one still waiting
+	var oldID, recentID int64
+	for _, id := range []*int64{&oldID, &recentID} {
+		if *id, err = lobby.CreateGame(4, GameRules{}); err != nil {
+			t.Fatalf("CreateGame failed: %v", err)
+		}
+		for _, userID := range []int64{aliceID, bobID} {
+			if _, err := games.JoinGame(*id, userID); err != nil {
+				t.Fatalf("JoinGame failed: %v", err)
+			}
+		}
+		tx, err := games.BeginTx()
+		if err != nil {
+			t.Fatalf("BeginTx failed: %v", err)
+		}
+		if err := games.FinishGameTx(tx, *id, bobID, "bankruptcy"); err != nil {
+			t.Fatalf("FinishGameTx failed: %v", err)
+		}
+		if err := games.CommitTx(tx); err != nil {
+			t.Fatalf("CommitTx failed: %v", err)
+		}
+	}
+	longAgo := time.Now().Add(-60 * 24 * time.Hour).Unix()
+	if _, err := lobby.db.Exec(`UPDATE games SET finished_at = ? WHERE id = ?`, longAgo, oldID); err != nil {
+		t.Fatalf("Backdate failed: %v", err)
+	}
+	// alice took 2 turns in 30s and bob 2 in 50s
+	if _, err := lobby.db.Exec(`UPDATE game_players SET turns_taken = 2, turn_seconds = CASE user_id WHEN ? THEN 30 ELSE 50 END WHERE game_id = ?`, aliceID, oldID); err != nil {
+		t.Fatalf("Set turn times failed: %v", err)
+	}
+	waitingID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+
+	archived, err := lobby.ArchiveFinishedGames(time.Now().Add(-30 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("ArchiveFinishedGames failed: %v", err)
+	}
+	if archived != 1 {
+		t.Errorf("Expected only the old game to be archived, got %d", archived)
+	}
+
+	list, total, err := lobby.ListArchivedGames(10, 0)
+	if err != nil {
+		t.Fatalf("ListArchivedGames failed: %v", err)
+	}
+	if total != 1 || len(list) != 1 || list[0].ID != oldID {
+		t.Fatalf("Expected game %d alone in the archive, got total %d: %+v", oldID, total, list)
+	}
+	got := list[0]
+	if got.WinnerID != bobID || got.EndReason != "bankruptcy" || got.FinishedAt != longAgo {
+		t.Errorf("Expected the result to survive archival, got %+v", got)
+	}
+	if len(got.Players) != 2 || got.Players[0] != "alice" || got.Players[1] != "bob" {
+		t.Errorf("Expected players [alice bob], got %v", got.Players)
+	}
+	if got.AvgTurnSeconds != 20 {
+		t.Errorf("Expected an average turn of 20s, got %v", got.AvgTurnSeconds)
+	}
+
+	if err := lobby.ArchiveGame(waitingID); err != ErrGameNotFinished {
+		t.Errorf("Expected ErrGameNotFinished for a waiting game, got %v", err)
+	}
+	if err := lobby.ArchiveGame(9999); err != ErrGameNotFound {
+		t.Errorf("Expected ErrGameNotFound for a missing game, got %v", err)
+	}
+	if err := lobby.ArchiveGame(recentID); err != nil {
+		t.Fatalf("ArchiveGame failed: %v", err)
+	}
+	if _, total, _ = lobby.ListArchivedGames(10, 0); total != 2 {
+		t.Errorf("Expected 2 archived games after archiving by hand, got %d", total)
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 )
 
 type AuthStore interface {
 	GetUserByUsername(username string) (*User, error)
 	GetUserByID(userID int64) (*User, error)
 	CreateUser(username, passwordHash string) (int64, error)
+	// Guests
+	CreateGuestUser(username string) (int64, error)
+	ClaimGuest(userID int64, username, passwordHash string) error
+	ListStaleGuests(now time.Time) ([]int64, error)
+	DeleteUser(userID int64) error
+	SetDisplayName(userID int64, displayName string) error
 	// Friends
 	SearchUsers(query string, excludeUserID int64, limit int) ([]*User, error)
 	SendFriendRequest(fromUserID, toUserID int64) error
@@ -18,12 +25,17 @@ type AuthStore interface {
 	GetFriends(userID int64) ([]*User, error)
 	GetPendingRequests(userID int64) ([]*FriendRequest, error)
 	AreFriends(userID1, userID2 int64) (bool, error)
+	// Health
+	Ping() error
+	SchemaVersion() (int, error)
 }
 
 type User struct {
 	ID           int64
 	Username     string
 	PasswordHash string
+	DisplayName  string // '' = show Username
+	IsGuest      bool   // passwordless until claimed; see CreateGuestUser
 	CreatedAt    string
 }
 
@@ -35,6 +47,13 @@ type FriendRequest struct {
 	CreatedAt    string
 }
 
+// Reasons a friend request is refused
+var (
+	ErrFriendUnknown    = errors.New("no such user")
+	ErrFriendshipExists = errors.New("friendship or request already exists")
+	ErrNoFriendRequest  = errors.New("no pending friend request found")
+)
+
 type SQLiteAuthStore struct {
 	db *sql.DB
 }
@@ -43,10 +62,11 @@ func NewAuthStore(db *sql.DB) *SQLiteAuthStore {
 	return &SQLiteAuthStore{db: db}
 }
 
+// GetUserByUsername looks up a user ignoring case; the stored casing is returned
 func (s *SQLiteAuthStore) GetUserByUsername(username string) (*User, error) {
 	user := &User{}
-	err := s.db.QueryRow(`SELECT id, username, password_hash, created_at FROM users WHERE username = ?`,
-		username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	err := s.db.QueryRow(`SELECT id, username, password_hash, display_name, is_guest, created_at FROM users WHERE username = ? COLLATE NOCASE`,
+		username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.DisplayName, &user.IsGuest, &user.CreatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -58,8 +78,8 @@ func (s *SQLiteAuthStore) GetUserByUsername(username string) (*User, error) {
 
 func (s *SQLiteAuthStore) GetUserByID(userID int64) (*User, error) {
 	user := &User{}
-	err := s.db.QueryRow(`SELECT id, username, password_hash, created_at FROM users WHERE id = ?`,
-		userID).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	err := s.db.QueryRow(`SELECT id, username, password_hash, display_name, is_guest, created_at FROM users WHERE id = ?`,
+		userID).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.DisplayName, &user.IsGuest, &user.CreatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -80,12 +100,151 @@ func (s *SQLiteAuthStore) CreateUser(username, passwordHash string) (int64, erro
 	return result.LastInsertId()
 }
 
+// CreateGuestUser creates a guest account. It has no password, so it can't be
+// logged into; its sessions are the only way in until it is claimed.
+func (s *SQLiteAuthStore) CreateGuestUser(username string) (int64, error) {
+	result, err := s.db.Exec(
+		"INSERT INTO users (username, password_hash, is_guest) VALUES (?, '', 1)",
+		username,
+	)
+	if err != nil {
+		return 0, wrapDBError("create guest user", err)
+	}
+	return result.LastInsertId()
+}
+
+// ClaimGuest turns a guest into a regular account with the given username and
+// password, keeping its ID and so its games. Returns sql.ErrNoRows if the user
+// is not a guest.
+func (s *SQLiteAuthStore) ClaimGuest(userID int64, username, passwordHash string) error {
+	result, err := s.db.Exec(`
+		UPDATE users SET username = ?, password_hash = ?, is_guest = 0
+		WHERE id = ? AND is_guest = 1
+	`, username, passwordHash, userID)
+	if err != nil {
+		return wrapDBError("claim guest", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListStaleGuests returns guests with no live session and no seat in an
+// unfinished game; nobody can get back into them, so they can be deleted
+func (s *SQLiteAuthStore) ListStaleGuests(now time.Time) ([]int64, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id FROM users u
+		WHERE u.is_guest = 1
+		  AND NOT EXISTS (SELECT 1 FROM sessions WHERE user_id = u.id AND expires_at > ?)
+		  AND NOT EXISTS (
+			SELECT 1 FROM game_players gp JOIN games g ON g.id = gp.game_id
+			WHERE gp.user_id = u.id AND g.status != 'finished'
+		  )
+	`, now)
+	if err != nil {
+		return nil, wrapDBError("list stale guests", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, wrapDBError("scan guest", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SetDisplayName stores the user's display name; an empty name clears it
+func (s *SQLiteAuthStore) SetDisplayName(userID int64, displayName string) error {
+	result, err := s.db.Exec(`UPDATE users SET display_name = ? WHERE id = ?`, displayName, userID)
+	if err != nil {
+		return wrapDBError("set display name", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteUser removes a user and every row that references them (sessions,
+// friendships, invites, and their seats, properties and trades in unfinished
+// games) in one transaction. A user with seats in finished games is
+// anonymised instead of deleted, so those games keep their players: the
+// username is freed, the password cleared and the row marked deleted.
+// Returns sql.ErrNoRows if there is no such user or it was already deleted.
+func (s *SQLiteAuthStore) DeleteUser(userID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return wrapDBError("begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	const unfinished = `SELECT id FROM games WHERE status != 'finished'`
+	cleanup := []struct {
+		action string
+		query  string
+		args   []interface{}
+	}{
+		{"delete sessions", `DELETE FROM sessions WHERE user_id = ?`, []interface{}{userID}},
+		{"delete game invites", `DELETE FROM game_invites WHERE from_user_id = ? OR to_user_id = ?`, []interface{}{userID, userID}},
+		{"delete friendships", `DELETE FROM friendships WHERE user_id_1 = ? OR user_id_2 = ?`, []interface{}{userID, userID}},
+		{"delete trades", `DELETE FROM game_trades WHERE (from_user_id = ? OR to_user_id = ?) AND game_id IN (` + unfinished + `)`, []interface{}{userID, userID}},
+		{"delete jail cards", `DELETE FROM player_jail_cards WHERE user_id = ? AND game_id IN (` + unfinished + `)`, []interface{}{userID}},
+		{"delete owned properties", `DELETE FROM game_properties WHERE owner_id = ? AND game_id IN (` + unfinished + `)`, []interface{}{userID}},
+		{"delete game seats", `DELETE FROM game_players WHERE user_id = ? AND game_id IN (` + unfinished + `)`, []interface{}{userID}},
+	}
+	for _, c := range cleanup {
+		if _, err := tx.Exec(c.query, c.args...); err != nil {
+			return wrapDBError(c.action, err)
+		}
+	}
+
+	var played bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM game_players WHERE user_id = ?)`, userID).Scan(&played); err != nil {
+		return wrapDBError("check finished games", err)
+	}
+	var result sql.Result
+	if played {
+		result, err = tx.Exec(`
+			UPDATE users SET username = ?, password_hash = '', display_name = '', is_guest = 0, deleted_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND deleted_at IS NULL
+		`, fmt.Sprintf("deleted-%d", userID), userID)
+	} else {
+		result, err = tx.Exec(`DELETE FROM users WHERE id = ? AND deleted_at IS NULL`, userID)
+	}
+	if err != nil {
+		return wrapDBError("delete user", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// Ping checks that the database connection is alive
+func (s *SQLiteAuthStore) Ping() error {
+	if err := s.db.Ping(); err != nil {
+		return wrapDBError("ping database", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the database's applied migration version
+func (s *SQLiteAuthStore) SchemaVersion() (int, error) {
+	return SchemaVersion(s.db)
+}
+
 // Friends methods
 
 func (s *SQLiteAuthStore) SearchUsers(query string, excludeUserID int64, limit int) ([]*User, error) {
 	rows, err := s.db.Query(`
 		SELECT id, username, created_at FROM users
-		WHERE username LIKE ? AND id != ?
+		WHERE username LIKE ? AND id != ? AND deleted_at IS NULL
 		ORDER BY username
 		LIMIT ?
 	`, "%"+query+"%", excludeUserID, limit)
@@ -106,6 +265,14 @@ func (s *SQLiteAuthStore) SearchUsers(query string, excludeUserID int64, limit i
 }
 
 func (s *SQLiteAuthStore) SendFriendRequest(fromUserID, toUserID int64) error {
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE id = ? AND deleted_at IS NULL)`, toUserID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return ErrFriendUnknown
+	}
+
 	// Check if friendship already exists
 	var count int
 	err := s.db.QueryRow(`
@@ -116,7 +283,7 @@ func (s *SQLiteAuthStore) SendFriendRequest(fromUserID, toUserID int64) error {
 		return fmt.Errorf("failed to check existing friendship: %w", err)
 	}
 	if count > 0 {
-		return fmt.Errorf("friendship or request already exists")
+		return ErrFriendshipExists
 	}
 
 	_, err = s.db.Exec(`
@@ -138,7 +305,7 @@ func (s *SQLiteAuthStore) AcceptFriendRequest(userID, friendID int64) error {
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("no pending friend request found")
+		return ErrNoFriendRequest
 	}
 	return nil
 }
@@ -153,7 +320,7 @@ func (s *SQLiteAuthStore) DeclineFriendRequest(userID, friendID int64) error {
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("no pending friend request found")
+		return ErrNoFriendRequest
 	}
 	return nil
 }
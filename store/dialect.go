@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dialect is what opening and migrating a database needs to know about its
+// SQL. Migrations are written in SQLite's spelling and go through a
+// migrationTx, which has the dialect translate them.
+type dialect interface {
+	// open connects to the database dsn names
+	open(dsn string, busyTimeout time.Duration) (*sql.DB, error)
+	// prepareMigrations readies conn for schema changes and returns how to
+	// put it back afterwards
+	prepareMigrations(ctx context.Context, conn *sql.Conn) (restore func(), err error)
+	// translate rewrites a statement from SQLite's spelling
+	translate(query string) string
+	// columnExists reports whether table has column
+	columnExists(tx *sql.Tx, table, column string) (bool, error)
+	// deleteRules lists the ON DELETE action of each of table's foreign keys
+	deleteRules(tx *sql.Tx, table string) ([]string, error)
+	// nowUnix is an expression for the current time in unix seconds
+	nowUnix() string
+	// foldCase is expr as compared and indexed ignoring case
+	foldCase(expr string) string
+}
+
+// dialects maps the DB_DRIVER names Open accepts to their dialect
+var dialects = map[string]dialect{
+	"sqlite":   sqliteDialect{},
+	"postgres": postgresDialect{},
+}
+
+// Drivers returns the database drivers Open accepts, sorted
+func Drivers() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) open(dsn string, busyTimeout time.Duration) (*sql.DB, error) {
+	// Pragmas in the DSN run on every pooled connection, not just the first.
+	// WAL lets readers proceed while a game writes its turn state.
+	// Transactions take the write lock when they begin, so a check made inside
+	// one (funds, house supply) still holds when it writes; a deferred
+	// transaction would instead fail to upgrade once another writer committed.
+	return sql.Open("sqlite", fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate",
+		dsn, busyTimeout.Milliseconds()))
+}
+
+// prepareMigrations turns foreign keys off: they can't be toggled inside a
+// transaction and would block rebuilding a referenced table. Steps that copy
+// rows must drop dangling references themselves.
+func (sqliteDialect) prepareMigrations(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return nil, wrapDBError("disable foreign keys", err)
+	}
+	return func() { conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`) }, nil
+}
+
+func (sqliteDialect) translate(query string) string {
+	return query
+}
+
+func (sqliteDialect) columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	if err != nil {
+		return false, wrapDBError("read table info", err)
+	}
+	return n > 0, nil
+}
+
+func (sqliteDialect) deleteRules(tx *sql.Tx, table string) ([]string, error) {
+	return queryStrings(tx, `SELECT "on_delete" FROM pragma_foreign_key_list(?)`, table)
+}
+
+func (sqliteDialect) nowUnix() string {
+	return `CAST(strftime('%s', 'now') AS INTEGER)`
+}
+
+func (sqliteDialect) foldCase(expr string) string {
+	return expr + " COLLATE NOCASE"
+}
+
+// postgresDialect needs a database/sql driver registered as "pgx" (e.g.
+// github.com/jackc/pgx/v5/stdlib) linked into the binary. It covers opening
+// and migrating; the stores' queries are still in SQLite's spelling.
+type postgresDialect struct{}
+
+func (postgresDialect) open(dsn string, _ time.Duration) (*sql.DB, error) {
+	return sql.Open("pgx", dsn)
+}
+
+// prepareMigrations has nothing to do: Postgres changes schema inside a
+// transaction with its foreign keys on
+func (postgresDialect) prepareMigrations(context.Context, *sql.Conn) (func(), error) {
+	return func() {}, nil
+}
+
+// postgresTypes are the SQLite column spellings Postgres lacks
+var postgresTypes = strings.NewReplacer(
+	"INTEGER PRIMARY KEY AUTOINCREMENT", "INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY",
+	"DATETIME", "TIMESTAMP",
+)
+
+// translate spells column types the Postgres way and numbers the ?
+// placeholders $1, $2, ...; migrations don't put ? inside string literals
+func (postgresDialect) translate(query string) string {
+	query = postgresTypes.Replace(query)
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d postgresDialect) columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRow(d.translate(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?
+	`), table, column).Scan(&n)
+	if err != nil {
+		return false, wrapDBError("read table info", err)
+	}
+	return n > 0, nil
+}
+
+func (d postgresDialect) deleteRules(tx *sql.Tx, table string) ([]string, error) {
+	return queryStrings(tx, d.translate(`
+		SELECT rc.delete_rule
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.referential_constraints rc
+		  ON rc.constraint_schema = tc.constraint_schema AND rc.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = current_schema() AND tc.table_name = ? AND tc.constraint_type = 'FOREIGN KEY'
+	`), table)
+}
+
+func (postgresDialect) nowUnix() string {
+	return `CAST(EXTRACT(EPOCH FROM now()) AS INTEGER)`
+}
+
+func (postgresDialect) foldCase(expr string) string {
+	return "LOWER(" + expr + ")"
+}
+
+// queryStrings runs a query returning one text column
+func queryStrings(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, wrapDBError("query", err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, wrapDBError("scan", err)
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
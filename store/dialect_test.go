@@ -0,0 +1,35 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpen_RejectsUnknownDrivers(t *testing.T) {
+	if _, err := Open("oracle", "whatever", 1, 1, time.Second); err == nil || !strings.Contains(err.Error(), "unsupported database driver") {
+		t.Errorf("Expected an unsupported driver error, got %v", err)
+	}
+	if got := strings.Join(Drivers(), ","); got != "postgres,sqlite" {
+		t.Errorf("Expected postgres and sqlite, got %s", got)
+	}
+}
+
+func TestPostgresDialect_TranslatesMigrations(t *testing.T) {
+	d := postgresDialect{}
+
+	got := d.translate(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`)
+	if want := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	ddl := d.translate(schema + schemaMigrationsTable)
+	for _, sqliteOnly := range []string{"AUTOINCREMENT", "DATETIME", "?"} {
+		if strings.Contains(ddl, sqliteOnly) {
+			t.Errorf("Expected no %s in the Postgres schema", sqliteOnly)
+		}
+	}
+	if !strings.Contains(ddl, "id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY") {
+		t.Error("Expected identity columns in the Postgres schema")
+	}
+}
@@ -3,15 +3,21 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // GameStore handles in-game operations (turns, ready status, game state)
 type GameStore interface {
 	GetGame(gameID int64) (*Game, error)
 	GetGamePlayers(gameID int64) ([]*GamePlayer, error)
-	JoinGame(gameID, userID int64, playerOrder int) error // Legacy method for WebSocket game view
+	JoinGame(gameID, userID int64) (int, error)                       // Legacy method for WebSocket game view; returns the assigned player order
+	SeatPlayer(gameID, userID int64, tokens []string) (string, error) // LobbyStore.JoinGame, for players joining from the game view
 	UpdatePlayerReady(gameID, userID int64, isReady bool) error
 	UpdateGameStatus(gameID int64, status string) error
+	UpdateGameSettings(gameID int64, settings GameSettings) (bool, error)
+	TouchGameActivity(gameID int64) error
+	ListInactiveGames(before time.Time) ([]int64, error)
 	UpdateCurrentTurn(gameID, userID int64) error
 	GetCurrentTurnPlayer(gameID int64) (*GamePlayer, error)
 	MarkPlayerTurnComplete(gameID, userID int64) error
@@ -23,6 +29,9 @@ type GameStore interface {
 	// Transaction-aware operations
 	UpdatePlayerReadyTx(tx *sql.Tx, gameID, userID int64, isReady bool) error
 	UpdateGameStatusTx(tx *sql.Tx, gameID int64, status string) error
+	GetGameTx(tx *sql.Tx, gameID int64) (*Game, error)
+	IncrementRoundTx(tx *sql.Tx, gameID int64) (int, error)
+	FinishGameTx(tx *sql.Tx, gameID, winnerID int64, reason string) error
 	UpdateCurrentTurnTx(tx *sql.Tx, gameID, userID int64) error
 	MarkPlayerTurnCompleteTx(tx *sql.Tx, gameID, userID int64) error
 	// Game mechanics operations
@@ -67,6 +76,24 @@ type GameStore interface {
 	GetPendingTrades(gameID int64) ([]*GameTrade, error)
 	UpdateTradeStatus(tradeID int64, status string) error
 	TransferPropertyTx(tx *sql.Tx, gameID int64, position int, newOwnerID int64) error
+	// Event log
+	AppendEvent(gameID int64, eventType, payloadJSON string) (int64, error)
+	GetEvents(gameID, sinceSeq int64, limit int) ([]*GameEvent, error)
+	GetLastEvent(gameID int64, eventType string) (*GameEvent, error)
+	GetRecentEvents(gameID int64, limit int, types ...string) ([]*GameEvent, error)
+	// Spectator tokens
+	CreateSpectatorToken(gameID, createdBy int64, token string) error
+	GetSpectatorTokenGame(token string) (int64, error)
+	RevokeSpectatorToken(gameID int64, token string) (bool, error)
+}
+
+// GameEvent is one entry of a game's broadcast event log
+type GameEvent struct {
+	GameID      int64
+	Seq         int64
+	Type        string
+	PayloadJSON string
+	CreatedAt   string
 }
 
 // GameTrade represents a trade in the database
@@ -82,10 +109,54 @@ type GameTrade struct {
 
 // Game represents a game entity
 type Game struct {
-	ID         int64
-	Status     string
-	CreatedAt  string
-	MaxPlayers int
+	ID                int64
+	Status            string
+	CreatedAt         string
+	MinPlayers        int
+	MaxPlayers        int
+	TurnLimit         int   // rounds before the richest player wins; 0 = no limit
+	TimeLimitMinutes  int   // minutes before the richest player wins; 0 = no limit
+	Round             int   // current round, starting at 1
+	StartedAt         int64 // unix seconds; 0 until the game starts
+	WinnerID          int64 // 0 until the game finishes (or if nobody won)
+	EndReason         string
+	Seed              int64  // random seed stored at creation for reproducing the game
+	ManualStart       bool   // only the host starts the game; readiness alone doesn't
+	Board             string // custom board as JSON, validated at creation; empty for the standard board
+	StartingMoney     int    // money each player joins with
+	LastActivityAt    int64  // unix seconds a player was last seen connected; 0 if never recorded
+	HouseLimit        int    // houses the bank holds
+	HotelLimit        int    // hotels the bank holds
+	UnlimitedBuilding bool   // house rule: the limits don't apply
+}
+
+// GameSettings are the parts of a game the host can change before it starts
+type GameSettings struct {
+	MinPlayers       int
+	MaxPlayers       int
+	StartingMoney    int
+	TurnLimit        int
+	TimeLimitMinutes int
+	ManualStart      bool
+}
+
+const gameColumns = `id, status, created_at, min_players, max_players, turn_limit, time_limit_minutes,
+	round, COALESCE(started_at, 0), COALESCE(winner_id, 0), end_reason, seed, manual_start, board, starting_money, last_activity_at,
+	house_limit, hotel_limit, unlimited_building`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGame(row rowScanner) (*Game, error) {
+	game := &Game{}
+	err := row.Scan(&game.ID, &game.Status, &game.CreatedAt, &game.MinPlayers, &game.MaxPlayers, &game.TurnLimit,
+		&game.TimeLimitMinutes, &game.Round, &game.StartedAt, &game.WinnerID, &game.EndReason, &game.Seed, &game.ManualStart, &game.Board, &game.StartingMoney, &game.LastActivityAt,
+		&game.HouseLimit, &game.HotelLimit, &game.UnlimitedBuilding)
+	if err != nil {
+		return nil, err
+	}
+	return game, nil
 }
 
 // GamePlayer represents a player in a game
@@ -93,6 +164,7 @@ type GamePlayer struct {
 	GameID        int64
 	UserID        int64
 	Username      string
+	DisplayName   string // the user's display name, or Username if they have none
 	PlayerOrder   int
 	IsReady       bool
 	IsCurrentTurn bool
@@ -104,6 +176,9 @@ type GamePlayer struct {
 	PendingAction string
 	InJail        bool
 	JailTurns     int
+	TurnsTaken    int // completed turns and the time they took, see UpdateCurrentTurnTx
+	TurnSeconds   int64
+	Token         string // board piece, see game.PlayerTokens
 }
 
 // GameProperty represents a property owned by a player
@@ -125,12 +200,7 @@ func NewGameStore(db *sql.DB) *SQLiteGameStore {
 }
 
 func (s *SQLiteGameStore) GetGame(gameID int64) (*Game, error) {
-	game := &Game{}
-	err := s.db.QueryRow(
-		"SELECT id, status, created_at, max_players FROM games WHERE id = ?",
-		gameID,
-	).Scan(&game.ID, &game.Status, &game.CreatedAt, &game.MaxPlayers)
-
+	game, err := scanGame(s.db.QueryRow("SELECT "+gameColumns+" FROM games WHERE id = ?", gameID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -142,9 +212,11 @@ func (s *SQLiteGameStore) GetGame(gameID int64) (*Game, error) {
 
 func (s *SQLiteGameStore) GetGamePlayers(gameID int64) ([]*GamePlayer, error) {
 	rows, err := s.db.Query(`
-		SELECT gp.game_id, gp.user_id, u.username, gp.player_order, gp.is_ready,
+		SELECT gp.game_id, gp.user_id, u.username, COALESCE(NULLIF(u.display_name, ''), u.username),
+		       gp.player_order, gp.is_ready,
 		       gp.is_current_turn, gp.has_played_turn, gp.money, gp.position,
-		       gp.is_bankrupt, gp.has_rolled, gp.pending_action, gp.in_jail, gp.jail_turns
+		       gp.is_bankrupt, gp.has_rolled, gp.pending_action, gp.in_jail, gp.jail_turns,
+		       gp.turns_taken, gp.turn_seconds, gp.token
 		FROM game_players gp
 		JOIN users u ON gp.user_id = u.id
 		WHERE gp.game_id = ?
@@ -159,10 +231,11 @@ func (s *SQLiteGameStore) GetGamePlayers(gameID int64) ([]*GamePlayer, error) {
 	for rows.Next() {
 		player := &GamePlayer{}
 		var isReady, isCurrentTurn, hasPlayedTurn, isBankrupt, hasRolled, inJail int
-		if err := rows.Scan(&player.GameID, &player.UserID, &player.Username,
+		if err := rows.Scan(&player.GameID, &player.UserID, &player.Username, &player.DisplayName,
 			&player.PlayerOrder, &isReady, &isCurrentTurn, &hasPlayedTurn,
 			&player.Money, &player.Position, &isBankrupt, &hasRolled,
-			&player.PendingAction, &inJail, &player.JailTurns); err != nil {
+			&player.PendingAction, &inJail, &player.JailTurns,
+			&player.TurnsTaken, &player.TurnSeconds, &player.Token); err != nil {
 			return nil, fmt.Errorf("failed to scan player: %w", err)
 		}
 		player.IsReady = intToBool(isReady)
@@ -179,16 +252,38 @@ func (s *SQLiteGameStore) GetGamePlayers(gameID int64) ([]*GamePlayer, error) {
 	return players, nil
 }
 
-// JoinGame is a legacy method for WebSocket game view compatibility
-func (s *SQLiteGameStore) JoinGame(gameID, userID int64, playerOrder int) error {
-	_, err := s.db.Exec(
-		"INSERT INTO game_players (game_id, user_id, player_order, is_ready, is_current_turn) VALUES (?, ?, ?, 0, 0)",
-		gameID, userID, playerOrder,
-	)
+// JoinGame is a legacy method for WebSocket game view compatibility. The next
+// player order is computed in the insert itself so concurrent joins can't
+// share one; the primary key rejects the same user joining twice.
+func (s *SQLiteGameStore) JoinGame(gameID, userID int64) (int, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to join game: %w", err)
+		return 0, wrapDBError("begin join", err)
 	}
-	return nil
+	defer tx.Rollback()
+
+	var playerOrder int
+	err = tx.QueryRow(`
+		INSERT INTO game_players (game_id, user_id, player_order, is_ready, is_current_turn, money)
+		SELECT ?, ?, COALESCE(MAX(player_order), 0) + 1, 0, 0,
+		       (SELECT starting_money FROM games WHERE id = ?)
+		FROM game_players WHERE game_id = ?
+		RETURNING player_order
+	`, gameID, userID, gameID, gameID).Scan(&playerOrder)
+	if err != nil {
+		return 0, fmt.Errorf("failed to join game: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, wrapDBError("commit join", err)
+	}
+	return playerOrder, nil
+}
+
+// SeatPlayer joins the user to the game the way the lobby does, with the
+// same checks, errors and token assignment, see SQLiteLobbyStore.JoinGame
+func (s *SQLiteGameStore) SeatPlayer(gameID, userID int64, tokens []string) (string, error) {
+	return NewSQLiteLobbyStore(s.db).JoinGame(gameID, userID, "", tokens)
 }
 
 func (s *SQLiteGameStore) UpdatePlayerReady(gameID, userID int64, isReady bool) error {
@@ -202,10 +297,15 @@ func (s *SQLiteGameStore) UpdatePlayerReady(gameID, userID int64, isReady bool)
 	return nil
 }
 
+// updateGameStatusQuery also stamps started_at the first time a game goes in progress
+const updateGameStatusQuery = `UPDATE games SET status = ?,
+	started_at = CASE WHEN ? = 'in_progress' AND started_at IS NULL THEN CAST(strftime('%s', 'now') AS INTEGER) ELSE started_at END
+	WHERE id = ?`
+
 func (s *SQLiteGameStore) UpdateGameStatus(gameID int64, status string) error {
 	_, err := s.db.Exec(
-		"UPDATE games SET status = ? WHERE id = ?",
-		status, gameID,
+		updateGameStatusQuery,
+		status, status, gameID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update game status: %w", err)
@@ -213,22 +313,53 @@ func (s *SQLiteGameStore) UpdateGameStatus(gameID int64, status string) error {
 	return nil
 }
 
-func (s *SQLiteGameStore) UpdateCurrentTurn(gameID, userID int64) error {
+// UpdateGameSettings replaces a waiting game's settings and gives the players
+// who already joined the new starting money. It reports false, changing
+// nothing, if the game has started or more players have joined than the new
+// maxPlayers allows.
+func (s *SQLiteGameStore) UpdateGameSettings(gameID int64, settings GameSettings) (bool, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return false, wrapDBError("begin update settings", err)
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec("UPDATE game_players SET is_current_turn = 0 WHERE game_id = ?", gameID); err != nil {
-		return fmt.Errorf("failed to clear current turns: %w", err)
+	// The status and seat checks are part of the update so a concurrent join
+	// can't leave the game overfilled
+	result, err := tx.Exec(`
+		UPDATE games SET min_players = ?, max_players = ?, starting_money = ?,
+		       turn_limit = ?, time_limit_minutes = ?, manual_start = ?
+		WHERE id = ? AND status = 'waiting'
+		  AND (SELECT COUNT(*) FROM game_players WHERE game_id = games.id) <= ?
+	`, settings.MinPlayers, settings.MaxPlayers, settings.StartingMoney,
+		settings.TurnLimit, settings.TimeLimitMinutes, settings.ManualStart, gameID, settings.MaxPlayers)
+	if err != nil {
+		return false, wrapDBError("update game settings", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return false, wrapDBError("update game settings", err)
+	} else if n == 0 {
+		return false, nil
 	}
 
-	if _, err := tx.Exec(
-		"UPDATE game_players SET is_current_turn = 1 WHERE game_id = ? AND user_id = ?",
-		gameID, userID,
-	); err != nil {
-		return fmt.Errorf("failed to set current turn: %w", err)
+	if _, err := tx.Exec(`UPDATE game_players SET money = ? WHERE game_id = ?`, settings.StartingMoney, gameID); err != nil {
+		return false, wrapDBError("update starting money", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, wrapDBError("commit update settings", err)
+	}
+	return true, nil
+}
+
+func (s *SQLiteGameStore) UpdateCurrentTurn(gameID, userID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.UpdateCurrentTurnTx(tx, gameID, userID); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -313,8 +444,8 @@ func (s *SQLiteGameStore) UpdatePlayerReadyTx(tx *sql.Tx, gameID, userID int64,
 
 func (s *SQLiteGameStore) UpdateGameStatusTx(tx *sql.Tx, gameID int64, status string) error {
 	_, err := tx.Exec(
-		"UPDATE games SET status = ? WHERE id = ?",
-		status, gameID,
+		updateGameStatusQuery,
+		status, status, gameID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update game status: %w", err)
@@ -322,7 +453,102 @@ func (s *SQLiteGameStore) UpdateGameStatusTx(tx *sql.Tx, gameID int64, status st
 	return nil
 }
 
+func (s *SQLiteGameStore) GetGameTx(tx *sql.Tx, gameID int64) (*Game, error) {
+	game, err := scanGame(tx.QueryRow("SELECT "+gameColumns+" FROM games WHERE id = ?", gameID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game: %w", err)
+	}
+	return game, nil
+}
+
+// IncrementRoundTx advances the game to its next round and returns the new round number
+func (s *SQLiteGameStore) IncrementRoundTx(tx *sql.Tx, gameID int64) (int, error) {
+	if _, err := tx.Exec("UPDATE games SET round = round + 1 WHERE id = ?", gameID); err != nil {
+		return 0, fmt.Errorf("failed to increment round: %w", err)
+	}
+	var round int
+	if err := tx.QueryRow("SELECT round FROM games WHERE id = ?", gameID).Scan(&round); err != nil {
+		return 0, fmt.Errorf("failed to read round: %w", err)
+	}
+	return round, nil
+}
+
+// FinishGameTx marks the game finished and records the winner (0 for none) and why it ended
+func (s *SQLiteGameStore) FinishGameTx(tx *sql.Tx, gameID, winnerID int64, reason string) error {
+	var winner interface{}
+	if winnerID != 0 {
+		winner = winnerID
+	}
+	_, err := tx.Exec(
+		"UPDATE games SET status = 'finished', winner_id = ?, end_reason = ?, finished_at = CAST(strftime('%s', 'now') AS INTEGER) WHERE id = ?",
+		winner, reason, gameID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish game: %w", err)
+	}
+	return nil
+}
+
+// TouchGameActivity records that a player of the game is connected now
+func (s *SQLiteGameStore) TouchGameActivity(gameID int64) error {
+	_, err := s.db.Exec(
+		"UPDATE games SET last_activity_at = CAST(strftime('%s', 'now') AS INTEGER) WHERE id = ?",
+		gameID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record game activity: %w", err)
+	}
+	return nil
+}
+
+// ListInactiveGames returns the games in progress that no player has been
+// connected to since before, counting from the start of the game
+func (s *SQLiteGameStore) ListInactiveGames(before time.Time) ([]int64, error) {
+	rows, err := s.db.Query(`
+		SELECT id FROM games
+		WHERE status = 'in_progress' AND MAX(COALESCE(started_at, 0), last_activity_at) < ?
+		ORDER BY id
+	`, before.Unix())
+	if err != nil {
+		return nil, wrapDBError("list inactive games", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, wrapDBError("scan inactive game", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateCurrentTurnTx hands the turn to userID. The turn being handed over is
+// timed first: it counts towards its player's turns_taken and turn_seconds.
+// The first turn of a game has no predecessor and only starts the clock.
 func (s *SQLiteGameStore) UpdateCurrentTurnTx(tx *sql.Tx, gameID, userID int64) error {
+	if _, err := tx.Exec(`
+		UPDATE game_players
+		SET turns_taken = turns_taken + 1,
+		    turn_seconds = turn_seconds + MAX(0, CAST(strftime('%s', 'now') AS INTEGER) - g.turn_started_at)
+		FROM games g
+		WHERE g.id = game_players.game_id AND g.turn_started_at > 0
+		  AND game_players.game_id = ? AND game_players.is_current_turn = 1
+	`, gameID); err != nil {
+		return fmt.Errorf("failed to record turn time: %w", err)
+	}
+	if _, err := tx.Exec(
+		"UPDATE games SET turn_started_at = CAST(strftime('%s', 'now') AS INTEGER) WHERE id = ?",
+		gameID,
+	); err != nil {
+		return fmt.Errorf("failed to start turn clock: %w", err)
+	}
+
 	if _, err := tx.Exec("UPDATE game_players SET is_current_turn = 0 WHERE game_id = ?", gameID); err != nil {
 		return fmt.Errorf("failed to clear current turns: %w", err)
 	}
@@ -958,3 +1184,98 @@ func (s *SQLiteGameStore) TransferPropertyTx(tx *sql.Tx, gameID int64, position
 	}
 	return nil
 }
+
+// AppendEvent adds an event to the game's log and returns its sequence number.
+// The next seq is computed in the same statement so concurrent appends can't collide.
+func (s *SQLiteGameStore) AppendEvent(gameID int64, eventType, payloadJSON string) (int64, error) {
+	var seq int64
+	err := s.db.QueryRow(`
+		INSERT INTO game_events (game_id, seq, type, payload_json)
+		SELECT ?, COALESCE(MAX(seq), 0) + 1, ?, ? FROM game_events WHERE game_id = ?
+		RETURNING seq
+	`, gameID, eventType, payloadJSON, gameID).Scan(&seq)
+	if err != nil {
+		return 0, wrapDBError("append game event", err)
+	}
+	return seq, nil
+}
+
+// GetEvents returns up to limit events with seq greater than sinceSeq, oldest first
+func (s *SQLiteGameStore) GetEvents(gameID, sinceSeq int64, limit int) ([]*GameEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT game_id, seq, type, payload_json, created_at
+		FROM game_events
+		WHERE game_id = ? AND seq > ?
+		ORDER BY seq
+		LIMIT ?
+	`, gameID, sinceSeq, limit)
+	if err != nil {
+		return nil, wrapDBError("get game events", err)
+	}
+	defer rows.Close()
+
+	events := []*GameEvent{}
+	for rows.Next() {
+		ev := &GameEvent{}
+		if err := rows.Scan(&ev.GameID, &ev.Seq, &ev.Type, &ev.PayloadJSON, &ev.CreatedAt); err != nil {
+			return nil, wrapDBError("scan game event", err)
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// GetRecentEvents returns up to limit of the game's latest events of the given
+// types, newest first
+func (s *SQLiteGameStore) GetRecentEvents(gameID int64, limit int, types ...string) ([]*GameEvent, error) {
+	if len(types) == 0 {
+		return []*GameEvent{}, nil
+	}
+	args := []interface{}{gameID}
+	for _, t := range types {
+		args = append(args, t)
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(`
+		SELECT game_id, seq, type, payload_json, created_at
+		FROM game_events
+		WHERE game_id = ? AND type IN (?`+strings.Repeat(", ?", len(types)-1)+`)
+		ORDER BY seq DESC
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, wrapDBError("get recent game events", err)
+	}
+	defer rows.Close()
+
+	events := []*GameEvent{}
+	for rows.Next() {
+		ev := &GameEvent{}
+		if err := rows.Scan(&ev.GameID, &ev.Seq, &ev.Type, &ev.PayloadJSON, &ev.CreatedAt); err != nil {
+			return nil, wrapDBError("scan game event", err)
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// GetLastEvent returns the game's most recent event of the given type, or of
+// any type if eventType is empty. Returns nil if there is none.
+func (s *SQLiteGameStore) GetLastEvent(gameID int64, eventType string) (*GameEvent, error) {
+	ev := &GameEvent{}
+	err := s.db.QueryRow(`
+		SELECT game_id, seq, type, payload_json, created_at
+		FROM game_events
+		WHERE game_id = ? AND (? = '' OR type = ?)
+		ORDER BY seq DESC
+		LIMIT 1
+	`, gameID, eventType, eventType).Scan(&ev.GameID, &ev.Seq, &ev.Type, &ev.PayloadJSON, &ev.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapDBError("get last game event", err)
+	}
+	return ev, nil
+}
@@ -4,21 +4,30 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type LobbyStore interface {
-	ListGames(userID int64) ([]*LobbyGameDTO, error)
-	CreateGame(maxPlayers int) (int64, error)
-	JoinGame(gameID, userID int64, username string) error
+	ListGames(userID int64, filter GameListFilter, limit, offset int) ([]*LobbyGameDTO, int, error)
+	CreateGame(maxPlayers int, rules GameRules) (int64, error)
+	CreateGameWithHost(maxPlayers int, rules GameRules, userID int64, maxActiveGames int, tokens []string) (int64, string, error)
+	JoinGame(gameID, userID int64, username string, tokens []string) (string, error)
 	LeaveGame(gameID, userID int64) error
 	GetUserCurrentGame(userID int64) (*LobbyGameDTO, error)
 	IsUserInGame(userID int64) (bool, int64, error)
+	CountActiveGamesForUser(userID int64) (int, error)
+	GetGamesForUser(userID int64) ([]*UserGameDTO, error)
 	GetGameWithPlayers(gameID, userID int64) (*LobbyGameDTO, error)
 	// Game invites
 	InviteToGame(gameID, fromUserID, toUserID int64) error
 	GetGameInvites(userID int64) ([]*GameInvite, error)
 	AcceptGameInvite(inviteID, userID int64) error
 	DeclineGameInvite(inviteID, userID int64) error
+	// Archival of finished games
+	ArchiveGame(gameID int64) error
+	ArchiveFinishedGames(finishedBefore time.Time) (int64, error)
+	ListArchivedGames(limit, offset int) ([]*ArchivedGameDTO, int, error)
 }
 
 type GameInvite struct {
@@ -31,19 +40,72 @@ type GameInvite struct {
 	CreatedAt    string
 }
 
+// GameListFilter narrows the lobby game list. Zero value means all non-finished, non-archived games.
+type GameListFilter struct {
+	Status   string // "waiting" or "in_progress"; empty for any
+	Joinable bool   // only waiting games with a free seat
+}
+
+// whereClause builds the SQL condition and args for the filter
+func (f GameListFilter) whereClause() (string, []interface{}) {
+	conds := []string{"status != 'finished'", "archived = 0"}
+	var args []interface{}
+	if f.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Joinable {
+		conds = append(conds, "status = 'waiting'",
+			"(SELECT COUNT(*) FROM game_players gp WHERE gp.game_id = games.id) < max_players")
+	}
+	return strings.Join(conds, " AND "), args
+}
+
 // LobbyGameDTO is the simplified DTO for lobby game list
 type LobbyGameDTO struct {
-	ID         int64            `json:"id"`
-	Status     string           `json:"status"`
-	MaxPlayers int              `json:"maxPlayers"`
-	Players    []LobbyPlayerDTO `json:"players"`
-	IsJoined   bool             `json:"isJoined"` // true if current user is in this game
+	ID               int64            `json:"id"`
+	Status           string           `json:"status"`
+	MinPlayers       int              `json:"minPlayers"`
+	MaxPlayers       int              `json:"maxPlayers"`
+	TurnLimit        int              `json:"turnLimit,omitempty"`
+	TimeLimitMinutes int              `json:"timeLimitMinutes,omitempty"`
+	ManualStart      bool             `json:"manualStart"`
+	CustomBoard      bool             `json:"customBoard,omitempty"` // played on a board supplied at creation
+	Players          []LobbyPlayerDTO `json:"players"`
+	PlayerCount      int              `json:"playerCount"`
+	ConnectedCount   int              `json:"connectedCount"` // players with an open game socket; filled in by the HTTP layer
+	IsJoined         bool             `json:"isJoined"`       // true if current user is in this game
+}
+
+// UserGameDTO is one of the games a user is playing, for rejoining it
+type UserGameDTO struct {
+	ID          int64  `json:"id"`
+	Status      string `json:"status"`
+	PlayerCount int    `json:"playerCount"`
+	MaxPlayers  int    `json:"maxPlayers"`
+	IsMyTurn    bool   `json:"isMyTurn"`
+}
+
+// GameRules are the options chosen at game creation. When a victory limit
+// is reached the player with the highest net worth wins. Zero means no limit.
+type GameRules struct {
+	MinPlayers        int // players needed before a ready game can start
+	TurnLimit         int // full rounds
+	TimeLimitMinutes  int
+	Seed              int64  // for reproducing the game's dice and shuffles
+	ManualStart       bool   // the host starts the game instead of everyone readying up
+	Board             string // custom board JSON, already validated; empty for the standard board
+	HouseLimit        int    // houses the bank holds; 0 = the standard 32
+	HotelLimit        int    // hotels the bank holds; 0 = the standard 12
+	UnlimitedBuilding bool   // house rule: building is never short of houses or hotels
 }
 
 // LobbyPlayerDTO contains minimal player info for lobby
 type LobbyPlayerDTO struct {
 	UserID   int64  `json:"userId"`
 	Username string `json:"username"`
+	IsReady  bool   `json:"isReady"`
+	Token    string `json:"token"` // board piece; empty for players who joined before tokens
 }
 
 type SQLiteLobbyStore struct {
@@ -54,16 +116,27 @@ func NewSQLiteLobbyStore(db *sql.DB) *SQLiteLobbyStore {
 	return &SQLiteLobbyStore{db: db}
 }
 
-func (s *SQLiteLobbyStore) ListGames(userID int64) ([]*LobbyGameDTO, error) {
-	// Get all active games
+// ListGames returns one page of non-finished games matching the filter (newest
+// first) along with the total number of matching games.
+func (s *SQLiteLobbyStore) ListGames(userID int64, filter GameListFilter, limit, offset int) ([]*LobbyGameDTO, int, error) {
+	where, whereArgs := filter.whereClause()
+
+	var total int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM games WHERE `+where, whereArgs...).Scan(&total)
+	if err != nil {
+		return nil, 0, wrapDBError("count games", err)
+	}
+
+	// Get the requested page of matching games
 	rows, err := s.db.Query(`
-		SELECT id, status, max_players
+		SELECT id, status, min_players, max_players, turn_limit, time_limit_minutes, manual_start, board != ''
 		FROM games
-		WHERE status != 'finished'
+		WHERE `+where+`
 		ORDER BY id DESC
-	`)
+		LIMIT ? OFFSET ?
+	`, append(whereArgs, limit, offset)...)
 	if err != nil {
-		return nil, wrapDBError("list games", err)
+		return nil, 0, wrapDBError("list games", err)
 	}
 	defer rows.Close()
 
@@ -72,32 +145,38 @@ func (s *SQLiteLobbyStore) ListGames(userID int64) ([]*LobbyGameDTO, error) {
 	var gameIDs []int64
 	for rows.Next() {
 		game := &LobbyGameDTO{Players: []LobbyPlayerDTO{}}
-		if err := rows.Scan(&game.ID, &game.Status, &game.MaxPlayers); err != nil {
-			return nil, wrapDBError("scan game row", err)
+		if err := rows.Scan(&game.ID, &game.Status, &game.MinPlayers, &game.MaxPlayers, &game.TurnLimit, &game.TimeLimitMinutes, &game.ManualStart, &game.CustomBoard); err != nil {
+			return nil, 0, wrapDBError("scan game row", err)
 		}
 		gamesMap[game.ID] = game
 		gameIDs = append(gameIDs, game.ID)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate game rows: %w", err)
+		return nil, 0, fmt.Errorf("failed to iterate game rows: %w", err)
 	}
 
 	// If no games, return empty list
 	if len(gameIDs) == 0 {
-		return []*LobbyGameDTO{}, nil
+		return []*LobbyGameDTO{}, total, nil
 	}
 
-	// Get all players for all games in a single query
+	// Get all players for the games on this page in a single query
+	placeholders := strings.Repeat("?,", len(gameIDs))
+	placeholders = placeholders[:len(placeholders)-1]
+	args := make([]interface{}, len(gameIDs))
+	for i, id := range gameIDs {
+		args[i] = id
+	}
 	playerRows, err := s.db.Query(`
-		SELECT gp.game_id, gp.user_id, u.username
+		SELECT gp.game_id, gp.user_id, u.username, gp.is_ready, gp.token
 		FROM game_players gp
 		JOIN users u ON gp.user_id = u.id
-		WHERE gp.game_id IN (SELECT id FROM games WHERE status != 'finished')
+		WHERE gp.game_id IN (`+placeholders+`)
 		ORDER BY gp.game_id, gp.player_order
-	`)
+	`, args...)
 	if err != nil {
-		return nil, wrapDBError("query game players", err)
+		return nil, 0, wrapDBError("query game players", err)
 	}
 	defer playerRows.Close()
 
@@ -105,8 +184,8 @@ func (s *SQLiteLobbyStore) ListGames(userID int64) ([]*LobbyGameDTO, error) {
 	for playerRows.Next() {
 		var gameID int64
 		var player LobbyPlayerDTO
-		if err := playerRows.Scan(&gameID, &player.UserID, &player.Username); err != nil {
-			return nil, wrapDBError("scan player row", err)
+		if err := playerRows.Scan(&gameID, &player.UserID, &player.Username, &player.IsReady, &player.Token); err != nil {
+			return nil, 0, wrapDBError("scan player row", err)
 		}
 
 		if game, exists := gamesMap[gameID]; exists {
@@ -118,84 +197,211 @@ func (s *SQLiteLobbyStore) ListGames(userID int64) ([]*LobbyGameDTO, error) {
 	}
 
 	if err := playerRows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate player rows: %w", err)
+		return nil, 0, fmt.Errorf("failed to iterate player rows: %w", err)
 	}
 
 	// Convert map to ordered slice (maintaining DESC order from gameIDs)
 	games := make([]*LobbyGameDTO, 0, len(gameIDs))
 	for _, gameID := range gameIDs {
-		games = append(games, gamesMap[gameID])
+		game := gamesMap[gameID]
+		game.PlayerCount = len(game.Players)
+		games = append(games, game)
 	}
 
-	return games, nil
+	return games, total, nil
+}
+
+func (s *SQLiteLobbyStore) CreateGame(maxPlayers int, rules GameRules) (int64, error) {
+	return insertGame(s.db, maxPlayers, rules)
 }
 
-func (s *SQLiteLobbyStore) CreateGame(maxPlayers int) (int64, error) {
-	result, err := s.db.Exec(`INSERT INTO games (status, max_players) VALUES ('waiting', ?)`, maxPlayers)
+// CreateGameWithHost creates a game and seats its creator with the first of
+// tokens in one transaction, so a creator who can't join leaves no empty game
+// behind. A user already in maxActiveGames unfinished games gets
+// ErrTooManyGames; below that, like JoinGame, one in another unfinished game
+// gets ErrAlreadyInGame. Both are counted inside the transaction, so
+// concurrent creates can't both get past them. Returns the game and the
+// creator's token.
+func (s *SQLiteLobbyStore) CreateGameWithHost(maxPlayers int, rules GameRules, userID int64, maxActiveGames int, tokens []string) (int64, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, "", wrapDBError("begin create game", err)
+	}
+	defer tx.Rollback()
+
+	active, err := countActiveGames(tx, userID)
+	if err != nil {
+		return 0, "", err
+	}
+	if active >= maxActiveGames {
+		return 0, "", ErrTooManyGames
+	}
+	if active > 0 {
+		return 0, "", ErrAlreadyInGame
+	}
+
+	gameID, err := insertGame(tx, maxPlayers, rules)
+	if err != nil {
+		return 0, "", err
+	}
+	token, err := seatPlayer(tx, gameID, userID, tokens)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, "", wrapDBError("commit create game", err)
+	}
+	return gameID, token, nil
+}
+
+// execer and queryRower are what insertGame and countActiveGames need: the
+// database or a transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// insertGame adds a waiting game with no players
+func insertGame(db execer, maxPlayers int, rules GameRules) (int64, error) {
+	result, err := db.Exec(
+		`INSERT INTO games (status, min_players, max_players, turn_limit, time_limit_minutes, seed, manual_start, board,
+			house_limit, hotel_limit, unlimited_building) VALUES ('waiting', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rules.MinPlayers, maxPlayers, rules.TurnLimit, rules.TimeLimitMinutes, rules.Seed, rules.ManualStart, rules.Board,
+		rules.HouseLimit, rules.HotelLimit, rules.UnlimitedBuilding,
+	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create game: %w", err)
 	}
 	return result.LastInsertId()
 }
 
-func (s *SQLiteLobbyStore) JoinGame(gameID, userID int64, username string) error {
+// Reasons JoinGame turns a player away, besides ErrGameNotFound
+var (
+	ErrAlreadyInGame = errors.New("user already in another game")
+	ErrGameFull      = errors.New("game is full")
+	ErrGameStarted   = errors.New("game already started")
+	ErrTokenTaken    = errors.New("token already taken")
+)
+
+// ErrTooManyGames is returned by CreateGameWithHost for a user at the limit of
+// unfinished games
+var ErrTooManyGames = errors.New("too many active games")
+
+// ErrNotInGame is returned by LeaveGame for a user with no seat in the game
+var ErrNotInGame = errors.New("user not in game")
+
+// JoinGame seats the user with the first of tokens no other player in the
+// game has, and returns it. With no tokens the player gets none. Joining a
+// game the user is already in returns the token they have.
+func (s *SQLiteLobbyStore) JoinGame(gameID, userID int64, username string, tokens []string) (string, error) {
 	// Check if user is already in a game
 	isInGame, existingGameID, err := s.IsUserInGame(userID)
 	if err != nil {
-		return fmt.Errorf("failed to check user game status: %w", err)
+		return "", fmt.Errorf("failed to check user game status: %w", err)
 	}
 	if isInGame && existingGameID != gameID {
-		return errors.New("user already in another game")
+		return "", ErrAlreadyInGame
 	}
 	if isInGame && existingGameID == gameID {
-		return nil // Already in this game, no-op
+		return s.playerToken(gameID, userID) // Already in this game, no-op
 	}
 
-	// Check if game exists and is waiting
-	var status string
-	var maxPlayers, currentPlayers int
-	err = s.db.QueryRow(`
-		SELECT g.status, g.max_players, COUNT(gp.user_id) as player_count
-		FROM games g
-		LEFT JOIN game_players gp ON g.id = gp.game_id
-		WHERE g.id = ?
-		GROUP BY g.id
-	`, gameID).Scan(&status, &maxPlayers, &currentPlayers)
-	if err == sql.ErrNoRows {
-		return errors.New("game not found")
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", wrapDBError("begin join", err)
+	}
+	defer tx.Rollback()
+
+	token, err := seatPlayer(tx, gameID, userID, tokens)
+	if isUniqueViolation(err) {
+		tx.Rollback()
+		return s.playerToken(gameID, userID) // a concurrent request already joined this user
 	}
 	if err != nil {
-		return fmt.Errorf("failed to query game: %w", err)
+		return "", err
 	}
 
-	if status != "waiting" {
-		return errors.New("game already started")
+	if err := tx.Commit(); err != nil {
+		return "", wrapDBError("commit join", err)
+	}
+	return token, nil
+}
+
+// seatPlayer adds the user to the waiting game with the first of tokens no
+// other player in it has. When the game can't take them it returns why, see
+// joinRejection.
+func seatPlayer(tx *sql.Tx, gameID, userID int64, tokens []string) (string, error) {
+	if len(tokens) == 0 {
+		tokens = []string{""}
+	}
+	candidates := strings.TrimSuffix(strings.Repeat("(?, ?),", len(tokens)), ",")
+	args := make([]interface{}, 0, 2*len(tokens)+2)
+	for i, token := range tokens {
+		args = append(args, token, i)
+	}
+	args = append(args, userID, gameID)
+
+	// Capacity check, next player order, a free token and insert happen in one
+	// statement, so concurrent joins can neither overfill the game nor share
+	// an order or a token
+	var token string
+	err := tx.QueryRow(`
+		WITH candidates(token, rank) AS (VALUES `+candidates+`)
+		INSERT INTO game_players (game_id, user_id, player_order, is_ready, is_current_turn, money, token)
+		SELECT g.id, ?, (SELECT COALESCE(MAX(player_order), 0) + 1 FROM game_players WHERE game_id = g.id), 0, 0, g.starting_money, c.token
+		FROM games g, candidates c
+		WHERE g.id = ? AND g.status = 'waiting'
+		  AND (SELECT COUNT(*) FROM game_players WHERE game_id = g.id) < g.max_players
+		  AND (c.token = '' OR c.token NOT IN (SELECT token FROM game_players WHERE game_id = g.id))
+		ORDER BY c.rank
+		LIMIT 1
+		RETURNING token
+	`, args...).Scan(&token)
+	if err == sql.ErrNoRows {
+		return "", joinRejection(tx, gameID)
 	}
-	if currentPlayers >= maxPlayers {
-		return errors.New("game is full")
+	if err != nil {
+		return "", fmt.Errorf("failed to add player to game: %w", err)
 	}
+	return token, nil
+}
 
-	// Get next player order
-	var nextOrder int
-	err = s.db.QueryRow(`
-		SELECT COALESCE(MAX(player_order), 0) + 1
-		FROM game_players
-		WHERE game_id = ?
-	`, gameID).Scan(&nextOrder)
+// playerToken returns the token of a player in the game
+func (s *SQLiteLobbyStore) playerToken(gameID, userID int64) (string, error) {
+	var token string
+	err := s.db.QueryRow(`SELECT token FROM game_players WHERE game_id = ? AND user_id = ?`, gameID, userID).Scan(&token)
 	if err != nil {
-		return fmt.Errorf("failed to get next player order: %w", err)
+		return "", wrapDBError("get player token", err)
 	}
+	return token, nil
+}
 
-	// Add player to game
-	_, err = s.db.Exec(`
-		INSERT INTO game_players (game_id, user_id, player_order, is_ready, is_current_turn)
-		VALUES (?, ?, ?, 0, 0)
-	`, gameID, userID, nextOrder)
+// joinRejection explains why the conditional insert in JoinGame added no row
+func joinRejection(tx *sql.Tx, gameID int64) error {
+	var status string
+	var players, maxPlayers int
+	err := tx.QueryRow(`
+		SELECT status, max_players, (SELECT COUNT(*) FROM game_players WHERE game_id = games.id)
+		FROM games WHERE id = ?
+	`, gameID).Scan(&status, &maxPlayers, &players)
+	if err == sql.ErrNoRows {
+		return ErrGameNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to add player to game: %w", err)
+		return fmt.Errorf("failed to query game: %w", err)
 	}
 
-	return nil
+	if status != "waiting" {
+		return ErrGameStarted
+	}
+	if players >= maxPlayers {
+		return ErrGameFull
+	}
+	return ErrTokenTaken
 }
 
 func (s *SQLiteLobbyStore) LeaveGame(gameID, userID int64) error {
@@ -213,7 +419,7 @@ func (s *SQLiteLobbyStore) LeaveGame(gameID, userID int64) error {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return errors.New("user not in game")
+		return ErrNotInGame
 	}
 
 	// Check if game is now empty
@@ -227,6 +433,12 @@ func (s *SQLiteLobbyStore) LeaveGame(gameID, userID int64) error {
 
 	// If game is empty and still waiting, delete it
 	if playerCount == 0 {
+		_, err = s.db.Exec(`
+			DELETE FROM game_events WHERE game_id IN (SELECT id FROM games WHERE id = ? AND status = 'waiting')
+		`, gameID)
+		if err != nil {
+			return fmt.Errorf("failed to delete events of empty game: %w", err)
+		}
 		_, err = s.db.Exec(`
 			DELETE FROM games WHERE id = ? AND status = 'waiting'
 		`, gameID)
@@ -241,7 +453,7 @@ func (s *SQLiteLobbyStore) LeaveGame(gameID, userID int64) error {
 func (s *SQLiteLobbyStore) GetUserCurrentGame(userID int64) (*LobbyGameDTO, error) {
 	// Get game details and all players in a single query
 	rows, err := s.db.Query(`
-		SELECT g.id, g.status, g.max_players, gp.user_id, u.username
+		SELECT g.id, g.status, g.min_players, g.max_players, gp.user_id, u.username, gp.is_ready, gp.token
 		FROM game_players gp_user
 		JOIN games g ON gp_user.game_id = g.id
 		JOIN game_players gp ON gp.game_id = g.id
@@ -258,10 +470,10 @@ func (s *SQLiteLobbyStore) GetUserCurrentGame(userID int64) (*LobbyGameDTO, erro
 	for rows.Next() {
 		var gameID int64
 		var status string
-		var maxPlayers int
+		var minPlayers, maxPlayers int
 		var player LobbyPlayerDTO
 
-		if err := rows.Scan(&gameID, &status, &maxPlayers, &player.UserID, &player.Username); err != nil {
+		if err := rows.Scan(&gameID, &status, &minPlayers, &maxPlayers, &player.UserID, &player.Username, &player.IsReady, &player.Token); err != nil {
 			return nil, fmt.Errorf("failed to scan game and player: %w", err)
 		}
 
@@ -270,6 +482,7 @@ func (s *SQLiteLobbyStore) GetUserCurrentGame(userID int64) (*LobbyGameDTO, erro
 			game = &LobbyGameDTO{
 				ID:         gameID,
 				Status:     status,
+				MinPlayers: minPlayers,
 				MaxPlayers: maxPlayers,
 				IsJoined:   true,
 				Players:    []LobbyPlayerDTO{},
@@ -304,14 +517,63 @@ func (s *SQLiteLobbyStore) IsUserInGame(userID int64) (bool, int64, error) {
 	return true, gameID, nil
 }
 
+// CountActiveGamesForUser counts the waiting or in-progress games the user is part of
+func (s *SQLiteLobbyStore) CountActiveGamesForUser(userID int64) (int, error) {
+	return countActiveGames(s.db, userID)
+}
+
+func countActiveGames(db queryRower, userID int64) (int, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM game_players gp
+		JOIN games g ON g.id = gp.game_id
+		WHERE gp.user_id = ? AND g.status != 'finished'
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, wrapDBError("count active games", err)
+	}
+	return count, nil
+}
+
+// GetGamesForUser returns the waiting or in-progress games the user is part
+// of, newest first, and whether it is their turn in each
+func (s *SQLiteLobbyStore) GetGamesForUser(userID int64) ([]*UserGameDTO, error) {
+	rows, err := s.db.Query(`
+		SELECT g.id, g.status, g.max_players,
+		       (SELECT COUNT(*) FROM game_players p WHERE p.game_id = g.id),
+		       g.status = 'in_progress' AND gp.is_current_turn = 1
+		FROM game_players gp
+		JOIN games g ON g.id = gp.game_id
+		WHERE gp.user_id = ? AND g.status != 'finished' AND g.archived = 0
+		ORDER BY g.id DESC
+	`, userID)
+	if err != nil {
+		return nil, wrapDBError("query user games", err)
+	}
+	defer rows.Close()
+
+	games := []*UserGameDTO{}
+	for rows.Next() {
+		game := &UserGameDTO{}
+		if err := rows.Scan(&game.ID, &game.Status, &game.MaxPlayers, &game.PlayerCount, &game.IsMyTurn); err != nil {
+			return nil, wrapDBError("scan user game", err)
+		}
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate user games: %w", err)
+	}
+	return games, nil
+}
+
 func (s *SQLiteLobbyStore) GetGameWithPlayers(gameID, userID int64) (*LobbyGameDTO, error) {
 	// Get game details
 	var game LobbyGameDTO
 	err := s.db.QueryRow(`
-		SELECT id, status, max_players
+		SELECT id, status, min_players, max_players, turn_limit, time_limit_minutes, manual_start, board != ''
 		FROM games
 		WHERE id = ?
-	`, gameID).Scan(&game.ID, &game.Status, &game.MaxPlayers)
+	`, gameID).Scan(&game.ID, &game.Status, &game.MinPlayers, &game.MaxPlayers, &game.TurnLimit, &game.TimeLimitMinutes, &game.ManualStart, &game.CustomBoard)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -321,7 +583,7 @@ func (s *SQLiteLobbyStore) GetGameWithPlayers(gameID, userID int64) (*LobbyGameD
 
 	// Get players for this game
 	rows, err := s.db.Query(`
-		SELECT gp.user_id, u.username
+		SELECT gp.user_id, u.username, gp.is_ready, gp.token
 		FROM game_players gp
 		JOIN users u ON gp.user_id = u.id
 		WHERE gp.game_id = ?
@@ -335,7 +597,7 @@ func (s *SQLiteLobbyStore) GetGameWithPlayers(gameID, userID int64) (*LobbyGameD
 	game.Players = []LobbyPlayerDTO{}
 	for rows.Next() {
 		var player LobbyPlayerDTO
-		if err := rows.Scan(&player.UserID, &player.Username); err != nil {
+		if err := rows.Scan(&player.UserID, &player.Username, &player.IsReady, &player.Token); err != nil {
 			return nil, wrapDBError("scan player", err)
 		}
 		if player.UserID == userID {
@@ -347,6 +609,7 @@ func (s *SQLiteLobbyStore) GetGameWithPlayers(gameID, userID int64) (*LobbyGameD
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to iterate players: %w", err)
 	}
+	game.PlayerCount = len(game.Players)
 
 	return &game, nil
 }
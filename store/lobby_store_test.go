@@ -0,0 +1,207 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLobbyStore(t *testing.T) *SQLiteLobbyStore {
+	t.Helper()
+	db, err := InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewSQLiteLobbyStore(db)
+}
+
+func TestJoinGame_ConcurrentJoinsGetDistinctOrders(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+
+	const maxPlayers, joiners = 4, 10
+	gameID, err := lobby.CreateGame(maxPlayers, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+
+	userIDs := make([]int64, joiners)
+	for i := range userIDs {
+		if userIDs[i], err = auth.CreateUser(fmt.Sprintf("player%d", i), "hash"); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, joiners)
+	for i, userID := range userIDs {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			_, errs[i] = lobby.JoinGame(gameID, userID, "", nil)
+		}(i, userID)
+	}
+	wg.Wait()
+
+	joined := 0
+	for i, err := range errs {
+		if err == nil {
+			joined++
+		} else if err.Error() != "game is full" {
+			t.Errorf("Join %d failed unexpectedly: %v", i, err)
+		}
+	}
+	if joined != maxPlayers {
+		t.Errorf("Expected exactly %d successful joins, got %d", maxPlayers, joined)
+	}
+
+	rows, err := lobby.db.Query(`SELECT player_order FROM game_players WHERE game_id = ?`, gameID)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+	seen := make(map[int]bool)
+	for rows.Next() {
+		var order int
+		if err := rows.Scan(&order); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if seen[order] {
+			t.Errorf("Duplicate player_order %d", order)
+		}
+		seen[order] = true
+	}
+	if len(seen) != maxPlayers {
+		t.Errorf("Expected %d players in game, got %d", maxPlayers, len(seen))
+	}
+}
+
+func TestGameStoreJoinGame_ConcurrentJoinsGetDistinctOrders(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	gameID, err := lobby.CreateGame(8, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+
+	const joiners = 8
+	var wg sync.WaitGroup
+	orders := make([]int, joiners)
+	for i := 0; i < joiners; i++ {
+		userID, err := auth.CreateUser(fmt.Sprintf("player%d", i), "hash")
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			order, err := games.JoinGame(gameID, userID)
+			if err != nil {
+				t.Errorf("JoinGame failed: %v", err)
+			}
+			orders[i] = order
+		}(i, userID)
+	}
+	wg.Wait()
+
+	seen := make(map[int]bool)
+	for _, order := range orders {
+		if seen[order] {
+			t.Errorf("Duplicate player_order %d in %v", order, orders)
+		}
+		seen[order] = true
+	}
+
+	// The same user joining again is rejected by the primary key
+	players, _ := games.GetGamePlayers(gameID)
+	if _, err := games.JoinGame(gameID, players[0].UserID); err == nil {
+		t.Error("Expected duplicate join of the same user to fail")
+	}
+}
+
+func TestCreateGame_StoresManualStart(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	games := NewGameStore(lobby.db)
+
+	manualID, err := lobby.CreateGame(4, GameRules{MinPlayers: 2, ManualStart: true})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	autoID, err := lobby.CreateGame(4, GameRules{MinPlayers: 2})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+
+	for id, want := range map[int64]bool{manualID: true, autoID: false} {
+		game, err := games.GetGame(id)
+		if err != nil {
+			t.Fatalf("GetGame failed: %v", err)
+		}
+		dto, err := lobby.GetGameWithPlayers(id, 0)
+		if err != nil {
+			t.Fatalf("GetGameWithPlayers failed: %v", err)
+		}
+		if game.ManualStart != want || dto.ManualStart != want {
+			t.Errorf("Game %d: expected manual start %v, got %v from the game store and %v from the lobby", id, want, game.ManualStart, dto.ManualStart)
+		}
+	}
+}
+
+func TestGetGamesForUser_ListsUnfinishedGamesAndTurn(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+
+	waitingID, _ := lobby.CreateGame(4, GameRules{})
+	playingID, _ := lobby.CreateGame(2, GameRules{})
+	finishedID, _ := lobby.CreateGame(2, GameRules{})
+	for _, id := range []int64{waitingID, playingID, finishedID} {
+		if _, err := games.JoinGame(id, alice); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+	}
+	for _, id := range []int64{playingID, finishedID} {
+		if _, err := games.JoinGame(id, bob); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+		if err := games.UpdateGameStatus(id, "in_progress"); err != nil {
+			t.Fatalf("UpdateGameStatus failed: %v", err)
+		}
+		if err := games.UpdateCurrentTurn(id, alice); err != nil {
+			t.Fatalf("UpdateCurrentTurn failed: %v", err)
+		}
+	}
+	if err := games.UpdateGameStatus(finishedID, "finished"); err != nil {
+		t.Fatalf("UpdateGameStatus failed: %v", err)
+	}
+
+	mine, err := lobby.GetGamesForUser(alice)
+	if err != nil {
+		t.Fatalf("GetGamesForUser failed: %v", err)
+	}
+	if len(mine) != 2 || mine[0].ID != playingID || mine[1].ID != waitingID {
+		t.Fatalf("Expected the in-progress then the waiting game, got %+v", mine)
+	}
+	if !mine[0].IsMyTurn || mine[0].Status != "in_progress" || mine[0].PlayerCount != 2 {
+		t.Errorf("Expected alice's turn in the 2-player game in progress, got %+v", mine[0])
+	}
+	if mine[1].IsMyTurn || mine[1].Status != "waiting" || mine[1].PlayerCount != 1 || mine[1].MaxPlayers != 4 {
+		t.Errorf("Expected a waiting game with 1 of 4 seats taken, got %+v", mine[1])
+	}
+
+	theirs, err := lobby.GetGamesForUser(bob)
+	if err != nil {
+		t.Fatalf("GetGamesForUser failed: %v", err)
+	}
+	if len(theirs) != 1 || theirs[0].IsMyTurn {
+		t.Errorf("Expected bob's one game, not on his turn, got %+v", theirs)
+	}
+}
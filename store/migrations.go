@@ -1,10 +1,20 @@
 package store
 
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
 const schema = `
 CREATE TABLE IF NOT EXISTS users (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    username TEXT UNIQUE NOT NULL,
+    username TEXT UNIQUE NOT NULL,  -- also unique case-insensitively, see migrateUsernamesNoCase
     password_hash TEXT NOT NULL,
+    display_name TEXT NOT NULL DEFAULT '',  -- shown instead of username when set
+    is_guest INTEGER NOT NULL DEFAULT 0,    -- passwordless account until claimed
+    deleted_at DATETIME,                    -- account deleted, row kept for its finished games
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -13,6 +23,7 @@ CREATE TABLE IF NOT EXISTS sessions (
     user_id INTEGER NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     expires_at DATETIME NOT NULL,
+    last_seen_at DATETIME,                  -- last validation, to within a minute
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
@@ -20,7 +31,23 @@ CREATE TABLE IF NOT EXISTS games (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     status TEXT NOT NULL DEFAULT 'waiting',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    max_players INTEGER DEFAULT 4
+    min_players INTEGER NOT NULL DEFAULT 2,
+    max_players INTEGER DEFAULT 4,
+    turn_limit INTEGER NOT NULL DEFAULT 0,          -- rounds; 0 = no limit
+    time_limit_minutes INTEGER NOT NULL DEFAULT 0,  -- 0 = no limit
+    round INTEGER NOT NULL DEFAULT 1,
+    started_at INTEGER,                             -- unix seconds, set when the game starts
+    winner_id INTEGER,
+    end_reason TEXT NOT NULL DEFAULT '',
+    seed INTEGER NOT NULL DEFAULT 0,                -- for reproducing dice and shuffles
+    manual_start INTEGER NOT NULL DEFAULT 0,        -- only the host starts the game, see migrateManualStart
+    board TEXT NOT NULL DEFAULT '',                 -- custom board as JSON; '' = the standard board
+    turn_started_at INTEGER NOT NULL DEFAULT 0,     -- unix seconds the current turn began; 0 = not started
+    starting_money INTEGER NOT NULL DEFAULT 1500,   -- each player's money when they join
+    last_activity_at INTEGER NOT NULL DEFAULT 0,    -- unix seconds a player was last connected, see migrateGameActivity
+    house_limit INTEGER NOT NULL DEFAULT 32,        -- houses the bank has, see migrateBuildingSupply
+    hotel_limit INTEGER NOT NULL DEFAULT 12,
+    unlimited_building INTEGER NOT NULL DEFAULT 0   -- house rule: the bank never runs out
 );
 
 CREATE TABLE IF NOT EXISTS game_players (
@@ -37,9 +64,12 @@ CREATE TABLE IF NOT EXISTS game_players (
     pending_action TEXT DEFAULT '',
     in_jail INTEGER DEFAULT 0,
     jail_turns INTEGER DEFAULT 0,
+    turns_taken INTEGER NOT NULL DEFAULT 0,   -- completed turns, see UpdateCurrentTurnTx
+    turn_seconds INTEGER NOT NULL DEFAULT 0,  -- total time spent on them
+    token TEXT NOT NULL DEFAULT '',           -- board piece, unique per game; see migratePlayerTokens
     PRIMARY KEY (game_id, user_id),
-    FOREIGN KEY (game_id) REFERENCES games(id),
-    FOREIGN KEY (user_id) REFERENCES users(id)
+    FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS game_properties (
@@ -131,4 +161,414 @@ CREATE TABLE IF NOT EXISTS game_invites (
 );
 
 CREATE INDEX IF NOT EXISTS idx_game_invites_to_user ON game_invites(to_user_id, status);
+
+CREATE TABLE IF NOT EXISTS game_events (
+    game_id INTEGER NOT NULL,
+    seq INTEGER NOT NULL,           -- 1-based, per game
+    type TEXT NOT NULL,
+    payload_json TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (game_id, seq),
+    FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE
+);
+`
+
+// schemaMigrationsTable records which migrations have been applied
+const schemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
 `
+
+// migration is one step in the schema's history
+type migration struct {
+	version int
+	name    string
+	up      func(tx migrationTx) error
+}
+
+// migrations is the ordered schema history. Add changes by appending a step
+// with the next version; never edit or reorder a step that has shipped.
+// Steps 1-4 predate versioning and are idempotent, since databases created
+// before then have no schema_migrations rows and replay all of them.
+var migrations = []migration{
+	{1, "base schema", func(tx migrationTx) error {
+		_, err := tx.Exec(schema)
+		return err
+	}},
+	{2, "case-insensitive usernames", migrateUsernamesNoCase},
+	{3, "game rule and result columns", migrateGameResultColumns},
+	{4, "cascade game_players deletes", migrateGamePlayersCascade},
+	{5, "game archival", migrateGameArchival},
+	{6, "spectator tokens", migrateSpectatorTokens},
+	{7, "manual game start", migrateManualStart},
+	{8, "custom boards", migrateCustomBoards},
+	{9, "turn timing", migrateTurnTiming},
+	{10, "display names", migrateDisplayNames},
+	{11, "starting money", migrateStartingMoney},
+	{12, "game activity", migrateGameActivity},
+	{13, "guest accounts", migrateGuestAccounts},
+	{14, "session last seen", migrateSessionLastSeen},
+	{15, "building supply", migrateBuildingSupply},
+	{16, "player tokens", migratePlayerTokens},
+	{17, "deleted users", migrateDeletedUsers},
+}
+
+// migrate applies every migration newer than the database's version, each in
+// its own transaction together with its schema_migrations row, so a failed
+// step leaves the database at the previous version.
+func migrate(db *sql.DB, d dialect) error {
+	// Migrations share one connection, which the dialect may set up for
+	// schema changes
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	restore, err := d.prepareMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	if _, err := conn.ExecContext(ctx, d.translate(schemaMigrationsTable)); err != nil {
+		return wrapDBError("create schema_migrations", err)
+	}
+	var current int
+	if err := conn.QueryRowContext(ctx, currentVersionQuery).Scan(&current); err != nil {
+		return wrapDBError("read schema version", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, conn, d, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Applied migration %d: %s", m.version, m.name)
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, d dialect, m migration) error {
+	raw, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer raw.Rollback()
+
+	tx := migrationTx{Tx: raw, dialect: d}
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+		return wrapDBError("record migration", err)
+	}
+	return raw.Commit()
+}
+
+// migrationTx is a migration's transaction. Exec, Query and QueryRow take
+// statements in SQLite's spelling and have the dialect translate them.
+type migrationTx struct {
+	*sql.Tx
+	dialect
+}
+
+func (tx migrationTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.translate(query), args...)
+}
+
+func (tx migrationTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.translate(query), args...)
+}
+
+func (tx migrationTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.translate(query), args...)
+}
+
+const currentVersionQuery = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+
+// SchemaVersion returns the version of the last applied migration
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow(currentVersionQuery).Scan(&version); err != nil {
+		return 0, wrapDBError("read schema version", err)
+	}
+	return version, nil
+}
+
+// addColumnIfMissing adds a column to an existing table. CREATE TABLE IF NOT
+// EXISTS leaves older databases untouched, so new columns are added here.
+func addColumnIfMissing(tx migrationTx, table, column, definition string) error {
+	exists, err := tx.columnExists(tx.Tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return wrapDBError("add column "+table+"."+column, err)
+	}
+	return nil
+}
+
+// migrateGameResultColumns adds the game rule and result columns to games
+func migrateGameResultColumns(tx migrationTx) error {
+	columns := []struct{ name, definition string }{
+		{"min_players", "INTEGER NOT NULL DEFAULT 2"},
+		{"turn_limit", "INTEGER NOT NULL DEFAULT 0"},
+		{"time_limit_minutes", "INTEGER NOT NULL DEFAULT 0"},
+		{"round", "INTEGER NOT NULL DEFAULT 1"},
+		{"started_at", "INTEGER"},
+		{"winner_id", "INTEGER"},
+		{"end_reason", "TEXT NOT NULL DEFAULT ''"},
+		{"seed", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, "games", c.name, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateGameArchival adds the archived flag and the finish time it's based
+// on. Games that finished before finished_at existed count from now.
+func migrateGameArchival(tx migrationTx) error {
+	if err := addColumnIfMissing(tx, "games", "finished_at", "INTEGER"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "games", "archived", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE games SET finished_at = ` + tx.nowUnix() + ` WHERE status = 'finished' AND finished_at IS NULL`); err != nil {
+		return wrapDBError("backfill finished_at", err)
+	}
+	if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_games_archived ON games(archived, status)`); err != nil {
+		return wrapDBError("create archived index", err)
+	}
+	return nil
+}
+
+// migrateSpectatorTokens adds the read-only viewing links players can share.
+// Tokens go with their game when it is deleted.
+func migrateSpectatorTokens(tx migrationTx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS spectator_tokens (
+			token TEXT PRIMARY KEY,
+			game_id INTEGER NOT NULL,
+			created_by INTEGER NOT NULL,
+			created_at INTEGER NOT NULL,
+			FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_spectator_tokens_game ON spectator_tokens(game_id);
+	`)
+	if err != nil {
+		return wrapDBError("create spectator_tokens", err)
+	}
+	return nil
+}
+
+// migrateManualStart adds the rule for games the host starts by hand instead
+// of when everyone is ready. Existing games keep starting automatically.
+func migrateManualStart(tx migrationTx) error {
+	return addColumnIfMissing(tx, "games", "manual_start", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// migrateCustomBoards adds the per-game board definition. Existing games are
+// played on the standard board.
+func migrateCustomBoards(tx migrationTx) error {
+	return addColumnIfMissing(tx, "games", "board", "TEXT NOT NULL DEFAULT ''")
+}
+
+// migrateTurnTiming adds how long players take over their turns. Turns
+// already under way when it runs aren't timed.
+func migrateTurnTiming(tx migrationTx) error {
+	if err := addColumnIfMissing(tx, "games", "turn_started_at", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "game_players", "turns_taken", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	return addColumnIfMissing(tx, "game_players", "turn_seconds", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// migrateDisplayNames adds display names. Existing users have none and keep
+// being shown by username.
+func migrateDisplayNames(tx migrationTx) error {
+	return addColumnIfMissing(tx, "users", "display_name", "TEXT NOT NULL DEFAULT ''")
+}
+
+// migrateStartingMoney adds the money players join with, which the host can
+// change before the game starts. Existing games keep the standard 1500.
+func migrateStartingMoney(tx migrationTx) error {
+	return addColumnIfMissing(tx, "games", "starting_money", "INTEGER NOT NULL DEFAULT 1500")
+}
+
+// migrateGameActivity adds when a player was last connected to each game, so
+// games everyone walked away from can be finished. Games already in progress
+// count from their start.
+func migrateGameActivity(tx migrationTx) error {
+	return addColumnIfMissing(tx, "games", "last_activity_at", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// migrateGuestAccounts flags passwordless guest accounts, which are deleted
+// once they have no session and no unfinished game
+func migrateGuestAccounts(tx migrationTx) error {
+	return addColumnIfMissing(tx, "users", "is_guest", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// migrateSessionLastSeen records when each session was last used, so users
+// can tell their logins apart. Existing sessions start from their login time.
+func migrateSessionLastSeen(tx migrationTx) error {
+	if err := addColumnIfMissing(tx, "sessions", "last_seen_at", "DATETIME"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE sessions SET last_seen_at = created_at WHERE last_seen_at IS NULL`); err != nil {
+		return wrapDBError("backfill session last seen", err)
+	}
+	return nil
+}
+
+// migrateBuildingSupply adds how many houses and hotels the bank holds per
+// game. Existing games get the standard 32 and 12, which were enforced before
+// they could be changed.
+func migrateBuildingSupply(tx migrationTx) error {
+	if err := addColumnIfMissing(tx, "games", "house_limit", "INTEGER NOT NULL DEFAULT 32"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "games", "hotel_limit", "INTEGER NOT NULL DEFAULT 12"); err != nil {
+		return err
+	}
+	return addColumnIfMissing(tx, "games", "unlimited_building", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// migratePlayerTokens adds the piece each player moves around the board.
+// Players who joined before it have none and keep none.
+func migratePlayerTokens(tx migrationTx) error {
+	return addColumnIfMissing(tx, "game_players", "token", "TEXT NOT NULL DEFAULT ''")
+}
+
+// migrateDeletedUsers marks accounts deleted by users who had played a game.
+// Their row stays, anonymised, so finished games keep their seats.
+func migrateDeletedUsers(tx migrationTx) error {
+	return addColumnIfMissing(tx, "users", "deleted_at", "DATETIME")
+}
+
+// migrateUsernamesNoCase makes usernames unique regardless of case. Accounts
+// that collide with an older account (e.g. "Alice" after "alice") are renamed
+// by appending their user ID so the unique index can be created.
+func migrateUsernamesNoCase(tx migrationTx) error {
+	rows, err := tx.Query(`
+		SELECT id, username FROM users u
+		WHERE EXISTS (
+			SELECT 1 FROM users older
+			WHERE ` + tx.foldCase("older.username") + ` = ` + tx.foldCase("u.username") + ` AND older.id < u.id
+		)
+	`)
+	if err != nil {
+		return wrapDBError("find duplicate usernames", err)
+	}
+
+	type rename struct {
+		id       int64
+		username string
+	}
+	var renames []rename
+	for rows.Next() {
+		var r rename
+		if err := rows.Scan(&r.id, &r.username); err != nil {
+			rows.Close()
+			return wrapDBError("scan duplicate username", err)
+		}
+		renames = append(renames, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate duplicate usernames: %w", err)
+	}
+
+	for _, r := range renames {
+		newName := fmt.Sprintf("%s%d", r.username, r.id)
+		if _, err := tx.Exec(`UPDATE users SET username = ? WHERE id = ?`, newName, r.id); err != nil {
+			return wrapDBError("rename duplicate username", err)
+		}
+		log.Printf("Renamed user %d from %q to %q (case-insensitive duplicate)", r.id, r.username, newName)
+	}
+
+	if _, err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_nocase ON users(` + tx.foldCase("username") + `)`); err != nil {
+		return wrapDBError("create username index", err)
+	}
+	return nil
+}
+
+// migrateGamePlayersCascade rebuilds game_players on databases created before
+// its foreign keys cascaded. SQLite can't alter a constraint in place, so the
+// table is copied into a new one with the current definition. Seats whose
+// game or user no longer exists are dropped on the way.
+func migrateGamePlayersCascade(tx migrationTx) error {
+	rules, err := tx.deleteRules(tx.Tx, "game_players")
+	if err != nil {
+		return wrapDBError("read game_players foreign keys", err)
+	}
+	upToDate := true
+	for _, onDelete := range rules {
+		if onDelete != "CASCADE" {
+			upToDate = false
+		}
+	}
+	if upToDate {
+		return nil
+	}
+
+	const columns = `game_id, user_id, player_order, is_ready, is_current_turn, has_played_turn,
+		money, position, is_bankrupt, has_rolled, pending_action, in_jail, jail_turns`
+	steps := []struct{ what, stmt string }{
+		{"create game_players_new", `
+			CREATE TABLE game_players_new (
+				game_id INTEGER NOT NULL,
+				user_id INTEGER NOT NULL,
+				player_order INTEGER NOT NULL,
+				is_ready INTEGER DEFAULT 0,
+				is_current_turn INTEGER DEFAULT 0,
+				has_played_turn INTEGER DEFAULT 0,
+				money INTEGER DEFAULT 1500,
+				position INTEGER DEFAULT 0,
+				is_bankrupt INTEGER DEFAULT 0,
+				has_rolled INTEGER DEFAULT 0,
+				pending_action TEXT DEFAULT '',
+				in_jail INTEGER DEFAULT 0,
+				jail_turns INTEGER DEFAULT 0,
+				PRIMARY KEY (game_id, user_id),
+				FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`},
+		{"copy game_players", `
+			INSERT INTO game_players_new (` + columns + `)
+			SELECT ` + columns + ` FROM game_players
+			WHERE game_id IN (SELECT id FROM games) AND user_id IN (SELECT id FROM users)`},
+		{"drop old game_players", `DROP TABLE game_players`},
+		{"rename game_players_new", `ALTER TABLE game_players_new RENAME TO game_players`},
+		{"create game_players indexes", `
+			CREATE INDEX idx_game_players_game_id ON game_players(game_id);
+			CREATE INDEX idx_game_players_user_id ON game_players(user_id);
+			CREATE INDEX idx_game_players_current_turn ON game_players(game_id, is_current_turn)`},
+	}
+	var before, after int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM game_players`).Scan(&before); err != nil {
+		return wrapDBError("count game_players", err)
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step.stmt); err != nil {
+			return wrapDBError(step.what, err)
+		}
+	}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM game_players`).Scan(&after); err != nil {
+		return wrapDBError("count game_players", err)
+	}
+
+	log.Printf("Rebuilt game_players with cascading foreign keys (dropped %d orphaned seats)", before-after)
+	return nil
+}
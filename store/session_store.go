@@ -0,0 +1,122 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SessionStore keeps login sessions for auth.SessionManager
+type SessionStore interface {
+	CreateSession(sessionID string, userID int64, createdAt, expiresAt time.Time) error
+	GetSession(sessionID string) (*Session, error)
+	TouchSession(sessionID string, expiresAt, lastSeenAt time.Time) error
+	DeleteSession(sessionID string) error
+	ListSessions(userID int64, now time.Time) ([]*Session, error)
+	DeleteUserSessions(userID int64) (int, error)
+	DeleteExpiredSessions(now time.Time) (int, error)
+}
+
+type Session struct {
+	ID         string
+	UserID     int64
+	IsGuest    bool // the user's, so a guest's session keeps the guest TTL
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastSeenAt sql.NullTime
+}
+
+type SQLiteSessionStore struct {
+	db *sql.DB
+}
+
+func NewSessionStore(db *sql.DB) *SQLiteSessionStore {
+	return &SQLiteSessionStore{db: db}
+}
+
+// CreateSession stores a new session, last seen when it was created
+func (s *SQLiteSessionStore) CreateSession(sessionID string, userID int64, createdAt, expiresAt time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (session_id, user_id, created_at, expires_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, sessionID, userID, createdAt, expiresAt, createdAt)
+	return wrapDBError("create session", err)
+}
+
+// GetSession returns the session, expired or not, or nil if there is none
+func (s *SQLiteSessionStore) GetSession(sessionID string) (*Session, error) {
+	session := &Session{ID: sessionID}
+	err := s.db.QueryRow(`
+		SELECT s.user_id, s.created_at, s.expires_at, s.last_seen_at, u.is_guest
+		FROM sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.session_id = ?
+	`, sessionID).Scan(&session.UserID, &session.CreatedAt, &session.ExpiresAt, &session.LastSeenAt, &session.IsGuest)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapDBError("get session", err)
+	}
+	return session, nil
+}
+
+// TouchSession records that the session was used and moves its expiry
+func (s *SQLiteSessionStore) TouchSession(sessionID string, expiresAt, lastSeenAt time.Time) error {
+	_, err := s.db.Exec(`
+		UPDATE sessions SET expires_at = ?, last_seen_at = ? WHERE session_id = ?
+	`, expiresAt, lastSeenAt, sessionID)
+	return wrapDBError("touch session", err)
+}
+
+func (s *SQLiteSessionStore) DeleteSession(sessionID string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE session_id = ?`, sessionID)
+	return wrapDBError("delete session", err)
+}
+
+// ListSessions returns the user's sessions unexpired at now, most recently
+// used first
+func (s *SQLiteSessionStore) ListSessions(userID int64, now time.Time) ([]*Session, error) {
+	rows, err := s.db.Query(`
+		SELECT session_id, created_at, expires_at, last_seen_at
+		FROM sessions
+		WHERE user_id = ? AND expires_at >= ?
+		ORDER BY COALESCE(last_seen_at, created_at) DESC
+	`, userID, now)
+	if err != nil {
+		return nil, wrapDBError("list sessions", err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		session := &Session{UserID: userID}
+		if err := rows.Scan(&session.ID, &session.CreatedAt, &session.ExpiresAt, &session.LastSeenAt); err != nil {
+			return nil, wrapDBError("scan session", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteUserSessions ends every session of the user and returns how many
+// there were
+func (s *SQLiteSessionStore) DeleteUserSessions(userID int64) (int, error) {
+	return s.deleteSessions("delete user sessions", `DELETE FROM sessions WHERE user_id = ?`, userID)
+}
+
+// DeleteExpiredSessions removes the sessions expired before now and returns
+// how many there were
+func (s *SQLiteSessionStore) DeleteExpiredSessions(now time.Time) (int, error) {
+	return s.deleteSessions("delete expired sessions", `DELETE FROM sessions WHERE expires_at < ?`, now)
+}
+
+func (s *SQLiteSessionStore) deleteSessions(action, query string, arg interface{}) (int, error) {
+	result, err := s.db.Exec(query, arg)
+	if err != nil {
+		return 0, wrapDBError(action, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, wrapDBError(action, err)
+	}
+	return int(n), nil
+}
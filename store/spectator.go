@@ -0,0 +1,51 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CreateSpectatorToken stores a read-only viewing token for the game
+func (s *SQLiteGameStore) CreateSpectatorToken(gameID, createdBy int64, token string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO spectator_tokens (token, game_id, created_by, created_at) VALUES (?, ?, ?, ?)`,
+		token, gameID, createdBy, time.Now().Unix(),
+	)
+	if err != nil {
+		return wrapDBError("create spectator token", err)
+	}
+	return nil
+}
+
+// GetSpectatorTokenGame returns the game a token lets you watch, or 0 if the
+// token was never issued, was revoked, or its game has finished
+func (s *SQLiteGameStore) GetSpectatorTokenGame(token string) (int64, error) {
+	var gameID int64
+	err := s.db.QueryRow(`
+		SELECT t.game_id
+		FROM spectator_tokens t
+		JOIN games g ON g.id = t.game_id
+		WHERE t.token = ? AND g.status != 'finished'
+	`, token).Scan(&gameID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, wrapDBError("get spectator token", err)
+	}
+	return gameID, nil
+}
+
+// RevokeSpectatorToken deletes one of the game's tokens and reports whether
+// it existed
+func (s *SQLiteGameStore) RevokeSpectatorToken(gameID int64, token string) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM spectator_tokens WHERE token = ? AND game_id = ?`, token, gameID)
+	if err != nil {
+		return false, wrapDBError("revoke spectator token", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, wrapDBError("revoke spectator token", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import "testing"
+
+func TestSpectatorTokens_ExpireWithTheGame(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	gameID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	for _, token := range []string{"live", "revoked"} {
+		if err := games.CreateSpectatorToken(gameID, userID, token); err != nil {
+			t.Fatalf("CreateSpectatorToken failed: %v", err)
+		}
+	}
+
+	if got, err := games.GetSpectatorTokenGame("live"); err != nil || got != gameID {
+		t.Fatalf("Expected token to admit to game %d, got %d (%v)", gameID, got, err)
+	}
+	if got, _ := games.GetSpectatorTokenGame("never-issued"); got != 0 {
+		t.Errorf("Expected an unknown token to admit nowhere, got game %d", got)
+	}
+
+	if revoked, err := games.RevokeSpectatorToken(gameID+1, "revoked"); err != nil || revoked {
+		t.Errorf("Expected revoking through another game to do nothing, got %v (%v)", revoked, err)
+	}
+	if revoked, err := games.RevokeSpectatorToken(gameID, "revoked"); err != nil || !revoked {
+		t.Fatalf("Expected the token to be revoked, got %v (%v)", revoked, err)
+	}
+	if got, _ := games.GetSpectatorTokenGame("revoked"); got != 0 {
+		t.Errorf("Expected a revoked token to admit nowhere, got game %d", got)
+	}
+
+	if _, err := lobby.db.Exec(`UPDATE games SET status = 'finished' WHERE id = ?`, gameID); err != nil {
+		t.Fatalf("Finish game failed: %v", err)
+	}
+	if got, _ := games.GetSpectatorTokenGame("live"); got != 0 {
+		t.Errorf("Expected the token to expire with its game, got game %d", got)
+	}
+
+	if _, err := lobby.db.Exec(`DELETE FROM games WHERE id = ?`, gameID); err != nil {
+		t.Fatalf("Delete game failed: %v", err)
+	}
+	var left int
+	if err := lobby.db.QueryRow(`SELECT COUNT(*) FROM spectator_tokens`).Scan(&left); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if left != 0 {
+		t.Errorf("Expected deleting the game to drop its tokens, %d remain", left)
+	}
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -15,6 +17,11 @@ func wrapDBError(action string, err error) error {
 	return fmt.Errorf("failed to %s: %w", action, err)
 }
 
+// isUniqueViolation reports whether err is a UNIQUE or PRIMARY KEY constraint failure
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
 // boolToInt converts a boolean to SQLite integer (0 or 1)
 func boolToInt(b bool) int {
 	if b {
@@ -28,9 +35,15 @@ func intToBool(i int) bool {
 	return i == 1
 }
 
-// InitDB initializes the database connection with proper configuration
-func InitDB(dbPath string, maxOpenConnections, maxIdleConnections int) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", dbPath)
+// Open connects to the database with the named driver, one of Drivers, and
+// brings its schema up to date. busyTimeout is how long a SQLite write waits
+// for another connection's lock before failing with "database is locked".
+func Open(driver, dsn string, maxOpenConnections, maxIdleConnections int, busyTimeout time.Duration) (*sql.DB, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+	db, err := d.open(dsn, busyTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -41,13 +54,14 @@ func InitDB(dbPath string, maxOpenConnections, maxIdleConnections int) (*sql.DB,
 	db.SetMaxOpenConns(maxOpenConnections)
 	db.SetMaxIdleConns(maxIdleConnections)
 
-	if _, err := db.Exec("PRAGMA foreign_keys = ON;"); err != nil {
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
-	}
-
-	if _, err := db.Exec(schema); err != nil {
+	if err := migrate(db, d); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
 	return db, nil
 }
+
+// InitDB opens the SQLite database at dbPath, see Open
+func InitDB(dbPath string, maxOpenConnections, maxIdleConnections int, busyTimeout time.Duration) (*sql.DB, error) {
+	return Open("sqlite", dbPath, maxOpenConnections, maxIdleConnections, busyTimeout)
+}
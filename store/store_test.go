@@ -0,0 +1,477 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestInitDB_PragmasApplyToEveryConnection(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	ctx := context.Background()
+
+	// Hold several connections at once so the pool can't hand back the same one
+	for i := 0; i < 4; i++ {
+		conn, err := lobby.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn failed: %v", err)
+		}
+		defer conn.Close()
+
+		var journalMode string
+		var foreignKeys, busyTimeout int
+		if err := conn.QueryRowContext(ctx, `PRAGMA journal_mode`).Scan(&journalMode); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&busyTimeout); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if journalMode != "wal" || foreignKeys != 1 || busyTimeout != 5000 {
+			t.Errorf("Connection %d: expected wal, foreign keys on, 5000ms timeout; got %s, %d, %d",
+				i, journalMode, foreignKeys, busyTimeout)
+		}
+	}
+}
+
+func TestInitDB_ConcurrentGameWritesDontLock(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	const rooms, turns = 8, 25
+	type seat struct{ gameID, userID int64 }
+	seats := make([]seat, rooms)
+	for i := range seats {
+		userID, err := auth.CreateUser(fmt.Sprintf("player%d", i), "hash")
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		gameID, err := lobby.CreateGame(2, GameRules{})
+		if err != nil {
+			t.Fatalf("CreateGame failed: %v", err)
+		}
+		if _, err := lobby.JoinGame(gameID, userID, "", nil); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+		seats[i] = seat{gameID, userID}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, rooms*turns)
+	for _, s := range seats {
+		wg.Add(1)
+		go func(s seat) {
+			defer wg.Done()
+			for turn := 1; turn <= turns; turn++ {
+				tx, err := games.BeginTx()
+				if err != nil {
+					errs <- err
+					return
+				}
+				if err := games.UpdatePlayerMoneyTx(tx, s.gameID, s.userID, 1500+turn); err != nil {
+					games.RollbackTx(tx)
+					errs <- err
+					return
+				}
+				if err := games.CommitTx(tx); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Concurrent write failed: %v", err)
+	}
+	for _, s := range seats {
+		var money int
+		if err := lobby.db.QueryRow(`SELECT money FROM game_players WHERE game_id = ? AND user_id = ?`, s.gameID, s.userID).Scan(&money); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if money != 1500+turns {
+			t.Errorf("Game %d: expected money %d, got %d", s.gameID, 1500+turns, money)
+		}
+	}
+}
+
+func TestDeleteGame_CascadesToPlayers(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	gameID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	if _, err := lobby.JoinGame(gameID, userID, "", nil); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+
+	if _, err := lobby.db.Exec(`DELETE FROM games WHERE id = ?`, gameID); err != nil {
+		t.Fatalf("Delete game failed: %v", err)
+	}
+
+	var seats int
+	if err := lobby.db.QueryRow(`SELECT COUNT(*) FROM game_players WHERE game_id = ?`, gameID).Scan(&seats); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if seats != 0 {
+		t.Errorf("Expected deleting the game to remove its players, %d remain", seats)
+	}
+}
+
+func TestDeleteUser_KeepsFinishedGamesUnderAnAnonymisedUser(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	finishedID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	waitingID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	for _, gameID := range []int64{finishedID, waitingID} {
+		if _, err := lobby.JoinGame(gameID, userID, "", nil); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+		if _, err := lobby.db.Exec(`INSERT INTO game_properties (game_id, position, owner_id) VALUES (?, 1, ?)`, gameID, userID); err != nil {
+			t.Fatalf("Insert property failed: %v", err)
+		}
+		// Finished before joining the next, since a user plays one game at a time
+		if gameID == finishedID {
+			if _, err := lobby.db.Exec(`UPDATE games SET status = 'finished' WHERE id = ?`, finishedID); err != nil {
+				t.Fatalf("Finish game failed: %v", err)
+			}
+		}
+	}
+
+	if err := auth.DeleteUser(userID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+
+	for _, c := range []struct {
+		gameID int64
+		want   int
+	}{{finishedID, 1}, {waitingID, 0}} {
+		var seats, properties int
+		if err := lobby.db.QueryRow(`SELECT COUNT(*) FROM game_players WHERE game_id = ? AND user_id = ?`, c.gameID, userID).Scan(&seats); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if err := lobby.db.QueryRow(`SELECT COUNT(*) FROM game_properties WHERE game_id = ? AND owner_id = ?`, c.gameID, userID).Scan(&properties); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if seats != c.want || properties != c.want {
+			t.Errorf("Game %d: expected %d seat and property left, got %d and %d", c.gameID, c.want, seats, properties)
+		}
+	}
+
+	user, err := auth.GetUserByID(userID)
+	if err != nil || user == nil {
+		t.Fatalf("Expected the user kept for the finished game, got %v, %v", user, err)
+	}
+	if user.Username == "alice" || user.PasswordHash != "" {
+		t.Errorf("Expected the user anonymised, got %+v", user)
+	}
+	if existing, _ := auth.GetUserByUsername("alice"); existing != nil {
+		t.Errorf("Expected the username freed, found user %d", existing.ID)
+	}
+	if found, _ := auth.SearchUsers("deleted", 0, 10); len(found) != 0 {
+		t.Errorf("Expected deleted users hidden from search, got %d", len(found))
+	}
+	if err := auth.DeleteUser(userID); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows deleting the user again, got %v", err)
+	}
+}
+
+func TestUpdateCurrentTurn_TimesTheTurnHandedOver(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	aliceID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	bobID, err := auth.CreateUser("bob", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	gameID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	for _, userID := range []int64{aliceID, bobID} {
+		if _, err := lobby.JoinGame(gameID, userID, "", nil); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+	}
+
+	// The first turn only starts the clock
+	if err := games.UpdateCurrentTurn(gameID, aliceID); err != nil {
+		t.Fatalf("UpdateCurrentTurn failed: %v", err)
+	}
+	if _, err := lobby.db.Exec(`UPDATE games SET turn_started_at = turn_started_at - 40 WHERE id = ?`, gameID); err != nil {
+		t.Fatalf("Backdate failed: %v", err)
+	}
+	if err := games.UpdateCurrentTurn(gameID, bobID); err != nil {
+		t.Fatalf("UpdateCurrentTurn failed: %v", err)
+	}
+
+	players, err := games.GetGamePlayers(gameID)
+	if err != nil {
+		t.Fatalf("GetGamePlayers failed: %v", err)
+	}
+	alice, bob := players[0], players[1]
+	if alice.TurnsTaken != 1 || alice.TurnSeconds < 40 || alice.TurnSeconds > 42 {
+		t.Errorf("Expected alice to have one turn of about 40s, got %d turns, %ds", alice.TurnsTaken, alice.TurnSeconds)
+	}
+	if bob.TurnsTaken != 0 || bob.TurnSeconds != 0 {
+		t.Errorf("Expected bob's running turn not to count yet, got %d turns, %ds", bob.TurnsTaken, bob.TurnSeconds)
+	}
+}
+
+func TestGetGamePlayers_DisplayNameFallsBackToUsername(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	gameID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		userID, err := auth.CreateUser(name, "hash")
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		if _, err := lobby.JoinGame(gameID, userID, "", nil); err != nil {
+			t.Fatalf("JoinGame failed: %v", err)
+		}
+		if name == "alice" {
+			if err := auth.SetDisplayName(userID, "Alice in Chains"); err != nil {
+				t.Fatalf("SetDisplayName failed: %v", err)
+			}
+		}
+	}
+	if err := auth.SetDisplayName(9999, "Ghost"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for a missing user, got %v", err)
+	}
+
+	players, err := games.GetGamePlayers(gameID)
+	if err != nil {
+		t.Fatalf("GetGamePlayers failed: %v", err)
+	}
+	if players[0].Username != "alice" || players[0].DisplayName != "Alice in Chains" {
+		t.Errorf("Expected alice shown by her display name, got %+v", players[0])
+	}
+	if players[1].DisplayName != "bob" {
+		t.Errorf("Expected bob shown by username, got %q", players[1].DisplayName)
+	}
+}
+
+func TestMigrateGamePlayersCascade_RebuildsLegacyTable(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	ctx := context.Background()
+
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	gameID, err := lobby.CreateGame(4, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+
+	// Recreate game_players as older databases had it, with one live seat and
+	// one orphaned by a game deleted while foreign keys were off
+	conn, err := lobby.db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	for _, stmt := range []string{
+		`PRAGMA foreign_keys = OFF`,
+		`DROP TABLE game_players`,
+		`CREATE TABLE game_players (
+			game_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			player_order INTEGER NOT NULL,
+			is_ready INTEGER DEFAULT 0,
+			is_current_turn INTEGER DEFAULT 0,
+			has_played_turn INTEGER DEFAULT 0,
+			money INTEGER DEFAULT 1500,
+			position INTEGER DEFAULT 0,
+			is_bankrupt INTEGER DEFAULT 0,
+			has_rolled INTEGER DEFAULT 0,
+			pending_action TEXT DEFAULT '',
+			in_jail INTEGER DEFAULT 0,
+			jail_turns INTEGER DEFAULT 0,
+			PRIMARY KEY (game_id, user_id),
+			FOREIGN KEY (game_id) REFERENCES games(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		fmt.Sprintf(`INSERT INTO game_players (game_id, user_id, player_order, money) VALUES (%d, %d, 1, 1234)`, gameID, userID),
+		fmt.Sprintf(`INSERT INTO game_players (game_id, user_id, player_order) VALUES (%d, %d, 1)`, gameID+100, userID),
+		`PRAGMA foreign_keys = ON`,
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Legacy setup %q failed: %v", stmt, err)
+		}
+	}
+	conn.Close()
+
+	// Replay the cascade step as a database from before it would
+	if _, err := lobby.db.Exec(`DELETE FROM schema_migrations WHERE version >= 4`); err != nil {
+		t.Fatalf("Reset schema version failed: %v", err)
+	}
+	if err := migrate(lobby.db, sqliteDialect{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	var seats, money int
+	if err := lobby.db.QueryRow(`SELECT COUNT(*), MAX(money) FROM game_players`).Scan(&seats, &money); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if seats != 1 || money != 1234 {
+		t.Errorf("Expected only the live seat to survive intact, got %d seats with money %d", seats, money)
+	}
+
+	var indexes int
+	if err := lobby.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'game_players' AND name LIKE 'idx_%'`).Scan(&indexes); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if indexes != 3 {
+		t.Errorf("Expected 3 game_players indexes after the rebuild, got %d", indexes)
+	}
+
+	if _, err := lobby.db.Exec(`DELETE FROM games WHERE id = ?`, gameID); err != nil {
+		t.Fatalf("Delete game failed: %v", err)
+	}
+	if err := lobby.db.QueryRow(`SELECT COUNT(*) FROM game_players`).Scan(&seats); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if seats != 0 {
+		t.Errorf("Expected the rebuilt table to cascade, %d seats remain", seats)
+	}
+}
+
+func TestMigrate_VersionsAndReplaysLegacyDatabases(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+
+	latest := migrations[len(migrations)-1].version
+	version, err := SchemaVersion(lobby.db)
+	if err != nil {
+		t.Fatalf("SchemaVersion failed: %v", err)
+	}
+	if version != latest {
+		t.Fatalf("Expected a fresh database at version %d, got %d", latest, version)
+	}
+
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	// A database from before versioning has no schema_migrations table and
+	// replays every step, which must leave its data alone
+	if _, err := lobby.db.Exec(`DROP TABLE schema_migrations`); err != nil {
+		t.Fatalf("Drop schema_migrations failed: %v", err)
+	}
+	if err := migrate(lobby.db, sqliteDialect{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if _, err := auth.GetUserByID(userID); err != nil {
+		t.Errorf("Expected user to survive the replay: %v", err)
+	}
+
+	// Up to date: nothing runs and the version is unchanged
+	if err := migrate(lobby.db, sqliteDialect{}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	var applied int
+	if err := lobby.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("Expected %d recorded migrations, got %d", len(migrations), applied)
+	}
+}
+
+func TestInitDB_ConcurrentReadThenWriteTransactionsSerialize(t *testing.T) {
+	lobby := newTestLobbyStore(t)
+	auth := NewAuthStore(lobby.db)
+	games := NewGameStore(lobby.db)
+
+	userID, err := auth.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	gameID, err := lobby.CreateGame(2, GameRules{})
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	if _, err := lobby.JoinGame(gameID, userID, "", nil); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+
+	// Each writer checks the balance then spends from it, like a build does;
+	// none may fail to upgrade its lock or act on a stale read
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := games.BeginTx()
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer games.RollbackTx(tx)
+			player, err := games.GetPlayerTx(tx, gameID, userID)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err := games.UpdatePlayerMoneyTx(tx, gameID, userID, player.Money-50); err != nil {
+				errs <- err
+				return
+			}
+			if err := games.CommitTx(tx); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Concurrent transaction failed: %v", err)
+	}
+	var money int
+	if err := lobby.db.QueryRow(`SELECT money FROM game_players WHERE game_id = ? AND user_id = ?`, gameID, userID).Scan(&money); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if money != 1500-writers*50 {
+		t.Errorf("Expected every spend to apply, got money %d", money)
+	}
+}
@@ -0,0 +1,90 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+)
+
+// BackplaneMessage is a room broadcast on its way between server instances
+type BackplaneMessage struct {
+	Origin string          `json:"origin"` // instance that published it, see Manager.instanceID
+	GameID int64           `json:"gameId"`
+	Data   json.RawMessage `json:"data"` // the OutgoingMessage as sent to clients, seq included
+}
+
+// Backplane carries room broadcasts between server instances, so a player
+// connected to one instance sees what happens in their game on another.
+// A shared channel (Redis pub/sub, Postgres LISTEN/NOTIFY) implements it by
+// publishing each message to every instance, including the one it came from;
+// the Manager drops its own. Only broadcasts cross it: turn timers,
+// countdowns, presence, CloseAllWithCode and ws tickets stay per instance.
+type Backplane interface {
+	// Publish hands a broadcast to every instance. It's called in broadcast
+	// order for each room, so it must not reorder a room's messages.
+	Publish(msg BackplaneMessage) error
+	// Subscribe registers the function that delivers broadcasts published by
+	// any instance. The Manager calls it once, when it's created.
+	Subscribe(deliver func(msg BackplaneMessage))
+}
+
+// publishQueueSize is how many broadcasts may wait for a slow backplane before
+// further ones are dropped
+const publishQueueSize = 1024
+
+// localBackplane is the default for a single instance: there is no one to
+// tell, so broadcasts stay in the process
+type localBackplane struct{}
+
+func (localBackplane) Publish(BackplaneMessage) error   { return nil }
+func (localBackplane) Subscribe(func(BackplaneMessage)) {}
+
+// newInstanceID names this process on the backplane
+func newInstanceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Failed to generate instance ID: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// publish shares a room broadcast with the other instances. Rooms call it
+// while delivering, so it only queues the message: a backplane that is slow
+// or down must not hold up play. The queue is drained in order, keeping each
+// room's broadcasts in seq order; when it's full the broadcast is dropped and
+// other instances' clients catch up from the event log when they reconnect.
+func (m *Manager) publish(gameID int64, data []byte) {
+	if m.publishQueue == nil {
+		return // local backplane: there is no one to tell
+	}
+	select {
+	case m.publishQueue <- BackplaneMessage{Origin: m.instanceID, GameID: gameID, Data: data}:
+	default:
+		log.Printf("Backplane queue full, dropped a broadcast for game %d", gameID)
+	}
+}
+
+// publishQueued hands queued broadcasts to the backplane, one at a time
+func (m *Manager) publishQueued() {
+	for msg := range m.publishQueue {
+		if err := m.backplane.Publish(msg); err != nil {
+			log.Printf("Failed to publish broadcast for game %d: %v", msg.GameID, err)
+		}
+	}
+}
+
+// relay delivers a broadcast published by another instance to the game's
+// local clients. It was recorded where it was made, so it isn't recorded
+// again, and with no local room there's no one here to tell.
+func (m *Manager) relay(msg BackplaneMessage) {
+	if msg.Origin == m.instanceID {
+		return
+	}
+	m.mu.RLock()
+	room, exists := m.rooms[msg.GameID]
+	m.mu.RUnlock()
+	if exists {
+		room.relay(msg.Data)
+	}
+}
@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// RoomConnections lists who is connected to one game room
+type RoomConnections struct {
+	GameID     int64     `json:"gameId"`
+	UserIDs    []int64   `json:"userIds"` // players with a live game socket, ascending
+	Players    int       `json:"players"`
+	Spectators int       `json:"spectators"`
+	LastActive time.Time `json:"lastActive"` // last connection or broadcast
+}
+
+// Connections is a point-in-time copy of every socket the server holds, for
+// debugging reports like "I'm connected but see no updates"
+type Connections struct {
+	Rooms        []RoomConnections `json:"rooms"` // by game ID
+	LobbyUserIDs []int64           `json:"lobbyUserIds"`
+	LobbyClients int               `json:"lobbyClients"`
+}
+
+// Connections copies who is connected where. Like Stats it copies the rooms
+// under the read lock and reads each after releasing it; every room and the
+// lobby is copied under its own lock, so the result shares nothing with them.
+func (m *Manager) Connections() Connections {
+	m.mu.RLock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.RUnlock()
+
+	conns := Connections{Rooms: make([]RoomConnections, 0, len(rooms))}
+	for _, room := range rooms {
+		conns.Rooms = append(conns.Rooms, room.connections())
+	}
+	slices.SortFunc(conns.Rooms, func(a, b RoomConnections) int {
+		return cmp.Compare(a.GameID, b.GameID)
+	})
+
+	conns.LobbyUserIDs = []int64{}
+	if m.lobbyManager != nil {
+		conns.LobbyUserIDs = m.lobbyManager.connectedUsers()
+	}
+	conns.LobbyClients = len(conns.LobbyUserIDs)
+	return conns
+}
+
+// connections copies the room's connected players and spectator count
+func (r *Room) connections() RoomConnections {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	conns := RoomConnections{
+		GameID:     r.gameID,
+		UserIDs:    make([]int64, 0, len(r.clients)),
+		Players:    len(r.clients),
+		Spectators: len(r.spectators),
+		LastActive: time.Unix(0, r.lastActive.Load()),
+	}
+	for userID := range r.clients {
+		conns.UserIDs = append(conns.UserIDs, userID)
+	}
+	slices.Sort(conns.UserIDs)
+	return conns
+}
+
+// connectedUsers returns the users with a lobby socket, ascending
+func (lm *LobbyManager) connectedUsers() []int64 {
+	lm.mu.RLock()
+	userIDs := make([]int64, 0, len(lm.clients))
+	for userID := range lm.clients {
+		userIDs = append(userIDs, userID)
+	}
+	lm.mu.RUnlock()
+
+	slices.Sort(userIDs)
+	return userIDs
+}
@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// latencyReportEvery sends the latency at least every this many pongs
+	latencyReportEvery = 5
+	// latencyReportDelta sends it sooner if it moved by at least this much
+	latencyReportDelta = 50 * time.Millisecond
+)
+
+// PongLatencyPayload is the measured ping/pong round trip for the receiving client
+type PongLatencyPayload struct {
+	Millis int64 `json:"millis"`
+}
+
+// markPing records when a ping was written. Called from the write pump.
+func (c *Client) markPing(now time.Time) {
+	c.pingSentAt.Store(now.UnixNano())
+}
+
+// recordPong measures the round trip of the last ping and reports whether it
+// should be sent to the client. Called from the read pump's pong handler, so
+// the reporting state needs no locking.
+func (c *Client) recordPong(now time.Time) (time.Duration, bool) {
+	sent := c.pingSentAt.Load()
+	if sent == 0 {
+		return 0, false
+	}
+	latency := now.Sub(time.Unix(0, sent))
+	c.latency.Store(int64(latency))
+
+	c.pongsSinceReport++
+	diff := latency - c.reportedLatency
+	if diff < 0 {
+		diff = -diff
+	}
+	if c.pongsSinceReport < latencyReportEvery && c.reportedLatency != 0 && diff < latencyReportDelta {
+		return latency, false
+	}
+
+	c.pongsSinceReport = 0
+	c.reportedLatency = latency
+	return latency, true
+}
+
+// Latency is the client's most recent ping/pong round trip, 0 if not measured yet
+func (c *Client) Latency() time.Duration {
+	return time.Duration(c.latency.Load())
+}
+
+// sendLatency queues a pong_latency message, dropping it if the client is backed up
+func (m *Manager) sendLatency(client *Client, latency time.Duration) {
+	m.sendToClient(client, OutgoingMessage{
+		Type:    "pong_latency",
+		Payload: PongLatencyPayload{Millis: latency.Milliseconds()},
+	})
+}
+
+// logTimeoutLatency logs the connection latency of a player whose turn timed
+// out, to tell laggy connections apart from idle players.
+func (m *Manager) logTimeoutLatency(room *Room, userID int64) {
+	room.mu.RLock()
+	client, connected := room.clients[userID]
+	room.mu.RUnlock()
+
+	if !connected {
+		log.Printf("Turn timed out for user %d in game %d (not connected)", userID, room.gameID)
+		return
+	}
+	log.Printf("Turn timed out for user %d in game %d (last latency %v)", userID, room.gameID, client.Latency())
+}
@@ -3,16 +3,21 @@ package ws
 import (
 	"encoding/json"
 	"log"
+	"monopoly/game"
 	"monopoly/store"
 )
 
 // Lobby event types
 const (
-	EventGameCreated      = "game_created"
-	EventGameDeleted      = "game_deleted"
-	EventPlayerJoined     = "player_joined"
-	EventPlayerLeft       = "player_left"
-	EventGameStatusChange = "game_status_changed"
+	EventGameCreated        = "game_created"
+	EventGameDeleted        = "game_deleted"
+	EventPlayerJoined       = "player_joined"
+	EventPlayerLeft         = "player_left"
+	EventGameStatusChange   = "game_status_changed"
+	EventPlayerReady        = "player_ready"
+	EventStartCountdown     = "start_countdown"
+	EventCountdownCancelled = "countdown_cancelled"
+	EventWaitingForPlayers  = "waiting_for_players"
 )
 
 // GameCreatedPayload contains data for a newly created game
@@ -45,14 +50,17 @@ type GameStatusChangePayload struct {
 	Status string `json:"status"`
 }
 
-// BroadcastGameCreated sends a game_created event to all connected lobby clients
+// PlayerReadyPayload contains a player's ready state in a waiting game
+type PlayerReadyPayload struct {
+	GameID  int64 `json:"gameId"`
+	UserID  int64 `json:"userId"`
+	IsReady bool  `json:"isReady"`
+}
+
+// BroadcastGameCreated sends a game_created event to the lobby clients
+// that follow the whole list; no one can be watching a game that didn't exist
 func (lm *LobbyManager) BroadcastGameCreated(gameID int64) {
-	lm.mu.RLock()
-	clients := make([]*LobbyClient, 0, len(lm.clients))
-	for _, client := range lm.clients {
-		clients = append(clients, client)
-	}
-	lm.mu.RUnlock()
+	clients, _ := lm.audience(gameID)
 
 	for _, client := range clients {
 		// Get game with personalized isJoined flag for this client
@@ -71,24 +79,28 @@ func (lm *LobbyManager) BroadcastGameCreated(gameID int64) {
 	}
 }
 
-// BroadcastGameDeleted sends a game_deleted event to all connected lobby clients
+// BroadcastGameDeleted sends a game_deleted event to all lobby clients that
+// follow the list or watched the game, which they stop watching
 func (lm *LobbyManager) BroadcastGameDeleted(gameID int64) {
 	payload := GameDeletedPayload{GameID: gameID}
-	lm.broadcastToAll(EventGameDeleted, payload)
+	listeners, watchers := lm.audience(gameID)
+	for _, client := range watchers {
+		lm.unsubscribe(client, []int64{gameID})
+	}
+	for _, client := range append(listeners, watchers...) {
+		lm.sendToClient(client, EventGameDeleted, payload)
+	}
 }
 
-// BroadcastPlayerJoined sends a player_joined event to all connected lobby clients
-func (lm *LobbyManager) BroadcastPlayerJoined(gameID, userID int64, username string) {
-	lm.mu.RLock()
-	clients := make([]*LobbyClient, 0, len(lm.clients))
-	for _, client := range lm.clients {
-		clients = append(clients, client)
-	}
-	lm.mu.RUnlock()
+// BroadcastPlayerJoined sends a player_joined event to the lobby clients that
+// follow the list, and the game's summary to those watching it
+func (lm *LobbyManager) BroadcastPlayerJoined(gameID, userID int64, username, token string) {
+	clients, watchers := lm.audience(gameID)
 
 	player := store.LobbyPlayerDTO{
 		UserID:   userID,
 		Username: username,
+		Token:    token,
 	}
 
 	for _, client := range clients {
@@ -99,16 +111,15 @@ func (lm *LobbyManager) BroadcastPlayerJoined(gameID, userID int64, username str
 		}
 		lm.sendToClient(client, EventPlayerJoined, payload)
 	}
+	for _, client := range watchers {
+		lm.sendSummary(client, gameID)
+	}
 }
 
-// BroadcastPlayerLeft sends a player_left event to all connected lobby clients
+// BroadcastPlayerLeft sends a player_left event to the lobby clients that
+// follow the list, and the game's summary to those watching it
 func (lm *LobbyManager) BroadcastPlayerLeft(gameID, userID int64) {
-	lm.mu.RLock()
-	clients := make([]*LobbyClient, 0, len(lm.clients))
-	for _, client := range lm.clients {
-		clients = append(clients, client)
-	}
-	lm.mu.RUnlock()
+	clients, watchers := lm.audience(gameID)
 
 	for _, client := range clients {
 		payload := PlayerLeftPayload{
@@ -118,15 +129,62 @@ func (lm *LobbyManager) BroadcastPlayerLeft(gameID, userID int64) {
 		}
 		lm.sendToClient(client, EventPlayerLeft, payload)
 	}
+	for _, client := range watchers {
+		lm.sendSummary(client, gameID)
+	}
 }
 
-// BroadcastGameStatusChange sends a game_status_changed event to all connected lobby clients
+// BroadcastGameStatusChange sends a game_status_changed event to the lobby, see broadcastGameChange
 func (lm *LobbyManager) BroadcastGameStatusChange(gameID int64, status string) {
 	payload := GameStatusChangePayload{
 		GameID: gameID,
 		Status: status,
 	}
-	lm.broadcastToAll(EventGameStatusChange, payload)
+	lm.broadcastGameChange(gameID, EventGameStatusChange, payload)
+}
+
+// BroadcastPlayerReady sends a player_ready event to the lobby, see broadcastGameChange
+func (lm *LobbyManager) BroadcastPlayerReady(gameID, userID int64, isReady bool) {
+	payload := PlayerReadyPayload{
+		GameID:  gameID,
+		UserID:  userID,
+		IsReady: isReady,
+	}
+	lm.broadcastGameChange(gameID, EventPlayerReady, payload)
+}
+
+// BroadcastStartCountdown tells lobby clients a game starts in the given number of seconds
+func (lm *LobbyManager) BroadcastStartCountdown(gameID int64, seconds int) {
+	payload := game.StartCountdownPayload{
+		GameID:  gameID,
+		Seconds: seconds,
+	}
+	lm.broadcastGameNotice(gameID, EventStartCountdown, payload)
+}
+
+// BroadcastCountdownCancelled tells lobby clients a pending game start was called off
+func (lm *LobbyManager) BroadcastCountdownCancelled(gameID, userID int64) {
+	payload := game.CountdownCancelledPayload{
+		GameID: gameID,
+		UserID: userID,
+	}
+	lm.broadcastGameNotice(gameID, EventCountdownCancelled, payload)
+}
+
+// SendWaitingForPlayers tells one user their game can't start until more players join
+func (lm *LobbyManager) SendWaitingForPlayers(gameID, userID int64, needed int) {
+	lm.mu.RLock()
+	client, ok := lm.clients[userID]
+	lm.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	payload := game.WaitingForPlayersPayload{
+		GameID: gameID,
+		Needed: needed,
+	}
+	lm.sendToClient(client, EventWaitingForPlayers, payload)
 }
 
 // sendToClient sends a message to a specific client
@@ -142,10 +200,29 @@ func (lm *LobbyManager) sendToClient(client *LobbyClient, eventType string, payl
 		return
 	}
 
-	select {
-	case client.send <- data:
-	default:
-		log.Printf("Client %d send buffer full, skipping event", client.userID)
+	if !client.trySend(data) {
+		log.Printf("Client %d send buffer full or closed, skipping event", client.userID)
+	}
+}
+
+// broadcastGameChange sends an event that changes a game's summary to the
+// clients following the whole list, and the new summary to those watching it
+func (lm *LobbyManager) broadcastGameChange(gameID int64, eventType string, payload interface{}) {
+	listeners, watchers := lm.audience(gameID)
+	for _, client := range listeners {
+		lm.sendToClient(client, eventType, payload)
+	}
+	for _, client := range watchers {
+		lm.sendSummary(client, gameID)
+	}
+}
+
+// broadcastGameNotice sends an event about a game that its summary doesn't
+// show, like a start countdown, to everyone following the list or the game
+func (lm *LobbyManager) broadcastGameNotice(gameID int64, eventType string, payload interface{}) {
+	listeners, watchers := lm.audience(gameID)
+	for _, client := range append(listeners, watchers...) {
+		lm.sendToClient(client, eventType, payload)
 	}
 }
 
@@ -166,10 +243,8 @@ func (lm *LobbyManager) broadcastToAll(eventType string, payload interface{}) {
 	defer lm.mu.RUnlock()
 
 	for _, client := range lm.clients {
-		select {
-		case client.send <- data:
-		default:
-			log.Printf("Client %d send buffer full, skipping event", client.userID)
+		if !client.trySend(data) {
+			log.Printf("Client %d send buffer full or closed, skipping event", client.userID)
 		}
 	}
 }
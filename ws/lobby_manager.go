@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"monopoly/store"
@@ -11,7 +12,7 @@ import (
 
 // LobbyLister defines interface for getting game lists
 type LobbyLister interface {
-	ListGames(userID int64) ([]*store.LobbyGameDTO, error)
+	ListGames(userID int64, filter store.GameListFilter, limit, offset int) ([]*store.LobbyGameDTO, int, error)
 	GetGameWithPlayers(gameID, userID int64) (*store.LobbyGameDTO, error)
 }
 
@@ -20,13 +21,18 @@ type LobbyManager struct {
 	clients map[int64]*LobbyClient
 	lobby   LobbyLister
 	mu      sync.RWMutex
+	pumps   sync.WaitGroup // tracks running write pumps for Shutdown
 }
 
 // LobbyClient represents a connected client in the lobby
 type LobbyClient struct {
-	conn   *websocket.Conn
-	userID int64
-	send   chan []byte
+	*outbox // closed when the client leaves or is replaced, see outbox
+	conn    *websocket.Conn
+	userID  int64
+
+	// Games the client watches instead of the whole list, see handleMessage
+	subMu         sync.Mutex
+	subscriptions map[int64]bool
 }
 
 // NewLobbyManager creates a new lobby manager
@@ -40,19 +46,61 @@ func NewLobbyManager(lobby LobbyLister) *LobbyManager {
 // HandleConnection handles a new WebSocket connection to the lobby
 func (lm *LobbyManager) HandleConnection(conn *websocket.Conn, userID int64) {
 	client := &LobbyClient{
+		outbox: newOutbox(256),
 		conn:   conn,
 		userID: userID,
-		send:   make(chan []byte, 256),
 	}
 
+	// A second lobby tab replaces the first, whose pumps must still exit
 	lm.mu.Lock()
+	if old, ok := lm.clients[userID]; ok {
+		old.close(CloseReplaced, "replaced")
+	}
 	lm.clients[userID] = client
 	lm.mu.Unlock()
 
-	go client.writePump()
+	lm.pumps.Add(1)
+	go func() {
+		defer lm.pumps.Done()
+		client.writePump()
+	}()
 	client.readPump(lm)
 }
 
+// ClientCount returns how many lobby sockets are connected
+func (lm *LobbyManager) ClientCount() int {
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+	return len(lm.clients)
+}
+
+// Shutdown notifies all lobby clients that the server is going away, closes
+// them and waits for the write pumps to flush their close
+// frames. It returns ctx.Err() if the context expires first.
+func (lm *LobbyManager) Shutdown(ctx context.Context) error {
+	lm.broadcastToAll("server_shutdown", map[string]interface{}{})
+
+	lm.mu.Lock()
+	for userID, client := range lm.clients {
+		client.close(0, "")
+		delete(lm.clients, userID)
+	}
+	lm.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		lm.pumps.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // BroadcastUpdate sends personalized games list updates to all connected lobby clients
 func (lm *LobbyManager) BroadcastUpdate() {
 	lm.mu.RLock()
@@ -64,7 +112,16 @@ func (lm *LobbyManager) BroadcastUpdate() {
 
 	// Send personalized update to each client
 	for _, client := range clients {
-		games, err := lm.lobby.ListGames(client.userID)
+		// Watchers get their games' summaries instead of the list
+		if watched := client.subscribedGames(); len(watched) > 0 {
+			for _, gameID := range watched {
+				lm.sendSummary(client, gameID)
+			}
+			continue
+		}
+
+		// Full updates carry the first page; clients page further over HTTP
+		games, _, err := lm.lobby.ListGames(client.userID, store.GameListFilter{}, 0, 0)
 		if err != nil {
 			log.Printf("Failed to list games for user %d: %v", client.userID, err)
 			continue
@@ -81,11 +138,8 @@ func (lm *LobbyManager) BroadcastUpdate() {
 			continue
 		}
 
-		select {
-		case client.send <- data:
-		default:
-			// Client buffer full, skip
-			log.Printf("Client %d send buffer full, skipping update", client.userID)
+		if !client.trySend(data) {
+			log.Printf("Client %d send buffer full or closed, skipping update", client.userID)
 		}
 	}
 }
@@ -93,19 +147,20 @@ func (lm *LobbyManager) BroadcastUpdate() {
 // readPump handles incoming messages from the client
 func (c *LobbyClient) readPump(lm *LobbyManager) {
 	defer func() {
-		lm.removeClient(c.userID)
+		lm.removeClient(c)
 		c.conn.Close()
 	}()
 
+	c.conn.SetReadLimit(maxLobbyMessageSize)
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
 				log.Printf("Lobby WebSocket error: %v", err)
 			}
 			break
 		}
-		// Lobby doesn't handle incoming messages, just keeps connection alive
+		lm.handleMessage(c, data)
 	}
 }
 
@@ -115,26 +170,36 @@ func (c *LobbyClient) writePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
-			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+		case message := <-c.send:
+			if err := writeText(c.conn, message); err != nil {
 				return
 			}
 
-			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				return
+		case <-c.done:
+			// Flush what was queued before the close, e.g. server_shutdown
+			for n := len(c.send); n > 0; n-- {
+				if err := writeText(c.conn, <-c.send); err != nil {
+					return
+				}
+			}
+			code, text := websocket.CloseGoingAway, ""
+			if c.closeCode != 0 {
+				code, text = c.closeCode, c.closeText
 			}
+			c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, text))
+			return
 		}
 	}
 }
 
-// removeClient removes a client from the lobby
-func (lm *LobbyManager) removeClient(userID int64) {
+// removeClient removes a client from the lobby, unless a newer connection of
+// the same user already replaced it
+func (lm *LobbyManager) removeClient(client *LobbyClient) {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
 
-	if client, ok := lm.clients[userID]; ok {
-		close(client.send)
-		delete(lm.clients, userID)
+	client.close(0, "")
+	if current, ok := lm.clients[client.userID]; ok && current == client {
+		delete(lm.clients, client.userID)
 	}
 }
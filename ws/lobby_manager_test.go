@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"monopoly/game"
+	"monopoly/store"
+	"testing"
+)
+
+// fakeLobby serves fixed games to the lobby manager
+type fakeLobby struct {
+	games map[int64]*store.LobbyGameDTO
+}
+
+func (f fakeLobby) ListGames(userID int64, filter store.GameListFilter, limit, offset int) ([]*store.LobbyGameDTO, int, error) {
+	var games []*store.LobbyGameDTO
+	for _, g := range f.games {
+		games = append(games, g)
+	}
+	return games, len(games), nil
+}
+
+func (f fakeLobby) GetGameWithPlayers(gameID, userID int64) (*store.LobbyGameDTO, error) {
+	return f.games[gameID], nil
+}
+
+// drainTypes returns the types of the messages queued for a lobby client
+func drainTypes(t *testing.T, c *LobbyClient) []string {
+	t.Helper()
+	var types []string
+	for len(c.send) > 0 {
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(<-c.send, &msg); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		types = append(types, msg.Type)
+	}
+	return types
+}
+
+func TestLobbySubscriptions_WatchersOnlyHearTheirGames(t *testing.T) {
+	games := map[int64]*store.LobbyGameDTO{
+		1: {ID: 1, Status: game.StatusWaiting},
+		2: {ID: 2, Status: game.StatusInProgress},
+		3: {ID: 3, Status: game.StatusFinished},
+	}
+	lm := NewLobbyManager(fakeLobby{games: games})
+	listener := &LobbyClient{outbox: newOutbox(16), userID: 100}
+	watcher := &LobbyClient{outbox: newOutbox(16), userID: 101}
+	lm.clients[listener.userID] = listener
+	lm.clients[watcher.userID] = watcher
+
+	lm.handleMessage(watcher, []byte(`{"type":"subscribe","payload":{"gameIds":[1]}}`))
+	if got := fmt.Sprint(drainTypes(t, watcher)); got != "[subscriptions game_summary]" {
+		t.Fatalf("Expected the subscription and the game's summary, got %s", got)
+	}
+
+	for _, bad := range []string{
+		`{"type":"subscribe","payload":{"gameIds":[]}}`,
+		`{"type":"subscribe","payload":{"gameIds":[99]}}`, // missing
+		`{"type":"subscribe","payload":{"gameIds":[3]}}`,  // finished
+		`{"type":"watch"}`,
+		`not json`,
+	} {
+		lm.handleMessage(watcher, []byte(bad))
+		if got := fmt.Sprint(drainTypes(t, watcher)); got != "[error]" {
+			t.Errorf("%s: expected an error, got %s", bad, got)
+		}
+	}
+
+	lm.BroadcastPlayerJoined(2, 102, "carol", "car")
+	if got := drainTypes(t, watcher); len(got) != 0 {
+		t.Errorf("Expected nothing about an unwatched game, got %v", got)
+	}
+	if got := fmt.Sprint(drainTypes(t, listener)); got != "[player_joined]" {
+		t.Errorf("Expected the listener to hear about every game, got %s", got)
+	}
+
+	lm.BroadcastPlayerReady(1, 102, true)
+	if got := fmt.Sprint(drainTypes(t, watcher)); got != "[game_summary]" {
+		t.Errorf("Expected a summary of the watched game, got %s", got)
+	}
+	drainTypes(t, listener)
+
+	lm.BroadcastUpdate()
+	if got := fmt.Sprint(drainTypes(t, watcher)); got != "[game_summary]" {
+		t.Errorf("Expected summaries instead of the list, got %s", got)
+	}
+	if got := fmt.Sprint(drainTypes(t, listener)); got != "[games_update]" {
+		t.Errorf("Expected the whole list, got %s", got)
+	}
+
+	// Unsubscribing from everything goes back to the whole list
+	lm.handleMessage(watcher, []byte(`{"type":"unsubscribe","payload":{"gameIds":[1]}}`))
+	drainTypes(t, watcher)
+	lm.BroadcastPlayerJoined(2, 103, "dave", "dog")
+	if got := fmt.Sprint(drainTypes(t, watcher)); got != "[player_joined]" {
+		t.Errorf("Expected every event after unsubscribing, got %s", got)
+	}
+}
+
+func TestLobbySubscriptions_Capped(t *testing.T) {
+	games := make(map[int64]*store.LobbyGameDTO)
+	ids := make([]int64, MaxLobbySubscriptions+1)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+		games[ids[i]] = &store.LobbyGameDTO{ID: ids[i], Status: game.StatusWaiting}
+	}
+	lm := NewLobbyManager(fakeLobby{games: games})
+	client := &LobbyClient{outbox: newOutbox(64), userID: 100}
+
+	if _, err := lm.subscribe(client, ids[:MaxLobbySubscriptions]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := lm.subscribe(client, ids[MaxLobbySubscriptions:]); err == nil {
+		t.Error("Expected a subscription past the cap to be refused")
+	}
+	if added, err := lm.subscribe(client, ids[:2]); err != nil || len(added) != 0 {
+		t.Errorf("Expected re-subscribing to watched games to be a no-op, got %v, %v", added, err)
+	}
+	if n := len(client.subscribedGames()); n != MaxLobbySubscriptions {
+		t.Errorf("Expected %d subscriptions, got %d", MaxLobbySubscriptions, n)
+	}
+}
@@ -0,0 +1,183 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"monopoly/errors"
+	"monopoly/game"
+	"monopoly/store"
+	"slices"
+)
+
+const (
+	// MaxLobbySubscriptions caps how many games one lobby socket can watch
+	MaxLobbySubscriptions = 20
+
+	// maxLobbyMessageSize bounds incoming lobby messages, which only carry
+	// subscription requests
+	maxLobbyMessageSize = 4 * 1024
+)
+
+// Lobby subscription message types
+const (
+	EventSubscriptions = "subscriptions"
+	EventGameSummary   = "game_summary"
+)
+
+// lobbyRequest is a message from a lobby client: "subscribe" or "unsubscribe"
+// with the games it names
+type lobbyRequest struct {
+	Type    string `json:"type"`
+	Payload struct {
+		GameIDs []int64 `json:"gameIds"`
+	} `json:"payload"`
+}
+
+// SubscriptionsPayload lists the games a lobby client now watches
+type SubscriptionsPayload struct {
+	GameIDs []int64 `json:"gameIds"`
+}
+
+// GameSummaryPayload is the current state of a watched game
+type GameSummaryPayload struct {
+	Game *store.LobbyGameDTO `json:"game"`
+}
+
+// watching reports whether the client watches any games, and whether gameID
+// is one of them
+func (c *LobbyClient) watching(gameID int64) (anyGame, thisGame bool) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	return len(c.subscriptions) > 0, c.subscriptions[gameID]
+}
+
+// subscribedGames returns the games the client watches; none means it follows
+// the whole lobby list
+func (c *LobbyClient) subscribedGames() []int64 {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	ids := make([]int64, 0, len(c.subscriptions))
+	for id := range c.subscriptions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// audience splits the lobby into the clients following the whole list, who
+// get every event, and those subscribed to gameID, who get its summary
+func (lm *LobbyManager) audience(gameID int64) (listeners, watchers []*LobbyClient) {
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+	for _, client := range lm.clients {
+		if anyGame, thisGame := client.watching(gameID); !anyGame {
+			listeners = append(listeners, client)
+		} else if thisGame {
+			watchers = append(watchers, client)
+		}
+	}
+	return listeners, watchers
+}
+
+// sendSummary sends a watcher the current state of one of its games
+func (lm *LobbyManager) sendSummary(client *LobbyClient, gameID int64) {
+	summary, err := lm.lobby.GetGameWithPlayers(gameID, client.userID)
+	if err != nil || summary == nil {
+		return
+	}
+	lm.sendToClient(client, EventGameSummary, GameSummaryPayload{Game: summary})
+}
+
+// handleMessage applies a subscribe or unsubscribe request. Watching a game
+// replaces the whole-list updates with summaries of the watched games only;
+// unsubscribing from all of them goes back to the whole list.
+func (lm *LobbyManager) handleMessage(client *LobbyClient, data []byte) {
+	var req lobbyRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		lm.sendError(client, errors.BadRequest("Invalid message format"))
+		return
+	}
+
+	var added []int64
+	var err error
+	switch req.Type {
+	case "subscribe":
+		added, err = lm.subscribe(client, req.Payload.GameIDs)
+	case "unsubscribe":
+		err = lm.unsubscribe(client, req.Payload.GameIDs)
+	default:
+		err = errors.BadRequest("Unknown message type: " + req.Type)
+	}
+	if err != nil {
+		lm.sendError(client, err)
+		return
+	}
+
+	lm.sendToClient(client, EventSubscriptions, SubscriptionsPayload{GameIDs: client.subscribedGames()})
+	for _, gameID := range added {
+		lm.sendSummary(client, gameID)
+	}
+}
+
+// subscribe adds games the client can watch: each must exist and not be
+// finished, and the total stays within MaxLobbySubscriptions. It returns the
+// games that weren't watched before.
+func (lm *LobbyManager) subscribe(client *LobbyClient, gameIDs []int64) ([]int64, error) {
+	if len(gameIDs) == 0 {
+		return nil, errors.BadRequest("gameIds is required")
+	}
+	if len(gameIDs) > MaxLobbySubscriptions {
+		return nil, errors.BadRequest(fmt.Sprintf("Cannot watch more than %d games", MaxLobbySubscriptions))
+	}
+	for _, gameID := range gameIDs {
+		if gameID <= 0 {
+			return nil, errors.BadRequest("Invalid game ID")
+		}
+		summary, err := lm.lobby.GetGameWithPlayers(gameID, client.userID)
+		if err != nil {
+			return nil, err
+		}
+		if summary == nil {
+			return nil, errors.GameNotFound()
+		}
+		if summary.Status == game.StatusFinished {
+			return nil, errors.GameFinished()
+		}
+	}
+
+	client.subMu.Lock()
+	defer client.subMu.Unlock()
+	var added []int64
+	for _, gameID := range gameIDs {
+		if !client.subscriptions[gameID] && !slices.Contains(added, gameID) {
+			added = append(added, gameID)
+		}
+	}
+	if len(client.subscriptions)+len(added) > MaxLobbySubscriptions {
+		return nil, errors.BadRequest(fmt.Sprintf("Cannot watch more than %d games", MaxLobbySubscriptions))
+	}
+	if client.subscriptions == nil {
+		client.subscriptions = make(map[int64]bool)
+	}
+	for _, gameID := range added {
+		client.subscriptions[gameID] = true
+	}
+	return added, nil
+}
+
+// unsubscribe stops watching games; ones the client didn't watch are ignored
+func (lm *LobbyManager) unsubscribe(client *LobbyClient, gameIDs []int64) error {
+	if len(gameIDs) == 0 {
+		return errors.BadRequest("gameIds is required")
+	}
+	client.subMu.Lock()
+	defer client.subMu.Unlock()
+	for _, gameID := range gameIDs {
+		delete(client.subscriptions, gameID)
+	}
+	return nil
+}
+
+// sendError reports a rejected lobby request to the client
+func (lm *LobbyManager) sendError(client *LobbyClient, err error) {
+	lm.sendToClient(client, "error", errorPayload(err))
+}
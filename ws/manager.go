@@ -1,10 +1,14 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"monopoly/errors"
 	"monopoly/game"
+	"monopoly/store"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -12,30 +16,195 @@ import (
 )
 
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
 )
 
+// Options holds per-connection limits for game sockets
+type Options struct {
+	MaxMessageSize int64         // read limit for incoming messages
+	SendBufferSize int           // outgoing messages queued per client before dropping
+	StartCountdown time.Duration // delay between everyone being ready and the game starting
+	MessageRate    float64       // incoming messages per second per client; 0 disables the limit
+	MessageBurst   int           // messages a client may send at once before MessageRate applies
+	// RoomIdleTimeout is how long a room may go without a connection or
+	// broadcast before StartRoomSweeper may evict it
+	RoomIdleTimeout time.Duration
+	// FineGrainedEvents sends each event of an action as its own message
+	// instead of one turn_update; meant for debugging clients
+	FineGrainedEvents bool
+	// TradeTTL is how long a trade offer waits for an answer before it is
+	// auto-declined; 0 lets offers wait until answered or cancelled
+	TradeTTL time.Duration
+	// AbandonAfter is how long a game in progress may go without any player
+	// connected before StartAbandonedGameSweeper finishes it
+	AbandonAfter time.Duration
+	// ReconnectGrace pauses the turn clock of a player whose socket drops
+	// during their turn, timing the turn out only if they are still away
+	// after this long; 0 keeps the clock running
+	ReconnectGrace time.Duration
+	// Backplane shares room broadcasts with other server instances; nil
+	// keeps them in this process
+	Backplane Backplane
+}
+
 type Manager struct {
 	rooms        map[int64]*Room
 	engine       *game.Engine
 	lobbyManager *LobbyManager
 	turnTimer    *game.TurnTimer
+	countdown    *game.StartCountdown
+	opts         Options
 	mu           sync.RWMutex
+	pumps        sync.WaitGroup // tracks running write pumps for Shutdown
+
+	backplane    Backplane
+	instanceID   string                // tells this instance's broadcasts apart on the backplane
+	publishQueue chan BackplaneMessage // broadcasts waiting for the backplane; nil when local
 }
 
-func NewManager(engine *game.Engine, lobbyManager *LobbyManager) *Manager {
+func NewManager(engine *game.Engine, lobbyManager *LobbyManager, opts Options) *Manager {
 	m := &Manager{
 		rooms:        make(map[int64]*Room),
 		engine:       engine,
 		lobbyManager: lobbyManager,
+		opts:         opts,
 	}
 	m.turnTimer = game.NewTurnTimer(engine)
+	m.countdown = game.NewStartCountdown()
+	m.backplane = opts.Backplane
+	if m.backplane == nil {
+		m.backplane = localBackplane{}
+	} else {
+		m.publishQueue = make(chan BackplaneMessage, publishQueueSize)
+		go m.publishQueued()
+	}
+	m.instanceID = newInstanceID()
+	m.backplane.Subscribe(m.relay)
 	return m
 }
 
+// SetReady records a waiting player's ready state, announces it in the lobby
+// and starts or cancels the start countdown accordingly.
+func (m *Manager) SetReady(gameID, userID int64, ready bool) error {
+	if _, err := m.engine.SetReady(gameID, userID, ready); err != nil {
+		return err
+	}
+	m.lobbyManager.BroadcastPlayerReady(gameID, userID, ready)
+
+	if ready {
+		// Readying alone can't start the game; say why instead of staying silent
+		needed, err := m.engine.PlayersNeeded(gameID)
+		if err != nil {
+			return err
+		}
+		if needed > 0 {
+			m.lobbyManager.SendWaitingForPlayers(gameID, userID, needed)
+		}
+	}
+
+	return m.UpdateStartCountdown(gameID, userID)
+}
+
+// PlayerLeft follows up on a player leaving a waiting game: lobby clients are
+// told, and a start countdown running for the old roster is called off once
+// the players left are too few or not all ready. deleted says the game went
+// away with its last player, which always ends the countdown.
+func (m *Manager) PlayerLeft(gameID, userID int64, deleted bool) error {
+	go m.lobbyManager.BroadcastPlayerLeft(gameID, userID)
+
+	if deleted {
+		m.countdown.Cancel(gameID)
+		go m.lobbyManager.BroadcastGameDeleted(gameID)
+		return nil
+	}
+	return m.UpdateStartCountdown(gameID, userID)
+}
+
+// UpdateStartCountdown starts the countdown when every player in a waiting game
+// is ready, and cancels a running one otherwise. Call it after anything that
+// changes the roster or readiness; userID is the player who caused the change.
+func (m *Manager) UpdateStartCountdown(gameID, userID int64) error {
+	allReady, err := m.engine.AllPlayersReady(gameID)
+	if err != nil {
+		return err
+	}
+
+	if !allReady {
+		if m.countdown.Cancel(gameID) {
+			m.lobbyManager.BroadcastCountdownCancelled(gameID, userID)
+		}
+		return nil
+	}
+
+	if m.opts.StartCountdown <= 0 {
+		m.finishStartCountdown(gameID)
+		return nil
+	}
+	if m.countdown.Start(gameID, m.opts.StartCountdown, func() { m.finishStartCountdown(gameID) }) {
+		m.lobbyManager.BroadcastStartCountdown(gameID, int(m.opts.StartCountdown/time.Second))
+	}
+	return nil
+}
+
+// finishStartCountdown starts the game, re-checking readiness in case a change
+// raced with the countdown expiring.
+func (m *Manager) finishStartCountdown(gameID int64) {
+	event, err := m.engine.StartGameIfReady(gameID)
+	if err != nil {
+		log.Printf("Failed to start game %d after countdown: %v", gameID, err)
+		return
+	}
+	if event == nil {
+		m.lobbyManager.BroadcastCountdownCancelled(gameID, 0)
+		return
+	}
+
+	log.Printf("Game %d started (all players ready)", gameID)
+	m.BroadcastGameEvent(gameID, event)
+}
+
+// StartGame starts a waiting game on the host's say-so, calling off any
+// countdown that was already running.
+func (m *Manager) StartGame(gameID, userID int64) error {
+	event, err := m.engine.StartGame(gameID, userID)
+	if err != nil {
+		return err
+	}
+	m.countdown.Cancel(gameID)
+
+	log.Printf("Game %d started by host %d", gameID, userID)
+	m.BroadcastGameEvent(gameID, event)
+	return nil
+}
+
+// UpdateSettings applies the host's change to a waiting game's settings and
+// re-checks whether it can start now that its seats or rules changed.
+func (m *Manager) UpdateSettings(gameID, userID int64, settings game.GameSettings) error {
+	event, err := m.engine.UpdateGameSettings(gameID, userID, settings)
+	if err != nil {
+		return err
+	}
+	// Settings can change over REST with no one in the room yet
+	m.BroadcastToRoom(gameID, OutgoingMessage{Type: event.Type, Payload: event.Payload})
+	m.lobbyManager.BroadcastUpdate()
+
+	if err := m.UpdateStartCountdown(gameID, userID); err != nil {
+		return err
+	}
+	started, err := m.engine.StartGameIfFull(gameID)
+	if err != nil {
+		return err
+	}
+	if started != nil {
+		log.Printf("Game %d started (full after settings change)", gameID)
+		m.countdown.Cancel(gameID)
+		m.BroadcastGameEvent(gameID, started)
+	}
+	return nil
+}
+
 func (m *Manager) GetRoom(gameID int64) *Room {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -43,11 +212,36 @@ func (m *Manager) GetRoom(gameID int64) *Room {
 	room, exists := m.rooms[gameID]
 	if !exists {
 		room = NewRoom(gameID)
+		room.record = m.recordEvent
+		room.onSlowClient = func(userID int64) { m.playerDropped(room, userID) }
+		room.publish = func(data []byte) { m.publish(gameID, data) }
 		m.rooms[gameID] = room
 	}
 	return room
 }
 
+// recordEvent appends a room broadcast to the game's replay log
+func (m *Manager) recordEvent(gameID int64, eventType string, payloadJSON []byte) int64 {
+	seq, err := m.engine.RecordEvent(gameID, eventType, payloadJSON)
+	if err != nil {
+		log.Printf("Failed to record %s event for game %d: %v", eventType, gameID, err)
+		return 0
+	}
+	return seq
+}
+
+// BroadcastToRoom sends msg to the game's room if it has one. Unlike
+// GetRoom it never creates a room, so broadcasts from REST actions don't
+// leave empty rooms behind; with no room there's no one to tell.
+func (m *Manager) BroadcastToRoom(gameID int64, msg OutgoingMessage) {
+	m.mu.RLock()
+	room, exists := m.rooms[gameID]
+	m.mu.RUnlock()
+	if exists {
+		room.Broadcast(msg)
+	}
+}
+
 // BroadcastGameEvent broadcasts a game event to a room and handles turn timer
 func (m *Manager) BroadcastGameEvent(gameID int64, event *game.Event) {
 	room := m.GetRoom(gameID)
@@ -62,12 +256,15 @@ func (m *Manager) BroadcastGameEvent(gameID int64, event *game.Event) {
 		if payload, ok := event.Payload.(game.GameStartedPayload); ok {
 			m.startTurnTimer(gameID, payload.CurrentPlayerID, room)
 		}
+		m.broadcastTurnStarted(room)
 	} else if event.Type == "turn_changed" {
 		if payload, ok := event.Payload.(game.TurnChangedPayload); ok {
 			m.startTurnTimer(gameID, payload.CurrentPlayerID, room)
 		}
+		m.broadcastTurnStarted(room)
 	} else if event.Type == "game_finished" {
 		m.turnTimer.CancelTurn(gameID)
+		m.broadcastGameOver(room)
 	} else if event.Type == "auction_started" {
 		// Start timer for first bidder
 		if payload, ok := event.Payload.(game.AuctionStartedPayload); ok {
@@ -86,59 +283,476 @@ func (m *Manager) BroadcastGameEvent(gameID int64, event *game.Event) {
 	} else if event.Type == "auction_ended" {
 		// Don't cancel timer here - turn_changed will handle it
 	}
+	m.syncLobbyStatus(gameID, event.Type)
+}
+
+// syncLobbyStatus tells the lobby when a game event changes the game's status,
+// so started games stop looking joinable and finished ones drop off the list
+func (m *Manager) syncLobbyStatus(gameID int64, eventType string) {
+	switch eventType {
+	case "game_started":
+		go m.lobbyManager.BroadcastGameStatusChange(gameID, game.StatusInProgress)
+	case "game_finished", "game_force_finished":
+		go m.lobbyManager.BroadcastGameStatusChange(gameID, game.StatusFinished)
+	}
+}
+
+// ForfeitPlayer bankrupts a player on the server's initiative (e.g. account
+// deletion) and broadcasts the resulting events to the room.
+func (m *Manager) ForfeitPlayer(gameID, userID int64) error {
+	events, err := m.engine.GiveUp(gameID, userID)
+	if err != nil {
+		return err
+	}
+
+	room := m.GetRoom(gameID)
+	m.broadcastEvents(room, events)
+	m.broadcastStandingsIfChanged(room, events)
+	return nil
 }
 
 func (m *Manager) HandleConnection(conn *websocket.Conn, gameID, userID int64) {
 	client := &Client{
+		outbox:  newOutbox(m.opts.SendBufferSize),
+		conn:    conn,
+		userID:  userID,
+		limiter: m.newMessageLimiter(),
+	}
+
+	room := m.GetRoom(gameID)
+	if old := room.AddClient(client); old != nil {
+		log.Printf("User %d reconnected to game %d, replacing older connection", userID, gameID)
+	} else {
+		m.playerReturned(room, userID)
+	}
+	if m.opts.AbandonAfter > 0 {
+		go m.engine.RecordActivity(gameID)
+	}
+
+	go m.sendInitialState(client, gameID)
+
+	m.pumps.Add(1)
+	go func() {
+		defer m.pumps.Done()
+		m.writePump(client)
+	}()
+	go m.readPump(client, room)
+}
+
+// HandleSpectator attaches a read-only connection to the game's room. A
+// spectator gets everything broadcast to the room but nothing addressed to a
+// single player. userID is the signed-in user watching, or 0 for an
+// anonymous viewer; a signed-in spectator may send claim_seat to take a free
+// seat in a waiting game, and anything else it sends is ignored.
+func (m *Manager) HandleSpectator(conn *websocket.Conn, gameID, userID int64) {
+	client := &Client{
+		outbox: newOutbox(m.opts.SendBufferSize),
 		conn:   conn,
 		userID: userID,
-		send:   make(chan []byte, 256),
 	}
 
 	room := m.GetRoom(gameID)
-	room.AddClient(client)
+	room.AddSpectator(client)
 
-	// If game is already in progress, send timer_started event to the new client
-	// This ensures players see the timer even if they connect after the game starts
+	go m.sendInitialState(client, gameID)
+
+	m.pumps.Add(1)
 	go func() {
-		state, err := m.engine.GetGameState(gameID)
-		if err == nil && state != nil && state.Status == game.StatusInProgress {
-			// Find current player and send timer_started
-			for _, p := range state.Players {
-				if p.IsCurrentTurn {
-					timerMsg := OutgoingMessage{
-						Type: "timer_started",
-						Payload: map[string]interface{}{
-							"playerId": p.UserID,
-							"duration": int(game.TurnTimeout.Seconds()),
-						},
-					}
-					data, _ := json.Marshal(timerMsg)
-					select {
-					case client.send <- data:
-					default:
-					}
-					break
-				}
+		defer m.pumps.Done()
+		m.writePump(client)
+	}()
+	go m.spectatorReadPump(client, room)
+}
+
+// RejectConnection sends err as an error message on a freshly upgraded socket
+// and closes it with code, without creating a room. Browsers can't read the
+// HTTP status of a failed handshake, so this is how the client learns why.
+func (m *Manager) RejectConnection(conn *websocket.Conn, code int, err error) {
+	defer conn.Close()
+
+	payload := errorPayload(err)
+	data, _ := json.Marshal(OutgoingMessage{Type: "error", Payload: payload})
+
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return
+	}
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, payload.Message))
+}
+
+// ForceFinishGame ends a game on an operator's request. Connected players get
+// game_force_finished and are disconnected with CloseGameEnded so they don't
+// try to reconnect, and the room is dropped.
+func (m *Manager) ForceFinishGame(gameID int64) error {
+	event, err := m.engine.ForceFinish(gameID)
+	if err != nil {
+		return err
+	}
+	m.turnTimer.CancelTurn(gameID)
+	m.countdown.Cancel(gameID)
+
+	m.mu.Lock()
+	room, exists := m.rooms[gameID]
+	delete(m.rooms, gameID)
+	m.mu.Unlock()
+
+	if exists {
+		room.Broadcast(OutgoingMessage{Type: event.Type, Payload: event.Payload})
+		room.CloseAllWithCode(CloseGameEnded, "game ended by an administrator")
+	}
+
+	m.syncLobbyStatus(gameID, event.Type)
+	return nil
+}
+
+// CancelGame calls off a waiting game at the host's request. Players in the
+// room get game_cancelled and are disconnected with CloseGameEnded, the room
+// is dropped, and the game disappears from everyone's lobby list.
+func (m *Manager) CancelGame(gameID, userID int64) error {
+	event, err := m.engine.CancelGame(gameID, userID)
+	if err != nil {
+		return err
+	}
+	m.countdown.Cancel(gameID)
+
+	m.mu.Lock()
+	room, exists := m.rooms[gameID]
+	delete(m.rooms, gameID)
+	m.mu.Unlock()
+
+	if exists {
+		room.Broadcast(OutgoingMessage{Type: event.Type, Payload: event.Payload})
+		room.CloseAllWithCode(CloseGameEnded, "game cancelled by the host")
+	}
+
+	log.Printf("Game %d cancelled by host %d", gameID, userID)
+	go m.lobbyManager.BroadcastGameDeleted(gameID)
+	return nil
+}
+
+// broadcastPresence tells the room a player's connection came up or went away.
+// A socket replaced by a newer one from the same user doesn't count.
+func (m *Manager) broadcastPresence(room *Room, userID int64, online bool) {
+	room.Broadcast(OutgoingMessage{
+		Type:    "presence_changed",
+		Payload: PresenceChangedPayload{UserID: userID, Online: online},
+	})
+}
+
+// playerDropped announces that a player's socket went away and, if it was
+// their turn, pauses their turn clock for what is left of the turn's
+// reconnect grace period so a brief drop doesn't cost them the turn
+func (m *Manager) playerDropped(room *Room, userID int64) {
+	m.broadcastPresence(room, userID, false)
+	if m.opts.ReconnectGrace <= 0 {
+		return
+	}
+	grace, ok := m.turnTimer.PauseForDisconnect(room.gameID, userID, m.opts.ReconnectGrace)
+	if !ok {
+		return
+	}
+	room.Broadcast(OutgoingMessage{
+		Type: "player_reconnecting",
+		Payload: PlayerReconnectingPayload{
+			UserID:       userID,
+			GraceSeconds: int(grace.Round(time.Second).Seconds()),
+		},
+	})
+}
+
+// playerReturned announces a player's new socket and restarts a turn clock
+// paused when they dropped, with the time they had left
+func (m *Manager) playerReturned(room *Room, userID int64) {
+	m.broadcastPresence(room, userID, true)
+	left, ok := m.turnTimer.ResumeAfterReconnect(room.gameID, userID)
+	if !ok {
+		return
+	}
+	room.Broadcast(OutgoingMessage{
+		Type: "timer_started",
+		Payload: map[string]interface{}{
+			"playerId": userID,
+			"duration": int(left.Round(time.Second).Seconds()),
+		},
+	})
+}
+
+// FillPresence marks which of the state's players currently have a live
+// game socket
+func (m *Manager) FillPresence(state *game.GameState) {
+	m.mu.RLock()
+	room, exists := m.rooms[state.ID]
+	m.mu.RUnlock()
+	if !exists {
+		return
+	}
+	for _, p := range state.Players {
+		p.IsOnline = room.IsOnline(p.UserID)
+	}
+}
+
+// Stats is a point-in-time count of the manager's rooms and sockets
+type Stats struct {
+	Rooms       int // rooms held in memory
+	ActiveGames int // rooms with at least one connected player
+	Players     int // connected game sockets
+	Spectators  int // connected spectator sockets
+}
+
+// Stats counts rooms and connections. Like FillConnectedCounts it copies the
+// rooms under the read lock and counts after releasing it.
+func (m *Manager) Stats() Stats {
+	m.mu.RLock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.RUnlock()
+
+	stats := Stats{Rooms: len(rooms)}
+	for _, room := range rooms {
+		players := room.ClientCount()
+		if players > 0 {
+			stats.ActiveGames++
+		}
+		stats.Players += players
+		stats.Spectators += room.SpectatorCount()
+	}
+	return stats
+}
+
+// FillConnectedCounts sets how many players of each listed game have a live
+// game socket. The rooms are looked up under one read lock and counted after
+// it is released, so a long page doesn't hold up joins and disconnects.
+func (m *Manager) FillConnectedCounts(games []*store.LobbyGameDTO) {
+	rooms := make([]*Room, len(games))
+	m.mu.RLock()
+	for i, g := range games {
+		rooms[i] = m.rooms[g.ID]
+	}
+	m.mu.RUnlock()
+
+	for i, room := range rooms {
+		if room != nil {
+			games[i].ConnectedCount = room.ClientCount()
+		}
+	}
+}
+
+// sendInitialState sends a newly connected client a game_state snapshot, so a
+// waiting room can show ready flags and the host without a REST call. In a
+// running game it follows up with timer_started so the turn timer shows up
+// even for players who connect mid-turn.
+func (m *Manager) sendInitialState(client *Client, gameID int64) {
+	state, err := m.engine.GetGameState(gameID)
+	if err != nil {
+		m.sendError(client, err)
+		return
+	}
+
+	m.FillPresence(state)
+	state.Protocol = protocolOf(client.conn)
+	m.sendToClient(client, OutgoingMessage{Type: "game_state", Payload: state})
+
+	if state.Status == game.StatusInProgress && state.CurrentPlayerID != 0 {
+		// Show the running clock, which may be part-way through or paused
+		duration := game.TurnTimeout
+		if left, ok := m.turnTimer.Remaining(state.ID); ok {
+			duration = left.Round(time.Second)
+		}
+		m.sendToClient(client, OutgoingMessage{
+			Type: "timer_started",
+			Payload: map[string]interface{}{
+				"playerId": state.CurrentPlayerID,
+				"duration": int(duration.Seconds()),
+			},
+		})
+	}
+}
+
+// sendToClient queues a message for one client, dropping it if the client is backed up
+func (m *Manager) sendToClient(client *Client, message OutgoingMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Failed to marshal %s message: %v", message.Type, err)
+		return
+	}
+	client.trySend(data)
+}
+
+// Shutdown notifies every room that the server is going away, closes all
+// clients and waits for the write pumps to flush their close
+// frames. It returns ctx.Err() if the context expires first.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.turnTimer.CancelAll()
+
+	m.mu.RLock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.RUnlock()
+
+	for _, room := range rooms {
+		room.Broadcast(OutgoingMessage{
+			Type:    "server_shutdown",
+			Payload: map[string]interface{}{},
+		})
+		room.CloseAll()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.pumps.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StartRoomSweeper evicts idle rooms every interval for the life of the process
+func (m *Manager) StartRoomSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if evicted := m.evictIdleRooms(time.Now()); evicted > 0 {
+				log.Printf("Evicted %d idle game rooms", evicted)
 			}
 		}
 	}()
+}
 
-	go m.writePump(client)
-	go m.readPump(client, room)
+// evictIdleRooms drops rooms unused for RoomIdleTimeout that no longer need to
+// be in memory: finished (or deleted) games, whose lingering sockets are
+// closed with CloseGameEnded, and empty waiting rooms. Everything they show is
+// already in the database, so a later connection just builds a fresh room.
+// Rooms of games in progress stay, since turn timers broadcast into them.
+func (m *Manager) evictIdleRooms(now time.Time) int {
+	m.mu.RLock()
+	var idle []*Room
+	for _, room := range m.rooms {
+		if room.idleFor(now) >= m.opts.RoomIdleTimeout {
+			idle = append(idle, room)
+		}
+	}
+	m.mu.RUnlock()
+
+	evicted := 0
+	for _, room := range idle {
+		state, err := m.engine.GetGameState(room.gameID)
+		if err != nil && errors.From(err).Code != errors.ErrCodeGameNotFound {
+			log.Printf("Failed to check idle room for game %d: %v", room.gameID, err)
+			continue
+		}
+		if state != nil {
+			if state.Status == game.StatusInProgress {
+				continue
+			}
+			if state.Status == game.StatusWaiting && !room.IsEmpty() {
+				continue
+			}
+		}
+
+		// A connection may have arrived since the scan
+		m.mu.Lock()
+		if m.rooms[room.gameID] != room || room.idleFor(now) < m.opts.RoomIdleTimeout {
+			m.mu.Unlock()
+			continue
+		}
+		delete(m.rooms, room.gameID)
+		m.mu.Unlock()
+
+		room.CloseAllWithCode(CloseGameEnded, "game over")
+		evicted++
+	}
+	return evicted
+}
+
+// StartAbandonedGameSweeper finishes games in progress that no player has
+// been connected to for AbandonAfter, checking every interval for the life
+// of the process
+func (m *Manager) StartAbandonedGameSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if finished := m.finishAbandonedGames(time.Now()); finished > 0 {
+				log.Printf("Finished %d abandoned games", finished)
+			}
+		}
+	}()
+}
+
+// finishAbandonedGames first records activity for every game with a player
+// connected, so a long session counts as activity even though connecting
+// is only recorded once, then finishes the games that are still inactive.
+// No one is connected to them, so nothing is broadcast to their rooms; the
+// lobby hears that they finished.
+func (m *Manager) finishAbandonedGames(now time.Time) int {
+	m.mu.RLock()
+	var connected []int64
+	for gameID, room := range m.rooms {
+		if room.ClientCount() > 0 {
+			connected = append(connected, gameID)
+		}
+	}
+	m.mu.RUnlock()
+	for _, gameID := range connected {
+		m.engine.RecordActivity(gameID)
+	}
+
+	cutoff := now.Add(-m.opts.AbandonAfter)
+	gameIDs, err := m.engine.InactiveGames(cutoff)
+	if err != nil {
+		log.Printf("Failed to list inactive games: %v", err)
+		return 0
+	}
+
+	finished := 0
+	for _, gameID := range gameIDs {
+		event, err := m.engine.FinishAbandoned(gameID, cutoff)
+		if err != nil {
+			log.Printf("Failed to finish abandoned game %d: %v", gameID, err)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+		m.turnTimer.CancelTurn(gameID)
+		m.syncLobbyStatus(gameID, event.Type)
+		finished++
+	}
+	return finished
 }
 
 func (m *Manager) readPump(client *Client, room *Room) {
 	defer func() {
-		room.RemoveClient(client)
+		if r := recover(); r != nil {
+			log.Printf("Recovered panic in read pump for user %d in game %d: %v\n%s", client.userID, room.gameID, r, debug.Stack())
+		}
+		if room.RemoveClient(client) {
+			m.playerDropped(room, client.userID)
+		}
 		client.conn.Close()
 		m.cleanupRoomIfNeeded(room.gameID)
 	}()
 
 	client.conn.SetReadDeadline(time.Now().Add(pongWait))
-	client.conn.SetReadLimit(maxMessageSize)
+	client.conn.SetReadLimit(m.opts.MaxMessageSize)
 	client.conn.SetPongHandler(func(string) error {
-		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		now := time.Now()
+		client.conn.SetReadDeadline(now.Add(pongWait))
+		if latency, report := client.recordPong(now); report {
+			m.sendLatency(client, latency)
+		}
 		return nil
 	})
 
@@ -151,9 +765,15 @@ func (m *Manager) readPump(client *Client, room *Room) {
 			break
 		}
 
+		if !m.throttle(client, room) {
+			continue
+		}
+		room.touch()
+
 		var inMsg IncomingMessage
 		if err := json.Unmarshal(message, &inMsg); err != nil {
 			log.Printf("Failed to unmarshal message: %v", err)
+			m.sendError(client, errors.BadRequest("Invalid message format"))
 			continue
 		}
 
@@ -161,36 +781,142 @@ func (m *Manager) readPump(client *Client, room *Room) {
 	}
 }
 
+// spectatorReadPump keeps a spectator's heartbeat going and notices when the
+// connection goes away. Incoming messages are read and dropped.
+func (m *Manager) spectatorReadPump(client *Client, room *Room) {
+	seated := false
+	defer func() {
+		if seated {
+			return // the connection now belongs to readPump
+		}
+		room.RemoveSpectator(client)
+		client.conn.Close()
+		m.cleanupRoomIfNeeded(room.gameID)
+	}()
+
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetReadLimit(m.opts.MaxMessageSize)
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for !seated {
+		_, message, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var inMsg IncomingMessage
+		if json.Unmarshal(message, &inMsg) == nil && inMsg.Type == "claim_seat" {
+			seated = m.claimSeat(client, room)
+		}
+	}
+	m.readPump(client, room)
+}
+
+// claimSeat seats a signed-in spectator in the game and turns its connection
+// into a player connection, then tells the room and the lobby as a join from
+// the lobby would. It reports whether the spectator's socket is now a player
+// connection; once the seat is saved the join is announced either way.
+func (m *Manager) claimSeat(client *Client, room *Room) bool {
+	if client.userID == 0 {
+		m.sendError(client, errors.Unauthorized())
+		return false
+	}
+	event, err := m.engine.ClaimSeat(room.gameID, client.userID)
+	if err != nil {
+		m.sendError(client, err)
+		return false
+	}
+	// The seat is taken whatever becomes of the socket: if it was closed in
+	// the meantime, the player has joined and is merely offline
+	promoted := room.promoteSpectator(client)
+	log.Printf("Spectator %d took a seat in game %d", client.userID, room.gameID)
+
+	room.Broadcast(OutgoingMessage{Type: event.Type, Payload: event.Payload})
+	if promoted {
+		client.limiter = m.newMessageLimiter()
+		m.playerReturned(room, client.userID)
+		// The player now sees the game as one of its players
+		go m.sendInitialState(client, room.gameID)
+	}
+	if payload, ok := event.Payload.(game.PlayerJoinedPayload); ok {
+		go m.lobbyManager.BroadcastPlayerJoined(room.gameID, client.userID, payload.Player.Username, payload.Player.Token)
+	}
+
+	// The new player isn't ready yet, so any pending start is called off
+	if err := m.UpdateStartCountdown(room.gameID, client.userID); err != nil {
+		log.Printf("Error updating start countdown for game %d: %v", room.gameID, err)
+	}
+	started, err := m.engine.StartGameIfFull(room.gameID)
+	if err != nil {
+		log.Printf("Error starting game %d: %v", room.gameID, err)
+	} else if started != nil {
+		log.Printf("Game %d started (full)", room.gameID)
+		m.BroadcastGameEvent(room.gameID, started)
+	}
+	return promoted
+}
+
+// compressMinSize is the smallest message worth deflating; below it the
+// framing overhead and CPU cost outweigh the bytes saved
+const compressMinSize = 512
+
+// writeText writes one text frame, compressed if it's large enough and the
+// client negotiated permessage-deflate in the handshake. Clients that didn't
+// get plain frames.
+func writeText(conn *websocket.Conn, message []byte) error {
+	conn.EnableWriteCompression(len(message) >= compressMinSize)
+	return conn.WriteMessage(websocket.TextMessage, message)
+}
+
 func (m *Manager) writePump(client *Client) {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered panic in write pump for user %d: %v\n%s", client.userID, r, debug.Stack())
+		}
 		ticker.Stop()
 		client.conn.Close()
 	}()
 
 	for {
 		select {
-		case message, ok := <-client.send:
+		case message := <-client.send:
 			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				client.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			if err := writeText(client.conn, message); err != nil {
 				return
 			}
 
-			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				return
-			}
-
-			// Send any queued messages, each as its own frame
+			// Drain whatever else is queued. Every message gets its own frame and
+			// is never joined with others, so clients parse each frame as exactly
+			// one JSON message whatever characters the payload contains
 			n := len(client.send)
 			for i := 0; i < n; i++ {
-				if err := client.conn.WriteMessage(websocket.TextMessage, <-client.send); err != nil {
+				if err := writeText(client.conn, <-client.send); err != nil {
 					return
 				}
 			}
 
+		case <-client.done:
+			// Flush what was queued before the close, e.g. game_force_finished
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			for n := len(client.send); n > 0; n-- {
+				if err := writeText(client.conn, <-client.send); err != nil {
+					return
+				}
+			}
+			code, text := websocket.CloseGoingAway, ""
+			if client.closeCode != 0 {
+				code, text = client.closeCode, client.closeText
+			}
+			client.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, text))
+			return
+
 		case <-ticker.C:
 			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			// Marked first so a fast pong can't beat the timestamp
+			client.markPing(time.Now())
 			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -229,6 +955,14 @@ func (m *Manager) cleanupRoomIfNeeded(gameID int64) {
 }
 
 func (m *Manager) handleMessage(client *Client, room *Room, msg *IncomingMessage) {
+	// A bug in one handler must not kill the connection: log it and tell the client
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered panic handling %q from user %d in game %d: %v\n%s", msg.Type, client.userID, room.gameID, r, debug.Stack())
+			m.sendError(client, errors.InternalError(fmt.Sprintf("panic handling %s: %v", msg.Type, r)))
+		}
+	}()
+
 	switch msg.Type {
 	case "roll_dice":
 		m.handleRollDice(client, room)
@@ -240,6 +974,11 @@ func (m *Manager) handleMessage(client *Client, room *Room, msg *IncomingMessage
 		m.handleMultiEventWithTimerRestart(client, room, func() ([]*game.Event, error) {
 			return m.engine.PassProperty(room.gameID, client.userID)
 		})
+	case "pay_tax":
+		choice, _ := msg.Payload["tax_choice"].(string)
+		m.handleMultiEventWithTimerRestart(client, room, func() ([]*game.Event, error) {
+			return m.engine.PayIncomeTax(room.gameID, client.userID, choice)
+		})
 	case "place_bid":
 		m.handlePlaceBid(client, room, msg)
 	case "pass_auction":
@@ -249,9 +988,18 @@ func (m *Manager) handleMessage(client *Client, room *Room, msg *IncomingMessage
 		m.handleSingleEvent(client, room, func() (*game.Event, error) {
 			return m.engine.EndTurn(room.gameID, client.userID)
 		})
+	case "skip_turn":
+		// The timer keeps running if the skip is rejected; turn_changed restarts it
+		m.handleMultiEvent(client, room, func() ([]*game.Event, error) {
+			events, err := m.engine.SkipTurn(room.gameID, client.userID)
+			if err == nil {
+				log.Printf("User %d skipped their turn in game %d", client.userID, room.gameID)
+			}
+			return events, err
+		})
 	case "chat":
 		m.handleChat(client, room, msg)
-	case "pay_jail_bail":
+	case "pay_jail_bail", "pay_jail":
 		m.handleMultiEventWithTimerRestart(client, room, func() ([]*game.Event, error) {
 			return m.engine.PayJailBail(room.gameID, client.userID)
 		})
@@ -277,14 +1025,48 @@ func (m *Manager) handleMessage(client *Client, room *Room, msg *IncomingMessage
 		m.handleCancelTrade(client, room, msg)
 	case "give_up":
 		m.handleGiveUp(client, room)
+	case "set_ready":
+		m.handleSetReady(client, room, msg)
+	case "start_game":
+		if err := m.StartGame(room.gameID, client.userID); err != nil {
+			m.sendError(client, err)
+		}
+	case "update_settings":
+		if err := m.UpdateSettings(room.gameID, client.userID, parseGameSettings(msg.Payload)); err != nil {
+			m.sendError(client, err)
+		}
 	default:
 		log.Printf("Unknown message type: %s", msg.Type)
 	}
 }
 
+// parseGameSettings reads the settings an update_settings message changes;
+// fields it leaves out or gets wrong keep their current value
+func parseGameSettings(payload map[string]interface{}) game.GameSettings {
+	intField := func(key string) *int {
+		if v, ok := payload[key].(float64); ok {
+			n := int(v)
+			return &n
+		}
+		return nil
+	}
+	settings := game.GameSettings{
+		MinPlayers:       intField("minPlayers"),
+		MaxPlayers:       intField("maxPlayers"),
+		StartingMoney:    intField("startingMoney"),
+		TurnLimit:        intField("turnLimit"),
+		TimeLimitMinutes: intField("timeLimitMinutes"),
+	}
+	if v, ok := payload["manualStart"].(bool); ok {
+		settings.ManualStart = &v
+	}
+	return settings
+}
+
 func (m *Manager) handleMortgage(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -297,6 +1079,7 @@ func (m *Manager) handleMortgage(client *Client, room *Room, msg *IncomingMessag
 func (m *Manager) handleMortgageWithTimerRestart(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -309,6 +1092,7 @@ func (m *Manager) handleMortgageWithTimerRestart(client *Client, room *Room, msg
 func (m *Manager) handleUnmortgage(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -321,6 +1105,7 @@ func (m *Manager) handleUnmortgage(client *Client, room *Room, msg *IncomingMess
 func (m *Manager) handleUnmortgageWithTimerRestart(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -333,6 +1118,7 @@ func (m *Manager) handleUnmortgageWithTimerRestart(client *Client, room *Room, m
 func (m *Manager) handleBuyHouse(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -345,6 +1131,7 @@ func (m *Manager) handleBuyHouse(client *Client, room *Room, msg *IncomingMessag
 func (m *Manager) handleBuyHouseWithTimerRestart(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -357,6 +1144,7 @@ func (m *Manager) handleBuyHouseWithTimerRestart(client *Client, room *Room, msg
 func (m *Manager) handleSellHouse(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -369,6 +1157,7 @@ func (m *Manager) handleSellHouse(client *Client, room *Room, msg *IncomingMessa
 func (m *Manager) handleSellHouseWithTimerRestart(client *Client, room *Room, msg *IncomingMessage) {
 	posFloat, ok := msg.Payload["position"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid position"))
 		return
 	}
 	position := int(posFloat)
@@ -381,6 +1170,7 @@ func (m *Manager) handleSellHouseWithTimerRestart(client *Client, room *Room, ms
 func (m *Manager) handleProposeTrade(client *Client, room *Room, msg *IncomingMessage) {
 	toUserIDFloat, ok := msg.Payload["toUserId"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid user ID"))
 		return
 	}
 	toUserID := int64(toUserIDFloat)
@@ -410,14 +1200,46 @@ func (m *Manager) handleProposeTrade(client *Client, room *Room, msg *IncomingMe
 		}
 	}
 
+	var proposed *game.Event
 	m.handleSingleEvent(client, room, func() (*game.Event, error) {
-		return m.engine.ProposeTrade(room.gameID, client.userID, toUserID, offer)
+		event, err := m.engine.ProposeTrade(room.gameID, client.userID, toUserID, offer)
+		proposed = event
+		return event, err
+	})
+	if proposed != nil {
+		if payload, ok := proposed.Payload.(game.TradeProposedPayload); ok {
+			m.scheduleTradeExpiry(room, payload.Trade.ID)
+		}
+	}
+}
+
+// scheduleTradeExpiry auto-declines the trade after Options.TradeTTL. The
+// timer isn't cancelled when the trade is answered; ExpireTrade ignores
+// trades that are no longer pending.
+func (m *Manager) scheduleTradeExpiry(room *Room, tradeID int64) {
+	if m.opts.TradeTTL <= 0 {
+		return
+	}
+	time.AfterFunc(m.opts.TradeTTL, func() {
+		event, err := m.engine.ExpireTrade(room.gameID, tradeID)
+		if err != nil {
+			log.Printf("Failed to expire trade %d in game %d: %v", tradeID, room.gameID, err)
+			return
+		}
+		if event == nil {
+			return
+		}
+		room.Broadcast(OutgoingMessage{
+			Type:    event.Type,
+			Payload: event.Payload,
+		})
 	})
 }
 
 func (m *Manager) handleAcceptTrade(client *Client, room *Room, msg *IncomingMessage) {
 	tradeIDFloat, ok := msg.Payload["tradeId"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid trade ID"))
 		return
 	}
 	tradeID := int64(tradeIDFloat)
@@ -430,6 +1252,7 @@ func (m *Manager) handleAcceptTrade(client *Client, room *Room, msg *IncomingMes
 func (m *Manager) handleDeclineTrade(client *Client, room *Room, msg *IncomingMessage) {
 	tradeIDFloat, ok := msg.Payload["tradeId"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid trade ID"))
 		return
 	}
 	tradeID := int64(tradeIDFloat)
@@ -442,6 +1265,7 @@ func (m *Manager) handleDeclineTrade(client *Client, room *Room, msg *IncomingMe
 func (m *Manager) handleCancelTrade(client *Client, room *Room, msg *IncomingMessage) {
 	tradeIDFloat, ok := msg.Payload["tradeId"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid trade ID"))
 		return
 	}
 	tradeID := int64(tradeIDFloat)
@@ -451,10 +1275,30 @@ func (m *Manager) handleCancelTrade(client *Client, room *Room, msg *IncomingMes
 	})
 }
 
+// handleSetReady toggles readiness from the game socket. Membership is checked
+// up front so a stale or non-player socket gets NOT_IN_GAME and changes nothing.
+func (m *Manager) handleSetReady(client *Client, room *Room, msg *IncomingMessage) {
+	ready, ok := msg.Payload["ready"].(bool)
+	if !ok {
+		m.sendError(client, errors.BadRequest("ready must be true or false"))
+		return
+	}
+
+	if _, err := m.engine.RejoinGame(room.gameID, client.userID); err != nil {
+		m.sendError(client, err)
+		return
+	}
+
+	if err := m.SetReady(room.gameID, client.userID, ready); err != nil {
+		m.sendError(client, err)
+	}
+}
+
 func (m *Manager) handleChat(client *Client, room *Room, msg *IncomingMessage) {
 	// Extract message text from payload
 	text, ok := msg.Payload["message"].(string)
 	if !ok || text == "" {
+		m.sendError(client, errors.BadRequest("Chat message must be non-empty text"))
 		return
 	}
 
@@ -506,17 +1350,15 @@ func (m *Manager) handleRollDice(client *Client, room *Room) {
 		}
 	}
 
-	for _, event := range events {
-		room.Broadcast(OutgoingMessage{
-			Type:    event.Type,
-			Payload: event.Payload,
-		})
-		m.handleEventSideEffects(event, room)
-	}
+	m.broadcastEvents(room, events)
+	m.broadcastStandingsIfChanged(room, events)
 
 	// Restart timer only if turn didn't end
 	if !turnEnded && len(events) > 0 {
 		m.restartTurnTimer(room.gameID, client.userID, room)
+		if rolledDoubles(events) {
+			m.broadcastTurnStarted(room)
+		}
 	}
 }
 
@@ -534,6 +1376,7 @@ func (m *Manager) handleSingleEvent(client *Client, room *Room, action func() (*
 		})
 		m.handleEventSideEffects(event, room)
 	}
+	m.broadcastStandingsIfChanged(room, []*game.Event{event})
 }
 
 func (m *Manager) handleMultiEvent(client *Client, room *Room, action func() ([]*game.Event, error)) {
@@ -543,13 +1386,8 @@ func (m *Manager) handleMultiEvent(client *Client, room *Room, action func() ([]
 		return
 	}
 
-	for _, event := range events {
-		room.Broadcast(OutgoingMessage{
-			Type:    event.Type,
-			Payload: event.Payload,
-		})
-		m.handleEventSideEffects(event, room)
-	}
+	m.broadcastEvents(room, events)
+	m.broadcastStandingsIfChanged(room, events)
 }
 
 func (m *Manager) handleMultiEventWithTimerRestart(client *Client, room *Room, action func() ([]*game.Event, error)) {
@@ -567,13 +1405,8 @@ func (m *Manager) handleMultiEventWithTimerRestart(client *Client, room *Room, a
 		}
 	}
 
-	for _, event := range events {
-		room.Broadcast(OutgoingMessage{
-			Type:    event.Type,
-			Payload: event.Payload,
-		})
-		m.handleEventSideEffects(event, room)
-	}
+	m.broadcastEvents(room, events)
+	m.broadcastStandingsIfChanged(room, events)
 
 	// Restart timer only if action succeeded and turn didn't end
 	if !turnEnded && len(events) > 0 {
@@ -599,6 +1432,7 @@ func (m *Manager) handleSingleEventWithTimerRestart(client *Client, room *Room,
 		})
 		m.handleEventSideEffects(event, room)
 	}
+	m.broadcastStandingsIfChanged(room, []*game.Event{event})
 
 	// Restart timer only if action succeeded and turn didn't end
 	if !turnEnded && event != nil {
@@ -616,43 +1450,105 @@ func (m *Manager) handleEventSideEffects(event *game.Event, room *Room) {
 		if payload, ok := event.Payload.(game.GameStartedPayload); ok {
 			m.startTurnTimer(room.gameID, payload.CurrentPlayerID, room)
 		}
-		go m.lobbyManager.BroadcastGameStatusChange(room.gameID, "in_progress")
+		m.broadcastTurnStarted(room)
 	case "turn_changed":
 		if payload, ok := event.Payload.(game.TurnChangedPayload); ok {
 			m.startTurnTimer(room.gameID, payload.CurrentPlayerID, room)
 		}
+		m.broadcastTurnStarted(room)
 	case "game_finished":
 		m.turnTimer.CancelTurn(room.gameID)
-		go m.lobbyManager.BroadcastGameStatusChange(room.gameID, "finished")
+		m.broadcastGameOver(room)
 	}
+	m.syncLobbyStatus(room.gameID, event.Type)
 }
 
-func (m *Manager) sendError(client *Client, err error) {
-	var userMessage string
-	var errorCode string
+// broadcastGameOver sends the committed result and final standings of a
+// finished game, then closes the spectators with 4002: there's nothing left to
+// watch and their tokens no longer admit them
+func (m *Manager) broadcastGameOver(room *Room) {
+	defer room.CloseSpectators(CloseGameEnded, "game over")
+	event, err := m.engine.GameOver(room.gameID)
+	if err != nil {
+		log.Printf("Failed to build game_over for game %d: %v", room.gameID, err)
+		return
+	}
+	if event == nil {
+		return
+	}
+	room.Broadcast(OutgoingMessage{
+		Type:    event.Type,
+		Payload: event.Payload,
+	})
+}
 
-	if appErr, ok := err.(*errors.AppError); ok {
-		userMessage = appErr.UserMessage()
-		errorCode = string(appErr.Code)
-		log.Printf("WS Error [%s]: %s", appErr.Code, appErr.Error())
-	} else {
-		userMessage = "An error occurred. Please try again."
-		errorCode = "UNKNOWN_ERROR"
-		log.Printf("WS Error: %v", err)
+// broadcastTurnStarted tells the room which actions the current player may take
+func (m *Manager) broadcastTurnStarted(room *Room) {
+	event, err := m.engine.TurnStarted(room.gameID)
+	if err != nil {
+		log.Printf("Failed to build turn_started for game %d: %v", room.gameID, err)
+		return
+	}
+	if event == nil {
+		return
 	}
+	room.Broadcast(OutgoingMessage{
+		Type:    event.Type,
+		Payload: event.Payload,
+	})
+}
 
-	errorMsg := OutgoingMessage{
-		Type: "error",
-		Payload: map[string]string{
-			"code":    errorCode,
-			"message": userMessage,
-		},
+// rolledDoubles reports whether a roll grants another roll this turn
+func rolledDoubles(events []*game.Event) bool {
+	for _, event := range events {
+		if event.Type == "go_to_jail" {
+			return false
+		}
 	}
-	data, _ := json.Marshal(errorMsg)
-	select {
-	case client.send <- data:
-	default:
+	for _, event := range events {
+		if payload, ok := event.Payload.(game.DiceRolledPayload); ok && payload.IsDoubles {
+			return true
+		}
 	}
+	return false
+}
+
+// broadcastStandingsIfChanged sends a fresh leaderboard snapshot once per
+// action if any of its events moved money or property.
+func (m *Manager) broadcastStandingsIfChanged(room *Room, events []*game.Event) {
+	changed := false
+	for _, event := range events {
+		if event != nil && game.AffectsStandings(event.Type) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+
+	standings, err := m.engine.GetStandings(room.gameID)
+	if err != nil {
+		log.Printf("Failed to compute standings for game %d: %v", room.gameID, err)
+		return
+	}
+	room.Broadcast(OutgoingMessage{
+		Type:    "standings_updated",
+		Payload: game.StandingsUpdatedPayload{Standings: standings},
+	})
+}
+
+func (m *Manager) sendError(client *Client, err error) {
+	data, _ := json.Marshal(OutgoingMessage{Type: "error", Payload: errorPayload(err)})
+	client.trySend(data)
+}
+
+// errorPayload builds the error sent to a client, logging the details.
+// Errors without a code of their own are reported as INTERNAL_ERROR.
+func errorPayload(err error) ErrorPayload {
+	appErr := errors.From(err)
+	log.Printf("WS Error [%s]: %s", appErr.Code, appErr.Error())
+	return ErrorPayload{Code: appErr.Code, Message: appErr.UserMessage()}
 }
 
 // startTurnTimer starts a timer for the current player's turn
@@ -676,13 +1572,16 @@ func (m *Manager) startTurnTimer(gameID, currentPlayerID int64, room *Room) {
 
 			// If game finished due to timeout, notify lobby
 			if event.Type == "game_finished" {
-				go m.lobbyManager.BroadcastGameStatusChange(gameID, "finished")
+				m.broadcastGameOver(room)
+				m.syncLobbyStatus(gameID, event.Type)
 			} else if event.Type == "turn_timeout" {
+				m.logTimeoutLatency(room, currentPlayerID)
 				// Start timer for next player if turn changed
 				if payload, ok := event.Payload.(map[string]interface{}); ok {
 					// The payload is set directly in Go, so values are int64
 					if nextPlayerID, ok := payload["currentPlayerId"].(int64); ok {
 						m.startTurnTimer(gameID, nextPlayerID, room)
+						m.broadcastTurnStarted(room)
 					}
 				}
 			}
@@ -712,12 +1611,15 @@ func (m *Manager) restartTurnTimer(gameID, currentPlayerID int64, room *Room) {
 
 			// If game finished due to timeout, notify lobby
 			if event.Type == "game_finished" {
-				go m.lobbyManager.BroadcastGameStatusChange(gameID, "finished")
+				m.broadcastGameOver(room)
+				m.syncLobbyStatus(gameID, event.Type)
 			} else if event.Type == "turn_timeout" {
+				m.logTimeoutLatency(room, currentPlayerID)
 				// Start timer for next player if turn changed
 				if payload, ok := event.Payload.(map[string]interface{}); ok {
 					if nextPlayerID, ok := payload["currentPlayerId"].(int64); ok {
 						m.startTurnTimer(gameID, nextPlayerID, room)
+						m.broadcastTurnStarted(room)
 					}
 				}
 			}
@@ -728,6 +1630,7 @@ func (m *Manager) restartTurnTimer(gameID, currentPlayerID int64, room *Room) {
 func (m *Manager) handlePlaceBid(client *Client, room *Room, msg *IncomingMessage) {
 	amountFloat, ok := msg.Payload["amount"].(float64)
 	if !ok {
+		m.sendError(client, errors.BadRequest("Invalid bid amount"))
 		return
 	}
 	amount := int(amountFloat)
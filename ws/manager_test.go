@@ -0,0 +1,937 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"monopoly/errors"
+	"monopoly/game"
+	"monopoly/store"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+// brokenStore panics on every call, standing in for a handler bug
+type brokenStore struct {
+	store.GameStore
+}
+
+func TestHandleMessage_RecoversFromPanic(t *testing.T) {
+	m := NewManager(game.NewEngine(brokenStore{}), nil, Options{SendBufferSize: 4})
+	room := NewRoom(1)
+	client := newTestClient(100)
+
+	var msg IncomingMessage
+	raw := `{"type":"mortgage_property","payload":{"position":3}}`
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Twice: the first panic must not stop later messages from being handled
+	for i := 0; i < 2; i++ {
+		m.handleMessage(client, room, &msg)
+
+		if len(client.send) != 1 {
+			t.Fatalf("Expected one error message after panic %d, got %d", i+1, len(client.send))
+		}
+		var out struct {
+			Type    string            `json:"type"`
+			Payload map[string]string `json:"payload"`
+		}
+		if err := json.Unmarshal(<-client.send, &out); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if out.Type != "error" || out.Payload["code"] != "INTERNAL_ERROR" {
+			t.Errorf("Expected generic internal error, got %+v", out)
+		}
+	}
+}
+
+func TestReadPump_AnswersMalformedMessagesAndStaysOpen(t *testing.T) {
+	players := []*store.GamePlayer{{GameID: 1, UserID: 100, Username: "player1"}}
+	m := NewManager(game.NewEngine(rosterStore{players: players}), nil, Options{SendBufferSize: 16})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		m.HandleConnection(conn, 1, 100)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	nextError := func() ErrorPayload {
+		t.Helper()
+		for {
+			var msg struct {
+				Type    string       `json:"type"`
+				Payload ErrorPayload `json:"payload"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Expected an error reply on an open socket, got %v", err)
+			}
+			if msg.Type == "error" {
+				return msg.Payload
+			}
+		}
+	}
+
+	cases := []struct {
+		raw  string
+		want errors.ErrorCode
+	}{
+		{`{"type":"mortgage_property","payload":{"position":"three"}}`, errors.ErrCodeBadRequest},
+		{`{"type":"set_ready","payload":{"ready":"yes"}}`, errors.ErrCodeBadRequest},
+		{`{"type":"place_bid","payload":null}`, errors.ErrCodeBadRequest},
+		{`{"type":"mortgage_property","payload":`, errors.ErrCodeBadRequest},
+		// The socket still handles well-formed messages afterwards
+		{`{"type":"mortgage_property","payload":{"position":3}}`, errors.ErrCodeGameNotStarted},
+	}
+	for _, c := range cases {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(c.raw)); err != nil {
+			t.Fatalf("%s: write failed, socket closed: %v", c.raw, err)
+		}
+		if got := nextError(); got.Code != c.want {
+			t.Errorf("%s: expected %s, got %+v", c.raw, c.want, got)
+		}
+	}
+}
+
+func TestWriteText_CompressesOnlyForNegotiatingClients(t *testing.T) {
+	large := []byte(`{"type":"game_state","payload":"` + strings.Repeat("board ", 200) + `"}`)
+	small := []byte(`{"type":"pong"}`)
+
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		writeText(conn, large)
+		writeText(conn, small)
+	}))
+	defer srv.Close()
+
+	for _, compress := range []bool{true, false} {
+		dialer := websocket.Dialer{EnableCompression: compress}
+		conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("compress=%v: dial failed: %v", compress, err)
+		}
+
+		negotiated := strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+		if negotiated != compress {
+			t.Errorf("compress=%v: expected deflate negotiated=%v, got %v", compress, compress, negotiated)
+		}
+		for _, want := range [][]byte{large, small} {
+			_, got, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("compress=%v: read failed: %v", compress, err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("compress=%v: message garbled, got %d bytes, want %d", compress, len(got), len(want))
+			}
+		}
+		conn.Close()
+	}
+}
+
+func TestWritePump_SendsQueuedMessagesAsSeparateFrames(t *testing.T) {
+	// Pretty-printed JSON contains raw newlines, which a newline-joined batch
+	// would split mid-message
+	queued := [][]byte{
+		[]byte(`{"type":"dice_rolled","payload":{"dice":[3,4]}}`),
+		[]byte("{\n  \"type\": \"chat\",\n  \"payload\": {\"text\": \"line one\\nline two\"}\n}"),
+		[]byte(`{"type":"turn_ended","payload":{}}`),
+	}
+
+	m := &Manager{}
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := &Client{outbox: newOutbox(len(queued)), conn: conn, userID: 100}
+		for _, msg := range queued {
+			client.send <- msg
+		}
+		client.close(0, "")
+		m.writePump(client)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	for i, want := range queued {
+		_, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d failed: %v", i, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("frame %d: got %q, want %q", i, got, want)
+		}
+		if !json.Valid(got) {
+			t.Errorf("frame %d is not a single JSON document", i)
+		}
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going-away close after the queue, got %v", err)
+	}
+}
+
+func TestRejectConnection_SendsTypedErrorAndCloseCode(t *testing.T) {
+	m := NewManager(game.NewEngine(brokenStore{}), nil, Options{SendBufferSize: 4})
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		m.RejectConnection(conn, CloseGameNotFound, errors.GameNotFound())
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	var msg struct {
+		Type    string       `json:"type"`
+		Payload ErrorPayload `json:"payload"`
+	}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Expected error message before close, got %v", err)
+	}
+	if msg.Type != "error" || msg.Payload.Code != errors.ErrCodeGameNotFound {
+		t.Errorf("Expected GAME_NOT_FOUND error, got %+v", msg)
+	}
+
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, CloseGameNotFound) {
+		t.Errorf("Expected close code %d, got %v", CloseGameNotFound, err)
+	}
+	if len(m.rooms) != 0 {
+		t.Errorf("Expected no room to be created, got %d", len(m.rooms))
+	}
+}
+
+func TestErrorPayload_UsesStableCodes(t *testing.T) {
+	wrapped := fmt.Errorf("rolling dice: %w", errors.NotYourTurn())
+	if p := errorPayload(wrapped); p.Code != errors.ErrCodeNotYourTurn || p.Message != errors.NotYourTurn().Message {
+		t.Errorf("Expected NOT_YOUR_TURN from a wrapped error, got %+v", p)
+	}
+
+	if p := errorPayload(fmt.Errorf("disk on fire")); p.Code != errors.ErrCodeInternal || strings.Contains(p.Message, "disk") {
+		t.Errorf("Expected a generic INTERNAL_ERROR for an uncoded error, got %+v", p)
+	}
+}
+
+// rosterStore serves a waiting game's roster; any write panics via the nil GameStore
+type rosterStore struct {
+	store.GameStore
+	players []*store.GamePlayer
+}
+
+func (s rosterStore) GetGame(gameID int64) (*store.Game, error) {
+	return &store.Game{ID: gameID, Status: game.StatusWaiting, MaxPlayers: 4}, nil
+}
+
+func (s rosterStore) GetGamePlayers(gameID int64) ([]*store.GamePlayer, error) {
+	return s.players, nil
+}
+
+func (s rosterStore) GetGameProperties(gameID int64) ([]*store.GameProperty, error) {
+	return nil, nil
+}
+
+func (s rosterStore) GetAllImprovements(gameID int64) (map[int]int, error) {
+	return map[int]int{}, nil
+}
+
+func TestHandleSetReady_RejectsNonMember(t *testing.T) {
+	players := []*store.GamePlayer{{GameID: 1, UserID: 100, Username: "player1"}}
+	m := NewManager(game.NewEngine(rosterStore{players: players}), nil, Options{SendBufferSize: 4})
+	room := NewRoom(1)
+	outsider := newTestClient(200)
+
+	var msg IncomingMessage
+	if err := json.Unmarshal([]byte(`{"type":"set_ready","payload":{"ready":true}}`), &msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m.handleMessage(outsider, room, &msg)
+
+	if len(outsider.send) != 1 {
+		t.Fatalf("Expected exactly one message, got %d", len(outsider.send))
+	}
+	var out struct {
+		Type    string       `json:"type"`
+		Payload ErrorPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(<-outsider.send, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.Type != "error" || out.Payload.Code != errors.ErrCodeNotInGame {
+		t.Errorf("Expected NOT_IN_GAME error, got %+v", out)
+	}
+	if players[0].IsReady {
+		t.Error("Expected no readiness change")
+	}
+}
+
+func TestHandleConnection_PresenceIgnoresReplacedSockets(t *testing.T) {
+	players := []*store.GamePlayer{
+		{GameID: 1, UserID: 100, Username: "player1"},
+		{GameID: 1, UserID: 101, Username: "player2"},
+		{GameID: 1, UserID: 102, Username: "player3"},
+	}
+	m := NewManager(game.NewEngine(rosterStore{players: players}), nil, Options{SendBufferSize: 16})
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := strconv.ParseInt(r.URL.Query().Get("user"), 10, 64)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		m.HandleConnection(conn, 1, userID)
+	}))
+	closes := make(chan string, 16)
+	srv.Listener = closeReportingListener{Listener: srv.Listener, closes: closes}
+	srv.Start()
+	defer srv.Close()
+
+	dial := func(userID int64) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws%s?user=%d", strings.TrimPrefix(srv.URL, "http"), userID), nil)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		return conn
+	}
+
+	observer := dial(100)
+	defer observer.Close()
+	presence := make(chan PresenceChangedPayload, 8)
+	go func() {
+		for {
+			var msg struct {
+				Type    string                 `json:"type"`
+				Payload PresenceChangedPayload `json:"payload"`
+			}
+			if err := observer.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Type == "presence_changed" {
+				presence <- msg.Payload
+			}
+		}
+	}()
+	expect := func(want PresenceChangedPayload) {
+		t.Helper()
+		select {
+		case got := <-presence:
+			if got != want {
+				t.Fatalf("Expected %+v, got %+v", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %+v, got nothing", want)
+		}
+	}
+
+	expect(PresenceChangedPayload{UserID: 100, Online: true})
+
+	first := dial(101)
+	defer first.Close()
+	expect(PresenceChangedPayload{UserID: 101, Online: true})
+	for {
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if err := first.ReadJSON(&msg); err != nil {
+			t.Fatalf("Expected game_state on connect, got %v", err)
+		}
+		if msg.Type == "game_state" {
+			break
+		}
+	}
+
+	// A reconnect closes the old socket as replaced and says nothing
+	second := dial(101)
+	for {
+		if _, _, err := first.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, CloseReplaced) {
+				t.Fatalf("Expected the old socket closed as replaced, got %v", err)
+			}
+			break
+		}
+	}
+	// The server closes a game socket from both pumps, the read pump only
+	// after deciding on presence, so once the old socket was closed twice any
+	// presence_changed it caused is already queued ahead of the next player's
+	for seen := 0; seen < 2; {
+		select {
+		case addr := <-closes:
+			if addr == first.LocalAddr().String() {
+				seen++
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the server to finish with the replaced socket")
+		}
+	}
+	third := dial(102)
+	defer third.Close()
+	expect(PresenceChangedPayload{UserID: 102, Online: true})
+
+	second.Close()
+	expect(PresenceChangedPayload{UserID: 101, Online: false})
+}
+
+// closeReportingListener reports the remote address of every Close call on
+// the connections it accepts
+type closeReportingListener struct {
+	net.Listener
+	closes chan<- string
+}
+
+func (l closeReportingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return closeReportingConn{Conn: conn, closes: l.closes}, nil
+}
+
+type closeReportingConn struct {
+	net.Conn
+	closes chan<- string
+}
+
+func (c closeReportingConn) Close() error {
+	c.closes <- c.RemoteAddr().String()
+	return c.Conn.Close()
+}
+
+func TestThrottle_KickedPlayerDropsLikeAClosedSocket(t *testing.T) {
+	players := []*store.GamePlayer{{GameID: 1, UserID: 100, Username: "player1"}, {GameID: 1, UserID: 101, Username: "player2"}}
+	m := NewManager(game.NewEngine(rosterStore{players: players}), nil, Options{SendBufferSize: 16, ReconnectGrace: time.Minute})
+	room := m.GetRoom(1)
+	flooder, observer := newTestClient(100), newTestClient(101)
+	room.AddClient(flooder)
+	room.AddClient(observer)
+	m.turnTimer.StartTurn(1, flooder.userID, func(*game.Event) {})
+	defer m.turnTimer.CancelTurn(1)
+
+	// One strike short of a kick, with the last cooldown just over
+	now := time.Now()
+	flooder.limiter = rate.NewLimiter(rate.Limit(1), 1)
+	flooder.limiter.AllowN(now, 1)
+	flooder.strikes = rateLimitMaxStrikes - 1
+	flooder.mutedUntil = now.Add(-time.Second)
+
+	if m.throttle(flooder, room) {
+		t.Fatal("Expected the message to be refused")
+	}
+	if flooder.closeCode != CloseRateLimited {
+		t.Errorf("Expected close code %d, got %d", CloseRateLimited, flooder.closeCode)
+	}
+
+	var types []string
+	for len(observer.send) > 0 {
+		var out struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(<-observer.send, &out); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		types = append(types, out.Type)
+	}
+	if got := strings.Join(types, ","); got != "presence_changed,player_reconnecting" {
+		t.Errorf("Expected the drop and its reconnect grace, got %s", got)
+	}
+}
+
+// loggingRosterStore is a rosterStore that also accepts event log appends
+type loggingRosterStore struct {
+	rosterStore
+}
+
+func (s loggingRosterStore) AppendEvent(gameID int64, eventType, payloadJSON string) (int64, error) {
+	return 1, nil
+}
+
+func TestBroadcastGameEvent_NotifiesLobbyOfFinish(t *testing.T) {
+	lm := NewLobbyManager(nil)
+	watcher := &LobbyClient{outbox: newOutbox(4), userID: 300}
+	lm.clients[watcher.userID] = watcher
+
+	m := NewManager(game.NewEngine(loggingRosterStore{}), lm, Options{SendBufferSize: 4})
+	m.BroadcastGameEvent(1, &game.Event{Type: "game_finished", GameID: 1})
+
+	select {
+	case raw := <-watcher.send:
+		var out struct {
+			Type    string                  `json:"type"`
+			Payload GameStatusChangePayload `json:"payload"`
+		}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if out.Type != EventGameStatusChange || out.Payload.GameID != 1 || out.Payload.Status != game.StatusFinished {
+			t.Errorf("Expected game 1 to be reported finished, got %+v", out)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the lobby to hear that the game finished")
+	}
+}
+
+func TestBroadcastGameEvent_ClosesSpectatorsWhenTheGameFinishes(t *testing.T) {
+	m := NewManager(game.NewEngine(loggingRosterStore{}), NewLobbyManager(nil), Options{SendBufferSize: 4})
+	room := m.GetRoom(1)
+	player := newTestClient(100)
+	spectator := &Client{outbox: newOutbox(4)}
+	room.AddClient(player)
+	room.AddSpectator(spectator)
+
+	m.BroadcastGameEvent(1, &game.Event{Type: "game_finished", GameID: 1})
+
+	if spectator.closeCode != CloseGameEnded {
+		t.Errorf("Expected the spectator to be closed with %d, got %d", CloseGameEnded, spectator.closeCode)
+	}
+	if room.SpectatorCount() != 0 {
+		t.Errorf("Expected no spectators left, got %d", room.SpectatorCount())
+	}
+	if room.ClientCount() != 1 || player.closeCode != 0 {
+		t.Errorf("Expected the player to stay connected, got %d clients and close code %d", room.ClientCount(), player.closeCode)
+	}
+}
+
+func TestFillConnectedCounts(t *testing.T) {
+	m := NewManager(game.NewEngine(brokenStore{}), nil, Options{SendBufferSize: 4})
+	room := m.GetRoom(1)
+	room.AddClient(newTestClient(100))
+	room.AddClient(newTestClient(101))
+
+	games := []*store.LobbyGameDTO{{ID: 1}, {ID: 2}}
+	m.FillConnectedCounts(games)
+
+	if games[0].ConnectedCount != 2 {
+		t.Errorf("Expected 2 connected in game 1, got %d", games[0].ConnectedCount)
+	}
+	if games[1].ConnectedCount != 0 {
+		t.Errorf("Expected no one connected in a game without a room, got %d", games[1].ConnectedCount)
+	}
+}
+
+func TestBroadcastToRoom_DoesNotCreateRooms(t *testing.T) {
+	m := NewManager(game.NewEngine(loggingRosterStore{}), nil, Options{SendBufferSize: 4})
+	m.BroadcastToRoom(1, OutgoingMessage{Type: "settings_updated"})
+	if stats := m.Stats(); stats.Rooms != 0 {
+		t.Fatalf("Expected no room for a game nobody is connected to, got %d", stats.Rooms)
+	}
+
+	client := newTestClient(100)
+	m.GetRoom(2).AddClient(client)
+	m.BroadcastToRoom(2, OutgoingMessage{Type: "settings_updated"})
+	select {
+	case data := <-client.outbox.send:
+		if !strings.Contains(string(data), `"settings_updated"`) {
+			t.Errorf("Expected settings_updated, got %s", data)
+		}
+	default:
+		t.Error("Expected the connected player to get the broadcast")
+	}
+}
+
+func TestConnections_CopiesRoomsAndLobby(t *testing.T) {
+	lm := NewLobbyManager(fakeLobby{})
+	lm.clients[102] = &LobbyClient{outbox: newOutbox(4), userID: 102}
+	m := NewManager(game.NewEngine(brokenStore{}), lm, Options{SendBufferSize: 4})
+	m.GetRoom(2).AddClient(newTestClient(101))
+	m.GetRoom(2).AddClient(newTestClient(100))
+	m.GetRoom(2).AddSpectator(newTestClient(0))
+	m.GetRoom(1)
+
+	conns := m.Connections()
+	if len(conns.Rooms) != 2 || conns.Rooms[0].GameID != 1 || conns.Rooms[0].Players != 0 {
+		t.Fatalf("Expected both rooms by game ID, the first empty, got %+v", conns.Rooms)
+	}
+	if got := conns.Rooms[1]; fmt.Sprint(got.UserIDs) != "[100 101]" || got.Players != 2 || got.Spectators != 1 {
+		t.Errorf("Expected players 100 and 101 and a spectator in game 2, got %+v", got)
+	}
+	if fmt.Sprint(conns.LobbyUserIDs) != "[102]" || conns.LobbyClients != 1 {
+		t.Errorf("Expected user 102 in the lobby, got %+v", conns)
+	}
+
+	// The snapshot doesn't follow later changes
+	m.GetRoom(2).AddClient(newTestClient(103))
+	if len(conns.Rooms[1].UserIDs) != 2 {
+		t.Errorf("Expected the snapshot to be a copy, got %v", conns.Rooms[1].UserIDs)
+	}
+}
+
+// statusStore serves empty games with fixed statuses; unknown games don't exist
+type statusStore struct {
+	store.GameStore
+	statuses map[int64]string
+}
+
+func (s statusStore) GetGame(gameID int64) (*store.Game, error) {
+	status, ok := s.statuses[gameID]
+	if !ok {
+		return nil, nil
+	}
+	return &store.Game{ID: gameID, Status: status, MaxPlayers: 4}, nil
+}
+
+func (s statusStore) GetGamePlayers(gameID int64) ([]*store.GamePlayer, error) {
+	return nil, nil
+}
+
+func (s statusStore) GetGameProperties(gameID int64) ([]*store.GameProperty, error) {
+	return nil, nil
+}
+
+func (s statusStore) GetAllImprovements(gameID int64) (map[int]int, error) {
+	return map[int]int{}, nil
+}
+
+func TestEvictIdleRooms(t *testing.T) {
+	statuses := map[int64]string{
+		1: game.StatusFinished,
+		2: game.StatusInProgress,
+		3: game.StatusWaiting,
+		4: game.StatusWaiting,
+	} // game 5 was deleted
+	m := NewManager(game.NewEngine(statusStore{statuses: statuses}), nil, Options{
+		SendBufferSize:  4,
+		RoomIdleTimeout: 30 * time.Minute,
+	})
+	lingering := newTestClient(100)
+	m.GetRoom(1).AddClient(lingering)
+	m.GetRoom(2)
+	m.GetRoom(3).AddClient(newTestClient(101))
+	m.GetRoom(4)
+	m.GetRoom(5)
+
+	if evicted := m.evictIdleRooms(time.Now()); evicted != 0 {
+		t.Fatalf("Expected no evictions before the timeout, got %d", evicted)
+	}
+
+	if evicted := m.evictIdleRooms(time.Now().Add(time.Hour)); evicted != 3 {
+		t.Errorf("Expected 3 evictions, got %d", evicted)
+	}
+	for gameID, kept := range map[int64]bool{1: false, 2: true, 3: true, 4: false, 5: false} {
+		m.mu.RLock()
+		_, exists := m.rooms[gameID]
+		m.mu.RUnlock()
+		if exists != kept {
+			t.Errorf("Game %d: expected room kept=%v, got %v", gameID, kept, exists)
+		}
+	}
+
+	if !lingering.closed() || lingering.closeCode != CloseGameEnded {
+		t.Errorf("Expected the finished game's socket closed with %d, got closed=%v code=%d", CloseGameEnded, lingering.closed(), lingering.closeCode)
+	}
+}
+
+func TestPlayerLeft_CancelsCountdownBelowMinimum(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := game.NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := game.NewEngine(store.NewGameStore(db))
+	// An hour-long countdown never fires during the test; the cancel is seen
+	// through the lobby's countdown_cancelled instead of by waiting it out
+	lm := NewLobbyManager(lobby)
+	watcher := &LobbyClient{outbox: newOutbox(16), userID: 300}
+	lm.clients[watcher.userID] = watcher
+	m := NewManager(engine, lm, Options{SendBufferSize: 4, StartCountdown: time.Hour})
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(4, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	gameID := created.ID
+	if err := lobby.JoinGame(gameID, bob, "bob"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	for _, userID := range []int64{alice, bob} {
+		if err := m.SetReady(gameID, userID, true); err != nil {
+			t.Fatalf("SetReady failed: %v", err)
+		}
+	}
+	if !m.countdown.Running(gameID) {
+		t.Fatal("Expected the countdown to start once both players are ready")
+	}
+
+	if err := lobby.LeaveGame(gameID, bob); err != nil {
+		t.Fatalf("LeaveGame failed: %v", err)
+	}
+	if err := m.PlayerLeft(gameID, bob, false); err != nil {
+		t.Fatalf("PlayerLeft failed: %v", err)
+	}
+	if m.countdown.Running(gameID) {
+		t.Error("Expected the countdown cancelled with one player left")
+	}
+	// countdown_cancelled goes out before PlayerLeft returns
+	cancelled := false
+	for len(watcher.send) > 0 {
+		var out struct {
+			Type    string                         `json:"type"`
+			Payload game.CountdownCancelledPayload `json:"payload"`
+		}
+		if err := json.Unmarshal(<-watcher.send, &out); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if out.Type == EventCountdownCancelled {
+			cancelled = out.Payload.GameID == gameID && out.Payload.UserID == bob
+		}
+	}
+	if !cancelled {
+		t.Error("Expected the lobby to hear that bob leaving cancelled the countdown")
+	}
+
+	state, err := engine.GetGameState(gameID)
+	if err != nil {
+		t.Fatalf("GetGameState failed: %v", err)
+	}
+	if state.Status != game.StatusWaiting {
+		t.Errorf("Expected the game still waiting, got %s", state.Status)
+	}
+}
+
+// memoryBackplane publishes to every subscribed manager, like a shared
+// channel, and signals on published once each message was delivered
+type memoryBackplane struct {
+	mu          sync.Mutex
+	subscribers []func(BackplaneMessage)
+	published   chan struct{}
+}
+
+func (b *memoryBackplane) Publish(msg BackplaneMessage) error {
+	b.mu.Lock()
+	subscribers := append([]func(BackplaneMessage){}, b.subscribers...)
+	b.mu.Unlock()
+	for _, deliver := range subscribers {
+		deliver(msg)
+	}
+	b.published <- struct{}{}
+	return nil
+}
+
+func (b *memoryBackplane) Subscribe(deliver func(BackplaneMessage)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, deliver)
+}
+
+func TestBackplane_DeliversBroadcastsToPlayersOnOtherInstances(t *testing.T) {
+	backplane := &memoryBackplane{published: make(chan struct{}, 2)}
+	a := NewManager(game.NewEngine(loggingRosterStore{}), nil, Options{SendBufferSize: 4, Backplane: backplane})
+	b := NewManager(game.NewEngine(loggingRosterStore{}), nil, Options{SendBufferSize: 4, Backplane: backplane})
+	alice, bob := newTestClient(100), newTestClient(101)
+	a.GetRoom(1).AddClient(alice)
+	b.GetRoom(1).AddClient(bob)
+
+	a.GetRoom(1).Broadcast(OutgoingMessage{Type: "dice_rolled"})
+	// A game nobody on b is connected to gets no room there
+	a.GetRoom(2).Broadcast(OutgoingMessage{Type: "dice_rolled"})
+	for range 2 {
+		select {
+		case <-backplane.published:
+		case <-time.After(time.Second):
+			t.Fatal("Expected both broadcasts to be published")
+		}
+	}
+
+	for name, client := range map[string]*Client{"alice": alice, "bob": bob} {
+		if len(client.send) != 1 {
+			t.Fatalf("Expected %s to get the broadcast once, got %d messages", name, len(client.send))
+		}
+		var out OutgoingMessage
+		if err := json.Unmarshal(<-client.send, &out); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if out.Type != "dice_rolled" || out.Seq != 1 {
+			t.Errorf("Expected %s to get dice_rolled with the recorded seq, got %+v", name, out)
+		}
+	}
+	if stats := b.Stats(); stats.Rooms != 1 {
+		t.Errorf("Expected relaying not to create rooms, got %d", stats.Rooms)
+	}
+}
+
+func TestClaimSeat_SeatsSignedInSpectatorsWhileSeatsAreFree(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := game.NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := game.NewEngine(store.NewGameStore(db))
+	m := NewManager(engine, NewLobbyManager(lobby), Options{SendBufferSize: 16})
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	carol, _ := auth.CreateUser("carol", "hash")
+	dave, _ := auth.CreateUser("dave", "hash")
+	created, err := lobby.CreateGame(3, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	room := m.GetRoom(created.ID)
+	spectate := func(userID int64) *Client {
+		client := &Client{outbox: newOutbox(16), userID: userID}
+		room.AddSpectator(client)
+		return client
+	}
+	errorCode := func(client *Client) errors.ErrorCode {
+		for len(client.send) > 0 {
+			var out struct {
+				Type    string       `json:"type"`
+				Payload ErrorPayload `json:"payload"`
+			}
+			if json.Unmarshal(<-client.send, &out) == nil && out.Type == "error" {
+				return out.Payload.Code
+			}
+		}
+		return ""
+	}
+
+	anonymous := spectate(0)
+	if m.claimSeat(anonymous, room) || errorCode(anonymous) != errors.ErrCodeUnauthorized {
+		t.Error("Expected an anonymous spectator to be turned away as unauthorized")
+	}
+
+	watcher := spectate(bob)
+	if !m.claimSeat(watcher, room) {
+		t.Fatalf("Expected bob to take the free seat, got %s", errorCode(watcher))
+	}
+	if !room.IsOnline(bob) || room.SpectatorCount() != 1 {
+		t.Errorf("Expected bob's socket to become a player connection, online=%v spectators=%d", room.IsOnline(bob), room.SpectatorCount())
+	}
+	state, err := engine.GetGameState(created.ID)
+	if err != nil {
+		t.Fatalf("GetGameState failed: %v", err)
+	}
+	if len(state.Players) != 2 || state.Players[1].UserID != bob || state.Players[1].Token != game.PlayerTokens[1] {
+		t.Fatalf("Expected bob seated second with the next free token, got %+v", state.Players)
+	}
+
+	// The last seat goes through the lobby while carol is still watching
+	if err := lobby.JoinGame(created.ID, dave, "dave"); err != nil {
+		t.Fatalf("JoinGame failed: %v", err)
+	}
+	late := spectate(carol)
+	if m.claimSeat(late, room) || errorCode(late) != errors.ErrCodeGameFull {
+		t.Error("Expected carol to be told the game filled up")
+	}
+	if room.IsOnline(carol) || room.SpectatorCount() != 2 {
+		t.Error("Expected carol to stay a spectator")
+	}
+}
+
+func TestClaimSeat_AnnouncesTheSeatWhenTheSpectatorClosedMeanwhile(t *testing.T) {
+	db, err := store.InitDB(filepath.Join(t.TempDir(), "test.db"), 8, 8, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	lobby, auth := game.NewLobby(store.NewSQLiteLobbyStore(db), 3), store.NewAuthStore(db)
+	engine := game.NewEngine(store.NewGameStore(db))
+	m := NewManager(engine, NewLobbyManager(lobby), Options{SendBufferSize: 16})
+
+	alice, _ := auth.CreateUser("alice", "hash")
+	bob, _ := auth.CreateUser("bob", "hash")
+	created, err := lobby.CreateGame(3, store.GameRules{}, alice, "alice")
+	if err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	room := m.GetRoom(created.ID)
+	onlooker := &Client{outbox: newOutbox(16)}
+	room.AddSpectator(onlooker)
+	closed := &Client{outbox: newOutbox(16), userID: bob}
+	room.AddSpectator(closed)
+	room.RemoveSpectator(closed) // e.g. CloseAll between the claim and the promotion
+
+	if m.claimSeat(closed, room) {
+		t.Error("Expected a closed spectator's socket not to become a player connection")
+	}
+	state, err := engine.GetGameState(created.ID)
+	if err != nil {
+		t.Fatalf("GetGameState failed: %v", err)
+	}
+	if len(state.Players) != 2 || room.IsOnline(bob) {
+		t.Fatalf("Expected bob seated but offline, got %d players, online=%v", len(state.Players), room.IsOnline(bob))
+	}
+	joined := false
+	for len(onlooker.send) > 0 {
+		var out OutgoingMessage
+		if json.Unmarshal(<-onlooker.send, &out) == nil && out.Type == "player_joined" {
+			joined = true
+		}
+	}
+	if !joined {
+		t.Error("Expected the room to hear of bob's seat")
+	}
+}
+
+// stalledBackplane never finishes publishing until released
+type stalledBackplane struct {
+	release chan struct{}
+}
+
+func (b stalledBackplane) Publish(BackplaneMessage) error {
+	<-b.release
+	return nil
+}
+
+func (stalledBackplane) Subscribe(func(BackplaneMessage)) {}
+
+func TestBackplane_StalledBackplaneDoesNotHoldUpTheRoom(t *testing.T) {
+	backplane := stalledBackplane{release: make(chan struct{})}
+	defer close(backplane.release)
+	m := NewManager(game.NewEngine(loggingRosterStore{}), nil, Options{SendBufferSize: 4, Backplane: backplane})
+	alice := newTestClient(100)
+	m.GetRoom(1).AddClient(alice)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 3 {
+			m.GetRoom(1).Broadcast(OutgoingMessage{Type: "dice_rolled"})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected broadcasts to go out while the backplane is stalled")
+	}
+	if len(alice.send) != 3 {
+		t.Errorf("Expected alice to get all 3 broadcasts, got %d", len(alice.send))
+	}
+}
@@ -1,5 +1,7 @@
 package ws
 
+import "monopoly/errors"
+
 type IncomingMessage struct {
 	Type    string                 `json:"type"`
 	Payload map[string]interface{} `json:"payload"`
@@ -8,4 +10,26 @@ type IncomingMessage struct {
 type OutgoingMessage struct {
 	Type    string      `json:"type"`
 	Payload interface{} `json:"payload"`
+	Seq     int64       `json:"seq,omitempty"` // position in the game's event log, if recorded
+}
+
+// PresenceChangedPayload is broadcast when a player's game socket connects or drops
+type PresenceChangedPayload struct {
+	UserID int64 `json:"userId"`
+	Online bool  `json:"online"`
+}
+
+// PlayerReconnectingPayload is broadcast when the player whose turn it is
+// drops: their turn clock is paused and the turn is skipped only if they
+// haven't reconnected within GraceSeconds
+type PlayerReconnectingPayload struct {
+	UserID       int64 `json:"userId"`
+	GraceSeconds int   `json:"graceSeconds"`
+}
+
+// ErrorPayload is sent with "error" messages. Code is one of the stable
+// errors.ErrorCode values for clients to branch on; Message is for display.
+type ErrorPayload struct {
+	Code    errors.ErrorCode `json:"code"`
+	Message string           `json:"message"`
 }
@@ -0,0 +1,65 @@
+package ws
+
+import "sync"
+
+// outbox is a connection's queue of outgoing frames, drained by its write
+// pump. send is never closed, since broadcasts, direct replies and the lobby
+// all queue to it without holding any lock that closing could take; closing
+// it while one of them is mid-send would panic. Closing the connection closes
+// done instead, and the write pump flushes what is queued, sends the close
+// frame and exits. Frames queued after that are dropped with the outbox.
+type outbox struct {
+	send chan []byte
+	done chan struct{}
+
+	closeOnce sync.Once
+	// closeCode and closeText are sent in the close frame. Set before done is
+	// closed, read by the write pump after it observes the close.
+	closeCode int
+	closeText string
+}
+
+func newOutbox(size int) *outbox {
+	return &outbox{
+		send: make(chan []byte, size),
+		done: make(chan struct{}),
+	}
+}
+
+// close asks the write pump to send a close frame with code and text (0 for
+// "going away") and exit. Only the first call has any effect; it reports
+// whether this call was it.
+func (o *outbox) close(code int, text string) bool {
+	closed := false
+	o.closeOnce.Do(func() {
+		o.closeCode = code
+		o.closeText = text
+		close(o.done)
+		closed = true
+	})
+	return closed
+}
+
+// closed reports whether close has been called
+func (o *outbox) closed() bool {
+	select {
+	case <-o.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// trySend queues data without blocking, dropping it if the connection is
+// closed or backed up. It reports whether data was queued.
+func (o *outbox) trySend(data []byte) bool {
+	if o.closed() {
+		return false
+	}
+	select {
+	case o.send <- data:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,39 @@
+package ws
+
+import "github.com/gorilla/websocket"
+
+// ProtocolV1 is the message protocol described in CLAUDE.md. Clients ask for
+// it in the Sec-WebSocket-Protocol header.
+const ProtocolV1 = "monopoly.v1"
+
+// Protocols lists the protocol versions the server speaks. During a rollout
+// it holds both the old and the new version.
+var Protocols = []string{ProtocolV1}
+
+// CloseUnsupportedProtocol is the close code sent when a client offered only
+// protocol versions missing from Protocols
+const CloseUnsupportedProtocol = 4010
+
+// AcceptsProtocols reports whether a client offering these subprotocols can
+// be served. Clients that offer none predate versioning and get ProtocolV1.
+func AcceptsProtocols(offered []string) bool {
+	if len(offered) == 0 {
+		return true
+	}
+	for _, p := range offered {
+		for _, supported := range Protocols {
+			if p == supported {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// protocolOf returns the protocol negotiated on conn
+func protocolOf(conn *websocket.Conn) string {
+	if conn == nil || conn.Subprotocol() == "" {
+		return ProtocolV1
+	}
+	return conn.Subprotocol()
+}
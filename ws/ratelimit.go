@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"log"
+	"monopoly/errors"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// CloseRateLimited is the close code sent to a client that kept flooding the
+// socket after being told to slow down
+const CloseRateLimited = 4029
+
+const (
+	// rateLimitCooldown is how long messages are dropped after the limit is hit
+	rateLimitCooldown = 5 * time.Second
+	// rateLimitMaxStrikes closes the connection on this many limit hits
+	rateLimitMaxStrikes = 3
+	// rateLimitStrikeReset forgives a client's strikes once it has stayed
+	// under the limit this long after its last cooldown ended, so occasional
+	// bursts over a long session don't add up to a kick
+	rateLimitStrikeReset = time.Minute
+)
+
+type rateVerdict int
+
+const (
+	rateAllow rateVerdict = iota
+	rateDrop              // within a cooldown, dropped silently
+	rateWarn              // over the limit: tell the client and start a cooldown
+	rateKick              // over the limit too often: close the connection
+)
+
+// checkRate decides what to do with an incoming message. Called only from the
+// read pump, so the cooldown state needs no locking. A client without a
+// limiter is never throttled.
+func (c *Client) checkRate(now time.Time) rateVerdict {
+	if c.limiter == nil {
+		return rateAllow
+	}
+	if now.Before(c.mutedUntil) {
+		return rateDrop
+	}
+	if c.limiter.AllowN(now, 1) {
+		return rateAllow
+	}
+
+	if c.strikes > 0 && !now.Before(c.mutedUntil.Add(rateLimitStrikeReset)) {
+		c.strikes = 0
+	}
+	c.strikes++
+	if c.strikes >= rateLimitMaxStrikes {
+		return rateKick
+	}
+	c.mutedUntil = now.Add(rateLimitCooldown)
+	return rateWarn
+}
+
+// newMessageLimiter builds a client's limiter from the options, nil if disabled
+func (m *Manager) newMessageLimiter() *rate.Limiter {
+	if m.opts.MessageRate <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(m.opts.MessageRate), m.opts.MessageBurst)
+}
+
+// throttle applies the client's rate limit and reports whether the message
+// should be handled. A kicked player goes offline like any dropped socket.
+func (m *Manager) throttle(client *Client, room *Room) bool {
+	switch client.checkRate(time.Now()) {
+	case rateWarn:
+		log.Printf("User %d in game %d exceeded the message rate, muting for %v", client.userID, room.gameID, rateLimitCooldown)
+		m.sendError(client, errors.RateLimited())
+		return false
+	case rateKick:
+		log.Printf("User %d in game %d kept exceeding the message rate, closing connection", client.userID, room.gameID)
+		m.sendError(client, errors.RateLimited())
+		if room.CloseClient(client, CloseRateLimited, "rate limited") {
+			m.playerDropped(room, client.userID)
+		}
+		return false
+	case rateDrop:
+		return false
+	}
+	return true
+}
@@ -4,62 +4,370 @@ import (
 	"encoding/json"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
 )
 
+// CloseReplaced is the close code sent to a socket that was superseded by a
+// newer connection from the same user (e.g. a second tab).
+const CloseReplaced = 4001
+
+// CloseGameNotFound is the close code sent when the requested game doesn't exist
+const CloseGameNotFound = 4004
+
+// CloseGameEnded is the close code sent when an operator force-finishes the
+// game or its host cancels it before it starts, and to spectators once the
+// game is over
+const CloseGameEnded = 4002
+
+// CloseSpectateDenied is the close code sent when a spectator token is
+// unknown, revoked or belongs to a finished game
+const CloseSpectateDenied = 4003
+
+// CloseTooSlow is the close code sent to a client that kept falling behind on
+// broadcasts. Messages were dropped, so it should reconnect and resync from
+// the snapshot.
+const CloseTooSlow = 4008
+
+// maxSendDrops is how many broadcasts in a row a client may miss because its
+// send buffer is full before it's disconnected
+const maxSendDrops = 3
+
 type Client struct {
-	conn   *websocket.Conn
-	userID int64
-	send   chan []byte
+	*outbox // closed by the room that holds the client, see outbox
+	conn    *websocket.Conn
+	userID  int64
+
+	// Heartbeat latency: pingSentAt is written by the write pump and latency by
+	// the read pump. The report fields are only touched by the read pump.
+	pingSentAt       atomic.Int64 // unix nanos of the last ping
+	latency          atomic.Int64 // last round trip as a time.Duration
+	pongsSinceReport int
+	reportedLatency  time.Duration
+
+	// Incoming message rate limit, only touched by the read pump (see ratelimit.go)
+	limiter    *rate.Limiter
+	mutedUntil time.Time
+	strikes    int // limit hits since the last quiet rateLimitStrikeReset
+
+	// Broadcasts missed in a row on a full send buffer, only touched under
+	// the room's broadcastMu
+	drops int
 }
 
+// EventRecorder persists a broadcast and returns its sequence number (0 if not recorded)
+type EventRecorder func(gameID int64, eventType string, payloadJSON []byte) int64
+
 type Room struct {
-	gameID  int64
-	clients map[*Client]bool
-	mu      sync.RWMutex
+	gameID     int64
+	clients    map[int64]*Client // one authoritative connection per user
+	spectators map[*Client]struct{}
+	mu         sync.RWMutex
+
+	// record is optional. broadcastMu keeps delivery order equal to seq order.
+	record      EventRecorder
+	broadcastMu sync.Mutex
+
+	// onSlowClient is optional, called after a player is disconnected for
+	// falling behind on broadcasts
+	onSlowClient func(userID int64)
+
+	// publish is optional, called with each encoded broadcast under
+	// broadcastMu so other instances get them in order, see Backplane. It
+	// must not block; Manager.publish only queues.
+	publish func(data []byte)
+
+	lastActive atomic.Int64 // unix nanos of the last connection or broadcast, see evictIdleRooms
 }
 
 func NewRoom(gameID int64) *Room {
-	return &Room{
-		gameID:  gameID,
-		clients: make(map[*Client]bool),
+	r := &Room{
+		gameID:     gameID,
+		clients:    make(map[int64]*Client),
+		spectators: make(map[*Client]struct{}),
 	}
+	r.touch()
+	return r
+}
+
+// touch marks the room as in use now
+func (r *Room) touch() {
+	r.lastActive.Store(time.Now().UnixNano())
+}
+
+// idleFor returns how long before now the room was last in use
+func (r *Room) idleFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, r.lastActive.Load()))
 }
 
-func (r *Room) AddClient(client *Client) {
+// AddClient registers the client as the user's connection. An older connection
+// for the same user is evicted with a "replaced" close frame and returned.
+func (r *Room) AddClient(client *Client) *Client {
+	r.touch()
 	r.mu.Lock()
-	r.clients[client] = true
-	r.mu.Unlock()
+	defer r.mu.Unlock()
+
+	old, exists := r.clients[client.userID]
+	if exists && old != client {
+		old.close(CloseReplaced, "replaced")
+	}
+	r.clients[client.userID] = client
+	if exists && old != client {
+		return old
+	}
+	return nil
 }
 
-func (r *Room) RemoveClient(client *Client) {
+// RemoveClient unregisters the client and reports whether it did. It is a
+// no-op if the client was already evicted by a newer connection of the same
+// user, or removed by CloseAll.
+func (r *Room) RemoveClient(client *Client) bool {
 	r.mu.Lock()
-	if _, ok := r.clients[client]; ok {
-		delete(r.clients, client)
-		close(client.send)
+	defer r.mu.Unlock()
+	if current, ok := r.clients[client.userID]; ok && current == client {
+		delete(r.clients, client.userID)
+		client.close(0, "")
+		return true
 	}
-	r.mu.Unlock()
+	return false
+}
+
+// CloseClient is RemoveClient with a specific close frame
+func (r *Room) CloseClient(client *Client, code int, text string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.clients[client.userID]; ok && current == client {
+		delete(r.clients, client.userID)
+		client.close(code, text)
+		return true
+	}
+	return false
+}
+
+// AddSpectator registers a read-only connection. Spectators receive room
+// broadcasts but aren't players, so they don't count as online or connected.
+func (r *Room) AddSpectator(client *Client) {
+	r.touch()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spectators[client] = struct{}{}
+}
+
+// promoteSpectator makes a spectator's connection its user's player
+// connection, once the user has taken a seat. It reports false if the
+// spectator already left.
+func (r *Room) promoteSpectator(client *Client) bool {
+	r.touch()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.spectators[client]; !ok {
+		return false
+	}
+	delete(r.spectators, client)
+	if old, exists := r.clients[client.userID]; exists && old != client {
+		old.close(CloseReplaced, "replaced")
+	}
+	r.clients[client.userID] = client
+	return true
+}
+
+// RemoveSpectator unregisters a spectator, a no-op if CloseAll got there first
+func (r *Room) RemoveSpectator(client *Client) {
+	r.closeSpectator(client, 0, "")
+}
+
+func (r *Room) closeSpectator(client *Client, code int, text string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.spectators[client]; ok {
+		delete(r.spectators, client)
+		client.close(code, text)
+		return true
+	}
+	return false
+}
+
+// IsOnline reports whether the user has a live connection in the room
+func (r *Room) IsOnline(userID int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.clients[userID]
+	return ok
+}
+
+// Broadcast sends the message to every player and spectator. A client whose
+// send buffer stays full for maxSendDrops broadcasts in a row has missed
+// messages it can't get back, so it's disconnected with CloseTooSlow rather
+// than left silently out of sync.
+func (r *Room) Broadcast(message OutgoingMessage) {
+	r.broadcast(func() (OutgoingMessage, bool) {
+		return r.stamp(message)
+	})
+}
+
+// BroadcastTurnUpdate sends the events as one turn_update message together
+// with the state they left the game in. Each event is still recorded on its
+// own, so the log and replay stay fine-grained; the message carries the seq
+// of the last one.
+func (r *Room) BroadcastTurnUpdate(events []OutgoingMessage, state *TurnUpdateState) {
+	r.broadcast(func() (OutgoingMessage, bool) {
+		payload := TurnUpdatePayload{Events: make([]OutgoingMessage, len(events)), State: state}
+		var seq int64
+		for i, event := range events {
+			stamped, ok := r.stamp(event)
+			if !ok {
+				return OutgoingMessage{}, false
+			}
+			payload.Events[i] = stamped
+			seq = stamped.Seq
+		}
+		return OutgoingMessage{Type: "turn_update", Payload: payload, Seq: seq}, true
+	})
 }
 
-func (r *Room) Broadcast(message interface{}) {
-	data, err := json.Marshal(message)
+// stamp records the message if the room keeps a log, replacing its payload
+// with the recorded JSON and setting its seq
+func (r *Room) stamp(message OutgoingMessage) (OutgoingMessage, bool) {
+	if r.record == nil {
+		return message, true
+	}
+	payload, err := json.Marshal(message.Payload)
 	if err != nil {
-		log.Printf("Failed to marshal message: %v", err)
-		return
+		log.Printf("Failed to marshal payload: %v", err)
+		return message, false
+	}
+	message.Payload = json.RawMessage(payload)
+	message.Seq = r.record(r.gameID, message.Type, payload)
+	return message, true
+}
+
+// broadcast delivers the message built by build and disconnects clients that
+// fell too far behind. build runs under broadcastMu, so seqs are assigned in
+// delivery order.
+func (r *Room) broadcast(build func() (OutgoingMessage, bool)) {
+	r.fanOut(func() ([]byte, bool) {
+		message, ok := build()
+		if !ok {
+			return nil, false
+		}
+		data, err := json.Marshal(message)
+		if err != nil {
+			log.Printf("Failed to marshal message: %v", err)
+			return nil, false
+		}
+		if r.publish != nil {
+			r.publish(data)
+		}
+		return data, true
+	})
+}
+
+// relay delivers a broadcast another instance already recorded and encoded
+func (r *Room) relay(data []byte) {
+	r.fanOut(func() ([]byte, bool) {
+		return data, true
+	})
+}
+
+// fanOut delivers the data built by encode and disconnects clients that fell
+// too far behind
+func (r *Room) fanOut(encode func() ([]byte, bool)) {
+	players, spectators := r.deliver(encode)
+
+	for _, client := range players {
+		if r.CloseClient(client, CloseTooSlow, "too slow") {
+			log.Printf("Disconnected user %d from game %d: send buffer full for %d broadcasts", client.userID, r.gameID, maxSendDrops)
+			if r.onSlowClient != nil {
+				r.onSlowClient(client.userID)
+			}
+		}
+	}
+	for _, client := range spectators {
+		if r.closeSpectator(client, CloseTooSlow, "too slow") {
+			log.Printf("Disconnected a spectator from game %d: send buffer full for %d broadcasts", r.gameID, maxSendDrops)
+		}
+	}
+}
+
+// deliver queues the encoded message for every client and returns the
+// players and spectators that have now missed too many broadcasts
+func (r *Room) deliver(encode func() ([]byte, bool)) (players, spectators []*Client) {
+	r.touch()
+	r.broadcastMu.Lock()
+	defer r.broadcastMu.Unlock()
+
+	data, ok := encode()
+	if !ok {
+		return nil, nil
 	}
 
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for client := range r.clients {
-		select {
-		case client.send <- data:
-		default:
-			// Client's send channel is full, skip
+	for _, client := range r.clients {
+		if !queue(client, data) {
 			log.Printf("Client %d send buffer full", client.userID)
+			if client.drops >= maxSendDrops {
+				players = append(players, client)
+			}
 		}
 	}
+	for client := range r.spectators {
+		if !queue(client, data) {
+			log.Printf("Spectator send buffer full in game %d", r.gameID)
+			if client.drops >= maxSendDrops {
+				spectators = append(spectators, client)
+			}
+		}
+	}
+	return players, spectators
+}
+
+// queue hands data to the client's write pump without blocking and keeps its
+// count of consecutive drops
+func queue(client *Client, data []byte) bool {
+	if client.trySend(data) {
+		client.drops = 0
+		return true
+	}
+	client.drops++
+	return false
+}
+
+// CloseAll removes every client from the room and closes them, which makes
+// each write pump flush its queue, send a close frame and exit.
+func (r *Room) CloseAll() {
+	r.CloseAllWithCode(0, "")
+}
+
+// CloseAllWithCode is CloseAll with a specific close frame; code 0 means
+// "going away", which clients treat as a cue to reconnect.
+func (r *Room) CloseAllWithCode(code int, text string) {
+	r.mu.Lock()
+	for userID, client := range r.clients {
+		delete(r.clients, userID)
+		client.close(code, text)
+	}
+	for client := range r.spectators {
+		delete(r.spectators, client)
+		client.close(code, text)
+	}
+	r.mu.Unlock()
+}
+
+// CloseSpectators closes every spectator with the given close frame; players
+// stay connected to see the result
+func (r *Room) CloseSpectators(code int, text string) {
+	r.mu.Lock()
+	for client := range r.spectators {
+		delete(r.spectators, client)
+		client.close(code, text)
+	}
+	r.mu.Unlock()
 }
 
 func (r *Room) ClientCount() int {
@@ -68,6 +376,16 @@ func (r *Room) ClientCount() int {
 	return len(r.clients)
 }
 
+// SpectatorCount returns how many spectators are connected
+func (r *Room) SpectatorCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.spectators)
+}
+
+// IsEmpty reports whether neither players nor spectators are connected
 func (r *Room) IsEmpty() bool {
-	return r.ClientCount() == 0
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.clients) == 0 && len(r.spectators) == 0
 }
@@ -0,0 +1,373 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func newTestClient(userID int64) *Client {
+	return &Client{outbox: newOutbox(4), userID: userID}
+}
+
+func TestRoomAddClient_EvictsOlderConnectionOfSameUser(t *testing.T) {
+	room := NewRoom(1)
+
+	first := newTestClient(100)
+	second := newTestClient(100)
+
+	if evicted := room.AddClient(first); evicted != nil {
+		t.Fatalf("Expected no eviction on first connect, got %v", evicted)
+	}
+	if evicted := room.AddClient(second); evicted != first {
+		t.Fatalf("Expected first connection to be evicted")
+	}
+
+	if room.ClientCount() != 1 {
+		t.Errorf("Expected 1 client, got %d", room.ClientCount())
+	}
+	if !first.closed() {
+		t.Error("Expected evicted client to be closed")
+	}
+	if first.closeCode != CloseReplaced {
+		t.Errorf("Expected close code %d, got %d", CloseReplaced, first.closeCode)
+	}
+
+	// Broadcasts only reach the newest connection
+	room.Broadcast(OutgoingMessage{Type: "chat"})
+	if len(second.send) != 1 {
+		t.Errorf("Expected newest connection to receive broadcast")
+	}
+
+	// The evicted connection's cleanup must not unregister its replacement
+	if room.RemoveClient(first) {
+		t.Error("Expected removing the evicted connection to be a no-op")
+	}
+	if room.ClientCount() != 1 {
+		t.Errorf("Expected replacement to stay registered, got %d clients", room.ClientCount())
+	}
+	if !room.IsOnline(100) {
+		t.Error("Expected user to stay online through the replacement")
+	}
+}
+
+func TestRoomRemoveClient_UserGoesOffline(t *testing.T) {
+	room := NewRoom(1)
+	client := newTestClient(100)
+	room.AddClient(client)
+
+	if !room.IsOnline(100) {
+		t.Fatal("Expected user to be online after connecting")
+	}
+	if !room.RemoveClient(client) {
+		t.Fatal("Expected RemoveClient to report removal")
+	}
+	if room.IsOnline(100) {
+		t.Error("Expected user to be offline after disconnecting")
+	}
+}
+
+func TestRoomAddClient_DifferentUsersCoexist(t *testing.T) {
+	room := NewRoom(1)
+
+	room.AddClient(newTestClient(100))
+	if evicted := room.AddClient(newTestClient(101)); evicted != nil {
+		t.Error("Expected no eviction for a different user")
+	}
+	if room.ClientCount() != 2 {
+		t.Errorf("Expected 2 clients, got %d", room.ClientCount())
+	}
+}
+
+func TestRoomBroadcast_StampsRecordedSeq(t *testing.T) {
+	room := NewRoom(1)
+	var seq int64
+	room.record = func(gameID int64, eventType string, payloadJSON []byte) int64 {
+		seq++
+		return seq
+	}
+
+	client := newTestClient(100)
+	room.AddClient(client)
+
+	room.Broadcast(OutgoingMessage{Type: "dice_rolled", Payload: map[string]int{"die1": 3}})
+
+	var msg struct {
+		Type    string         `json:"type"`
+		Payload map[string]int `json:"payload"`
+		Seq     int64          `json:"seq"`
+	}
+	if err := json.Unmarshal(<-client.send, &msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if msg.Seq != 1 || msg.Payload["die1"] != 3 {
+		t.Errorf("Expected seq 1 with original payload, got %+v", msg)
+	}
+}
+
+func TestRoomBroadcastTurnUpdate_RecordsEachEventInOneMessage(t *testing.T) {
+	room := NewRoom(1)
+	var recorded []string
+	room.record = func(gameID int64, eventType string, payloadJSON []byte) int64 {
+		recorded = append(recorded, eventType)
+		return int64(len(recorded))
+	}
+
+	client := newTestClient(100)
+	room.AddClient(client)
+
+	room.BroadcastTurnUpdate([]OutgoingMessage{
+		{Type: "dice_rolled", Payload: map[string]int{"die1": 3}},
+		{Type: "player_moved", Payload: map[string]int{"newPosition": 7}},
+	}, &TurnUpdateState{CurrentPlayerID: 100})
+
+	if len(client.send) != 1 {
+		t.Fatalf("Expected one message, got %d", len(client.send))
+	}
+	var msg struct {
+		Type    string `json:"type"`
+		Seq     int64  `json:"seq"`
+		Payload struct {
+			Events []struct {
+				Type    string         `json:"type"`
+				Payload map[string]int `json:"payload"`
+				Seq     int64          `json:"seq"`
+			} `json:"events"`
+			State TurnUpdateState `json:"state"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(<-client.send, &msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if msg.Type != "turn_update" || msg.Seq != 2 {
+		t.Errorf("Expected turn_update with the last event's seq, got %s seq %d", msg.Type, msg.Seq)
+	}
+	if len(recorded) != 2 || recorded[0] != "dice_rolled" || recorded[1] != "player_moved" {
+		t.Errorf("Expected each event recorded on its own, got %v", recorded)
+	}
+	events := msg.Payload.Events
+	if len(events) != 2 || events[0].Seq != 1 || events[1].Seq != 2 || events[1].Payload["newPosition"] != 7 {
+		t.Errorf("Expected both events in order with their seqs, got %+v", events)
+	}
+	if msg.Payload.State.CurrentPlayerID != 100 {
+		t.Errorf("Expected resulting state, got %+v", msg.Payload.State)
+	}
+}
+
+func TestClientRecordPong_ThrottlesReports(t *testing.T) {
+	client := newTestClient(100)
+	start := time.Now()
+
+	if _, report := client.recordPong(start); report {
+		t.Fatal("Expected no report for a pong without a ping")
+	}
+
+	pong := func(rtt time.Duration) bool {
+		client.markPing(start)
+		latency, report := client.recordPong(start.Add(rtt))
+		if latency != rtt {
+			t.Fatalf("Expected latency %v, got %v", rtt, latency)
+		}
+		return report
+	}
+
+	if !pong(40 * time.Millisecond) {
+		t.Fatal("Expected the first measurement to be reported")
+	}
+	for i := 1; i < latencyReportEvery; i++ {
+		if pong(45 * time.Millisecond) {
+			t.Fatalf("Expected small change not to be reported on pong %d", i)
+		}
+	}
+	if !pong(45 * time.Millisecond) {
+		t.Error("Expected a periodic report after latencyReportEvery pongs")
+	}
+	if !pong(200 * time.Millisecond) {
+		t.Error("Expected a large change to be reported right away")
+	}
+	if client.Latency() != 200*time.Millisecond {
+		t.Errorf("Expected last latency 200ms, got %v", client.Latency())
+	}
+}
+
+func TestClientCheckRate_CooldownThenKick(t *testing.T) {
+	client := newTestClient(100)
+	client.limiter = rate.NewLimiter(rate.Limit(1), 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if v := client.checkRate(now); v != rateAllow {
+			t.Fatalf("Expected burst message %d to be allowed, got %v", i, v)
+		}
+	}
+
+	for strike := 1; strike < rateLimitMaxStrikes; strike++ {
+		if v := client.checkRate(now); v != rateWarn {
+			t.Fatalf("Expected a warning on strike %d, got %v", strike, v)
+		}
+		if v := client.checkRate(now.Add(time.Second)); v != rateDrop {
+			t.Fatalf("Expected messages dropped during cooldown, got %v", v)
+		}
+		now = now.Add(rateLimitCooldown)
+		if v := client.checkRate(now); v != rateAllow {
+			t.Fatalf("Expected a message allowed after cooldown, got %v", v)
+		}
+		client.limiter.AllowN(now, 1) // use up the refilled tokens
+	}
+
+	if v := client.checkRate(now); v != rateKick {
+		t.Errorf("Expected a kick after %d strikes, got %v", rateLimitMaxStrikes, v)
+	}
+}
+
+func TestClientCheckRate_ForgivesStrikesAfterAQuietSpell(t *testing.T) {
+	client := newTestClient(100)
+	client.limiter = rate.NewLimiter(rate.Limit(1), 1)
+	now := time.Now()
+
+	// Occasional bursts, each long after the last cooldown ended, never add up
+	for i := 0; i < 2*rateLimitMaxStrikes; i++ {
+		client.limiter.AllowN(now, 1)
+		if v := client.checkRate(now); v != rateWarn {
+			t.Fatalf("Expected burst %d to be warned, not %v", i+1, v)
+		}
+		now = now.Add(rateLimitCooldown + rateLimitStrikeReset)
+	}
+	if client.strikes != 1 {
+		t.Errorf("Expected only the latest strike to count, got %d", client.strikes)
+	}
+}
+
+func TestRoomSpectators_ReceiveBroadcastsWithoutCountingAsPlayers(t *testing.T) {
+	room := NewRoom(1)
+	player := newTestClient(100)
+	spectator := &Client{outbox: newOutbox(4)}
+	room.AddClient(player)
+	room.AddSpectator(spectator)
+
+	room.Broadcast(OutgoingMessage{Type: "dice_rolled"})
+	if len(player.send) != 1 || len(spectator.send) != 1 {
+		t.Fatalf("Expected both to get the broadcast, got player %d spectator %d", len(player.send), len(spectator.send))
+	}
+	if room.ClientCount() != 1 {
+		t.Errorf("Expected the spectator not to count as a connected player, got %d", room.ClientCount())
+	}
+
+	room.RemoveClient(player)
+	if room.IsEmpty() {
+		t.Error("Expected a room with a spectator not to be empty")
+	}
+	room.RemoveSpectator(spectator)
+	room.RemoveSpectator(spectator) // second removal is a no-op
+	if !room.IsEmpty() {
+		t.Error("Expected the room to be empty")
+	}
+}
+
+func TestRoomBroadcast_DisconnectsClientsThatKeepFallingBehind(t *testing.T) {
+	room := NewRoom(1)
+	var slow []int64
+	room.onSlowClient = func(userID int64) { slow = append(slow, userID) }
+
+	fast := newTestClient(100)
+	stuck := &Client{outbox: newOutbox(1), userID: 101}
+	spectator := &Client{outbox: newOutbox(1)}
+	room.AddClient(fast)
+	room.AddClient(stuck)
+	room.AddSpectator(spectator)
+
+	// Fill both one-slot buffers, then miss one short of the limit
+	for i := 0; i < maxSendDrops; i++ {
+		room.Broadcast(OutgoingMessage{Type: "dice_rolled"})
+		<-fast.send
+	}
+	if !room.IsOnline(101) || len(slow) != 0 {
+		t.Fatalf("Expected the client to survive %d drops", maxSendDrops-1)
+	}
+
+	room.Broadcast(OutgoingMessage{Type: "dice_rolled"})
+	if room.IsOnline(101) {
+		t.Error("Expected the stuck client to be disconnected")
+	}
+	if stuck.closeCode != CloseTooSlow || spectator.closeCode != CloseTooSlow {
+		t.Errorf("Expected close code %d, got player %d spectator %d", CloseTooSlow, stuck.closeCode, spectator.closeCode)
+	}
+	if len(slow) != 1 || slow[0] != 101 {
+		t.Errorf("Expected the slow client hook for user 101 only, got %v", slow)
+	}
+	if !room.IsOnline(100) {
+		t.Error("Expected the client keeping up to stay connected")
+	}
+	if room.RemoveClient(stuck) {
+		t.Error("Expected the read pump's later removal to be a no-op")
+	}
+}
+
+func TestRoomBroadcast_DeliveryResetsDropCount(t *testing.T) {
+	room := NewRoom(1)
+	client := &Client{outbox: newOutbox(1), userID: 100}
+	room.AddClient(client)
+
+	// Miss all but one allowed broadcast, catch up, and miss them again
+	for round := 0; round < 2; round++ {
+		for i := 0; i < maxSendDrops; i++ {
+			room.Broadcast(OutgoingMessage{Type: "dice_rolled"})
+		}
+		<-client.send
+	}
+	if !room.IsOnline(100) {
+		t.Error("Expected a client that caught up in between to stay connected")
+	}
+}
+
+// Run with -race: direct sends, broadcasts and disconnects all touch a
+// client's queue at once, and none of them may send on a closed channel
+func TestRoom_ConcurrentBroadcastAndRemove(t *testing.T) {
+	m := &Manager{}
+	room := NewRoom(1)
+
+	for round := 0; round < 50; round++ {
+		clients := make([]*Client, 8)
+		for i := range clients {
+			clients[i] = &Client{outbox: newOutbox(64), userID: int64(100 + i)}
+			room.AddClient(clients[i])
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				room.Broadcast(OutgoingMessage{Type: "chat"})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				for _, client := range clients {
+					m.sendToClient(client, OutgoingMessage{Type: "chat"})
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, client := range clients {
+				room.RemoveClient(client)
+			}
+		}()
+		wg.Wait()
+
+		for _, client := range clients {
+			if !client.closed() {
+				t.Fatalf("Round %d: expected user %d to be closed", round, client.userID)
+			}
+		}
+	}
+	if !room.IsEmpty() {
+		t.Error("Expected every client to be removed")
+	}
+}
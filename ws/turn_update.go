@@ -0,0 +1,77 @@
+package ws
+
+import (
+	"log"
+	"monopoly/game"
+)
+
+// TurnUpdatePayload is sent with "turn_update" messages: every event one
+// action produced, in order, plus the state the game was left in. Clients
+// that only care about the outcome can apply State and skip Events.
+type TurnUpdatePayload struct {
+	Events []OutgoingMessage `json:"events"`
+	State  *TurnUpdateState  `json:"state,omitempty"`
+}
+
+// TurnUpdateState is the part of game.GameState an action can change. The
+// board and game settings are left out; clients already have them.
+type TurnUpdateState struct {
+	Status              string         `json:"status"`
+	Players             []*game.Player `json:"players"`
+	CurrentPlayerID     int64          `json:"currentPlayerId"`
+	TurnPhase           string         `json:"turnPhase,omitempty"`
+	Properties          map[int]int64  `json:"properties"`
+	MortgagedProperties map[int]bool   `json:"mortgagedProperties"`
+	Improvements        map[int]int    `json:"improvements"`
+	Round               int            `json:"round"`
+	WinnerID            int64          `json:"winnerId"`
+}
+
+// broadcastEvents sends the events of one action to the room and runs their
+// side effects. Several events go out as a single turn_update unless
+// Options.FineGrainedEvents asks for one message each.
+func (m *Manager) broadcastEvents(room *Room, events []*game.Event) {
+	if m.opts.FineGrainedEvents || len(events) < 2 {
+		m.broadcastEach(room, events)
+		return
+	}
+
+	state, err := m.engine.GetGameState(room.gameID)
+	if err != nil {
+		log.Printf("Failed to load state for turn_update in game %d: %v", room.gameID, err)
+		m.broadcastEach(room, events)
+		return
+	}
+	m.FillPresence(state)
+
+	messages := make([]OutgoingMessage, len(events))
+	for i, event := range events {
+		messages[i] = OutgoingMessage{Type: event.Type, Payload: event.Payload}
+	}
+	room.BroadcastTurnUpdate(messages, &TurnUpdateState{
+		Status:              state.Status,
+		Players:             state.Players,
+		CurrentPlayerID:     state.CurrentPlayerID,
+		TurnPhase:           state.TurnPhase,
+		Properties:          state.Properties,
+		MortgagedProperties: state.MortgagedProperties,
+		Improvements:        state.Improvements,
+		Round:               state.Round,
+		WinnerID:            state.WinnerID,
+	})
+	for _, event := range events {
+		m.handleEventSideEffects(event, room)
+	}
+}
+
+// broadcastEach sends every event as its own message, running each one's side
+// effects straight after it
+func (m *Manager) broadcastEach(room *Room, events []*game.Event) {
+	for _, event := range events {
+		room.Broadcast(OutgoingMessage{
+			Type:    event.Type,
+			Payload: event.Payload,
+		})
+		m.handleEventSideEffects(event, room)
+	}
+}